@@ -46,11 +46,42 @@ func (a Api) Router() *gin.Engine {
 	router.POST("/ledgers", a.CreateLedger)
 	router.GET("/ledgers/:id", a.GetLedger)
 	router.GET("/ledgers", a.GetAllLedgers)
+	router.PUT("/ledgers/:id/transfer-rules", a.UpdateLedgerTransferRules)
+	router.GET("/ledgers/:id/trial-balance", a.GetTrialBalance)
+	router.GET("/ledgers/:id/balance-sheet", a.GetBalanceSheet)
+	router.GET("/ledgers/:id/income-statement", a.GetIncomeStatement)
+	router.POST("/ledgers/:id/periods", a.ClosePeriod)
+	router.GET("/ledgers/:id/periods", a.GetLedgerPeriods)
+	router.GET("/periods/:id", a.GetPeriod)
+	router.GET("/periods/:id/balances", a.GetPeriodBalances)
+	router.POST("/periods/:id/reopen", a.ReopenPeriod)
+
+	// FX rate routes
+	router.POST("/fx/rates", a.CreateFXRate)
+	router.GET("/fx/rates", a.GetFXRates)
+	router.GET("/fx/rates/:base/:quote", a.GetFXRate)
+	router.POST("/fx/quotes", a.CreateFXQuote)
+	router.GET("/fx/quotes/:id", a.GetFXQuote)
+	router.POST("/currencies", a.CreateCurrency)
+	router.GET("/currencies", a.GetCurrencies)
+	router.GET("/currencies/:code", a.GetCurrency)
+
+	// Fee rule routes
+	router.POST("/fee-rules", a.CreateFeeRule)
+	router.GET("/fee-rules", a.GetFeeRules)
+	router.GET("/fee-rules/:id", a.GetFeeRule)
+	router.PUT("/fee-rules/:id/activate", a.ActivateFeeRule)
+	router.PUT("/fee-rules/:id/deactivate", a.DeactivateFeeRule)
 
 	// Balance routes
 	router.POST("/balances", a.CreateBalance)
 	router.GET("/balances", a.GetBalances)
 	router.GET("/balances/:id", a.GetBalance)
+	router.PUT("/balances/:id/overdraft-limit", a.UpdateOverdraftLimit)
+	router.PUT("/balances/:id/account-type", a.UpdateBalanceAccountType)
+	router.PUT("/balances/:id/status", a.UpdateBalanceStatus)
+	router.GET("/balances/:id/status-history", a.GetBalanceStatusHistory)
+	router.GET("/balances/:id/statement", a.GetBalanceStatement)
 
 	// Balance Monitor routes
 	router.POST("/balance-monitors", a.CreateBalanceMonitor)
@@ -58,13 +89,23 @@ func (a Api) Router() *gin.Engine {
 	router.GET("/balance-monitors", a.GetAllBalanceMonitors)
 	router.GET("/balance-monitors/balances/:balance_id", a.GetBalanceMonitorsByBalanceID)
 	router.PUT("/balance-monitors/:id", a.UpdateBalanceMonitor)
+	router.GET("/balance-monitors/:id/events", a.GetBalanceMonitorEvents)
 
 	// Transaction routes
 	router.POST("/transactions", a.QueueTransaction)
+	router.GET("/transactions", a.GetTransactions)
 	router.POST("/refund-transaction/:id", a.RefundTransaction)
 	router.GET("/transactions/:id", a.GetTransaction)
 	router.PUT("/transactions/inflight/:txID", a.UpdateInflightStatus)
 
+	// Schedule routes
+	router.POST("/schedules", a.CreateSchedule)
+	router.GET("/schedules", a.GetAllSchedules)
+	router.GET("/schedules/:id", a.GetSchedule)
+	router.PUT("/schedules/:id/pause", a.PauseSchedule)
+	router.PUT("/schedules/:id/resume", a.ResumeSchedule)
+	router.PUT("/schedules/:id/cancel", a.CancelSchedule)
+
 	// Identity routes
 	router.POST("/identities", a.CreateIdentity)
 	router.GET("/identities/:id", a.GetIdentity)
@@ -112,6 +153,7 @@ func NewAPI(b *blnk.Blnk) *Api {
 		r.Use(middleware.SecretKeyAuthMiddleware())
 	}
 	r.Use(middleware.RateLimitMiddleware(conf))
+	r.Use(middleware.IdempotencyMiddleware(b))
 	r.Use(otelgin.Middleware("BLNK"))
 
 	r.GET("/", func(c *gin.Context) {
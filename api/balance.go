@@ -16,17 +16,20 @@ limitations under the License.
 package api
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/jerry-enebeli/blnk"
 	model2 "github.com/jerry-enebeli/blnk/api/model"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jerry-enebeli/blnk/model"
 )
 
 // CreateBalance creates a new balance record in the system.
-// It binds the incoming JSON request to a CreateBalance object, validates it,
+// It binds the incoming JSON request to a CreateBalance object, resolves the precision of its currency, validates it,
 // and then creates the balance record. If any errors occur during validation
 // or creation, it responds with an appropriate error message.
 //
@@ -43,7 +46,15 @@ func (a Api) CreateBalance(c *gin.Context) {
 		return
 	}
 
-	err := newBalance.ValidateCreateBalance()
+	// The precision of a registered currency is needed to validate the overdraft limit
+	precision, err := a.blnk.ResolvePrecision(c.Request.Context(), newBalance.Currency, newBalance.Precision)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	newBalance.Precision = precision
+
+	err = newBalance.ValidateCreateBalance()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
 		return
@@ -62,12 +73,13 @@ func (a Api) CreateBalance(c *gin.Context) {
 // It extracts the ID from the route parameters and the 'include' query
 // parameter to fetch additional related information. If the ID is missing
 // or there's an error retrieving the balance, it responds with an appropriate error message.
+// When the 'as_of' query parameter (RFC3339) is set, the balance is returned as it stood at that time.
 //
 // Parameters:
 // - c: The Gin context containing the request and response.
 //
 // Responses:
-// - 400 Bad Request: If the ID is missing or there's an error retrieving the balance.
+// - 400 Bad Request: If the ID is missing, 'as_of' is invalid or there's an error retrieving the balance.
 // - 200 OK: If the balance is successfully retrieved.
 func (a Api) GetBalance(c *gin.Context) {
 	id, passed := c.Params.Get("id")
@@ -77,6 +89,23 @@ func (a Api) GetBalance(c *gin.Context) {
 		return
 	}
 
+	if asOfParam := c.Query("as_of"); asOfParam != "" {
+		asOf, err := time.Parse(time.RFC3339, asOfParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid as_of. use the RFC3339 format e.g 2024-09-30T23:59:59Z"})
+			return
+		}
+
+		resp, err := a.blnk.GetBalanceAtTime(c.Request.Context(), id, asOf)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
 	// Extracting 'include' parameter from the query
 	includes := c.QueryArray("include")
 
@@ -89,6 +118,224 @@ func (a Api) GetBalance(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// GetBalanceStatement returns the statement of a balance for the period given by the 'from' and 'to' query parameters,
+// with its opening balance, every posting with its counterparty and the running balance, and its closing balance.
+// The 'format' query parameter selects json (the default), csv or pdf.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, 'from', 'to' or 'format' is invalid, or there's an error building the statement.
+// - 200 OK: If the statement is successfully built, in the requested format.
+func (a Api) GetBalanceStatement(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. pass id in the route /:id"})
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from. use the RFC3339 format e.g 2024-09-01T00:00:00Z"})
+		return
+	}
+
+	var to time.Time
+	if toParam := c.Query("to"); toParam != "" {
+		to, err = time.Parse(time.RFC3339, toParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to. use the RFC3339 format e.g 2024-09-30T23:59:59Z"})
+			return
+		}
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format. use json, csv or pdf"})
+		return
+	}
+
+	statement, err := a.blnk.GetBalanceStatement(c.Request.Context(), id, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, statement)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "text/csv"
+	if format == "csv" {
+		err = blnk.WriteStatementCSV(&buf, statement)
+	} else {
+		contentType = "application/pdf"
+		err = blnk.WriteStatementPDF(&buf, statement)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("statement_%s_%s_%s.%s", id, statement.From.Format("20060102"), statement.To.Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// UpdateOverdraftLimit sets how far below zero a balance may go.
+// It binds the incoming JSON request to an UpdateOverdraftLimit object, validates it,
+// and updates the limit of the balance. If any errors occur during binding, validation,
+// or update, it responds with an appropriate error message.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, or there's an error in binding JSON, validating the limit, or updating the balance.
+// - 200 OK: If the limit is successfully updated, with the updated balance.
+func (a Api) UpdateOverdraftLimit(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. pass id in the route /:id"})
+		return
+	}
+
+	var req model2.UpdateOverdraftLimit
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateUpdateOverdraftLimit(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.UpdateOverdraftLimit(c.Request.Context(), id, float64(req.OverdraftLimit))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateBalanceAccountType places a balance in its ledger's chart of accounts.
+// It binds the incoming JSON request to an UpdateBalanceAccountType object, validates it,
+// and updates the account type and normal side of the balance. If any errors occur during binding,
+// validation, or update, it responds with an appropriate error message.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, or there's an error in binding JSON, validating the account type, or updating the balance.
+// - 200 OK: If the account type is successfully updated, with the updated balance.
+func (a Api) UpdateBalanceAccountType(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. pass id in the route /:id"})
+		return
+	}
+
+	var req model2.UpdateBalanceAccountType
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateUpdateBalanceAccountType(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.UpdateBalanceAccountType(c.Request.Context(), id, req.AccountType, req.NormalSide)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateBalanceStatus moves a balance to a new status, e.g. freezing a compromised wallet.
+// It binds the incoming JSON request to an UpdateBalanceStatus object, validates it,
+// and changes the status of the balance, recording the reason. If any errors occur during binding,
+// validation, or update, it responds with an appropriate error message.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, or there's an error in binding JSON, validating the status, or updating the balance.
+// - 200 OK: If the status is successfully changed, with the updated balance.
+func (a Api) UpdateBalanceStatus(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. pass id in the route /:id"})
+		return
+	}
+
+	var req model2.UpdateBalanceStatus
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateUpdateBalanceStatus(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.UpdateBalanceStatus(c.Request.Context(), id, req.Status, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetBalanceStatusHistory retrieves the status changes of a balance, newest first.
+// The page is controlled by the 'limit' and 'offset' query parameters.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID or pagination parameters are invalid or there's an error retrieving the changes.
+// - 200 OK: If the status changes are successfully retrieved.
+func (a Api) GetBalanceStatusHistory(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. pass id in the route /:id"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset value"})
+		return
+	}
+
+	changes, err := a.blnk.GetBalanceStatusChanges(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
 // GetBalances retrieves a list of balance records with pagination.
 // It extracts the 'limit' and 'offset' query parameters to control pagination,
 // and the 'include' query parameter to fetch additional related information.
@@ -228,8 +475,45 @@ func (a Api) GetBalanceMonitorsByBalanceID(c *gin.Context) {
 	c.JSON(http.StatusOK, monitors)
 }
 
+// GetBalanceMonitorEvents retrieves the history of a balance monitor firing, newest first.
+// The page is controlled by the 'limit' and 'offset' query parameters.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID or pagination parameters are invalid or there's an error retrieving the events.
+// - 200 OK: If the monitor events are successfully retrieved.
+func (a Api) GetBalanceMonitorEvents(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. pass id in the route /:id"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset value"})
+		return
+	}
+
+	events, err := a.blnk.GetMonitorEvents(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
 // UpdateBalanceMonitor updates an existing balance monitor record by its ID.
-// It binds the incoming JSON request to a BalanceMonitor object, updates the record,
+// It binds the incoming JSON request to a CreateBalanceMonitor object, validates it, updates the record,
 // and responds with a success message. If any errors occur during binding, validation,
 // or update, it responds with an appropriate error message.
 //
@@ -240,18 +524,24 @@ func (a Api) GetBalanceMonitorsByBalanceID(c *gin.Context) {
 // - 400 Bad Request: If there's an error in binding JSON, validating the balance monitor, or updating the record.
 // - 200 OK: If the balance monitor is successfully updated.
 func (a Api) UpdateBalanceMonitor(c *gin.Context) {
-	var monitor model.BalanceMonitor
+	var update model2.CreateBalanceMonitor
 	id, passed := c.Params.Get("id")
 	if !passed {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. pass id in the route /:id"})
 		return
 	}
 
-	if err := c.ShouldBindJSON(&monitor); err != nil {
+	if err := c.ShouldBindJSON(&update); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if err := update.ValidateCreateBalanceMonitor(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	monitor := update.ToBalanceMonitor()
 	monitor.MonitorID = id
 	err := a.blnk.UpdateMonitor(c.Request.Context(), &monitor)
 	if err != nil {
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"net/http"
+
+	model2 "github.com/jerry-enebeli/blnk/api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCurrency registers a custom asset, e.g. a token or loyalty points, with its precision and rounding.
+// It binds the incoming JSON request to a CreateCurrency object, validates it, and registers the asset.
+// ISO 4217 currencies are registered already and cannot be redefined.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If there's an error in binding JSON, validating the asset, or registering it.
+// - 201 Created: If the asset is successfully registered.
+func (a Api) CreateCurrency(c *gin.Context) {
+	var req model2.CreateCurrency
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateCreateCurrency(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.CreateCurrency(c.Request.Context(), req.Code, req.Name, req.Precision, req.Rounding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetCurrencies retrieves the currency registry: the ISO 4217 currencies and the custom assets.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If there's an error retrieving the currencies.
+// - 200 OK: If the currencies are successfully retrieved.
+func (a Api) GetCurrencies(c *gin.Context) {
+	resp, err := a.blnk.GetCurrencies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetCurrency retrieves a currency of the registry by its code.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the code is missing or the currency is not registered.
+// - 200 OK: If the currency is successfully retrieved.
+func (a Api) GetCurrency(c *gin.Context) {
+	code, passed := c.Params.Get("code")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required. Pass code in the route /:code"})
+		return
+	}
+
+	resp, err := a.blnk.GetCurrency(c.Request.Context(), code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	model2 "github.com/jerry-enebeli/blnk/api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateFeeRule records a fee rule charging percentage, fixed or tiered fees on the transfers it is scoped to.
+// It binds the incoming JSON request to a CreateFeeRule object, validates it, and records the rule.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If there's an error in binding JSON, validating the rule, or recording it.
+// - 201 Created: If the rule is successfully recorded.
+func (a Api) CreateFeeRule(c *gin.Context) {
+	var req model2.CreateFeeRule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateCreateFeeRule(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.CreateFeeRule(c.Request.Context(), req.ToFeeRule())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetFeeRules retrieves fee rules, newest first. The page is controlled by the 'limit' and 'offset' query parameters.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the pagination parameters are invalid or there's an error retrieving the rules.
+// - 200 OK: If the rules are successfully retrieved.
+func (a Api) GetFeeRules(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset value"})
+		return
+	}
+
+	resp, err := a.blnk.GetFeeRules(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetFeeRule retrieves a fee rule by its ID.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing or there's an error retrieving the rule.
+// - 200 OK: If the rule is successfully retrieved.
+func (a Api) GetFeeRule(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	resp, err := a.blnk.GetFeeRule(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ActivateFeeRule makes a deactivated fee rule charge the transfers it is scoped to again.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing or the rule cannot be activated.
+// - 200 OK: If the rule is successfully activated.
+func (a Api) ActivateFeeRule(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	resp, err := a.blnk.ActivateFeeRule(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeactivateFeeRule stops a fee rule from charging new transfers.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing or the rule cannot be deactivated.
+// - 200 OK: If the rule is successfully deactivated.
+func (a Api) DeactivateFeeRule(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	resp, err := a.blnk.DeactivateFeeRule(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	model2 "github.com/jerry-enebeli/blnk/api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateFXRate records the rate of a currency pair from a point in time.
+// It binds the incoming JSON request to a CreateFXRate object, validates it, and records the rate.
+// Transactions between balances of the pair convert at the rate in effect at their posting date.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If there's an error in binding JSON, validating the rate, or recording it.
+// - 201 Created: If the rate is successfully recorded.
+func (a Api) CreateFXRate(c *gin.Context) {
+	var req model2.CreateFXRate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateCreateFXRate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.CreateFXRate(c.Request.Context(), req.BaseCurrency, req.QuoteCurrency, req.Rate, req.EffectiveTime(), req.Source)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetFXRates retrieves recorded FX rates, latest effective first. They can be filtered by the 'base' and 'quote'
+// query parameters, and the page is controlled by the 'limit' and 'offset' query parameters.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the pagination parameters are invalid or there's an error retrieving the rates.
+// - 200 OK: If the rates are successfully retrieved.
+func (a Api) GetFXRates(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset value"})
+		return
+	}
+
+	resp, err := a.blnk.GetFXRates(c.Request.Context(), c.Query("base"), c.Query("quote"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetFXRate retrieves the rate converting one currency to another, in effect now or at the RFC3339 time
+// in the 'at' query parameter.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the 'at' parameter is invalid, no rate of the pair applies, or there's an error retrieving it.
+// - 200 OK: If the rate is successfully retrieved.
+func (a Api) GetFXRate(c *gin.Context) {
+	at := time.Now()
+	if value := c.Query("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at value. Use RFC3339"})
+			return
+		}
+		at = parsed
+	}
+
+	resp, err := a.blnk.GetFXRate(c.Request.Context(), c.Param("base"), c.Param("quote"), at)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateFXQuote quotes a rate for a currency pair and locks it until the quote expires.
+// It binds the incoming JSON request to a CreateFXQuote object, validates it, and creates the quote.
+// A transaction that passes the quote's ID as quote_id converts at exactly the quoted rate.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If there's an error in binding JSON, validating the quote, or no rate of the pair applies.
+// - 201 Created: If the quote is successfully created.
+func (a Api) CreateFXQuote(c *gin.Context) {
+	var req model2.CreateFXQuote
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateCreateFXQuote(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.CreateFXQuote(c.Request.Context(), req.BaseCurrency, req.QuoteCurrency, req.Margin, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetFXQuote retrieves an FX quote by its ID, including the transaction that used it.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing or there's an error retrieving the quote.
+// - 200 OK: If the quote is successfully retrieved.
+func (a Api) GetFXQuote(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	resp, err := a.blnk.GetFXQuote(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -85,6 +85,43 @@ func (a Api) GetLedger(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// UpdateLedgerTransferRules replaces the account type pairs a ledger lets money move between.
+// It binds the incoming JSON request to an UpdateLedgerTransferRules object, validates it,
+// and updates the rules of the ledger. An empty list lets any account types transact again.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, or there's an error in binding JSON, validating the rules, or updating the ledger.
+// - 200 OK: If the rules are successfully updated, with the updated ledger.
+func (a Api) UpdateLedgerTransferRules(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	var req model2.UpdateLedgerTransferRules
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	if err := req.ValidateUpdateLedgerTransferRules(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.UpdateLedgerTransferRules(c.Request.Context(), id, req.ToTransferRules())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // GetAllLedgers retrieves all ledger records in the system.
 // It fetches the ledger records and responds with the list of ledgers.
 // If there's an error retrieving the ledgers, it responds with an appropriate error message.
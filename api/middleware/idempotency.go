@@ -0,0 +1,187 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client supplied idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from a previous request with the same key.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyStore persists idempotency keys and the responses recorded for them.
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (*model.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, body []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
+
+// responseRecorder copies everything written to the response so it can be stored against the idempotency key.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware creates a middleware that makes mutating requests safe to retry.
+// When a POST, PUT, PATCH or DELETE request carries an Idempotency-Key header, the key is stored with a hash of the
+// request and, once the handler completes, with its response. A request reusing the key gets the original response
+// back, while reusing the key for a different request is rejected. Responses with a 5xx status are not stored,
+// so the request can be retried with the same key.
+//
+// Parameters:
+// - store: The store used to persist idempotency keys.
+//
+// Returns:
+// - gin.HandlerFunc: A middleware function that enforces idempotency keys.
+func IdempotencyMiddleware(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must not be longer than 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(c.Request, body)
+		existing, reserved, err := store.ReserveIdempotencyKey(c.Request.Context(), key, hash)
+		if err != nil {
+			if apiErr, ok := err.(apierror.APIError); ok && apiErr.Code != apierror.ErrInternalServer {
+				c.AbortWithStatusJSON(apierror.MapErrorToHTTPStatus(apiErr), gin.H{"error": apiErr.Message})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check idempotency key"})
+			return
+		}
+
+		if !reserved {
+			replayResponse(c, existing, hash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		completed := false
+		defer func() {
+			// Release the key if the handler panicked or failed, so the request can be retried.
+			if !completed {
+				if err := store.ReleaseIdempotencyKey(context.Background(), key); err != nil {
+					logrus.Errorf("failed to release idempotency key %s: %v", key, err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		if err := store.CompleteIdempotencyKey(context.Background(), key, recorder.Status(), recorder.body.Bytes()); err != nil {
+			logrus.Errorf("failed to record response for idempotency key %s: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// replayResponse responds to a request whose idempotency key has already been used.
+//
+// Parameters:
+// - c: The Gin context of the request.
+// - existing: The stored idempotency key.
+// - hash: The hash of the current request.
+func replayResponse(c *gin.Context, existing *model.IdempotencyKey, hash string) {
+	if existing.RequestHash != hash {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key has already been used with a different request"})
+		return
+	}
+	if !existing.Completed() {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(existing.StatusCode, "application/json; charset=utf-8", existing.ResponseBody)
+	c.Abort()
+}
+
+// requestHash identifies a request by its method, path and body, so a key cannot be reused for a different request.
+//
+// Parameters:
+// - r: The HTTP request.
+// - body: The request body.
+//
+// Returns:
+// - string: The hex encoded SHA-256 hash of the request.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isMutatingMethod reports whether requests with the given HTTP method change state.
+//
+// Parameters:
+// - method: The HTTP method.
+//
+// Returns:
+// - bool: True for POST, PUT, PATCH and DELETE.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
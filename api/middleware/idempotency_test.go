@@ -0,0 +1,152 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]*model.IdempotencyKey
+}
+
+func (s *memoryIdempotencyStore) ReserveIdempotencyKey(_ context.Context, key, requestHash string) (*model.IdempotencyKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.keys[key]; ok {
+		return existing, false, nil
+	}
+	reserved := &model.IdempotencyKey{Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	s.keys[key] = reserved
+	return reserved, true, nil
+}
+
+func (s *memoryIdempotencyStore) CompleteIdempotencyKey(_ context.Context, key string, statusCode int, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.keys[key].StatusCode = statusCode
+	s.keys[key].ResponseBody = append([]byte(nil), body...)
+	s.keys[key].CompletedAt = &now
+	return nil
+}
+
+func (s *memoryIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key)
+	return nil
+}
+
+func newIdempotentRouter(calls *int, status int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(&memoryIdempotencyStore{keys: map[string]*model.IdempotencyKey{}}))
+	router.POST("/ledgers", func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"call": *calls})
+	})
+	return router
+}
+
+func sendWithKey(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/ledgers", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestIdempotencyMiddleware_ReplaysResponse(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls, http.StatusCreated)
+
+	first := sendWithKey(router, "key1", `{"name":"ledger"}`)
+	second := sendWithKey(router, "key1", `{"name":"ledger"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+}
+
+func TestIdempotencyMiddleware_RejectsDifferentPayload(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls, http.StatusCreated)
+
+	sendWithKey(router, "key1", `{"name":"ledger"}`)
+	resp := sendWithKey(router, "key1", `{"name":"other"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+}
+
+func TestIdempotencyMiddleware_ReleasesKeyOnServerError(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls, http.StatusInternalServerError)
+
+	sendWithKey(router, "key1", `{"name":"ledger"}`)
+	sendWithKey(router, "key1", `{"name":"ledger"}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyMiddleware_WithoutKey(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls, http.StatusCreated)
+
+	sendWithKey(router, "", `{"name":"ledger"}`)
+	sendWithKey(router, "", `{"name":"ledger"}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+type releasingIdempotencyStore struct {
+	memoryIdempotencyStore
+}
+
+func (s *releasingIdempotencyStore) ReserveIdempotencyKey(_ context.Context, key, _ string) (*model.IdempotencyKey, bool, error) {
+	return nil, false, apierror.NewAPIError(apierror.ErrConflict, "Idempotency key '"+key+"' is being released", nil)
+}
+
+func TestIdempotencyMiddleware_PassesThroughConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(&releasingIdempotencyStore{}))
+	router.POST("/ledgers", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	resp := sendWithKey(router, "key1", `{"name":"ledger"}`)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Contains(t, resp.Body.String(), "is being released")
+}
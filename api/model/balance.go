@@ -16,18 +16,60 @@ limitations under the License.
 package model
 
 type CreateBalance struct {
-	LedgerId   string                 `json:"ledger_id"`
-	IdentityId string                 `json:"identity_id"`
-	Currency   string                 `json:"currency"`
-	Precision  float64                `json:"precision"`
-	MetaData   map[string]interface{} `json:"meta_data"`
+	LedgerId       string                 `json:"ledger_id"`
+	IdentityId     string                 `json:"identity_id"`
+	Currency       string                 `json:"currency"`
+	Precision      float64                `json:"precision"`
+	OverdraftLimit Amount                 `json:"overdraft_limit"`
+	AccountType    string                 `json:"account_type"`
+	NormalSide     string                 `json:"normal_side"`
+	MetaData       map[string]interface{} `json:"meta_data"`
+}
+
+type UpdateBalanceAccountType struct {
+	AccountType string `json:"account_type"`
+	NormalSide  string `json:"normal_side"`
+}
+
+type UpdateOverdraftLimit struct {
+	OverdraftLimit Amount `json:"overdraft_limit"`
+}
+
+type UpdateBalanceStatus struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
 }
 
 type CreateBalanceMonitor struct {
-	BalanceId   string                 `json:"balance_id"`
-	Condition   MonitorCondition       `json:"condition"`
-	CallBackURL string                 `json:"call_back_url"`
-	MetaData    map[string]interface{} `json:"meta_data"`
+	BalanceId       string                 `json:"balance_id"`
+	Condition       MonitorCondition       `json:"condition"`
+	Conditions      *MonitorConditionGroup `json:"conditions"`
+	TriggerMode     string                 `json:"trigger_mode"`
+	CooldownSeconds int64                  `json:"cooldown_seconds"`
+	Description     string                 `json:"description"`
+	CallBackURL     string                 `json:"call_back_url"`
+	CallBackSecret  string                 `json:"call_back_secret"`
+	MaxRetries      *int                   `json:"max_retries"`
+	RetryBackoff    *int64                 `json:"retry_backoff_seconds"`
+	Actions         []MonitorAction        `json:"actions"`
+	MetaData        map[string]interface{} `json:"meta_data"`
+}
+
+// MonitorConditionGroup combines conditions and nested groups with AND or OR logic.
+type MonitorConditionGroup struct {
+	Logic      string                  `json:"logic"`
+	Conditions []MonitorCondition      `json:"conditions"`
+	Groups     []MonitorConditionGroup `json:"groups"`
+}
+
+// MonitorAction is something the monitor does on its own when it fires: sweep the excess above the target to
+// the counterparty balance, top up the shortfall below the target from it, or freeze the balance.
+type MonitorAction struct {
+	Type                  string  `json:"type"`
+	CounterpartyBalanceID string  `json:"counterparty_balance_id"`
+	Target                float64 `json:"target"`
+	Precision             float64 `json:"precision"`
+	Description           string  `json:"description"`
 }
 
 type MonitorCondition struct {
@@ -0,0 +1,37 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+type CreateFeeRule struct {
+	Name             string                 `json:"name"`
+	Type             string                 `json:"type"`
+	Percentage       float64                `json:"percentage"`
+	Amount           float64                `json:"amount"`
+	Tiers            []FeeTier              `json:"tiers"`
+	MinAmount        float64                `json:"min_amount"`
+	MaxAmount        float64                `json:"max_amount"`
+	FeeBalance       string                 `json:"fee_balance"`
+	LedgerID         string                 `json:"ledger_id"`
+	Currency         string                 `json:"currency"`
+	MetaData         map[string]interface{} `json:"meta_data"`
+	IdentityCategory string                 `json:"identity_category"`
+}
+
+type FeeTier struct {
+	UpTo       float64 `json:"up_to"`
+	Percentage float64 `json:"percentage"`
+	Amount     float64 `json:"amount"`
+}
@@ -16,6 +16,26 @@ limitations under the License.
 package model
 
 type CreateLedger struct {
-	Name     string                 `json:"name"`
-	MetaData map[string]interface{} `json:"meta_data"`
+	Name          string                 `json:"name"`
+	TransferRules []TransferRule         `json:"transfer_rules"`
+	MetaData      map[string]interface{} `json:"meta_data"`
+}
+
+type TransferRule struct {
+	SourceType      string `json:"source_type"`
+	DestinationType string `json:"destination_type"`
+}
+
+type UpdateLedgerTransferRules struct {
+	TransferRules []TransferRule `json:"transfer_rules"`
+}
+
+type ClosePeriod struct {
+	Name      string `json:"name"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+type ReopenPeriod struct {
+	Reason string `json:"reason"`
 }
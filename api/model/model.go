@@ -17,6 +17,9 @@ package model
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -47,9 +50,118 @@ func destinationOrDestinationsValidation(t *RecordTransaction) validation.RuleFu
 func (l *CreateLedger) ValidateCreateLedger() error {
 	return validation.ValidateStruct(l,
 		validation.Field(&l.Name, validation.Required),
+		validation.Field(&l.TransferRules, validation.By(func(value interface{}) error {
+			return validateTransferRules(l.TransferRules)
+		})),
+	)
+}
+
+// ValidateUpdateLedgerTransferRules checks every rule names known account types. An empty list removes the rules.
+func (u *UpdateLedgerTransferRules) ValidateUpdateLedgerTransferRules() error {
+	return validation.ValidateStruct(u,
+		validation.Field(&u.TransferRules, validation.By(func(value interface{}) error {
+			return validateTransferRules(u.TransferRules)
+		})),
+	)
+}
+
+// ValidateClosePeriod checks a period to close has a name and RFC3339 start and end dates.
+func (p *ClosePeriod) ValidateClosePeriod() error {
+	return validation.ValidateStruct(p,
+		validation.Field(&p.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&p.StartDate, validation.Required, validation.By(func(value interface{}) error {
+			return validateDateFormat("2006-01-02T15:04:05Z07:00", value.(string))
+		})),
+		validation.Field(&p.EndDate, validation.Required, validation.By(func(value interface{}) error {
+			return validateDateFormat("2006-01-02T15:04:05Z07:00", value.(string))
+		})),
+	)
+}
+
+// ValidateReopenPeriod checks a period is reopened with a reason.
+func (r *ReopenPeriod) ValidateReopenPeriod() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Reason, validation.Required, validation.Length(1, 500)),
+	)
+}
+
+// ValidateCreateFXRate checks an FX rate is a positive rate between two different currencies with an optional
+// RFC3339 effective time.
+func (r *CreateFXRate) ValidateCreateFXRate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.BaseCurrency, validation.Required, validation.Length(3, 10)),
+		validation.Field(&r.QuoteCurrency, validation.Required, validation.Length(3, 10), validation.NotIn(r.BaseCurrency).Error("must differ from base_currency")),
+		validation.Field(&r.Rate, validation.Required, validation.Min(0.0).Exclusive()),
+		validation.Field(&r.EffectiveFrom, validation.By(func(value interface{}) error {
+			if value.(string) == "" {
+				return nil
+			}
+			return validateDateFormat("2006-01-02T15:04:05Z07:00", value.(string))
+		})),
+	)
+}
+
+// ValidateCreateFXQuote checks an FX quote is for two different currencies with a margin below 1 and a
+// positive expiry of at most an hour.
+func (q *CreateFXQuote) ValidateCreateFXQuote() error {
+	return validation.ValidateStruct(q,
+		validation.Field(&q.BaseCurrency, validation.Required, validation.Length(3, 10)),
+		validation.Field(&q.QuoteCurrency, validation.Required, validation.Length(3, 10), validation.NotIn(q.BaseCurrency).Error("must differ from base_currency")),
+		validation.Field(&q.Margin, validation.Min(0.0), validation.Max(1.0).Exclusive()),
+		validation.Field(&q.ExpiresIn, validation.Min(0), validation.Max(3600)),
+	)
+}
+
+// ValidateCreateCurrency checks a custom asset has a code, a name, a power of ten precision and a known rounding.
+func (c *CreateCurrency) ValidateCreateCurrency() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Code, validation.Required, validation.Length(2, 10)),
+		validation.Field(&c.Name, validation.Required),
+		validation.Field(&c.Precision, validation.Required, validation.By(func(value interface{}) error {
+			if !model.IsValidPrecision(value.(float64)) {
+				return errors.New("must be a power of ten, e.g. 100 for two decimal places")
+			}
+			return nil
+		})),
+		validation.Field(&c.Rounding, validation.In(model.RoundingNone, model.RoundingDown, model.RoundingUp, model.RoundingHalfUp, model.RoundingHalfEven)),
+	)
+}
+
+// ValidateCreateFeeRule checks a fee rule is named, of a known type and pays its fees into a balance. The amounts
+// of the rule are checked when it is created.
+func (f *CreateFeeRule) ValidateCreateFeeRule() error {
+	return validation.ValidateStruct(f,
+		validation.Field(&f.Name, validation.Required),
+		validation.Field(&f.Type, validation.Required, validation.In(model.FeeTypePercentage, model.FeeTypeFixed, model.FeeTypeTiered)),
+		validation.Field(&f.Percentage, validation.When(f.Type == model.FeeTypePercentage, validation.Required), validation.Min(0.0), validation.Max(100.0)),
+		validation.Field(&f.Amount, validation.When(f.Type == model.FeeTypeFixed, validation.Required), validation.Min(0.0)),
+		validation.Field(&f.Tiers, validation.When(f.Type == model.FeeTypeTiered, validation.Required)),
+		validation.Field(&f.MinAmount, validation.Min(0.0)),
+		validation.Field(&f.MaxAmount, validation.Min(0.0)),
+		validation.Field(&f.FeeBalance, validation.Required),
 	)
 }
 
+// validateTransferRules checks the account types of every transfer rule of a ledger.
+func validateTransferRules(rules []TransferRule) error {
+	for i := range rules {
+		err := validation.ValidateStruct(&rules[i],
+			validation.Field(&rules[i].SourceType, validation.Required, accountTypeIn),
+			validation.Field(&rules[i].DestinationType, validation.Required, accountTypeIn),
+		)
+		if err != nil {
+			return fmt.Errorf("transfer_rules[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// accountTypeIn accepts the account types of a chart of accounts.
+var accountTypeIn = validation.In(model.AccountTypeAsset, model.AccountTypeLiability, model.AccountTypeEquity, model.AccountTypeIncome, model.AccountTypeExpense)
+
+// normalSideIn accepts the sides a balance can normally grow on.
+var normalSideIn = validation.In(model.NormalSideDebit, model.NormalSideCredit)
+
 func validateDateFormat(format, value string) error {
 	_, err := time.Parse(format, value)
 	if err != nil {
@@ -62,13 +174,56 @@ func (b *CreateBalance) ValidateCreateBalance() error {
 	return validation.ValidateStruct(b,
 		validation.Field(&b.LedgerId, validation.Required),
 		validation.Field(&b.Currency, validation.Required),
+		validation.Field(&b.OverdraftLimit, validation.By(overdraftLimitValidation(b.Precision))),
+		validation.Field(&b.AccountType, accountTypeIn),
+		validation.Field(&b.NormalSide, normalSideIn, validation.When(b.AccountType == "", validation.Empty.Error("requires an account type"))),
+	)
+}
+
+// ValidateUpdateBalanceAccountType checks the account type is known. The normal side is optional and defaults to
+// the account type's usual side.
+func (u *UpdateBalanceAccountType) ValidateUpdateBalanceAccountType() error {
+	return validation.ValidateStruct(u,
+		validation.Field(&u.AccountType, validation.Required, accountTypeIn),
+		validation.Field(&u.NormalSide, normalSideIn),
+	)
+}
+
+// ValidateUpdateOverdraftLimit only checks the sign of the limit, the balance precision is checked when the limit is applied.
+func (u *UpdateOverdraftLimit) ValidateUpdateOverdraftLimit() error {
+	return validation.ValidateStruct(u,
+		validation.Field(&u.OverdraftLimit, validation.Min(Amount(0)).Error("overdraft limit cannot be negative")),
 	)
 }
 
+// ValidateUpdateBalanceStatus checks the status is known and a reason is given. Whether the balance can move
+// to the status is checked when the change is applied.
+func (u *UpdateBalanceStatus) ValidateUpdateBalanceStatus() error {
+	return validation.ValidateStruct(u,
+		validation.Field(&u.Status, validation.Required, validation.In(model.BalanceStatusActive, model.BalanceStatusFrozen, model.BalanceStatusDebitBlocked, model.BalanceStatusClosed)),
+		validation.Field(&u.Reason, validation.Required, validation.Length(1, 500)),
+	)
+}
+
+// overdraftLimitValidation checks that an overdraft limit is not negative and converts into whole minor units.
+func overdraftLimitValidation(precision float64) validation.RuleFunc {
+	return func(value interface{}) error {
+		limit, ok := value.(Amount)
+		if !ok {
+			return errors.New("invalid type for overdraft limit")
+		}
+		if limit < 0 {
+			return errors.New("overdraft limit cannot be negative")
+		}
+		_, err := model.ToPreciseAmount(float64(limit), precision)
+		return err
+	}
+}
+
 func (b *CreateBalanceMonitor) ValidateCreateBalanceMonitor() error {
 	return validation.ValidateStruct(b,
 		validation.Field(&b.BalanceId, validation.Required),
-		validation.Field(&b.Condition, validation.Required, validation.By(func(value interface{}) error {
+		validation.Field(&b.Condition, validation.When(b.Conditions == nil, validation.Required, validation.By(func(value interface{}) error {
 			// Convert the interface{} to MonitorCondition type
 			condition, ok := value.(MonitorCondition)
 			if !ok {
@@ -77,13 +232,100 @@ func (b *CreateBalanceMonitor) ValidateCreateBalanceMonitor() error {
 			}
 			// Call the ValidateMonitorCondition method
 			return condition.ValidateMonitorCondition()
+		})).Else(validation.By(func(value interface{}) error {
+			if b.Condition != (MonitorCondition{}) {
+				return errors.New("either condition or conditions is required, not both")
+			}
+			return nil
+		}))),
+		validation.Field(&b.Conditions, validation.When(b.Conditions != nil, validation.By(func(value interface{}) error {
+			return b.Conditions.ValidateMonitorConditionGroup()
+		}))),
+		validation.Field(&b.TriggerMode, validation.In(model.MonitorTriggerLevel, model.MonitorTriggerEdge)),
+		validation.Field(&b.CooldownSeconds, validation.Min(int64(0))),
+		validation.Field(&b.CallBackURL, validation.By(func(value interface{}) error {
+			if b.CallBackURL == "" {
+				return nil
+			}
+			callBackURL, err := url.Parse(b.CallBackURL)
+			if err != nil || (callBackURL.Scheme != "http" && callBackURL.Scheme != "https") || callBackURL.Host == "" {
+				return errors.New("must be a valid http or https URL")
+			}
+			return nil
+		})),
+		validation.Field(&b.CallBackSecret, validation.When(b.CallBackSecret != "", validation.By(func(value interface{}) error {
+			if b.CallBackURL == "" {
+				return errors.New("requires a call_back_url")
+			}
+			return nil
+		}))),
+		validation.Field(&b.MaxRetries, validation.When(b.MaxRetries != nil, validation.Min(0), validation.Max(25))),
+		validation.Field(&b.RetryBackoff, validation.When(b.RetryBackoff != nil, validation.Min(int64(1)))),
+		validation.Field(&b.Actions, validation.By(func(value interface{}) error {
+			return validateMonitorActions(b.BalanceId, b.Actions)
 		})),
 	)
 }
 
+// validateMonitorActions checks every action of a monitor. A freeze cannot be combined with a sweep or top-up,
+// since the frozen balance would reject the transfer.
+func validateMonitorActions(balanceID string, actions []MonitorAction) error {
+	freezes, transfers := false, false
+	for i := range actions {
+		if err := actions[i].ValidateMonitorAction(balanceID); err != nil {
+			return fmt.Errorf("actions[%d]: %w", i, err)
+		}
+		if actions[i].Type == model.MonitorActionFreeze {
+			freezes = true
+		} else {
+			transfers = true
+		}
+	}
+	if freezes && transfers {
+		return errors.New("a freeze cannot be combined with a sweep or top_up")
+	}
+	return nil
+}
+
+// ValidateMonitorAction checks an action of the monitor on the given balance. Sweeps and top-ups need a
+// counterparty balance other than the monitored one and a target that converts into whole minor units.
+func (a *MonitorAction) ValidateMonitorAction(balanceID string) error {
+	transfer := a.Type != model.MonitorActionFreeze
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Type, validation.Required, validation.In(model.MonitorActionSweep, model.MonitorActionTopUp, model.MonitorActionFreeze)),
+		validation.Field(&a.CounterpartyBalanceID, validation.When(transfer, validation.Required, validation.NotIn(balanceID).Error("must differ from the monitored balance"))),
+		validation.Field(&a.Precision, validation.When(transfer, validation.Required)),
+		validation.Field(&a.Target, validation.When(transfer, validation.By(func(value interface{}) error {
+			_, err := model.ToPreciseAmount(a.Target, a.Precision)
+			return err
+		}))),
+	)
+}
+
+// ValidateMonitorConditionGroup checks the logic of a group and every condition and group nested in it.
+func (g *MonitorConditionGroup) ValidateMonitorConditionGroup() error {
+	if g.Logic != model.ConditionLogicAnd && g.Logic != model.ConditionLogicOr {
+		return fmt.Errorf("logic must be %s or %s", model.ConditionLogicAnd, model.ConditionLogicOr)
+	}
+	if len(g.Conditions) == 0 && len(g.Groups) == 0 {
+		return errors.New("a condition group needs at least one condition or group")
+	}
+	for i := range g.Conditions {
+		if err := g.Conditions[i].ValidateMonitorCondition(); err != nil {
+			return fmt.Errorf("conditions[%d]: %w", i, err)
+		}
+	}
+	for i := range g.Groups {
+		if err := g.Groups[i].ValidateMonitorConditionGroup(); err != nil {
+			return fmt.Errorf("groups[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
 func (c *MonitorCondition) ValidateMonitorCondition() error {
 	return validation.ValidateStruct(c,
-		validation.Field(&c.Field, validation.Required, validation.In("debit_balance", "credit_balance", "balance", "inflight_debit_balance", "inflight_credit_balance", "inflight_balance")),
+		validation.Field(&c.Field, validation.Required, validation.In("debit_balance", "credit_balance", "balance", "inflight_debit_balance", "inflight_credit_balance", "inflight_balance", "available_balance")),
 		validation.Field(&c.Operator, validation.Required),
 		validation.Field(&c.Precision, validation.Required),
 		validation.Field(&c.Value, validation.Required),
@@ -92,10 +334,41 @@ func (c *MonitorCondition) ValidateMonitorCondition() error {
 
 func (t *RecordTransaction) ValidateRecordTransaction() error {
 	return validation.ValidateStruct(t,
-		validation.Field(&t.Amount, validation.Required),
+		validation.Field(&t.Amount, validation.When(t.PreciseAmount == nil, validation.Required), validation.By(func(value interface{}) error {
+			// The amount must convert into whole minor units with the given precision. Without a precision,
+			// the currency registry decides it when the transaction is queued.
+			if t.Precision == 0 {
+				return nil
+			}
+			preciseAmount, err := model.ToPreciseAmount(float64(t.Amount), t.Precision)
+			if err != nil || t.Amount == 0 || t.PreciseAmount == nil {
+				return err
+			}
+			if preciseAmount.Cmp((*big.Int)(t.PreciseAmount)) != 0 {
+				return errors.New("amount does not match precise_amount")
+			}
+			return nil
+		})),
+		validation.Field(&t.PreciseAmount, validation.By(func(value interface{}) error {
+			if t.PreciseAmount != nil && (*big.Int)(t.PreciseAmount).Sign() <= 0 {
+				return errors.New("precise_amount must be positive")
+			}
+			return nil
+		})),
 		validation.Field(&t.Currency, validation.Required),
 		validation.Field(&t.Reference, validation.Required),
 		validation.Field(&t.Description, validation.Required),
+		validation.Field(&t.QuoteID, validation.When(t.QuoteID != "",
+			validation.By(func(value interface{}) error {
+				if t.Rate != 0 {
+					return errors.New("rate cannot be given with quote_id, the quoted rate applies")
+				}
+				if len(t.Sources) > 0 || len(t.Destinations) > 0 {
+					return errors.New("quote_id cannot be used with sources or destinations")
+				}
+				return nil
+			}),
+		)),
 		validation.Field(&t.Source, validation.By(sourceOrSourcesValidation(t))),
 		validation.Field(&t.Destination, validation.By(destinationOrDestinationsValidation(t))),
 		validation.Field(&t.ScheduledFor, validation.When(t.ScheduledFor != "", validation.By(func(value interface{}) error {
@@ -114,6 +387,23 @@ func (t *RecordTransaction) ValidateRecordTransaction() error {
 			return validateDateFormat("2006-01-02T15:04:05Z07:00", dateStr)
 		})),
 		),
+		validation.Field(&t.EffectiveDate, validation.When(t.EffectiveDate != "", validation.By(func(value interface{}) error {
+			effectiveDate, err := time.Parse("2006-01-02T15:04:05Z07:00", value.(string))
+			if err != nil {
+				return errors.New("please format the effective date as 'YYYY-MM-DDTHH:MM:SS+00:00' (e.g., 2024-04-22T15:28:03+00:00)")
+			}
+			if effectiveDate.After(time.Now()) {
+				return errors.New("effective date cannot be in the future, use scheduled_for to post a transaction later")
+			}
+			return nil
+		})),
+		),
+	)
+}
+
+func (r *RefundTransaction) ValidateRefundTransaction() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Amount, validation.Min(Amount(0)).Error("amount must not be negative")),
 	)
 }
 
@@ -138,29 +428,123 @@ func (a *CreateAccount) ValidateCreateAccount() error {
 }
 
 func (l *CreateLedger) ToLedger() model.Ledger {
-	return model.Ledger{Name: l.Name, MetaData: l.MetaData}
+	ledger := model.Ledger{Name: l.Name, MetaData: l.MetaData}
+	ledger.TransferRules = toTransferRules(l.TransferRules)
+	return ledger
+}
+
+func (u *UpdateLedgerTransferRules) ToTransferRules() []model.TransferRule {
+	return toTransferRules(u.TransferRules)
+}
+
+// Dates returns the start and end of the period to close. They are expected to have been validated.
+func (p *ClosePeriod) Dates() (time.Time, time.Time) {
+	start, err := time.Parse("2006-01-02T15:04:05Z07:00", p.StartDate)
+	if err != nil {
+		logrus.Error(err)
+	}
+	end, err := time.Parse("2006-01-02T15:04:05Z07:00", p.EndDate)
+	if err != nil {
+		logrus.Error(err)
+	}
+	return start, end
+}
+
+// EffectiveTime returns when the rate takes effect, or the zero time to make it effective now.
+// It is expected to have been validated.
+func (r *CreateFXRate) EffectiveTime() time.Time {
+	if r.EffectiveFrom == "" {
+		return time.Time{}
+	}
+	effectiveFrom, err := time.Parse("2006-01-02T15:04:05Z07:00", r.EffectiveFrom)
+	if err != nil {
+		logrus.Error(err)
+	}
+	return effectiveFrom
+}
+
+func (f *CreateFeeRule) ToFeeRule() model.FeeRule {
+	rule := model.FeeRule{Name: f.Name, Type: f.Type, Percentage: f.Percentage, Amount: f.Amount, MinAmount: f.MinAmount,
+		MaxAmount: f.MaxAmount, FeeBalance: f.FeeBalance, LedgerID: f.LedgerID, Currency: f.Currency, MetaData: f.MetaData,
+		IdentityCategory: f.IdentityCategory}
+	for _, tier := range f.Tiers {
+		rule.Tiers = append(rule.Tiers, model.FeeTier{UpTo: tier.UpTo, Percentage: tier.Percentage, Amount: tier.Amount})
+	}
+	return rule
+}
+
+func toTransferRules(rules []TransferRule) []model.TransferRule {
+	var transferRules []model.TransferRule
+	for _, rule := range rules {
+		transferRules = append(transferRules, model.TransferRule{SourceType: rule.SourceType, DestinationType: rule.DestinationType})
+	}
+	return transferRules
 }
 
 func (b *CreateBalance) ToBalance() model.Balance {
-	return model.Balance{LedgerID: b.LedgerId, IdentityID: b.IdentityId, Currency: b.Currency, MetaData: b.MetaData, CurrencyMultiplier: b.Precision}
+	// The limit has been validated against the precision, so the conversion cannot fail here
+	overdraftLimit, _ := model.ToPreciseAmount(float64(b.OverdraftLimit), b.Precision)
+	return model.Balance{LedgerID: b.LedgerId, IdentityID: b.IdentityId, Currency: b.Currency, MetaData: b.MetaData, CurrencyMultiplier: b.Precision, OverdraftLimit: overdraftLimit,
+		AccountType: b.AccountType, NormalSide: b.NormalSide}
 }
 
 func (b *CreateBalanceMonitor) ToBalanceMonitor() model.BalanceMonitor {
-	return model.BalanceMonitor{BalanceID: b.BalanceId, Condition: model.AlertCondition{
-		Field:     b.Condition.Field,
-		Operator:  b.Condition.Operator,
-		Value:     b.Condition.Value,
-		Precision: b.Condition.Precision,
-	}, CallBackURL: b.CallBackURL}
+	monitor := model.BalanceMonitor{BalanceID: b.BalanceId, Condition: b.Condition.toAlertCondition(), CallBackURL: b.CallBackURL,
+		CallBackSecret: b.CallBackSecret, Description: b.Description, TriggerMode: b.TriggerMode, CooldownSeconds: b.CooldownSeconds,
+		MaxRetries: model.DefaultMonitorMaxRetries, RetryBackoffSeconds: model.DefaultMonitorRetryBackoffSeconds}
+	if b.MaxRetries != nil {
+		monitor.MaxRetries = *b.MaxRetries
+	}
+	if b.RetryBackoff != nil {
+		monitor.RetryBackoffSeconds = *b.RetryBackoff
+	}
+	if b.Conditions != nil {
+		group := b.Conditions.toConditionGroup()
+		monitor.Conditions = &group
+	}
+	for _, action := range b.Actions {
+		monitor.Actions = append(monitor.Actions, model.MonitorAction{Type: action.Type, CounterpartyBalanceID: action.CounterpartyBalanceID,
+			Target: action.Target, Precision: action.Precision, Description: action.Description})
+	}
+	return monitor
+}
+
+func (c MonitorCondition) toAlertCondition() model.AlertCondition {
+	return model.AlertCondition{
+		Field:     c.Field,
+		Operator:  c.Operator,
+		Value:     c.Value,
+		Precision: c.Precision,
+	}
+}
+
+func (g MonitorConditionGroup) toConditionGroup() model.ConditionGroup {
+	group := model.ConditionGroup{Logic: g.Logic}
+	for _, condition := range g.Conditions {
+		group.Conditions = append(group.Conditions, condition.toAlertCondition())
+	}
+	for _, nested := range g.Groups {
+		group.Groups = append(group.Groups, nested.toConditionGroup())
+	}
+	return group
 }
 
 func (a *CreateAccount) ToAccount() model.Account {
 	return model.Account{BalanceID: a.BalanceId, LedgerID: a.LedgerId, IdentityID: a.IdentityId, Currency: a.Currency, Number: a.Number, BankName: a.BankName, MetaData: a.MetaData}
 }
 
+// preciseAmount returns a copy of the precise amount sent with the request, or nil when only an amount was sent.
+func (t *RecordTransaction) preciseAmount() *big.Int {
+	if t.PreciseAmount == nil {
+		return nil
+	}
+	return new(big.Int).Set((*big.Int)(t.PreciseAmount))
+}
+
 func (t *RecordTransaction) ToTransaction() *model.Transaction {
 	var scheduledFor time.Time
 	var inflightExpiryDate time.Time
+	var effectiveDate time.Time
 
 	if t.ScheduledFor != "" {
 		scheduledTime, err := time.Parse("2006-01-02T15:04:05Z07:00", t.ScheduledFor)
@@ -182,5 +566,65 @@ func (t *RecordTransaction) ToTransaction() *model.Transaction {
 
 	}
 
-	return &model.Transaction{Currency: t.Currency, Source: t.Source, Description: t.Description, Reference: t.Reference, ScheduledFor: scheduledFor, Destination: t.Destination, Amount: t.Amount, AllowOverdraft: t.AllowOverDraft, MetaData: t.MetaData, Sources: t.Sources, Destinations: t.Destinations, Inflight: t.Inflight, Precision: t.Precision, InflightExpiryDate: inflightExpiryDate, Rate: t.Rate}
+	if t.EffectiveDate != "" {
+		parsed, err := time.Parse("2006-01-02T15:04:05Z07:00", t.EffectiveDate)
+		if err != nil {
+			logrus.Error(err)
+		}
+		effectiveDate = parsed
+	}
+
+	return &model.Transaction{Currency: t.Currency, Source: t.Source, Description: t.Description, Reference: t.Reference, ScheduledFor: scheduledFor, Destination: t.Destination, Amount: float64(t.Amount), PreciseAmount: t.preciseAmount(), AllowOverdraft: t.AllowOverDraft, MetaData: t.MetaData, Sources: t.Sources, Destinations: t.Destinations, Inflight: t.Inflight, Atomic: t.Atomic, Precision: t.Precision, InflightExpiryDate: inflightExpiryDate, EffectiveDate: effectiveDate, Rate: t.Rate, QuoteID: t.QuoteID}
+}
+
+func (s *CreateSchedule) ValidateCreateSchedule() error {
+	err := validation.ValidateStruct(s,
+		validation.Field(&s.Transaction.Reference, validation.Required.Error("transaction reference is required")),
+		validation.Field(&s.Transaction.ScheduledFor, validation.Empty.Error("scheduled_for is not supported on a schedule template, use start_at")),
+		validation.Field(&s.Transaction.InflightExpiryDate, validation.Empty.Error("inflight_expiry_date is not supported on a schedule template")),
+		validation.Field(&s.Transaction.EffectiveDate, validation.Empty.Error("effective_date is not supported on a schedule template, each occurrence is booked when it runs")),
+		validation.Field(&s.Transaction.QuoteID, validation.Empty.Error("quote_id is not supported on a schedule template, a quote converts a single transaction")),
+		validation.Field(&s.StartAt, validation.When(s.StartAt != "", validation.By(func(value interface{}) error {
+			return validateDateFormat("2006-01-02T15:04:05Z07:00", value.(string))
+		}))),
+		validation.Field(&s.EndAt, validation.When(s.EndAt != "", validation.By(func(value interface{}) error {
+			return validateDateFormat("2006-01-02T15:04:05Z07:00", value.(string))
+		}))),
+		validation.Field(&s.MaxOccurrences, validation.Min(0)),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Transaction.ValidateRecordTransaction(); err != nil {
+		return err
+	}
+
+	schedule := s.ToSchedule()
+	if !schedule.EndAt.IsZero() && !schedule.StartAt.IsZero() && !schedule.EndAt.After(schedule.StartAt) {
+		return errors.New("end_at must be after start_at")
+	}
+	return schedule.ValidateRecurrence()
+}
+
+func (s *CreateSchedule) ToSchedule() *model.Schedule {
+	var startAt, endAt time.Time
+
+	if s.StartAt != "" {
+		parsed, err := time.Parse("2006-01-02T15:04:05Z07:00", s.StartAt)
+		if err != nil {
+			logrus.Error(err)
+		}
+		startAt = parsed
+	}
+
+	if s.EndAt != "" {
+		parsed, err := time.Parse("2006-01-02T15:04:05Z07:00", s.EndAt)
+		if err != nil {
+			logrus.Error(err)
+		}
+		endAt = parsed
+	}
+
+	return &model.Schedule{Transaction: *s.Transaction.ToTransaction(), Cron: s.Cron, RRule: s.RRule, StartAt: startAt, EndAt: endAt, MaxOccurrences: s.MaxOccurrences, MetaData: s.MetaData}
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -245,6 +246,196 @@ func TestValidateRecordTransaction(t *testing.T) {
 	}
 }
 
+func TestValidateRefundTransaction(t *testing.T) {
+	full := RefundTransaction{}
+	assert.NoError(t, full.ValidateRefundTransaction())
+
+	partial := RefundTransaction{Amount: 12.5}
+	assert.NoError(t, partial.ValidateRefundTransaction())
+
+	negative := RefundTransaction{Amount: -1}
+	assert.Error(t, negative.ValidateRefundTransaction())
+}
+
+func TestValidateCreateBalanceMonitor(t *testing.T) {
+	condition := MonitorCondition{Field: "balance", Operator: "<", Value: 100, Precision: 100}
+
+	single := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition}
+	assert.NoError(t, single.ValidateCreateBalanceMonitor())
+
+	group := CreateBalanceMonitor{BalanceId: "bln_1", TriggerMode: "edge", CooldownSeconds: 60, Conditions: &MonitorConditionGroup{
+		Logic:      "AND",
+		Conditions: []MonitorCondition{condition},
+		Groups:     []MonitorConditionGroup{{Logic: "OR", Conditions: []MonitorCondition{condition, condition}}},
+	}}
+	assert.NoError(t, group.ValidateCreateBalanceMonitor())
+
+	both := group
+	both.Condition = condition
+	assert.Error(t, both.ValidateCreateBalanceMonitor())
+
+	badLogic := CreateBalanceMonitor{BalanceId: "bln_1", Conditions: &MonitorConditionGroup{Logic: "XOR", Conditions: []MonitorCondition{condition}}}
+	assert.Error(t, badLogic.ValidateCreateBalanceMonitor())
+
+	emptyGroup := CreateBalanceMonitor{BalanceId: "bln_1", Conditions: &MonitorConditionGroup{Logic: "OR", Groups: []MonitorConditionGroup{{Logic: "AND"}}}}
+	assert.Error(t, emptyGroup.ValidateCreateBalanceMonitor())
+
+	badMode := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, TriggerMode: "always"}
+	assert.Error(t, badMode.ValidateCreateBalanceMonitor())
+
+	negativeCooldown := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, CooldownSeconds: -1}
+	assert.Error(t, negativeCooldown.ValidateCreateBalanceMonitor())
+
+	badURL := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, CallBackURL: "ftp://example.com"}
+	assert.Error(t, badURL.ValidateCreateBalanceMonitor())
+
+	secretWithoutURL := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, CallBackSecret: "whsec"}
+	assert.Error(t, secretWithoutURL.ValidateCreateBalanceMonitor())
+
+	tooManyRetries := 26
+	retries := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, CallBackURL: "https://example.com/hooks", MaxRetries: &tooManyRetries}
+	assert.Error(t, retries.ValidateCreateBalanceMonitor())
+
+	noRetries, backoff := 0, int64(5)
+	callback := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, CallBackURL: "https://example.com/hooks", CallBackSecret: "whsec",
+		MaxRetries: &noRetries, RetryBackoff: &backoff}
+	assert.NoError(t, callback.ValidateCreateBalanceMonitor())
+	callbackMonitor := callback.ToBalanceMonitor()
+	assert.Equal(t, "whsec", callbackMonitor.CallBackSecret)
+	assert.Equal(t, 0, callbackMonitor.MaxRetries)
+	assert.Equal(t, int64(5), callbackMonitor.RetryBackoffSeconds)
+
+	monitor := group.ToBalanceMonitor()
+	assert.Equal(t, 5, monitor.MaxRetries)
+	if assert.NotNil(t, monitor.Conditions) {
+		assert.Equal(t, "AND", monitor.Conditions.Logic)
+		assert.Len(t, monitor.Conditions.Groups[0].Conditions, 2)
+	}
+	assert.Equal(t, int64(60), monitor.CooldownSeconds)
+}
+
+func TestValidateCreateBalanceMonitor_Actions(t *testing.T) {
+	condition := MonitorCondition{Field: "balance", Operator: ">", Value: 1000, Precision: 100}
+	sweep := MonitorAction{Type: "sweep", CounterpartyBalanceID: "bln_savings", Target: 500, Precision: 100}
+
+	valid := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, Actions: []MonitorAction{sweep, {Type: "top_up", CounterpartyBalanceID: "bln_funding", Precision: 100}}}
+	assert.NoError(t, valid.ValidateCreateBalanceMonitor())
+	monitor := valid.ToBalanceMonitor()
+	if assert.Len(t, monitor.Actions, 2) {
+		assert.Equal(t, "sweep", monitor.Actions[0].Type)
+		assert.Equal(t, "bln_savings", monitor.Actions[0].CounterpartyBalanceID)
+		assert.Equal(t, float64(500), monitor.Actions[0].Target)
+	}
+
+	freeze := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, Actions: []MonitorAction{{Type: "freeze"}}}
+	assert.NoError(t, freeze.ValidateCreateBalanceMonitor())
+
+	unknown := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, Actions: []MonitorAction{{Type: "close"}}}
+	assert.Error(t, unknown.ValidateCreateBalanceMonitor())
+
+	noCounterparty := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, Actions: []MonitorAction{{Type: "sweep", Target: 500, Precision: 100}}}
+	assert.Error(t, noCounterparty.ValidateCreateBalanceMonitor())
+
+	toItself := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, Actions: []MonitorAction{{Type: "sweep", CounterpartyBalanceID: "bln_1", Target: 500, Precision: 100}}}
+	assert.Error(t, toItself.ValidateCreateBalanceMonitor())
+
+	noPrecision := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, Actions: []MonitorAction{{Type: "top_up", CounterpartyBalanceID: "bln_funding", Target: 500}}}
+	assert.Error(t, noPrecision.ValidateCreateBalanceMonitor())
+
+	inexactTarget := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, Actions: []MonitorAction{{Type: "sweep", CounterpartyBalanceID: "bln_savings", Target: 500.001, Precision: 100}}}
+	assert.Error(t, inexactTarget.ValidateCreateBalanceMonitor())
+
+	freezeAndSweep := CreateBalanceMonitor{BalanceId: "bln_1", Condition: condition, Actions: []MonitorAction{sweep, {Type: "freeze"}}}
+	assert.Error(t, freezeAndSweep.ValidateCreateBalanceMonitor())
+}
+
+func TestValidateUpdateBalanceStatus(t *testing.T) {
+	assert.NoError(t, (&UpdateBalanceStatus{Status: "FROZEN", Reason: "card reported stolen"}).ValidateUpdateBalanceStatus())
+	assert.NoError(t, (&UpdateBalanceStatus{Status: "DEBIT_BLOCKED", Reason: "pending KYC review"}).ValidateUpdateBalanceStatus())
+	assert.Error(t, (&UpdateBalanceStatus{Status: "FROZEN"}).ValidateUpdateBalanceStatus())
+	assert.Error(t, (&UpdateBalanceStatus{Status: "frozen", Reason: "lowercase status"}).ValidateUpdateBalanceStatus())
+	assert.Error(t, (&UpdateBalanceStatus{Status: "DORMANT", Reason: "unknown status"}).ValidateUpdateBalanceStatus())
+}
+
+func TestValidateAccountTypes(t *testing.T) {
+	assert.NoError(t, (&CreateBalance{LedgerId: "ldg1", Currency: "USD", AccountType: "asset"}).ValidateCreateBalance())
+	assert.NoError(t, (&CreateBalance{LedgerId: "ldg1", Currency: "USD", AccountType: "asset", NormalSide: "credit"}).ValidateCreateBalance())
+	assert.Error(t, (&CreateBalance{LedgerId: "ldg1", Currency: "USD", AccountType: "revenue"}).ValidateCreateBalance())
+	assert.Error(t, (&CreateBalance{LedgerId: "ldg1", Currency: "USD", NormalSide: "debit"}).ValidateCreateBalance())
+
+	assert.NoError(t, (&UpdateBalanceAccountType{AccountType: "expense"}).ValidateUpdateBalanceAccountType())
+	assert.Error(t, (&UpdateBalanceAccountType{NormalSide: "debit"}).ValidateUpdateBalanceAccountType())
+	assert.Error(t, (&UpdateBalanceAccountType{AccountType: "income", NormalSide: "left"}).ValidateUpdateBalanceAccountType())
+
+	rules := []TransferRule{{SourceType: "asset", DestinationType: "liability"}}
+	assert.NoError(t, (&CreateLedger{Name: "wallets", TransferRules: rules}).ValidateCreateLedger())
+	assert.Error(t, (&CreateLedger{Name: "wallets", TransferRules: []TransferRule{{SourceType: "asset"}}}).ValidateCreateLedger())
+	assert.NoError(t, (&UpdateLedgerTransferRules{}).ValidateUpdateLedgerTransferRules())
+	assert.Error(t, (&UpdateLedgerTransferRules{TransferRules: []TransferRule{{SourceType: "cash", DestinationType: "asset"}}}).ValidateUpdateLedgerTransferRules())
+}
+
+func TestValidatePeriods(t *testing.T) {
+	period := ClosePeriod{Name: "September 2026", StartDate: "2026-08-31T23:59:59Z", EndDate: "2026-09-30T23:59:59Z"}
+	assert.NoError(t, period.ValidateClosePeriod())
+	start, end := period.Dates()
+	assert.Equal(t, time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC), end)
+	assert.True(t, start.Before(end))
+
+	assert.Error(t, (&ClosePeriod{StartDate: period.StartDate, EndDate: period.EndDate}).ValidateClosePeriod())
+	assert.Error(t, (&ClosePeriod{Name: "September 2026", StartDate: "2026-08-31", EndDate: period.EndDate}).ValidateClosePeriod())
+
+	assert.NoError(t, (&ReopenPeriod{Reason: "late supplier invoice"}).ValidateReopenPeriod())
+	assert.Error(t, (&ReopenPeriod{}).ValidateReopenPeriod())
+}
+
+func TestValidateCreateFXRate(t *testing.T) {
+	rate := CreateFXRate{BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1550.5, EffectiveFrom: "2026-10-01T00:00:00Z"}
+	assert.NoError(t, rate.ValidateCreateFXRate())
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), rate.EffectiveTime())
+
+	// Without an effective time the rate takes effect when it is recorded
+	assert.NoError(t, (&CreateFXRate{BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1550.5}).ValidateCreateFXRate())
+	assert.True(t, (&CreateFXRate{}).EffectiveTime().IsZero())
+
+	assert.Error(t, (&CreateFXRate{BaseCurrency: "USD", QuoteCurrency: "USD", Rate: 1}).ValidateCreateFXRate())
+	assert.Error(t, (&CreateFXRate{BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: -2}).ValidateCreateFXRate())
+	assert.Error(t, (&CreateFXRate{BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1550.5, EffectiveFrom: "2026-10-01"}).ValidateCreateFXRate())
+}
+
+func TestValidateFXQuote(t *testing.T) {
+	assert.NoError(t, (&CreateFXQuote{BaseCurrency: "USD", QuoteCurrency: "NGN", Margin: 0.01, ExpiresIn: 60}).ValidateCreateFXQuote())
+	assert.NoError(t, (&CreateFXQuote{BaseCurrency: "USD", QuoteCurrency: "NGN"}).ValidateCreateFXQuote())
+	assert.Error(t, (&CreateFXQuote{BaseCurrency: "USD", QuoteCurrency: "NGN", Margin: 1}).ValidateCreateFXQuote())
+	assert.Error(t, (&CreateFXQuote{BaseCurrency: "USD", QuoteCurrency: "NGN", ExpiresIn: 7200}).ValidateCreateFXQuote())
+
+	txn := RecordTransaction{Amount: 100, Precision: 100, Currency: "USD", Reference: "ref_fx", Description: "conversion", Source: "bln_usd", Destination: "bln_ngn", QuoteID: "fxq_1"}
+	assert.NoError(t, txn.ValidateRecordTransaction())
+	assert.Equal(t, "fxq_1", txn.ToTransaction().QuoteID)
+
+	txn.Rate = 1600
+	assert.ErrorContains(t, txn.ValidateRecordTransaction(), "rate cannot be given with quote_id")
+}
+
+func TestValidateCreateCurrency(t *testing.T) {
+	assert.NoError(t, (&CreateCurrency{Code: "PTS", Name: "Loyalty points", Precision: 100}).ValidateCreateCurrency())
+	assert.NoError(t, (&CreateCurrency{Code: "USDT", Name: "Tether", Precision: 1e6, Rounding: "HALF_EVEN"}).ValidateCreateCurrency())
+	assert.Error(t, (&CreateCurrency{Code: "PTS", Name: "Loyalty points"}).ValidateCreateCurrency())
+	assert.Error(t, (&CreateCurrency{Code: "PTS", Name: "Loyalty points", Precision: 25}).ValidateCreateCurrency())
+	assert.Error(t, (&CreateCurrency{Code: "PTS", Name: "Loyalty points", Precision: 100, Rounding: "CEILING"}).ValidateCreateCurrency())
+}
+
+func TestValidateCreateFeeRule(t *testing.T) {
+	rule := CreateFeeRule{Name: "Transfer fee", Type: "tiered", Currency: "USD", FeeBalance: "@Fees", Tiers: []FeeTier{{UpTo: 100, Amount: 1}, {Percentage: 0.5}}}
+	assert.NoError(t, rule.ValidateCreateFeeRule())
+	assert.Equal(t, []model.FeeTier{{UpTo: 100, Amount: 1}, {Percentage: 0.5}}, rule.ToFeeRule().Tiers)
+
+	assert.NoError(t, (&CreateFeeRule{Name: "Card fee", Type: "percentage", Percentage: 1.5, FeeBalance: "@Fees"}).ValidateCreateFeeRule())
+	assert.Error(t, (&CreateFeeRule{Name: "Card fee", Type: "percentage", FeeBalance: "@Fees"}).ValidateCreateFeeRule())
+	assert.Error(t, (&CreateFeeRule{Name: "Card fee", Type: "flat", Amount: 1, FeeBalance: "@Fees"}).ValidateCreateFeeRule())
+	assert.Error(t, (&CreateFeeRule{Name: "Card fee", Type: "fixed", Amount: 1}).ValidateCreateFeeRule())
+	assert.Error(t, (&CreateFeeRule{Name: "Card fee", Type: "tiered", FeeBalance: "@Fees"}).ValidateCreateFeeRule())
+}
+
 func TestToLedger(t *testing.T) {
 	createLedger := CreateLedger{
 		Name:     "Test Ledger",
@@ -327,7 +518,7 @@ func TestToTransaction(t *testing.T) {
 	assert.Equal(t, recordTransaction.Description, transaction.Description)
 	assert.Equal(t, recordTransaction.Reference, transaction.Reference)
 	assert.Equal(t, recordTransaction.Destination, transaction.Destination)
-	assert.Equal(t, recordTransaction.Amount, transaction.Amount)
+	assert.Equal(t, float64(recordTransaction.Amount), transaction.Amount)
 	assert.Equal(t, recordTransaction.AllowOverDraft, transaction.AllowOverdraft)
 	assert.Equal(t, recordTransaction.MetaData, transaction.MetaData)
 	assert.Equal(t, recordTransaction.Sources, transaction.Sources)
@@ -336,3 +527,68 @@ func TestToTransaction(t *testing.T) {
 	assert.Equal(t, recordTransaction.Precision, transaction.Precision)
 	assert.Equal(t, recordTransaction.Rate, transaction.Rate)
 }
+
+func TestEffectiveDate(t *testing.T) {
+	recordTransaction := RecordTransaction{Currency: "USD", Source: "@card-settlements", Destination: "bln_1", Amount: 10, Reference: "settlement_1", Description: "Card settlement",
+		EffectiveDate: "2024-05-01T00:00:00Z"}
+	assert.NoError(t, recordTransaction.ValidateRecordTransaction())
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), recordTransaction.ToTransaction().EffectiveDate)
+
+	recordTransaction.EffectiveDate = "2024-05-01"
+	assert.Error(t, recordTransaction.ValidateRecordTransaction())
+
+	recordTransaction.EffectiveDate = time.Now().Add(time.Hour).Format(time.RFC3339)
+	assert.Error(t, recordTransaction.ValidateRecordTransaction())
+}
+
+func TestAmountUnmarshalJSON(t *testing.T) {
+	var txn RecordTransaction
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "19.99", "precision": 100}`), &txn))
+	assert.Equal(t, Amount(19.99), txn.Amount)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 19.99}`), &txn))
+	assert.Equal(t, Amount(19.99), txn.Amount)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": "19.99.1"}`), &txn))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": "1234567890.123456789"}`), &txn))
+}
+
+func TestValidateRecordTransactionAmountPrecision(t *testing.T) {
+	txn := RecordTransaction{Amount: 19.999, Precision: 100, Currency: "USD", Reference: "ref", Description: "test", Source: "bln_1", Destination: "bln_2"}
+	assert.Error(t, txn.ValidateRecordTransaction())
+
+	txn.Amount = 19.99
+	assert.NoError(t, txn.ValidateRecordTransaction())
+
+	// Without a precision, the currency registry checks the amount when the transaction is queued
+	txn.Amount = 19.999
+	txn.Precision = 0
+	assert.NoError(t, txn.ValidateRecordTransaction())
+}
+
+func TestRecordTransactionPreciseAmount(t *testing.T) {
+	var txn RecordTransaction
+	payload := `{"precise_amount": "1500000000000000000000000", "precision": 1000000000000000000, "currency": "ETH", "reference": "ref", "description": "test", "source": "bln_1", "destination": "bln_2"}`
+	assert.NoError(t, json.Unmarshal([]byte(payload), &txn))
+	assert.NoError(t, txn.ValidateRecordTransaction())
+	assert.Equal(t, "1500000000000000000000000", txn.ToTransaction().PreciseAmount.String())
+
+	txn.Amount = 2
+	assert.Error(t, txn.ValidateRecordTransaction())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"precise_amount": "1.5"}`), &txn))
+}
+
+func TestCreateBalanceOverdraftLimit(t *testing.T) {
+	balance := CreateBalance{LedgerId: "ldg_1", Currency: "USD", Precision: 100, OverdraftLimit: 5000}
+	assert.NoError(t, balance.ValidateCreateBalance())
+	assert.Equal(t, "500000", balance.ToBalance().OverdraftLimit.String())
+
+	balance.OverdraftLimit = -1
+	assert.Error(t, balance.ValidateCreateBalance())
+
+	update := UpdateOverdraftLimit{OverdraftLimit: -1}
+	assert.Error(t, update.ValidateUpdateOverdraftLimit())
+	update.OverdraftLimit = 0
+	assert.NoError(t, update.ValidateUpdateOverdraftLimit())
+}
@@ -0,0 +1,26 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+type CreateSchedule struct {
+	Transaction    RecordTransaction      `json:"transaction"`
+	Cron           string                 `json:"cron"`
+	RRule          string                 `json:"rrule"`
+	StartAt        string                 `json:"start_at"`
+	EndAt          string                 `json:"end_at"`
+	MaxOccurrences int                    `json:"max_occurrences"`
+	MetaData       map[string]interface{} `json:"meta_data"`
+}
@@ -16,15 +16,69 @@ limitations under the License.
 package model
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
 	"github.com/jerry-enebeli/blnk/model"
 )
 
+// Amount is a transaction amount that can be sent either as a JSON number (19.99) or as a decimal string ("19.99").
+// Amounts that cannot be held without losing a digit are rejected instead of being rounded.
+type Amount float64
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	raw := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+
+	amount, err := model.ParseAmount(raw)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	*a = Amount(amount)
+	return nil
+}
+
+// PreciseAmount is an amount already expressed in minor units (amount * precision), sent as a JSON number or string.
+// It is meant for assets with many decimal places, such as 18-decimal tokens, whose amounts do not fit in Amount.
+type PreciseAmount big.Int
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *PreciseAmount) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+
+	precise, err := model.ParsePreciseAmount(raw)
+	if err != nil {
+		return err
+	}
+	(*big.Int)(p).Set(precise)
+	return nil
+}
+
 type RecordTransaction struct {
-	Amount             float64                `json:"amount"`
+	Amount             Amount                 `json:"amount"`
+	PreciseAmount      *PreciseAmount         `json:"precise_amount"`
 	Rate               float64                `json:"rate"`
+	QuoteID            string                 `json:"quote_id,omitempty"`
 	Precision          float64                `json:"precision"`
 	AllowOverDraft     bool                   `json:"allow_overdraft"`
 	Inflight           bool                   `json:"inflight"`
+	Atomic             bool                   `json:"atomic"`
 	Source             string                 `json:"source"`
 	Reference          string                 `json:"reference"`
 	Destination        string                 `json:"destination"`
@@ -33,12 +87,17 @@ type RecordTransaction struct {
 	BalanceId          string                 `json:"balance_id"`
 	ScheduledFor       string                 `json:"scheduled_for"`
 	InflightExpiryDate string                 `json:"inflight_expiry_date,omitempty"`
+	EffectiveDate      string                 `json:"effective_date,omitempty"`
 	Sources            []model.Distribution   `json:"sources"`
 	Destinations       []model.Distribution   `json:"destinations"`
 	MetaData           map[string]interface{} `json:"meta_data"`
 }
 
+type RefundTransaction struct {
+	Amount Amount `json:"amount"`
+}
+
 type InflightUpdate struct {
-	Status string  `json:"status"`
-	Amount float64 `json:"amount"`
+	Status string `json:"status"`
+	Amount Amount `json:"amount"`
 }
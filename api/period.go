@@ -0,0 +1,184 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	model2 "github.com/jerry-enebeli/blnk/api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClosePeriod closes an accounting period of a ledger, e.g. September 2026, and snapshots its balances.
+// It binds the incoming JSON request to a ClosePeriod object, validates it, and closes the period.
+// Once closed, transactions with a posting date in the period are rejected on the ledger's balances.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, or there's an error in binding JSON, validating the period, or closing it.
+// - 201 Created: If the period is successfully closed.
+func (a Api) ClosePeriod(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	var req model2.ClosePeriod
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateClosePeriod(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	start, end := req.Dates()
+	resp, err := a.blnk.ClosePeriod(c.Request.Context(), id, req.Name, start, end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetLedgerPeriods retrieves the accounting periods of a ledger, latest first, including reopened ones.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing or there's an error retrieving the periods.
+// - 200 OK: If the periods are successfully retrieved.
+func (a Api) GetLedgerPeriods(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	resp, err := a.blnk.GetAccountingPeriods(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetPeriod retrieves an accounting period by its ID.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing or there's an error retrieving the period.
+// - 200 OK: If the period is successfully retrieved.
+func (a Api) GetPeriod(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	resp, err := a.blnk.GetAccountingPeriod(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetPeriodBalances retrieves the balances of a ledger as they stood when one of its accounting periods was closed.
+// The page is controlled by the 'limit' and 'offset' query parameters.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID or pagination parameters are invalid or there's an error retrieving the balances.
+// - 200 OK: If the balances are successfully retrieved.
+func (a Api) GetPeriodBalances(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset value"})
+		return
+	}
+
+	resp, err := a.blnk.GetPeriodBalances(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReopenPeriod reopens a closed accounting period so transactions can be posted in it again.
+// It binds the incoming JSON request to a ReopenPeriod object and requires a reason, which is recorded on the period.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, or there's an error in binding JSON, validating the reason, or reopening the period.
+// - 200 OK: If the period is successfully reopened.
+func (a Api) ReopenPeriod(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	var req model2.ReopenPeriod
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.ValidateReopenPeriod(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.ReopenPeriod(c.Request.Context(), id, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jerry-enebeli/blnk"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseReportTime reads an optional RFC3339 time from the query, returning the zero time when it is absent.
+func parseReportTime(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s. use the RFC3339 format e.g 2024-09-30T23:59:59Z", name)
+	}
+	return parsed, nil
+}
+
+// parseReportFormat reads the export format of a report from the query, json unless csv is asked for.
+func parseReportFormat(c *gin.Context) (string, error) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		return "", fmt.Errorf("invalid format. use json or csv")
+	}
+	return format, nil
+}
+
+// writeReportCSV sends a report rendered as CSV as a file attachment.
+func writeReportCSV(c *gin.Context, filename string, write func(buf *bytes.Buffer) error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+// GetTrialBalance builds the trial balance of a ledger for the period given by the optional 'from' and 'to' query
+// parameters, and returns it as JSON or, with 'format=csv', as a CSV file.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, 'from', 'to' or 'format' is invalid, or there's an error building the trial balance.
+// - 200 OK: If the trial balance is successfully built, in the requested format.
+func (a Api) GetTrialBalance(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	from, err := parseReportTime(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseReportTime(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format, err := parseReportFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trialBalance, err := a.blnk.GetTrialBalance(c.Request.Context(), id, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, trialBalance)
+		return
+	}
+	filename := fmt.Sprintf("trial_balance_%s_%s.csv", id, trialBalance.To.Format("20060102"))
+	writeReportCSV(c, filename, func(buf *bytes.Buffer) error {
+		return blnk.WriteTrialBalanceCSV(buf, trialBalance)
+	})
+}
+
+// GetBalanceSheet builds the balance sheet of a ledger at the time given by the optional 'as_of' query parameter,
+// in the optional 'currency', and returns it as JSON or, with 'format=csv', as a CSV file.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, 'as_of' or 'format' is invalid, or there's an error building the balance sheet.
+// - 200 OK: If the balance sheet is successfully built, in the requested format.
+func (a Api) GetBalanceSheet(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	asOf, err := parseReportTime(c, "as_of")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format, err := parseReportFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sheet, err := a.blnk.GetBalanceSheet(c.Request.Context(), id, c.Query("currency"), asOf)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, sheet)
+		return
+	}
+	filename := fmt.Sprintf("balance_sheet_%s_%s.csv", id, sheet.AsOf.Format("20060102"))
+	writeReportCSV(c, filename, func(buf *bytes.Buffer) error {
+		return blnk.WriteBalanceSheetCSV(buf, sheet)
+	})
+}
+
+// GetIncomeStatement builds the income statement of a ledger for the period given by the optional 'from' and 'to'
+// query parameters, in the optional 'currency', and returns it as JSON or, with 'format=csv', as a CSV file.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If the ID is missing, 'from', 'to' or 'format' is invalid, or there's an error building the income statement.
+// - 200 OK: If the income statement is successfully built, in the requested format.
+func (a Api) GetIncomeStatement(c *gin.Context) {
+	id, passed := c.Params.Get("id")
+	if !passed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required. Pass id in the route /:id"})
+		return
+	}
+
+	from, err := parseReportTime(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseReportTime(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format, err := parseReportFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	statement, err := a.blnk.GetIncomeStatement(c.Request.Context(), id, c.Query("currency"), from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, statement)
+		return
+	}
+	filename := fmt.Sprintf("income_statement_%s_%s_%s.csv", id, statement.From.Format("20060102"), statement.To.Format("20060102"))
+	writeReportCSV(c, filename, func(buf *bytes.Buffer) error {
+		return blnk.WriteIncomeStatementCSV(buf, statement)
+	})
+}
@@ -17,6 +17,7 @@ package api
 
 import (
 	"context"
+	"math/big"
 	"net/http"
 	"testing"
 
@@ -153,8 +154,8 @@ func TestRecordTransaction(t *testing.T) {
 			assert.Equal(t, tt.expectedCode, resp.Code)
 
 			if !tt.wantErr && tt.expectedCode == http.StatusCreated {
-				assert.Equal(t, tt.payload.Amount, response.Amount)
-				assert.Equal(t, int64(tt.payload.Precision*tt.payload.Amount), response.PreciseAmount)
+				assert.Equal(t, float64(tt.payload.Amount), response.Amount)
+				assert.Equal(t, big.NewInt(int64(tt.payload.Precision*float64(tt.payload.Amount))).String(), response.PreciseAmount.String())
 				assert.Equal(t, tt.payload.Reference, response.Reference)
 				assert.Equal(t, tt.payload.Description, response.Description)
 				assert.Equal(t, tt.payload.Currency, response.Currency)
@@ -16,8 +16,13 @@ limitations under the License.
 package api
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -146,14 +151,15 @@ func (a Api) QueueTransaction(c *gin.Context) {
 }
 
 // RefundTransaction processes a refund for a transaction based on the given ID.
-// It retrieves the transaction to be refunded and processes it in batches. If any errors
+// The request body may carry an amount for a partial refund; without one, everything that has not been refunded yet
+// is refunded. It retrieves the transaction to be refunded and processes it in batches. If any errors
 // occur during retrieval or processing, it responds with an appropriate error message.
 //
 // Parameters:
 // - c: The Gin context containing the request and response.
 //
 // Responses:
-// - 400 Bad Request: If there's an error in retrieving the transaction or no transaction is found to refund.
+// - 400 Bad Request: If the amount is invalid, there's an error in retrieving the transaction or no transaction is found to refund.
 // - 201 Created: If the refund is successfully processed.
 func (a Api) RefundTransaction(c *gin.Context) {
 	id, passed := c.Params.Get("id")
@@ -165,13 +171,37 @@ func (a Api) RefundTransaction(c *gin.Context) {
 		})
 		return
 	}
-	transaction, err := a.blnk.ProcessTransactionInBatches(c.Request.Context(), id, 0, 1, false, a.blnk.GetRefundableTransactionsByParentID, a.blnk.RefundWorker)
+
+	// The body is optional, an empty one refunds the full refundable amount
+	var req model2.RefundTransaction
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input format",
+			"details": err.Error(),
+			"code":    "INVALID_INPUT",
+		})
+		return
+	}
+	if err := req.ValidateRefundTransaction(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid refund amount",
+			"details": err.Error(),
+			"code":    "INVALID_AMOUNT",
+		})
+		return
+	}
+
+	transaction, err := a.blnk.RefundTransactions(c.Request.Context(), id, float64(req.Amount))
 	if err != nil {
 		errorCode := "REFUND_ERROR"
 		if strings.Contains(err.Error(), "not in a state that can be refunded") {
 			errorCode = "INVALID_STATUS"
 		} else if strings.Contains(err.Error(), "transaction not found") {
 			errorCode = "NOT_FOUND"
+		} else if strings.Contains(err.Error(), "already been fully refunded") {
+			errorCode = "ALREADY_REFUNDED"
+		} else if strings.Contains(err.Error(), "cannot refund") {
+			errorCode = "INVALID_AMOUNT"
 		}
 		
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -193,6 +223,84 @@ func (a Api) RefundTransaction(c *gin.Context) {
 	c.JSON(http.StatusCreated, resp)
 }
 
+// GetTransactions lists transactions, newest first, straight from the database.
+// Transactions can be filtered with the source, destination, balance_id, status, currency, reference_prefix,
+// parent_transaction, from and to (creation time), effective_from and effective_to (effective date) query parameters,
+// and by metadata with meta_data.<key>=<value>.
+// Pages are requested with 'limit' and the 'cursor' returned as next_cursor with the previous page.
+//
+// Parameters:
+// - c: The Gin context containing the request and response.
+//
+// Responses:
+// - 400 Bad Request: If a query parameter or the cursor is invalid, or there's an error retrieving the transactions.
+// - 200 OK: If the transactions are successfully retrieved.
+func (a Api) GetTransactions(c *gin.Context) {
+	filter, err := transactionFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := a.blnk.ListTransactions(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// transactionFilterFromQuery builds a transaction filter from the query parameters of a request.
+//
+// Parameters:
+// - c: The Gin context containing the request.
+//
+// Returns:
+// - model.TransactionFilter: The filter described by the query parameters.
+// - error: An error if the limit or a date is invalid.
+func transactionFilterFromQuery(c *gin.Context) (model.TransactionFilter, error) {
+	filter := model.TransactionFilter{
+		Source:            c.Query("source"),
+		Destination:       c.Query("destination"),
+		BalanceID:         c.Query("balance_id"),
+		Status:            strings.ToUpper(c.Query("status")),
+		Currency:          c.Query("currency"),
+		ReferencePrefix:   c.Query("reference_prefix"),
+		ParentTransaction: c.Query("parent_transaction"),
+		Cursor:            c.Query("cursor"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		limitInt, err := strconv.Atoi(limit)
+		if err != nil || limitInt < 1 {
+			return filter, errors.New("invalid limit value")
+		}
+		filter.Limit = limitInt
+	}
+
+	for param, dest := range map[string]*time.Time{"from": &filter.From, "to": &filter.To, "effective_from": &filter.EffectiveFrom, "effective_to": &filter.EffectiveTo} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s date, use the format 'YYYY-MM-DDTHH:MM:SS+00:00'", param)
+			}
+			*dest = parsed
+		}
+	}
+
+	for param, values := range c.Request.URL.Query() {
+		if key, found := strings.CutPrefix(param, "meta_data."); found && key != "" && len(values) > 0 {
+			if filter.MetaData == nil {
+				filter.MetaData = make(map[string]string)
+			}
+			filter.MetaData[key] = values[0]
+		}
+	}
+
+	return filter, nil
+}
+
 // GetTransaction retrieves a transaction by its ID.
 // It returns the transaction details if found. If the ID is not provided or an error
 // occurs while retrieving the transaction, it responds with an appropriate error message.
@@ -262,7 +370,7 @@ func (a Api) UpdateInflightStatus(c *gin.Context) {
 
 	status := req.Status
 	if status == "commit" {
-		transaction, err := a.blnk.ProcessTransactionInBatches(c.Request.Context(), id, req.Amount, 1, false, a.blnk.GetInflightTransactionsByParentID, a.blnk.CommitWorker)
+		transaction, err := a.blnk.ProcessTransactionInBatches(c.Request.Context(), id, float64(req.Amount), 1, false, a.blnk.GetInflightTransactionsByParentID, a.blnk.CommitWorker)
 		if err != nil {
 			errorCode := "COMMIT_ERROR"
 			if strings.Contains(err.Error(), "not in inflight status") {
@@ -288,7 +396,7 @@ func (a Api) UpdateInflightStatus(c *gin.Context) {
 		}
 		resp = transaction[0]
 	} else if status == "void" {
-		transaction, err := a.blnk.ProcessTransactionInBatches(c.Request.Context(), id, req.Amount, 1, false, a.blnk.GetInflightTransactionsByParentID, a.blnk.VoidWorker)
+		transaction, err := a.blnk.ProcessTransactionInBatches(c.Request.Context(), id, float64(req.Amount), 1, false, a.blnk.GetInflightTransactionsByParentID, a.blnk.VoidWorker)
 		if err != nil {
 			errorCode := "VOID_ERROR"
 			if strings.Contains(err.Error(), "not in inflight status") {
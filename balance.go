@@ -19,7 +19,10 @@ package blnk
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	redlock "github.com/jerry-enebeli/blnk/internal/lock"
 	"github.com/jerry-enebeli/blnk/internal/notification"
 	"github.com/jerry-enebeli/blnk/model"
 	"go.opentelemetry.io/otel"
@@ -32,6 +35,15 @@ var (
 	balanceTracer = otel.Tracer("blnk.transactions")
 )
 
+const (
+	// snapshotDelay is how far in the past balance snapshots are taken.
+	snapshotDelay = 5 * time.Minute
+	// snapshotBatchSize is the number of balances read per page when taking snapshots.
+	snapshotBatchSize = 1000
+	// monitorLockWait is how long a monitor action waits for the lock of the balance it changes.
+	monitorLockWait = 30 * time.Second
+)
+
 // NewBalanceTracker creates a new BalanceTracker instance.
 // It initializes the Balances and Frequencies maps.
 //
@@ -44,15 +56,66 @@ func NewBalanceTracker() *model.BalanceTracker {
 	}
 }
 
+// MonitorCheckTask is the payload of a queued check of the monitors of a balance.
+type MonitorCheckTask struct {
+	Balance     model.Balance     `json:"balance"`
+	Transaction model.Transaction `json:"transaction"`
+}
+
+// CheckBalanceMonitors checks the monitors of a balance updated by a transaction, as queued by queueMonitorCheck.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - task MonitorCheckTask: The updated balance and the transaction that updated it.
+func (l *Blnk) CheckBalanceMonitors(ctx context.Context, task MonitorCheckTask) {
+	l.checkBalanceMonitors(ctx, &task.Balance, &task.Transaction)
+}
+
+// queueBalanceMonitorChecks queues a check of the monitors of every balance updated by a transaction and the balances
+// for indexing. Both happen in the background, so the transaction does not wait on them while it holds its locks.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction that updated the balances.
+// - balances []*model.Balance: The updated balances.
+func (l *Blnk) queueBalanceMonitorChecks(ctx context.Context, transaction *model.Transaction, balances ...*model.Balance) {
+	_, span := balanceTracer.Start(ctx, "QueueBalanceMonitorChecks")
+	defer span.End()
+
+	// Snapshot the balances and the transaction, the caller keeps updating them
+	txn := *transaction
+	snapshots := make([]model.Balance, len(balances))
+	for i, balance := range balances {
+		snapshots[i] = *balance
+	}
+
+	go func() {
+		for i := range snapshots {
+			if err := l.queue.queueMonitorCheck(&snapshots[i], &txn); err != nil {
+				span.RecordError(err)
+				notification.NotifyError(err)
+			}
+			if err := l.queue.queueIndexData(snapshots[i].BalanceID, "balances", &snapshots[i]); err != nil {
+				span.RecordError(err)
+				notification.NotifyError(err)
+			}
+		}
+	}()
+}
+
 // checkBalanceMonitors checks the balance monitors for a given updated balance.
 // It starts a tracing span, fetches the monitors, and checks each monitor's condition.
-// If a condition is met, it sends a webhook notification.
+// Each evaluation is recorded against the monitor, which decides from its trigger mode and cooldown
+// whether it fires. When a monitor fires, the event is added to its history, the monitor's actions are run and
+// recorded on the event, and an alert is sent to the monitor's callback URL, or as a webhook notification when
+// the monitor has none.
 //
 // Parameters:
 // - ctx context.Context: The context for the operation.
 // - updatedBalance *model.Balance: A pointer to the updated Balance model.
-func (l *Blnk) checkBalanceMonitors(ctx context.Context, updatedBalance *model.Balance) {
-	_, span := balanceTracer.Start(ctx, "CheckBalanceMonitors")
+// - transaction *model.Transaction: The transaction that updated the balance.
+func (l *Blnk) checkBalanceMonitors(ctx context.Context, updatedBalance *model.Balance, transaction *model.Transaction) {
+	ctx, span := balanceTracer.Start(ctx, "CheckBalanceMonitors")
 	defer span.End()
 
 	// Fetch monitors for this balance using datasource
@@ -64,22 +127,216 @@ func (l *Blnk) checkBalanceMonitors(ctx context.Context, updatedBalance *model.B
 	}
 
 	// Check each monitor's condition
-	for _, monitor := range monitors {
-		if monitor.CheckCondition(updatedBalance) {
-			span.AddEvent(fmt.Sprintf("Condition met for balance: %s", monitor.MonitorID))
-			go func(monitor model.BalanceMonitor) {
-				err := SendWebhook(NewWebhook{
-					Event:   "balance.monitor",
-					Payload: monitor,
-				})
-				if err != nil {
-					notification.NotifyError(err)
-				}
-			}(monitor)
+	now := time.Now()
+	for i := range monitors {
+		monitor := monitors[i]
+		// Every evaluation is recorded, so the monitor keeps the version of the balance it last saw
+		met := monitor.CheckCondition(updatedBalance)
+		event, err := l.datasource.RecordMonitorEvaluation(ctx, &monitor, met, updatedBalance, transaction.TransactionID, now)
+		if err != nil {
+			span.RecordError(err)
+			notification.NotifyError(err)
+			continue
+		}
+		if event == nil {
+			continue
+		}
+
+		span.AddEvent(fmt.Sprintf("Condition met for balance: %s", monitor.MonitorID), trace.WithAttributes(attribute.String("monitor.event_id", event.EventID)))
+		if len(monitor.Actions) > 0 {
+			event.Actions = l.runMonitorActions(ctx, &monitor, event, transaction)
+			if err := l.datasource.SetMonitorEventActions(ctx, event.EventID, event.Actions); err != nil {
+				span.RecordError(err)
+				notification.NotifyError(err)
+			}
+		}
+		if err := l.sendMonitorAlert(monitor, event); err != nil {
+			span.RecordError(err)
+			notification.NotifyError(err)
 		}
 	}
 }
 
+// runMonitorActions runs the actions of a monitor that fired, in order, and returns what came of each.
+// Sweeps and top-ups are queued as transactions linked to the monitor event, sized from the balance as it is when
+// the action runs, and freezes are applied directly.
+// To keep monitors from triggering each other endlessly, a monitor's actions are skipped when the transaction that
+// fired it was queued by one of its own actions, or by a chain of MaxMonitorActionDepth monitor actions.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - monitor *model.BalanceMonitor: The monitor that fired.
+// - event *model.MonitorEvent: The event recorded for the firing.
+// - transaction *model.Transaction: The transaction that fired the monitor.
+//
+// Returns:
+// - []model.MonitorActionResult: The outcome of each action.
+func (l *Blnk) runMonitorActions(ctx context.Context, monitor *model.BalanceMonitor, event *model.MonitorEvent, transaction *model.Transaction) []model.MonitorActionResult {
+	ctx, span := balanceTracer.Start(ctx, "RunMonitorActions")
+	defer span.End()
+
+	depth := model.MonitorActionDepth(transaction)
+	skipReason := ""
+	if firedBy, _ := transaction.MetaData["monitor_id"].(string); firedBy == monitor.MonitorID {
+		skipReason = "transaction was queued by an action of this monitor"
+	} else if depth >= model.MaxMonitorActionDepth {
+		skipReason = fmt.Sprintf("transaction was queued by a chain of %d monitor actions", depth)
+	}
+
+	results := make([]model.MonitorActionResult, len(monitor.Actions))
+	for i := range monitor.Actions {
+		action := &monitor.Actions[i]
+		results[i] = model.MonitorActionResult{Type: action.Type, Status: model.MonitorActionSkipped, Reason: skipReason}
+		if skipReason != "" {
+			continue
+		}
+
+		if action.Type == model.MonitorActionFreeze {
+			if event.Balance.Status == model.BalanceStatusFrozen || event.Balance.Status == model.BalanceStatusClosed {
+				results[i].Reason = fmt.Sprintf("balance is already %s", strings.ToLower(event.Balance.Status))
+				continue
+			}
+			if err := l.freezeBalance(ctx, monitor, event); err != nil {
+				span.RecordError(err)
+				results[i].Status, results[i].Reason = model.MonitorActionFailed, err.Error()
+				continue
+			}
+			results[i].Status = model.MonitorActionApplied
+			continue
+		}
+
+		queued, err := l.queueMonitorAction(ctx, action, event, i, depth)
+		if queued == nil && err == nil {
+			results[i].Reason = "balance is already at the target"
+			continue
+		}
+		if err != nil {
+			span.RecordError(err)
+			results[i].Status, results[i].Reason = model.MonitorActionFailed, err.Error()
+			continue
+		}
+		results[i].Status = model.MonitorActionQueued
+		results[i].TransactionID = queued.TransactionID
+		results[i].Reference = queued.Reference
+		results[i].PreciseAmount = queued.PreciseAmount
+	}
+
+	span.AddEvent("Monitor actions run", trace.WithAttributes(
+		attribute.String("monitor.id", monitor.MonitorID),
+		attribute.Int("monitor.actions", len(results))))
+	return results
+}
+
+// queueMonitorAction queues the transaction of a sweep or top-up. The amount is worked out from the current balance,
+// read under its lock, rather than the copy the monitor was checked against, which later postings may have changed.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - action *model.MonitorAction: The sweep or top-up to run.
+// - event *model.MonitorEvent: The event recorded for the firing.
+// - n int: The position of the action in the monitor's actions.
+// - depth int: How many monitor actions the transaction that fired the monitor was chained through.
+//
+// Returns:
+// - *model.Transaction: The queued transaction, or nil if the balance is already at the target.
+// - error: An error if the balance could not be locked or read, or the transaction could not be queued.
+func (l *Blnk) queueMonitorAction(ctx context.Context, action *model.MonitorAction, event *model.MonitorEvent, n, depth int) (*model.Transaction, error) {
+	var queued *model.Transaction
+	err := l.withBalanceLock(ctx, event.BalanceID, func() error {
+		balance, err := l.datasource.GetBalanceByIDLite(event.BalanceID)
+		if err != nil {
+			return err
+		}
+		txn := action.Transaction(event, balance, n, depth)
+		if txn == nil {
+			return nil
+		}
+		queued, err = l.QueueTransaction(ctx, txn)
+		return err
+	})
+	return queued, err
+}
+
+// sizeMonitorAction works out the amount of a transaction queued by a sweep or top-up again as it is recorded,
+// from the balances read under the transaction's lock. An action queued while an earlier one was still waiting
+// would otherwise move funds the earlier one already moved.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction being recorded. Its amount is updated.
+// - sourceBalance *model.Balance: The source balance, as it is now.
+// - destinationBalance *model.Balance: The destination balance, as it is now.
+//
+// Returns:
+// - error: ErrNothingToMove if the balance already reached the action's target, or an error if the monitor could not be read.
+func (l *Blnk) sizeMonitorAction(ctx context.Context, transaction *model.Transaction, sourceBalance, destinationBalance *model.Balance) error {
+	n, ok := model.MonitorActionIndex(transaction)
+	if !ok {
+		return nil
+	}
+	_, span := balanceTracer.Start(ctx, "SizeMonitorAction")
+	defer span.End()
+
+	monitorID, _ := transaction.MetaData["monitor_id"].(string)
+	monitor, err := l.datasource.GetMonitorByID(monitorID)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if n < 0 || n >= len(monitor.Actions) {
+		return fmt.Errorf("%w: monitor %s no longer has action %d", model.ErrNothingToMove, monitorID, n)
+	}
+
+	action := monitor.Actions[n]
+	balance := sourceBalance
+	if action.Type == model.MonitorActionTopUp {
+		balance = destinationBalance
+	}
+	amount := action.Amount(balance)
+	if amount == nil {
+		return fmt.Errorf("%w: balance %s is already at the target of monitor %s", model.ErrNothingToMove, balance.BalanceID, monitorID)
+	}
+	transaction.PreciseAmount = amount
+	return nil
+}
+
+// freezeBalance freezes the balance of a monitor that fired. The balance is locked while its status changes, waiting
+// for a transaction posting to it to finish, so the freeze cannot race with a debit of the same balance.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - monitor *model.BalanceMonitor: The monitor that fired.
+// - event *model.MonitorEvent: The event recorded for the firing. Its balance is updated to the new status.
+//
+// Returns:
+// - error: An error if the balance could not be locked or its status could not be changed.
+func (l *Blnk) freezeBalance(ctx context.Context, monitor *model.BalanceMonitor, event *model.MonitorEvent) error {
+	return l.withBalanceLock(ctx, event.BalanceID, func() error {
+		reason := fmt.Sprintf("frozen by monitor %s (event %s)", monitor.MonitorID, event.EventID)
+		_, err := l.changeBalanceStatus(ctx, event.BalanceID, model.BalanceStatusFrozen, reason, event.Balance)
+		return err
+	})
+}
+
+// withBalanceLock runs fn holding the lock of a balance, waiting up to monitorLockWait for a transaction posting
+// to it to finish.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - balanceID string: The ID of the balance to lock.
+// - fn func() error: The function to run while the lock is held.
+//
+// Returns:
+// - error: An error if the lock could not be acquired, or the error returned by fn.
+func (l *Blnk) withBalanceLock(ctx context.Context, balanceID string, fn func() error) error {
+	locker := redlock.NewLocker(l.redis, balanceID, model.GenerateUUIDWithSuffix("loc"))
+	if err := locker.WaitLock(ctx, time.Minute*30, monitorLockWait); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer l.releaseLock(ctx, locker)
+	return fn()
+}
+
 // getOrCreateBalanceByIndicator retrieves a balance by its indicator and currency.
 // If the balance does not exist, it creates a new one.
 // It starts a tracing span, fetches or creates the balance, and records relevant events.
@@ -150,7 +407,7 @@ func (l *Blnk) postBalanceActions(ctx context.Context, balance *model.Balance) {
 }
 
 // CreateBalance creates a new balance.
-// It starts a tracing span, creates the balance, and performs post-creation actions.
+// It starts a tracing span, resolves the precision of the balance's currency, creates the balance, and performs post-creation actions.
 //
 // Parameters:
 // - ctx context.Context: The context for the operation.
@@ -163,7 +420,19 @@ func (l *Blnk) CreateBalance(ctx context.Context, balance model.Balance) (model.
 	ctx, span := balanceTracer.Start(ctx, "CreateBalance")
 	defer span.End()
 
-	balance, err := l.datasource.CreateBalance(balance)
+	if balance.AccountType != "" && balance.NormalSide == "" {
+		balance.NormalSide = model.DefaultNormalSide(balance.AccountType)
+	}
+
+	// The precision of a balance in a registered currency comes from the currency registry
+	precision, err := l.ResolvePrecision(ctx, balance.Currency, balance.CurrencyMultiplier)
+	if err != nil {
+		span.RecordError(err)
+		return model.Balance{}, err
+	}
+	balance.CurrencyMultiplier = precision
+
+	balance, err = l.datasource.CreateBalance(balance)
 	if err != nil {
 		span.RecordError(err)
 		return model.Balance{}, err
@@ -197,6 +466,59 @@ func (l *Blnk) GetBalanceByID(ctx context.Context, id string, include []string)
 	return balance, nil
 }
 
+// GetBalanceAtTime retrieves a balance as it stood at a point in time.
+// The amounts are computed from the latest balance snapshot before that time and the transactions posted since.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - id string: The ID of the balance to retrieve.
+// - asOf time.Time: The point in time to compute the balance for.
+//
+// Returns:
+// - *model.Balance: A pointer to the Balance model with its historical amounts.
+// - error: An error if the balance could not be computed.
+func (l *Blnk) GetBalanceAtTime(ctx context.Context, id string, asOf time.Time) (*model.Balance, error) {
+	ctx, span := balanceTracer.Start(ctx, "GetBalanceAtTime")
+	defer span.End()
+
+	if asOf.After(time.Now()) {
+		err := fmt.Errorf("as_of cannot be in the future")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	balance, err := l.datasource.GetBalanceAtTime(ctx, id, asOf)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.AddEvent("Historical balance retrieved", trace.WithAttributes(attribute.String("balance.id", id)))
+	return balance, nil
+}
+
+// TakeBalanceSnapshots records a snapshot of every balance so point-in-time queries only need to replay
+// the transactions posted after the latest snapshot. The snapshot is taken slightly in the past to give
+// transactions that are still being processed time to be persisted.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+//
+// Returns:
+// - int: The number of snapshots recorded.
+// - error: An error if the snapshots could not be taken.
+func (l *Blnk) TakeBalanceSnapshots(ctx context.Context) (int, error) {
+	ctx, span := balanceTracer.Start(ctx, "TakeBalanceSnapshots")
+	defer span.End()
+
+	count, err := l.datasource.TakeBalanceSnapshots(ctx, time.Now().Add(-snapshotDelay), snapshotBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		return count, err
+	}
+	span.AddEvent("Balance snapshots taken", trace.WithAttributes(attribute.Int("snapshot.count", count)))
+	return count, nil
+}
+
 // GetAllBalances retrieves all balances.
 // It starts a tracing span, fetches all balances, and records relevant events.
 //
@@ -219,6 +541,233 @@ func (l *Blnk) GetAllBalances(ctx context.Context, limit, offset int) ([]model.B
 	return balances, nil
 }
 
+// UpdateOverdraftLimit sets how far below zero a balance may go.
+// The limit is given in major units and converted with the balance's precision (currency multiplier).
+// The balance is locked while the limit changes, so it cannot race with a transaction debiting the same balance.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - balanceID string: The ID of the balance to update.
+// - limit float64: The overdraft limit in major units, e.g. 5000 for a credit line of 5,000.00.
+//
+// Returns:
+// - *model.Balance: A pointer to the updated Balance model.
+// - error: An error if the limit is invalid or the balance could not be updated.
+func (l *Blnk) UpdateOverdraftLimit(ctx context.Context, balanceID string, limit float64) (*model.Balance, error) {
+	ctx, span := balanceTracer.Start(ctx, "UpdateOverdraftLimit")
+	defer span.End()
+
+	if limit < 0 {
+		err := fmt.Errorf("overdraft limit cannot be negative")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	locker := redlock.NewLocker(l.redis, balanceID, model.GenerateUUIDWithSuffix("loc"))
+	if err := locker.Lock(ctx, time.Minute*30); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer l.releaseLock(ctx, locker)
+
+	balance, err := l.datasource.GetBalanceByIDLite(balanceID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	preciseLimit, err := model.ToPreciseAmount(limit, balance.CurrencyMultiplier)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if err := l.datasource.UpdateOverdraftLimit(ctx, balanceID, preciseLimit); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	balance.OverdraftLimit = preciseLimit
+
+	go func() {
+		if err := l.queue.queueIndexData(balance.BalanceID, "balances", balance); err != nil {
+			span.RecordError(err)
+			notification.NotifyError(err)
+		}
+	}()
+
+	span.AddEvent("Overdraft limit updated", trace.WithAttributes(
+		attribute.String("balance.id", balanceID),
+		attribute.String("balance.overdraft_limit", preciseLimit.String()),
+	))
+	return balance, nil
+}
+
+// UpdateBalanceAccountType places a balance in its ledger's chart of accounts.
+// When no normal side is given, the account type's usual side is used, e.g. debit for an asset.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - balanceID string: The ID of the balance to update.
+// - accountType string: The balance's account type, e.g. asset or liability.
+// - normalSide string: The side that increases the balance, debit or credit. Optional.
+//
+// Returns:
+// - *model.Balance: A pointer to the updated Balance model.
+// - error: An error if the balance could not be updated.
+func (l *Blnk) UpdateBalanceAccountType(ctx context.Context, balanceID, accountType, normalSide string) (*model.Balance, error) {
+	ctx, span := balanceTracer.Start(ctx, "UpdateBalanceAccountType")
+	defer span.End()
+
+	if normalSide == "" {
+		normalSide = model.DefaultNormalSide(accountType)
+	}
+
+	if err := l.datasource.UpdateBalanceAccountType(ctx, balanceID, accountType, normalSide); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	balance, err := l.datasource.GetBalanceByIDLite(balanceID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	go func() {
+		if err := l.queue.queueIndexData(balance.BalanceID, "balances", balance); err != nil {
+			span.RecordError(err)
+			notification.NotifyError(err)
+		}
+	}()
+
+	span.AddEvent("Account type updated", trace.WithAttributes(
+		attribute.String("balance.id", balanceID),
+		attribute.String("balance.account_type", accountType),
+		attribute.String("balance.normal_side", normalSide),
+	))
+	return balance, nil
+}
+
+// UpdateBalanceStatus moves a balance to a new status and records why, e.g. freezing a compromised wallet.
+// The balance is locked while its status changes, so it cannot race with a transaction debiting the same balance.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - balanceID string: The ID of the balance to update.
+// - status string: The new status.
+// - reason string: Why the status is changed.
+//
+// Returns:
+// - *model.Balance: A pointer to the updated Balance model.
+// - error: An error if the balance cannot move to the status or could not be updated.
+func (l *Blnk) UpdateBalanceStatus(ctx context.Context, balanceID, status, reason string) (*model.Balance, error) {
+	ctx, span := balanceTracer.Start(ctx, "UpdateBalanceStatus")
+	defer span.End()
+
+	locker := redlock.NewLocker(l.redis, balanceID, model.GenerateUUIDWithSuffix("loc"))
+	if err := locker.Lock(ctx, time.Minute*30); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer l.releaseLock(ctx, locker)
+
+	change, err := l.changeBalanceStatus(ctx, balanceID, status, reason, nil)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	balance, err := l.datasource.GetBalanceByIDLite(balanceID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	go func() {
+		if err := l.queue.queueIndexData(balance.BalanceID, "balances", balance); err != nil {
+			span.RecordError(err)
+			notification.NotifyError(err)
+		}
+	}()
+
+	span.AddEvent("Balance status updated", trace.WithAttributes(
+		attribute.String("balance.id", balanceID),
+		attribute.String("balance.status", change.ToStatus),
+	))
+	return balance, nil
+}
+
+// changeBalanceStatus records a status change of a balance and sends it as a webhook notification.
+// Callers hold the lock of the balance, taken for the change.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - balanceID string: The ID of the balance.
+// - status string: The new status.
+// - reason string: Why the status is changed.
+// - balance *model.Balance: The balance to send with the webhook, or nil to send the change alone.
+//
+// Returns:
+// - *model.BalanceStatusChange: The recorded change.
+// - error: An error if the balance cannot move to the status or the change could not be recorded.
+func (l *Blnk) changeBalanceStatus(ctx context.Context, balanceID, status, reason string, balance *model.Balance) (*model.BalanceStatusChange, error) {
+	_, span := balanceTracer.Start(ctx, "ChangeBalanceStatus")
+	defer span.End()
+
+	change, err := l.datasource.ChangeBalanceStatus(ctx, balanceID, status, reason)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if balance != nil {
+		balance.Status = change.ToStatus
+	}
+
+	go func() {
+		err := SendWebhook(NewWebhook{
+			Event:   BalanceStatusChangedEvent,
+			Payload: BalanceStatusChanged{BalanceStatusChange: *change, Balance: balance},
+		})
+		if err != nil {
+			notification.NotifyError(err)
+		}
+	}()
+
+	span.AddEvent("Balance status changed", trace.WithAttributes(
+		attribute.String("balance.id", balanceID),
+		attribute.String("balance.status.from", change.FromStatus),
+		attribute.String("balance.status.to", change.ToStatus),
+	))
+	return change, nil
+}
+
+// GetBalanceStatusChanges retrieves the status history of a balance, newest first.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - balanceID string: The ID of the balance.
+// - limit int: The maximum number of changes to return.
+// - offset int: The offset to start fetching changes from.
+//
+// Returns:
+// - []model.BalanceStatusChange: The status changes.
+// - error: An error if the balance does not exist or the changes could not be retrieved.
+func (l *Blnk) GetBalanceStatusChanges(ctx context.Context, balanceID string, limit, offset int) ([]model.BalanceStatusChange, error) {
+	ctx, span := balanceTracer.Start(ctx, "GetBalanceStatusChanges")
+	defer span.End()
+
+	if _, err := l.datasource.GetBalanceByIDLite(balanceID); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	changes, err := l.datasource.GetBalanceStatusChanges(ctx, balanceID, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return changes, nil
+}
+
 // CreateMonitor creates a new balance monitor.
 // It starts a tracing span, applies precision to the monitor's condition value, and creates the monitor.
 // It records relevant events and errors.
@@ -234,10 +783,25 @@ func (l *Blnk) CreateMonitor(ctx context.Context, monitor model.BalanceMonitor)
 	_, span := balanceTracer.Start(ctx, "CreateMonitor")
 	defer span.End()
 
-	amount := int64(monitor.Condition.Value * monitor.Condition.Precision) // apply precision to value
-	amountBigInt := model.Int64ToBigInt(amount)
-	monitor.Condition.PreciseValue = amountBigInt
-	monitor, err := l.datasource.CreateMonitor(monitor)
+	amount, err := model.ToPreciseAmount(monitor.Condition.Value, monitor.Condition.Precision) // apply precision to value
+	if err != nil {
+		span.RecordError(err)
+		return model.BalanceMonitor{}, err
+	}
+	monitor.Condition.PreciseValue = amount
+	if monitor.Conditions != nil {
+		if err := monitor.Conditions.ApplyPrecision(); err != nil {
+			span.RecordError(err)
+			return model.BalanceMonitor{}, err
+		}
+	}
+	for i := range monitor.Actions {
+		if err := monitor.Actions[i].ApplyPrecision(); err != nil {
+			span.RecordError(err)
+			return model.BalanceMonitor{}, err
+		}
+	}
+	monitor, err = l.datasource.CreateMonitor(monitor)
 	if err != nil {
 		span.RecordError(err)
 		return model.BalanceMonitor{}, err
@@ -327,6 +891,18 @@ func (l *Blnk) UpdateMonitor(ctx context.Context, monitor *model.BalanceMonitor)
 	_, span := balanceTracer.Start(ctx, "UpdateMonitor")
 	defer span.End()
 
+	if monitor.Conditions != nil {
+		if err := monitor.Conditions.ApplyPrecision(); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+	for i := range monitor.Actions {
+		if err := monitor.Actions[i].ApplyPrecision(); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
 	err := l.datasource.UpdateMonitor(monitor)
 	if err != nil {
 		span.RecordError(err)
@@ -336,6 +912,34 @@ func (l *Blnk) UpdateMonitor(ctx context.Context, monitor *model.BalanceMonitor)
 	return nil
 }
 
+// GetMonitorEvents retrieves the history of a balance monitor firing, newest first.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - id string: The ID of the monitor.
+// - limit int: The maximum number of events to return.
+// - offset int: The offset to start from.
+//
+// Returns:
+// - []model.MonitorEvent: A slice of MonitorEvent models.
+// - error: An error if the monitor or its events could not be retrieved.
+func (l *Blnk) GetMonitorEvents(ctx context.Context, id string, limit, offset int) ([]model.MonitorEvent, error) {
+	ctx, span := balanceTracer.Start(ctx, "GetMonitorEvents")
+	defer span.End()
+
+	if _, err := l.datasource.GetMonitorByID(id); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	events, err := l.datasource.GetMonitorEvents(ctx, id, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.AddEvent("Monitor events retrieved", trace.WithAttributes(attribute.String("monitor.id", id), attribute.Int("event.count", len(events))))
+	return events, nil
+}
+
 // DeleteMonitor deletes a balance monitor by its ID.
 // It starts a tracing span, deletes the monitor, and records relevant events and errors.
 //
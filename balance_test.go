@@ -25,12 +25,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/jerry-enebeli/blnk/database/mocks"
 	"github.com/jerry-enebeli/blnk/model"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type BigIntString struct {
@@ -159,7 +163,7 @@ func TestCreateBalance(t *testing.T) {
 	// Convert metadata to JSON for mocking
 	metaDataJSON, _ := json.Marshal(balance.MetaData)
 	mock.ExpectExec("INSERT INTO blnk.balances").
-		WithArgs(sqlmock.AnyArg(), balance.Balance.String(), balance.CreditBalance.String(), balance.DebitBalance.String(), balance.Currency, balance.CurrencyMultiplier, balance.LedgerID, balance.IdentityID, sqlmock.AnyArg(), sqlmock.AnyArg(), metaDataJSON).
+		WithArgs(sqlmock.AnyArg(), balance.Balance.String(), balance.CreditBalance.String(), balance.DebitBalance.String(), balance.Currency, float64(100), balance.LedgerID, balance.IdentityID, sqlmock.AnyArg(), sqlmock.AnyArg(), metaDataJSON, "0", nil, nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	result, err := d.CreateBalance(context.Background(), balance)
@@ -186,8 +190,8 @@ func TestGetBalanceByID(t *testing.T) {
 	mock.ExpectBegin()
 
 	// Adjust the expected SQL to match the actual SQL output.
-	expectedSQL := `SELECT b\.balance_id, b\.balance, b\.credit_balance, b\.debit_balance, b\.currency, b\.currency_multiplier, b\.ledger_id, COALESCE\(b\.identity_id, ''\) as identity_id, b\.created_at, b\.meta_data, b\.inflight_balance, b\.inflight_credit_balance, b\.inflight_debit_balance, b\.version, b\.indicator FROM \( SELECT \* FROM blnk\.balances WHERE balance_id = \$1 \) AS b`
-	rows := sqlmock.NewRows([]string{"balance_id", "balance", "credit_balance", "debit_balance", "currency", "currency_multiplier", "ledger_id", "identity_id", "created_at", "meta_data", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "version", "indicator"}).
+	expectedSQL := `SELECT b\.balance_id, b\.balance, b\.credit_balance, b\.debit_balance, b\.currency, b\.currency_multiplier, b\.ledger_id, COALESCE\(b\.identity_id, ''\) as identity_id, b\.created_at, b\.meta_data, b\.inflight_balance, b\.inflight_credit_balance, b\.inflight_debit_balance, b\.version, b\.indicator, b\.overdraft_limit, b\.status FROM \( SELECT \* FROM blnk\.balances WHERE balance_id = \$1 \) AS b`
+	rows := sqlmock.NewRows([]string{"balance_id", "balance", "credit_balance", "debit_balance", "currency", "currency_multiplier", "ledger_id", "identity_id", "created_at", "meta_data", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "version", "indicator", "overdraft_limit", "status", "account_type", "normal_side"}).
 		AddRow(balanceID,
 			BigIntString{big.NewInt(100)},
 			BigIntString{big.NewInt(50)},
@@ -198,7 +202,9 @@ func TestGetBalanceByID(t *testing.T) {
 			BigIntString{big.NewInt(0)},
 			BigIntString{big.NewInt(0)},
 			0,
-			"test-indicator")
+			"test-indicator",
+			BigIntString{big.NewInt(0)},
+			"ACTIVE", nil, nil)
 
 	mock.ExpectQuery(expectedSQL).
 		WithArgs(balanceID).
@@ -342,7 +348,7 @@ func TestCreateMonitor(t *testing.T) {
 	}
 	monitor := model.BalanceMonitor{BalanceID: "test-balance", Description: "Test Monitor", CallBackURL: gofakeit.URL(), Condition: model.AlertCondition{Field: "field", Operator: "operator", Value: 1000, Precision: 100, PreciseValue: big.NewInt(100000)}}
 
-	mock.ExpectExec("INSERT INTO blnk.balance_monitors").WithArgs(sqlmock.AnyArg(), monitor.BalanceID, monitor.Condition.Field, monitor.Condition.Operator, monitor.Condition.Value, monitor.Condition.Precision, monitor.Condition.PreciseValue.String(), monitor.Description, monitor.CallBackURL, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO blnk.balance_monitors").WithArgs(sqlmock.AnyArg(), monitor.BalanceID, monitor.Condition.Field, monitor.Condition.Operator, monitor.Condition.Value, monitor.Condition.Precision, monitor.Condition.PreciseValue.String(), monitor.Description, monitor.CallBackURL, sqlmock.AnyArg(), sqlmock.AnyArg(), model.MonitorTriggerLevel, monitor.CooldownSeconds, nil, monitor.MaxRetries, int64(model.DefaultMonitorRetryBackoffSeconds), nil).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	result, err := d.CreateMonitor(context.Background(), monitor)
 
@@ -366,8 +372,8 @@ func TestGetMonitorByID(t *testing.T) {
 	}
 	monitorID := "test-monitor"
 
-	rows := sqlmock.NewRows([]string{"monitor_id", "balance_id", "field", "operator", "value", "precision", "precise_value", "description", "call_back_url", "created_at"}).
-		AddRow(monitorID, gofakeit.UUID(), "field", "operator", 1000, 100, 100000, "Test Monitor", gofakeit.URL(), time.Now())
+	rows := sqlmock.NewRows([]string{"monitor_id", "balance_id", "field", "operator", "value", "precision", "precise_value", "description", "call_back_url", "created_at", "conditions", "trigger_mode", "cooldown_seconds", "last_state", "last_triggered_at", "call_back_secret", "max_retries", "retry_backoff_seconds", "actions"}).
+		AddRow(monitorID, gofakeit.UUID(), "field", "operator", 1000, 100, 100000, "Test Monitor", gofakeit.URL(), time.Now(), nil, "level", 0, false, nil, nil, 5, 30, nil)
 
 	mock.ExpectQuery("SELECT .* FROM blnk.balance_monitors WHERE monitor_id =").WithArgs(monitorID).WillReturnRows(rows)
 
@@ -391,8 +397,8 @@ func TestGetAllMonitors(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error creating Blnk instance: %s", err)
 	}
-	rows := sqlmock.NewRows([]string{"monitor_id", "balance_id", "field", "operator", "value", "description", "call_back_url", "created_at"}).
-		AddRow("test-monitor", gofakeit.UUID(), "field", "operator", 100, "Test Monitor", gofakeit.URL(), time.Now())
+	rows := sqlmock.NewRows([]string{"monitor_id", "balance_id", "field", "operator", "value", "precision", "precise_value", "description", "call_back_url", "created_at", "conditions", "trigger_mode", "cooldown_seconds", "last_state", "last_triggered_at", "call_back_secret", "max_retries", "retry_backoff_seconds", "actions"}).
+		AddRow("test-monitor", gofakeit.UUID(), "field", "operator", 100, 100, 10000, "Test Monitor", gofakeit.URL(), time.Now(), nil, "level", 0, false, nil, nil, 5, 30, nil)
 
 	mock.ExpectQuery("SELECT .* FROM blnk.balance_monitors").WillReturnRows(rows)
 
@@ -417,8 +423,8 @@ func TestGetBalanceMonitors(t *testing.T) {
 		t.Fatalf("Error creating Blnk instance: %s", err)
 	}
 	balanceID := gofakeit.UUID()
-	rows := sqlmock.NewRows([]string{"monitor_id", "balance_id", "field", "operator", "value", "description", "call_back_url", "created_at", "precision", "precise_value"}).
-		AddRow("test-monitor", balanceID, "field", "operator", 100, "Test Monitor", gofakeit.URL(), time.Now(), 100, 100000)
+	rows := sqlmock.NewRows([]string{"monitor_id", "balance_id", "field", "operator", "value", "precision", "precise_value", "description", "call_back_url", "created_at", "conditions", "trigger_mode", "cooldown_seconds", "last_state", "last_triggered_at", "call_back_secret", "max_retries", "retry_backoff_seconds", "actions"}).
+		AddRow("test-monitor", balanceID, "field", "operator", 100, 100, 100000, "Test Monitor", gofakeit.URL(), time.Now(), nil, "edge", 60, true, time.Now(), "secret", 3, 10, nil)
 
 	mock.ExpectQuery("SELECT .* FROM blnk.balance_monitors WHERE balance_id =").WithArgs(balanceID).WillReturnRows(rows)
 
@@ -444,7 +450,7 @@ func TestUpdateMonitor(t *testing.T) {
 	}
 	monitor := &model.BalanceMonitor{MonitorID: "test-monitor", BalanceID: "test-balance", Description: "Updated Monitor"}
 
-	mock.ExpectExec("UPDATE blnk.balance_monitors").WithArgs(monitor.MonitorID, monitor.BalanceID, monitor.Condition.Field, monitor.Condition.Operator, monitor.Condition.Value, monitor.Description, monitor.CallBackURL).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE blnk.balance_monitors").WithArgs(monitor.MonitorID, monitor.BalanceID, nil, nil, monitor.Condition.Value, monitor.Description, monitor.CallBackURL, sqlmock.AnyArg(), model.MonitorTriggerLevel, monitor.CooldownSeconds, nil, monitor.MaxRetries, int64(model.DefaultMonitorRetryBackoffSeconds), nil).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = d.UpdateMonitor(context.Background(), monitor)
 
@@ -477,3 +483,146 @@ func TestDeleteMonitor(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestRunMonitorActions_Freeze(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS, redis: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	monitor := &model.BalanceMonitor{MonitorID: "mon_1", BalanceID: "bln_1", Actions: []model.MonitorAction{{Type: model.MonitorActionFreeze}}}
+	event := &model.MonitorEvent{EventID: "mev_1", MonitorID: "mon_1", BalanceID: "bln_1", Balance: &model.Balance{BalanceID: "bln_1", Status: model.BalanceStatusActive}}
+
+	mockDS.On("ChangeBalanceStatus", mock.Anything, "bln_1", model.BalanceStatusFrozen, "frozen by monitor mon_1 (event mev_1)").
+		Return(&model.BalanceStatusChange{ChangeID: "bsc_1", BalanceID: "bln_1", FromStatus: model.BalanceStatusActive, ToStatus: model.BalanceStatusFrozen}, nil)
+
+	results := blnk.runMonitorActions(context.Background(), monitor, event, &model.Transaction{TransactionID: "txn_1"})
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, model.MonitorActionApplied, results[0].Status)
+	}
+	assert.Equal(t, model.BalanceStatusFrozen, event.Balance.Status)
+	// The balance lock taken for the freeze is released
+	assert.False(t, mr.Exists("bln_1"))
+	mockDS.AssertExpectations(t)
+}
+
+func TestRunMonitorActions_NothingToMove(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS, redis: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	sweep := model.MonitorAction{Type: model.MonitorActionSweep, CounterpartyBalanceID: "bln_savings", Target: 1000, Precision: 100}
+	assert.NoError(t, sweep.ApplyPrecision())
+	monitor := &model.BalanceMonitor{MonitorID: "mon_1", BalanceID: "bln_1", Actions: []model.MonitorAction{sweep}}
+	// The monitor was checked against a balance above the target, which has since been swept back down
+	event := &model.MonitorEvent{EventID: "mev_1", MonitorID: "mon_1", BalanceID: "bln_1", Balance: &model.Balance{BalanceID: "bln_1", Balance: big.NewInt(150000)}}
+	mockDS.On("GetBalanceByIDLite", "bln_1").Return(&model.Balance{BalanceID: "bln_1", Balance: big.NewInt(100000)}, nil)
+
+	results := blnk.runMonitorActions(context.Background(), monitor, event, &model.Transaction{TransactionID: "txn_1"})
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, model.MonitorActionSkipped, results[0].Status)
+		assert.Empty(t, results[0].TransactionID)
+	}
+	assert.False(t, mr.Exists("bln_1"))
+	mockDS.AssertExpectations(t)
+}
+
+func TestSizeMonitorAction(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	sweep := model.MonitorAction{Type: model.MonitorActionSweep, CounterpartyBalanceID: "bln_savings", Target: 100, Precision: 100}
+	assert.NoError(t, sweep.ApplyPrecision())
+	mockDS.On("GetMonitorByID", "mon_1").Return(&model.BalanceMonitor{MonitorID: "mon_1", BalanceID: "bln_1", Actions: []model.MonitorAction{sweep}}, nil)
+	savings := &model.Balance{BalanceID: "bln_savings", Balance: big.NewInt(0)}
+
+	// Two sweeps were queued against 150.00 and then 160.00; the first moves what is above the target now
+	first := &model.Transaction{PreciseAmount: big.NewInt(5000), MetaData: map[string]interface{}{"monitor_id": "mon_1", "monitor_action": float64(0)}}
+	assert.NoError(t, blnk.sizeMonitorAction(context.Background(), first, &model.Balance{BalanceID: "bln_1", Balance: big.NewInt(16000)}, savings))
+	assert.Equal(t, big.NewInt(6000), first.PreciseAmount)
+
+	// and the second finds the balance already at the target
+	second := &model.Transaction{PreciseAmount: big.NewInt(6000), MetaData: map[string]interface{}{"monitor_id": "mon_1", "monitor_action": float64(0)}}
+	err := blnk.sizeMonitorAction(context.Background(), second, &model.Balance{BalanceID: "bln_1", Balance: big.NewInt(10000)}, savings)
+	assert.ErrorIs(t, err, model.ErrNothingToMove)
+
+	// Other transactions are left alone
+	other := &model.Transaction{PreciseAmount: big.NewInt(100)}
+	assert.NoError(t, blnk.sizeMonitorAction(context.Background(), other, savings, savings))
+	assert.Equal(t, big.NewInt(100), other.PreciseAmount)
+}
+
+func TestRunMonitorActions_LoopProtection(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	monitor := &model.BalanceMonitor{MonitorID: "mon_1", BalanceID: "bln_1", Actions: []model.MonitorAction{{Type: model.MonitorActionFreeze}}}
+	event := &model.MonitorEvent{EventID: "mev_2", MonitorID: "mon_1", BalanceID: "bln_1", Balance: &model.Balance{BalanceID: "bln_1"}}
+
+	// A transaction queued by this monitor's own action does not run its actions again
+	ownAction := &model.Transaction{TransactionID: "txn_2", MetaData: map[string]interface{}{"monitor_id": "mon_1", "monitor_action_depth": 1}}
+	results := blnk.runMonitorActions(context.Background(), monitor, event, ownAction)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, model.MonitorActionSkipped, results[0].Status)
+		assert.NotEmpty(t, results[0].Reason)
+	}
+
+	// Neither does a transaction at the end of a chain of monitor actions
+	chained := &model.Transaction{TransactionID: "txn_3", MetaData: map[string]interface{}{"monitor_id": "mon_2", "monitor_action_depth": float64(model.MaxMonitorActionDepth)}}
+	results = blnk.runMonitorActions(context.Background(), monitor, event, chained)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, model.MonitorActionSkipped, results[0].Status)
+	}
+	mockDS.AssertNotCalled(t, "ChangeBalanceStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunMonitorActions_FreezeAlreadyFrozen(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	monitor := &model.BalanceMonitor{MonitorID: "mon_1", BalanceID: "bln_1", Actions: []model.MonitorAction{{Type: model.MonitorActionFreeze}}}
+	event := &model.MonitorEvent{EventID: "mev_1", MonitorID: "mon_1", BalanceID: "bln_1", Balance: &model.Balance{BalanceID: "bln_1", Status: model.BalanceStatusFrozen}}
+
+	results := blnk.runMonitorActions(context.Background(), monitor, event, &model.Transaction{TransactionID: "txn_1"})
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, model.MonitorActionSkipped, results[0].Status)
+		assert.Equal(t, "balance is already frozen", results[0].Reason)
+	}
+	mockDS.AssertNotCalled(t, "ChangeBalanceStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetBalanceStatusChanges(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	changes := []model.BalanceStatusChange{{ChangeID: "bsc_1", BalanceID: "bln_1", FromStatus: model.BalanceStatusActive, ToStatus: model.BalanceStatusFrozen, Reason: "card reported stolen"}}
+
+	mockDS.On("GetBalanceByIDLite", "bln_1").Return(&model.Balance{BalanceID: "bln_1"}, nil)
+	mockDS.On("GetBalanceStatusChanges", mock.Anything, "bln_1", 20, 0).Return(changes, nil)
+
+	result, err := blnk.GetBalanceStatusChanges(context.Background(), "bln_1", 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, changes, result)
+	mockDS.AssertExpectations(t)
+}
+
+func TestCheckTransferRules(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	wallets := &model.Ledger{LedgerID: "ldg_wallets", TransferRules: []model.TransferRule{{SourceType: model.AccountTypeLiability, DestinationType: model.AccountTypeLiability}}}
+	mockDS.On("GetLedgerByID", "ldg_wallets").Return(wallets, nil)
+
+	alice := &model.Balance{BalanceID: "bln_alice", LedgerID: "ldg_wallets", AccountType: model.AccountTypeLiability}
+	bob := &model.Balance{BalanceID: "bln_bob", LedgerID: "ldg_wallets", AccountType: model.AccountTypeLiability}
+	cash := &model.Balance{BalanceID: "bln_cash", LedgerID: "ldg_wallets", AccountType: model.AccountTypeAsset}
+	untyped := &model.Balance{BalanceID: "bln_untyped", LedgerID: "ldg_wallets"}
+
+	assert.NoError(t, blnk.checkTransferRules(alice, bob))
+	assert.NoError(t, blnk.checkTransferRules(untyped, bob))
+	err := blnk.checkTransferRules(alice, cash)
+	assert.ErrorIs(t, err, model.ErrTransferNotAllowed)
+	mockDS.AssertExpectations(t)
+}
@@ -17,11 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -60,8 +63,8 @@ func (b *blnkInstance) processTransaction(ctx context.Context, t *asynq.Task) er
 	// Attempt to record the transaction.
 	_, err := b.blnk.RecordTransaction(ctx, &txn)
 	if err != nil {
-		// Check for "insufficient funds" error and handle rejection.
-		if strings.Contains(strings.ToLower(err.Error()), "insufficient funds") {
+		// Check for "insufficient funds", balance status, transfer rule, closed period, currency and monitor action errors and handle rejection.
+		if strings.Contains(strings.ToLower(err.Error()), "insufficient funds") || errors.Is(err, model.ErrBalanceUnavailable) || errors.Is(err, model.ErrTransferNotAllowed) || errors.Is(err, model.ErrPeriodClosed) || errors.Is(err, model.ErrCurrencyMismatch) || errors.Is(err, model.ErrNothingToMove) {
 			_, rejectErr := b.blnk.RejectTransaction(ctx, &txn, err.Error())
 			if rejectErr != nil {
 				return rejectErr
@@ -89,7 +92,10 @@ func (b *blnkInstance) indexData(_ context.Context, t *asynq.Task) error {
 	var data indexData
 
 	// Unmarshal the indexing data from the task payload.
-	if err := json.Unmarshal(t.Payload(), &data); err != nil {
+	// Numbers are kept as json.Number so amounts beyond float64 precision are indexed with every digit.
+	decoder := json.NewDecoder(bytes.NewReader(t.Payload()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
 		logrus.Error(err)
 		return err
 	}
@@ -135,6 +141,74 @@ func (b *blnkInstance) processInflightExpiry(cxt context.Context, t *asynq.Task)
 	return nil
 }
 
+// takeBalanceSnapshots records a snapshot of every balance, used to answer point-in-time balance queries.
+func (b *blnkInstance) takeBalanceSnapshots(cxt context.Context, _ *asynq.Task) error {
+	count, err := b.blnk.TakeBalanceSnapshots(cxt)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	logrus.Printf(" [*] Balance snapshots taken %d", count)
+	return nil
+}
+
+// purgeIdempotencyKeys removes expired idempotency keys and abandoned reservations.
+func (b *blnkInstance) purgeIdempotencyKeys(cxt context.Context, _ *asynq.Task) error {
+	count, err := b.blnk.PurgeIdempotencyKeys(cxt)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	logrus.Printf(" [*] Idempotency keys purged %d", count)
+	return nil
+}
+
+// runSchedules queues the occurrences of recurring transaction schedules that are due.
+func (b *blnkInstance) runSchedules(cxt context.Context, _ *asynq.Task) error {
+	count, err := b.blnk.RunDueSchedules(cxt)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	if count > 0 {
+		logrus.Printf(" [*] Scheduled transactions queued %d", count)
+	}
+	return nil
+}
+
+// deliverMonitorAlert posts a balance monitor alert to the monitor's callback URL.
+func (b *blnkInstance) deliverMonitorAlert(cxt context.Context, t *asynq.Task) error {
+	var alert blnk.MonitorAlertTask
+	if err := json.Unmarshal(t.Payload(), &alert); err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	if err := b.blnk.DeliverMonitorAlert(cxt, alert.Event); err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	logrus.Printf(" [*] Monitor alert delivered %s", alert.Event.EventID)
+	return nil
+}
+
+// checkBalanceMonitors checks the monitors of a balance updated by a transaction.
+func (b *blnkInstance) checkBalanceMonitors(cxt context.Context, t *asynq.Task) error {
+	var task blnk.MonitorCheckTask
+	if err := json.Unmarshal(t.Payload(), &task); err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	b.blnk.CheckBalanceMonitors(cxt, task)
+	logrus.Printf(" [*] Monitors checked for balance %s", task.Balance.BalanceID)
+	return nil
+}
+
 // workerCommands defines the "workers" command to start worker processes.
 // The workers listen to various queues such as transaction processing, indexing, and inflight expiry.
 func workerCommands(b *blnkInstance) *cobra.Command {
@@ -165,6 +239,11 @@ func workerCommands(b *blnkInstance) *cobra.Command {
 			queues[blnk.WEBHOOK_QUEUE] = 3
 			queues[blnk.INDEX_QUEUE] = 1
 			queues[blnk.EXPIREDINFLIGHT_QUEUE] = 3
+			queues[blnk.SNAPSHOT_QUEUE] = 1
+			queues[blnk.IDEMPOTENCY_QUEUE] = 1
+			queues[blnk.SCHEDULE_QUEUE] = 1
+			queues[blnk.MONITOR_ALERT_QUEUE] = 3
+			queues[blnk.MONITOR_CHECK_QUEUE] = 3
 
 			// Set up individual transaction queues with concurrency.
 			for i := 1; i <= blnk.NumberOfQueues; i++ {
@@ -183,6 +262,8 @@ func workerCommands(b *blnkInstance) *cobra.Command {
 				asynq.Config{
 					Concurrency: 1, // Set the concurrency level for processing tasks
 					Queues:      queues,
+					// Monitor alerts back off using the retry policy of their monitor
+					RetryDelayFunc: blnk.MonitorAlertRetryDelay,
 				},
 			)
 
@@ -197,6 +278,36 @@ func workerCommands(b *blnkInstance) *cobra.Command {
 			mux.HandleFunc(blnk.INDEX_QUEUE, b.indexData)
 			mux.HandleFunc(blnk.WEBHOOK_QUEUE, blnk.ProcessWebhook)
 			mux.HandleFunc(blnk.EXPIREDINFLIGHT_QUEUE, b.processInflightExpiry)
+			mux.HandleFunc(blnk.SNAPSHOT_QUEUE, b.takeBalanceSnapshots)
+			mux.HandleFunc(blnk.IDEMPOTENCY_QUEUE, b.purgeIdempotencyKeys)
+			mux.HandleFunc(blnk.SCHEDULE_QUEUE, b.runSchedules)
+			mux.HandleFunc(blnk.MONITOR_ALERT_QUEUE, b.deliverMonitorAlert)
+			mux.HandleFunc(blnk.MONITOR_CHECK_QUEUE, b.checkBalanceMonitors)
+
+			// Schedule periodic balance snapshots. Unique keeps overlapping runs from piling up.
+			scheduler := asynq.NewScheduler(redisOpt, nil)
+			_, err = scheduler.Register(conf.SnapshotSchedule, asynq.NewTask(blnk.SNAPSHOT_QUEUE, nil), asynq.Queue(blnk.SNAPSHOT_QUEUE), asynq.Unique(time.Hour))
+			if err != nil {
+				log.Printf("Error scheduling balance snapshots: %v", err)
+				return
+			}
+			// Purge expired idempotency keys hourly
+			_, err = scheduler.Register("@every 1h", asynq.NewTask(blnk.IDEMPOTENCY_QUEUE, nil), asynq.Queue(blnk.IDEMPOTENCY_QUEUE), asynq.Unique(time.Hour))
+			if err != nil {
+				log.Printf("Error scheduling idempotency key purge: %v", err)
+				return
+			}
+			// Check recurring transaction schedules every minute
+			_, err = scheduler.Register("@every 1m", asynq.NewTask(blnk.SCHEDULE_QUEUE, nil), asynq.Queue(blnk.SCHEDULE_QUEUE), asynq.Unique(time.Minute))
+			if err != nil {
+				log.Printf("Error scheduling recurring transactions: %v", err)
+				return
+			}
+			if err := scheduler.Start(); err != nil {
+				log.Printf("Error starting scheduler: %v", err)
+				return
+			}
+			defer scheduler.Shutdown()
 
 			// Run the Asynq server and start processing tasks from the queues.
 			if err := srv.Run(mux); err != nil {
@@ -30,7 +30,10 @@ import (
 )
 
 const (
-	DEFAULT_PORT = "5001"
+	DEFAULT_PORT              = "5001"
+	DEFAULT_SNAPSHOT_SCHEDULE = "@every 1h"
+	DEFAULT_FX_GAIN_LOSS      = "@FXGainLoss"
+	DEFAULT_FX_POSITION       = "@FXPosition"
 )
 
 var ConfigStore atomic.Value
@@ -74,6 +77,12 @@ type RateLimitConfig struct {
 	CleanupIntervalSec *int     `json:"cleanup_interval_sec" envconfig:"BLNK_RATE_LIMIT_CLEANUP_INTERVAL_SEC"`
 }
 
+// FXConfig names the indicator balances FX spread is booked between. Both are created per currency on first use.
+type FXConfig struct {
+	GainLossBalance string `json:"gain_loss_balance" envconfig:"BLNK_FX_GAIN_LOSS_BALANCE"`
+	PositionBalance string `json:"position_balance" envconfig:"BLNK_FX_POSITION_BALANCE"`
+}
+
 type SlackWebhook struct {
 	WebhookUrl string `json:"webhook_url"`
 }
@@ -102,6 +111,8 @@ type Configuration struct {
 	AccountNumberGeneration AccountNumberGenerationConfig `json:"account_number_generation"`
 	Notification            Notification                  `json:"notification"`
 	RateLimit               RateLimitConfig               `json:"rate_limit"`
+	SnapshotSchedule        string                        `json:"snapshot_schedule" envconfig:"BLNK_SNAPSHOT_SCHEDULE"`
+	FX                      FXConfig                      `json:"fx"`
 }
 
 func loadConfigFromFile(file string) error {
@@ -194,6 +205,19 @@ func (cnf *Configuration) validateAndAddDefaults() error {
 		log.Printf("Warning: Rate limit RPS not specified. Setting default value: %.2f", defaultRPS)
 	}
 
+	// Take balance snapshots hourly by default
+	if cnf.SnapshotSchedule == "" {
+		cnf.SnapshotSchedule = DEFAULT_SNAPSHOT_SCHEDULE
+	}
+
+	// Book FX spread between the default indicator balances unless others are configured
+	if cnf.FX.GainLossBalance == "" {
+		cnf.FX.GainLossBalance = DEFAULT_FX_GAIN_LOSS
+	}
+	if cnf.FX.PositionBalance == "" {
+		cnf.FX.PositionBalance = DEFAULT_FX_POSITION
+	}
+
 	// Set default cleanup interval if not specified
 	if cnf.RateLimit.CleanupIntervalSec == nil {
 		defaultCleanup := 10800 // 3 hours in seconds
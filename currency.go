@@ -0,0 +1,215 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// currencyTracer is an OpenTelemetry tracer for tracking the currency registry.
+var (
+	currencyTracer = otel.Tracer("blnk.currencies")
+)
+
+// CreateCurrency adds a custom asset, e.g. a token or loyalty points, to the currency registry. ISO 4217 currencies
+// are registered already and cannot be redefined.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - code string: The code of the asset.
+// - name string: The name of the asset.
+// - precision float64: The number of minor units in a major unit. It must be a power of ten.
+// - rounding string: How amounts falling between two minor units are rounded. Defaults to model.RoundingNone.
+//
+// Returns:
+// - *model.Currency: A pointer to the registered asset.
+// - error: An error if the asset is invalid, already registered, or could not be registered.
+func (l *Blnk) CreateCurrency(ctx context.Context, code, name string, precision float64, rounding string) (*model.Currency, error) {
+	ctx, span := currencyTracer.Start(ctx, "CreateCurrency")
+	defer span.End()
+
+	code = strings.ToUpper(code)
+	if _, ok := model.ISOCurrency(code); ok {
+		err := fmt.Errorf("%s is an ISO 4217 currency and is already registered", code)
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrConflict, err.Error(), err)
+	}
+	if !model.IsValidPrecision(precision) {
+		err := errors.New("precision must be a power of ten, e.g. 100 for two decimal places")
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+	if rounding == "" {
+		rounding = model.RoundingNone
+	}
+	if !model.IsValidRounding(rounding) {
+		err := fmt.Errorf("unknown rounding %s", rounding)
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+
+	currency, err := l.datasource.CreateCurrency(ctx, model.Currency{Code: code, Name: name, Precision: precision, Rounding: rounding})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.AddEvent("Currency registered", trace.WithAttributes(attribute.String("currency.code", code)))
+	return currency, nil
+}
+
+// GetCurrency retrieves a currency of the registry by its code.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - code string: The currency code.
+//
+// Returns:
+// - *model.Currency: A pointer to the currency.
+// - error: An error if the currency is not registered or could not be retrieved.
+func (l *Blnk) GetCurrency(ctx context.Context, code string) (*model.Currency, error) {
+	code = strings.ToUpper(code)
+	if currency, ok := model.ISOCurrency(code); ok {
+		return currency, nil
+	}
+	return l.datasource.GetCurrency(ctx, code)
+}
+
+// GetCurrencies retrieves the currency registry: the ISO 4217 currencies and the custom assets, ordered by code.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+//
+// Returns:
+// - []model.Currency: The currencies.
+// - error: An error if the custom assets could not be retrieved.
+func (l *Blnk) GetCurrencies(ctx context.Context) ([]model.Currency, error) {
+	custom, err := l.datasource.GetCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currencies := append(model.ISOCurrencies(), custom...)
+	sort.SliceStable(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies, nil
+}
+
+// lookupCurrency finds a currency in the registry.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - code string: The currency code.
+//
+// Returns:
+// - *model.Currency: A pointer to the currency, or nil if it is not registered.
+// - error: An error if the registry could not be read.
+func (l *Blnk) lookupCurrency(ctx context.Context, code string) (*model.Currency, error) {
+	currency, err := l.GetCurrency(ctx, code)
+	if err != nil {
+		var apiErr apierror.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == apierror.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return currency, nil
+}
+
+// ResolvePrecision decides the precision of an amount in a currency. A precision left at zero takes the currency's
+// precision from the registry, and a precision that differs from it is rejected. Currencies missing from the registry
+// keep the precision given.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - code string: The currency code.
+// - precision float64: The precision given, or zero.
+//
+// Returns:
+// - float64: The precision to use.
+// - error: An error if the precision conflicts with the currency or the registry could not be read.
+func (l *Blnk) ResolvePrecision(ctx context.Context, code string, precision float64) (float64, error) {
+	currency, err := l.lookupCurrency(ctx, code)
+	if err != nil || currency == nil {
+		return precision, err
+	}
+	if precision == 0 {
+		return currency.Precision, nil
+	}
+	if precision != currency.Precision {
+		err := fmt.Errorf("precision %v conflicts with the precision %v of %s", precision, currency.Precision, currency.Code)
+		return 0, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+	return precision, nil
+}
+
+// currencyPrecision finds the precision of a currency in the registry.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - code string: The currency code.
+// - fallback float64: The precision to use if the currency is not registered.
+//
+// Returns:
+// - float64: The precision of the currency, or the fallback.
+// - error: An error if the registry could not be read.
+func (l *Blnk) currencyPrecision(ctx context.Context, code string, fallback float64) (float64, error) {
+	precision, err := l.ResolvePrecision(ctx, code, 0)
+	if err != nil || precision != 0 {
+		return precision, err
+	}
+	return fallback, nil
+}
+
+// applyCurrency sets the precision of a transaction from the currency registry and converts its amount into minor
+// units with the currency's rounding. Commits, voids and refunds keep the precision of the transaction they follow.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction to queue.
+//
+// Returns:
+// - error: An error if the precision conflicts with the currency or the amount cannot be expressed in its minor units.
+func (l *Blnk) applyCurrency(ctx context.Context, transaction *model.Transaction) error {
+	if transaction.Refund || transaction.Status == StatusCommit || transaction.Status == StatusVoid {
+		return nil
+	}
+	currency, err := l.lookupCurrency(ctx, transaction.Currency)
+	if err != nil || currency == nil {
+		return err
+	}
+
+	transaction.Precision, err = l.ResolvePrecision(ctx, currency.Code, transaction.Precision)
+	if err != nil {
+		return err
+	}
+	if transaction.PreciseAmount == nil {
+		transaction.PreciseAmount, err = currency.ToPreciseAmount(transaction.Amount)
+		if err != nil {
+			return apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+		}
+	}
+	return nil
+}
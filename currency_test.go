@@ -0,0 +1,142 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/database/mocks"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateCurrency(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	// ISO 4217 currencies are registered already
+	_, err := blnk.CreateCurrency(ctx, "usd", "Dollar", 100, "")
+	assert.Equal(t, apierror.ErrConflict, err.(apierror.APIError).Code)
+
+	_, err = blnk.CreateCurrency(ctx, "PTS", "Loyalty points", 50, "")
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+
+	_, err = blnk.CreateCurrency(ctx, "PTS", "Loyalty points", 100, "CEILING")
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+
+	registered := &model.Currency{Code: "PTS", Name: "Loyalty points", Precision: 100, Rounding: model.RoundingNone, Custom: true}
+	mockDS.On("CreateCurrency", mock.Anything, model.Currency{Code: "PTS", Name: "Loyalty points", Precision: 100, Rounding: model.RoundingNone}).Return(registered, nil)
+
+	currency, err := blnk.CreateCurrency(ctx, "pts", "Loyalty points", 100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, registered, currency)
+	mockDS.AssertExpectations(t)
+}
+
+func TestGetCurrencies(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+
+	mockDS.On("GetCurrencies", mock.Anything).Return([]model.Currency{{Code: "PTS", Precision: 100, Custom: true}}, nil)
+
+	currencies, err := blnk.GetCurrencies(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, currencies, len(model.ISOCurrencies())+1)
+	for i := 1; i < len(currencies); i++ {
+		assert.Less(t, currencies[i-1].Code, currencies[i].Code)
+	}
+}
+
+func TestResolvePrecision(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	mockDS.On("GetCurrency", mock.Anything, "USDT").Return(&model.Currency{Code: "USDT", Precision: 1e6, Custom: true}, nil)
+	mockDS.On("GetCurrency", mock.Anything, "XYZ").Return(nil, apierror.NewAPIError(apierror.ErrNotFound, "Currency 'XYZ' not found", nil))
+
+	precision, err := blnk.ResolvePrecision(ctx, "USD", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(100), precision)
+
+	precision, err = blnk.ResolvePrecision(ctx, "USDT", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1e6), precision)
+
+	_, err = blnk.ResolvePrecision(ctx, "JPY", 100)
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+
+	// Currencies missing from the registry keep the precision given
+	precision, err = blnk.ResolvePrecision(ctx, "XYZ", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1000), precision)
+}
+
+func TestApplyCurrency(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	mockDS.On("GetCurrency", mock.Anything, "PTS").Return(&model.Currency{Code: "PTS", Precision: 100, Rounding: model.RoundingDown, Custom: true}, nil)
+
+	txn := &model.Transaction{Currency: "USD", Amount: 19.99}
+	assert.NoError(t, blnk.applyCurrency(ctx, txn))
+	assert.Equal(t, float64(100), txn.Precision)
+	assert.Equal(t, "1999", txn.PreciseAmount.String())
+
+	// ISO currencies take no rounding
+	txn = &model.Transaction{Currency: "USD", Amount: 19.999}
+	assert.Equal(t, apierror.ErrBadRequest, blnk.applyCurrency(ctx, txn).(apierror.APIError).Code)
+
+	txn = &model.Transaction{Currency: "PTS", Amount: 10.559}
+	assert.NoError(t, blnk.applyCurrency(ctx, txn))
+	assert.Equal(t, "1055", txn.PreciseAmount.String())
+
+	txn = &model.Transaction{Currency: "JPY", Amount: 500, Precision: 100}
+	assert.Error(t, blnk.applyCurrency(ctx, txn))
+}
+
+func TestApplyFXRate_MinorUnits(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	postedAt := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+
+	usd := &model.Balance{BalanceID: "bln_usd", Currency: "USD", CurrencyMultiplier: 100}
+	jpy := &model.Balance{BalanceID: "bln_jpy", Currency: "JPY", CurrencyMultiplier: 1}
+
+	mockDS.On("GetFXRate", mock.Anything, "USD", "JPY", postedAt).Return(&model.FXRate{RateID: "fxr_1", Rate: 150}, nil)
+
+	// 150 yen to the dollar converts each cent into 1.5 yen
+	txn := &model.Transaction{Currency: "USD", Precision: 100, EffectiveDate: postedAt}
+	assert.NoError(t, blnk.applyFXRate(context.Background(), txn, usd, jpy))
+	assert.Equal(t, 1.5, txn.Rate)
+
+	// A rate given by the caller is scaled the same way
+	txn = &model.Transaction{Currency: "USD", Precision: 100, Rate: 148, EffectiveDate: postedAt}
+	assert.NoError(t, blnk.applyFXRate(context.Background(), txn, usd, jpy))
+	assert.Equal(t, 1.48, txn.Rate)
+
+	// A commit keeps the rate its inflight transaction was converted at
+	txn = &model.Transaction{Currency: "USD", Precision: 100, Rate: 1.48, Status: StatusCommit, EffectiveDate: postedAt}
+	assert.NoError(t, blnk.applyFXRate(context.Background(), txn, usd, jpy))
+	assert.Equal(t, 1.48, txn.Rate)
+}
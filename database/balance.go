@@ -51,7 +51,7 @@ func prepareQueries(queryBuilder strings.Builder, include []string) string {
 	selectFields = append(selectFields,
 		"b.balance_id", "b.balance", "b.credit_balance", "b.debit_balance",
 		"b.currency", "b.currency_multiplier", "b.ledger_id",
-		"COALESCE(b.identity_id, '') as identity_id", "b.created_at", "b.meta_data", "b.inflight_balance", "b.inflight_credit_balance", "b.inflight_debit_balance", "b.version", "b.indicator")
+		"COALESCE(b.identity_id, '') as identity_id", "b.created_at", "b.meta_data", "b.inflight_balance", "b.inflight_credit_balance", "b.inflight_debit_balance", "b.version", "b.indicator", "b.overdraft_limit", "b.status", "b.account_type", "b.normal_side")
 
 	// Conditionally include identity fields
 	if contains(include, "identity") {
@@ -109,7 +109,8 @@ func scanRow(row *sql.Row, tx *sql.Tx, include []string) (*model.Balance, error)
 	scanArgs = append(scanArgs, &balance.BalanceID, &balanceStr, &creditBalanceStr,
 		&debitBalanceStr, &balance.Currency, &balance.CurrencyMultiplier,
 		&balance.LedgerID, &balance.IdentityID, &balance.CreatedAt, &metaDataJSON,
-		&inflightBalanceStr, &inflightCreditBalanceStr, &inflightDebitBalanceStr, &balance.Version, &indicator)
+		&inflightBalanceStr, &inflightCreditBalanceStr, &inflightDebitBalanceStr, &balance.Version, &indicator, bigIntScanner{&balance.OverdraftLimit}, &balance.Status,
+		stringScanner{&balance.AccountType}, stringScanner{&balance.NormalSide})
 
 	// Conditionally scan for identity fields
 	if contains(include, "identity") {
@@ -137,6 +138,7 @@ func scanRow(row *sql.Row, tx *sql.Tx, include []string) (*model.Balance, error)
 	balance.InflightBalance, _ = new(big.Int).SetString(inflightBalanceStr, 10)
 	balance.InflightCreditBalance, _ = new(big.Int).SetString(inflightCreditBalanceStr, 10)
 	balance.InflightDebitBalance, _ = new(big.Int).SetString(inflightDebitBalanceStr, 10)
+	balance.ComputeAvailableBalance()
 
 	// Handle null indicator field
 	if indicator.Valid {
@@ -213,12 +215,17 @@ func (d Datasource) CreateBalance(balance model.Balance) (model.Balance, error)
 	if balance.InflightDebitBalance == nil {
 		balance.InflightDebitBalance = big.NewInt(0)
 	}
+	if balance.OverdraftLimit == nil {
+		balance.OverdraftLimit = big.NewInt(0)
+	}
+	balance.ComputeAvailableBalance()
 
 	// Insert the balance into the database
 	_, err = d.Conn.Exec(`
-		INSERT INTO blnk.balances (balance_id, balance, credit_balance, debit_balance, currency, currency_multiplier, ledger_id, identity_id, indicator, created_at, meta_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,$11)
-	`, balance.BalanceID, balance.Balance.String(), balance.CreditBalance.String(), balance.DebitBalance.String(), balance.Currency, balance.CurrencyMultiplier, balance.LedgerID, identityID, indicator, balance.CreatedAt, &metaDataJSON)
+		INSERT INTO blnk.balances (balance_id, balance, credit_balance, debit_balance, currency, currency_multiplier, ledger_id, identity_id, indicator, created_at, meta_data, overdraft_limit, account_type, normal_side)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,$11, $12, $13, $14)
+	`, balance.BalanceID, balance.Balance.String(), balance.CreditBalance.String(), balance.DebitBalance.String(), balance.Currency, balance.CurrencyMultiplier, balance.LedgerID, identityID, indicator, balance.CreatedAt, &metaDataJSON, balance.OverdraftLimit.String(),
+		nullIfEmpty(balance.AccountType), nullIfEmpty(balance.NormalSide))
 
 	if err != nil {
 		// Handle specific PostgreSQL errors (e.g., unique or foreign key violations)
@@ -299,12 +306,11 @@ func (d Datasource) GetBalanceByID(id string, include []string) (*model.Balance,
 // - error: Returns an APIError in case of errors such as database failures or if the balance is not found.
 func (d Datasource) GetBalanceByIDLite(id string) (*model.Balance, error) {
 	var balance model.Balance
-	var balanceValue, creditBalanceValue, debitBalanceValue, inflightBalanceValue, inflightCreditBalanceValue, inflightDebitBalanceValue int64
 	var indicator sql.NullString
 
 	// Execute the query
 	row := d.Conn.QueryRow(`
-	   SELECT balance_id, indicator, currency, currency_multiplier, ledger_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, created_at, version
+	   SELECT balance_id, indicator, currency, currency_multiplier, ledger_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, created_at, version, overdraft_limit, status, account_type, normal_side
 	   FROM blnk.balances
 	   WHERE balance_id = $1
 	`, id)
@@ -316,14 +322,18 @@ func (d Datasource) GetBalanceByIDLite(id string) (*model.Balance, error) {
 		&balance.Currency,
 		&balance.CurrencyMultiplier,
 		&balance.LedgerID,
-		&balanceValue,
-		&creditBalanceValue,
-		&debitBalanceValue,
-		&inflightBalanceValue,
-		&inflightCreditBalanceValue,
-		&inflightDebitBalanceValue,
+		bigIntScanner{&balance.Balance},
+		bigIntScanner{&balance.CreditBalance},
+		bigIntScanner{&balance.DebitBalance},
+		bigIntScanner{&balance.InflightBalance},
+		bigIntScanner{&balance.InflightCreditBalance},
+		bigIntScanner{&balance.InflightDebitBalance},
 		&balance.CreatedAt,
 		&balance.Version,
+		bigIntScanner{&balance.OverdraftLimit},
+		&balance.Status,
+		stringScanner{&balance.AccountType},
+		stringScanner{&balance.NormalSide},
 	)
 
 	// Handle null indicator field
@@ -342,19 +352,12 @@ func (d Datasource) GetBalanceByIDLite(id string) (*model.Balance, error) {
 		}
 	}
 
-	// Convert balance fields from int64 to big.Int
-	balance.Balance = big.NewInt(balanceValue)
-	balance.CreditBalance = big.NewInt(creditBalanceValue)
-	balance.DebitBalance = big.NewInt(debitBalanceValue)
-	balance.InflightBalance = big.NewInt(inflightBalanceValue)
-	balance.InflightCreditBalance = big.NewInt(inflightCreditBalanceValue)
-	balance.InflightDebitBalance = big.NewInt(inflightDebitBalanceValue)
-
+	balance.ComputeAvailableBalance()
 	return &balance, nil
 }
 
 // GetBalanceByIndicator retrieves a balance from the database using the specified indicator and currency.
-// The function scans the query result into a Balance object and converts various fields from int64 to big.Int.
+// The function scans the query result into a Balance object, reading the NUMERIC balance fields into big.Int.
 // It returns the balance if found, or an error if the balance does not exist.
 //
 // Parameters:
@@ -366,11 +369,10 @@ func (d Datasource) GetBalanceByIDLite(id string) (*model.Balance, error) {
 // - error: An error if any issues occur during the query execution or data retrieval.
 func (d Datasource) GetBalanceByIndicator(indicator, currency string) (*model.Balance, error) {
 	var balance model.Balance
-	var balanceValue, creditBalanceValue, debitBalanceValue, inflightBalanceValue, inflightCreditBalanceValue, inflightDebitBalanceValue int64
 
 	// Execute query to find the balance with the given indicator and currency
 	row := d.Conn.QueryRow(`
-	   SELECT balance_id, indicator, currency, currency_multiplier, ledger_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, created_at, version
+	   SELECT balance_id, indicator, currency, currency_multiplier, ledger_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, created_at, version, overdraft_limit, status, account_type, normal_side
 	   FROM blnk.balances
 	   WHERE indicator = $1 AND currency = $2
 	`, indicator, currency)
@@ -382,31 +384,29 @@ func (d Datasource) GetBalanceByIndicator(indicator, currency string) (*model.Ba
 		&balance.Currency,
 		&balance.CurrencyMultiplier,
 		&balance.LedgerID,
-		&balanceValue,
-		&creditBalanceValue,
-		&debitBalanceValue,
-		&inflightBalanceValue,
-		&inflightCreditBalanceValue,
-		&inflightDebitBalanceValue,
+		bigIntScanner{&balance.Balance},
+		bigIntScanner{&balance.CreditBalance},
+		bigIntScanner{&balance.DebitBalance},
+		bigIntScanner{&balance.InflightBalance},
+		bigIntScanner{&balance.InflightCreditBalance},
+		bigIntScanner{&balance.InflightDebitBalance},
 		&balance.CreatedAt,
 		&balance.Version,
+		bigIntScanner{&balance.OverdraftLimit},
+		&balance.Status,
+		stringScanner{&balance.AccountType},
+		stringScanner{&balance.NormalSide},
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Handle the case where no balance was found with the given indicator and currency
-			return &model.Balance{}, fmt.Errorf("balance with indicator '%s' not found", indicator)
+			return &model.Balance{}, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Balance with indicator '%s' not found", indicator), err)
 		}
 		// Return other types of errors, such as query execution failures
 		return nil, err
 	}
 
-	// Convert the scanned int64 values into big.Int for precision in financial calculations
-	balance.Balance = big.NewInt(balanceValue)
-	balance.CreditBalance = big.NewInt(creditBalanceValue)
-	balance.DebitBalance = big.NewInt(debitBalanceValue)
-	balance.InflightBalance = big.NewInt(inflightBalanceValue)
-	balance.InflightCreditBalance = big.NewInt(inflightCreditBalanceValue)
-	balance.InflightDebitBalance = big.NewInt(inflightDebitBalanceValue)
+	balance.ComputeAvailableBalance()
 
 	// Return the populated Balance object
 	return &balance, nil
@@ -427,7 +427,7 @@ func (d Datasource) GetAllBalances(limit, offset int) ([]model.Balance, error) {
 	var indicator sql.NullString
 	// Execute SQL query to select all balances with a limit of 20 records
 	rows, err := d.Conn.Query(`
-		SELECT balance_id, indicator, balance, credit_balance, debit_balance, currency, currency_multiplier, ledger_id, created_at, meta_data
+		SELECT balance_id, indicator, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, currency, currency_multiplier, ledger_id, created_at, meta_data, overdraft_limit, status, account_type, normal_side
 		FROM blnk.balances
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -444,7 +444,6 @@ func (d Datasource) GetAllBalances(limit, offset int) ([]model.Balance, error) {
 
 	// Slice to store the retrieved balances
 	var balances []model.Balance
-	var balanceValue, creditBalanceValue, debitBalanceValue, inflightBalanceValue, inflightCreditBalanceValue, inflightDebitBalanceValue int64
 
 	// Iterate through the rows and scan each balance into the Balance object
 	for rows.Next() {
@@ -455,14 +454,21 @@ func (d Datasource) GetAllBalances(limit, offset int) ([]model.Balance, error) {
 		err = rows.Scan(
 			&balance.BalanceID,
 			&indicator,
-			&balanceValue,
-			&creditBalanceValue,
-			&debitBalanceValue,
+			bigIntScanner{&balance.Balance},
+			bigIntScanner{&balance.CreditBalance},
+			bigIntScanner{&balance.DebitBalance},
+			bigIntScanner{&balance.InflightBalance},
+			bigIntScanner{&balance.InflightCreditBalance},
+			bigIntScanner{&balance.InflightDebitBalance},
 			&balance.Currency,
 			&balance.CurrencyMultiplier,
 			&balance.LedgerID,
 			&balance.CreatedAt,
 			&metaDataJSON,
+			bigIntScanner{&balance.OverdraftLimit},
+			&balance.Status,
+			stringScanner{&balance.AccountType},
+			stringScanner{&balance.NormalSide},
 		)
 		if err != nil {
 			return nil, err // Return error if scanning fails
@@ -476,13 +482,7 @@ func (d Datasource) GetAllBalances(limit, offset int) ([]model.Balance, error) {
 			balance.Indicator = ""
 		}
 
-		// Convert the scanned int64 values into big.Int for accurate balance calculations
-		balance.Balance = big.NewInt(balanceValue)
-		balance.CreditBalance = big.NewInt(creditBalanceValue)
-		balance.DebitBalance = big.NewInt(debitBalanceValue)
-		balance.InflightBalance = big.NewInt(inflightBalanceValue)
-		balance.InflightCreditBalance = big.NewInt(inflightCreditBalanceValue)
-		balance.InflightDebitBalance = big.NewInt(inflightDebitBalanceValue)
+		balance.ComputeAvailableBalance()
 
 		// Parse the metadata JSON into the MetaData map field
 		err = json.Unmarshal(metaDataJSON, &balance.MetaData)
@@ -707,6 +707,71 @@ func (d Datasource) UpdateBalance(balance *model.Balance) error {
 	return nil
 }
 
+// UpdateOverdraftLimit sets how far below zero a balance may go, in the balance's minor units.
+// Only the limit is written, so it cannot overwrite amounts posted concurrently.
+//
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - balanceID: The ID of the balance to update.
+// - limit: The new overdraft limit. Zero means the balance may not go negative.
+//
+// Returns:
+// - error: Returns an APIError if the balance does not exist or the update fails.
+func (d Datasource) UpdateOverdraftLimit(ctx context.Context, balanceID string, limit *big.Int) error {
+	result, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.balances
+		SET overdraft_limit = $2
+		WHERE balance_id = $1
+	`, balanceID, limit.String())
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to update overdraft limit", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Balance with ID '%s' not found", balanceID), nil)
+	}
+
+	return nil
+}
+
+// UpdateBalanceAccountType places a balance in the ledger's chart of accounts.
+// Only the account type and normal side are written, so it cannot overwrite amounts posted concurrently.
+//
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - balanceID: The ID of the balance to update.
+// - accountType: The balance's account type, e.g. asset or liability. Empty removes the balance from the chart.
+// - normalSide: The side that increases the balance, debit or credit.
+//
+// Returns:
+// - error: Returns an APIError if the balance does not exist or the update fails.
+func (d Datasource) UpdateBalanceAccountType(ctx context.Context, balanceID, accountType, normalSide string) error {
+	result, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.balances
+		SET account_type = $2, normal_side = $3
+		WHERE balance_id = $1
+	`, balanceID, nullIfEmpty(accountType), nullIfEmpty(normalSide))
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to update account type", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Balance with ID '%s' not found", balanceID), nil)
+	}
+
+	return nil
+}
+
 // CreateMonitor creates a new BalanceMonitor record in the database.
 // This function generates a unique MonitorID for the monitor, sets the creation timestamp,
 // and inserts the monitor's data into the `blnk.balance_monitors` table.
@@ -723,16 +788,33 @@ func (d Datasource) CreateMonitor(monitor model.BalanceMonitor) (model.BalanceMo
 	monitor.MonitorID = model.GenerateUUIDWithSuffix("mon")
 	monitor.CreatedAt = time.Now()
 
+	if monitor.TriggerMode == "" {
+		monitor.TriggerMode = model.MonitorTriggerLevel
+	}
+	if monitor.RetryBackoffSeconds == 0 {
+		monitor.RetryBackoffSeconds = model.DefaultMonitorRetryBackoffSeconds
+	}
+
 	// If PreciseValue is nil, initialize it to 0
 	if monitor.Condition.PreciseValue == nil {
 		monitor.Condition.PreciseValue = big.NewInt(0)
 	}
 
+	// Monitors with a condition group store it as JSON and leave the single condition empty
+	conditionsJSON, err := monitorConditionsJSON(&monitor)
+	if err != nil {
+		return model.BalanceMonitor{}, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal conditions", err)
+	}
+	actionsJSON, err := monitorActionsJSON(&monitor)
+	if err != nil {
+		return model.BalanceMonitor{}, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal actions", err)
+	}
+
 	// Insert the monitor data into the balance_monitors table
-	_, err := d.Conn.Exec(`
-		INSERT INTO blnk.balance_monitors (monitor_id, balance_id, field, operator, value, precision, precise_value, description, call_back_url, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`, monitor.MonitorID, monitor.BalanceID, monitor.Condition.Field, monitor.Condition.Operator, monitor.Condition.Value, monitor.Condition.Precision, monitor.Condition.PreciseValue.String(), monitor.Description, monitor.CallBackURL, monitor.CreatedAt)
+	_, err = d.Conn.Exec(`
+		INSERT INTO blnk.balance_monitors (monitor_id, balance_id, field, operator, value, precision, precise_value, description, call_back_url, created_at, conditions, trigger_mode, cooldown_seconds, call_back_secret, max_retries, retry_backoff_seconds, actions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`, monitor.MonitorID, monitor.BalanceID, nullIfEmpty(monitor.Condition.Field), nullIfEmpty(monitor.Condition.Operator), monitor.Condition.Value, monitor.Condition.Precision, monitor.Condition.PreciseValue.String(), monitor.Description, monitor.CallBackURL, monitor.CreatedAt, conditionsJSON, monitor.TriggerMode, monitor.CooldownSeconds, nullIfEmpty(monitor.CallBackSecret), monitor.MaxRetries, monitor.RetryBackoffSeconds, actionsJSON)
 
 	// Handle database errors
 	if err != nil {
@@ -768,21 +850,15 @@ func (d Datasource) CreateMonitor(monitor model.BalanceMonitor) (model.BalanceMo
 // - *model.BalanceMonitor: A pointer to the BalanceMonitor object if found.
 // - error: If the monitor is not found or if any errors occur during the query, an `APIError` is returned.
 func (d Datasource) GetMonitorByID(id string) (*model.BalanceMonitor, error) {
-	var preciseValue int64 // Temporary variable to hold the precise value as int64
 
 	// Query the database to get the monitor details by MonitorID
 	row := d.Conn.QueryRow(`
-		SELECT monitor_id, balance_id, field, operator, value, precision, precise_value, description, call_back_url, created_at
+		SELECT `+monitorColumns+`
 		FROM blnk.balance_monitors WHERE monitor_id = $1
 	`, id)
 
-	// Initialize an empty BalanceMonitor object
-	monitor := &model.BalanceMonitor{}
-	// Initialize an empty AlertCondition object (part of the monitor)
-	condition := &model.AlertCondition{}
-
-	// Scan the result into the monitor and condition fields
-	err := row.Scan(&monitor.MonitorID, &monitor.BalanceID, &condition.Field, &condition.Operator, &condition.Value, &condition.Precision, &preciseValue, &monitor.Description, &monitor.CallBackURL, &monitor.CreatedAt)
+	// Scan the result into a BalanceMonitor object
+	monitor, err := scanMonitor(row)
 	if err != nil {
 		// Handle the case where the monitor with the specified ID is not found
 		if err == sql.ErrNoRows {
@@ -792,10 +868,6 @@ func (d Datasource) GetMonitorByID(id string) (*model.BalanceMonitor, error) {
 		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve monitor", err)
 	}
 
-	// Populate the PreciseValue field in the condition (convert from int64 to big.Int)
-	monitor.Condition = *condition
-	monitor.Condition.PreciseValue = big.NewInt(preciseValue)
-
 	// Return the populated BalanceMonitor object
 	return monitor, nil
 }
@@ -809,7 +881,7 @@ func (d Datasource) GetMonitorByID(id string) (*model.BalanceMonitor, error) {
 func (d Datasource) GetAllMonitors() ([]model.BalanceMonitor, error) {
 	// Query the database for all balance monitors
 	rows, err := d.Conn.Query(`
-		SELECT monitor_id, balance_id, field, operator, value, description, call_back_url, created_at
+		SELECT ` + monitorColumns + `
 		FROM blnk.balance_monitors
 	`)
 	if err != nil {
@@ -818,36 +890,7 @@ func (d Datasource) GetAllMonitors() ([]model.BalanceMonitor, error) {
 	}
 	defer rows.Close() // Ensure rows are closed after processing
 
-	// Initialize an empty slice to store the retrieved monitors
-	var monitors []model.BalanceMonitor
-
-	// Iterate through each row in the result set
-	for rows.Next() {
-		monitor := model.BalanceMonitor{}   // Create an empty BalanceMonitor object
-		condition := model.AlertCondition{} // Create an empty AlertCondition object (part of the monitor)
-
-		// Scan the row into the monitor and condition fields
-		err = rows.Scan(&monitor.MonitorID, &monitor.BalanceID, &condition.Field, &condition.Operator, &condition.Value, &monitor.Description, &monitor.CallBackURL, &monitor.CreatedAt)
-		if err != nil {
-			// Return an error if scanning fails
-			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan monitor data", err)
-		}
-
-		// Assign the scanned AlertCondition to the monitor
-		monitor.Condition = condition
-
-		// Append the monitor to the slice
-		monitors = append(monitors, monitor)
-	}
-
-	// Check for errors encountered during iteration
-	if err = rows.Err(); err != nil {
-		// Return an error if there were issues iterating over the result set
-		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over monitors", err)
-	}
-
-	// Return the slice of monitors
-	return monitors, nil
+	return collectMonitors(rows)
 }
 
 // GetBalanceMonitors retrieves all balance monitors associated with a specific balance ID from the database.
@@ -862,7 +905,7 @@ func (d Datasource) GetAllMonitors() ([]model.BalanceMonitor, error) {
 func (d Datasource) GetBalanceMonitors(balanceID string) ([]model.BalanceMonitor, error) {
 	// Query the database for monitors associated with the given balance ID
 	rows, err := d.Conn.Query(`
-		SELECT monitor_id, balance_id, field, operator, value, description, call_back_url, created_at, precision, precise_value
+		SELECT `+monitorColumns+`
 		FROM blnk.balance_monitors WHERE balance_id = $1
 	`, balanceID)
 	if err != nil {
@@ -871,43 +914,13 @@ func (d Datasource) GetBalanceMonitors(balanceID string) ([]model.BalanceMonitor
 	}
 	defer rows.Close() // Ensure rows are closed after processing
 
-	// Initialize an empty slice to store the retrieved monitors
-	var monitors []model.BalanceMonitor
-
-	// Iterate through each row in the result set
-	for rows.Next() {
-		var preciseValue int64              // Temporary variable to hold the precise value as int64
-		monitor := model.BalanceMonitor{}   // Create an empty BalanceMonitor object
-		condition := model.AlertCondition{} // Create an empty AlertCondition object (part of the monitor)
-
-		// Scan the row into the monitor and condition fields
-		err = rows.Scan(&monitor.MonitorID, &monitor.BalanceID, &condition.Field, &condition.Operator, &condition.Value, &monitor.Description, &monitor.CallBackURL, &monitor.CreatedAt, &condition.Precision, &preciseValue)
-		if err != nil {
-			// Return an error if scanning fails
-			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan monitor data", err)
-		}
-
-		// Assign the scanned AlertCondition to the monitor
-		monitor.Condition = condition
-		monitor.Condition.PreciseValue = big.NewInt(preciseValue)
-
-		// Append the monitor to the slice
-		monitors = append(monitors, monitor)
-	}
-
-	// Check for errors encountered during iteration
-	if err = rows.Err(); err != nil {
-		// Return an error if there were issues iterating over the result set
-		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over balance monitors", err)
-	}
-
-	// Return the slice of monitors
-	return monitors, nil
+	return collectMonitors(rows)
 }
 
 // UpdateMonitor updates an existing balance monitor in the database.
-// It updates fields such as `balance_id`, `field`, `operator`, `value`, `description`, and `call_back_url`
-// for the monitor identified by `monitor_id`.
+// It updates fields such as `balance_id`, `field`, `operator`, `value`, `description`, `call_back_url`,
+// `conditions`, `trigger_mode`, `cooldown_seconds` and the retry policy for the monitor identified by `monitor_id`.
+// The callback secret is only replaced when a new one is given.
 //
 // Parameters:
 // - monitor: A pointer to the `BalanceMonitor` object containing the updated values.
@@ -915,12 +928,30 @@ func (d Datasource) GetBalanceMonitors(balanceID string) ([]model.BalanceMonitor
 // Returns:
 // - error: If the update fails, an appropriate `APIError` is returned.
 func (d Datasource) UpdateMonitor(monitor *model.BalanceMonitor) error {
+	if monitor.TriggerMode == "" {
+		monitor.TriggerMode = model.MonitorTriggerLevel
+	}
+	if monitor.RetryBackoffSeconds == 0 {
+		monitor.RetryBackoffSeconds = model.DefaultMonitorRetryBackoffSeconds
+	}
+
+	conditionsJSON, err := monitorConditionsJSON(monitor)
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal conditions", err)
+	}
+	actionsJSON, err := monitorActionsJSON(monitor)
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal actions", err)
+	}
+
 	// Execute the SQL update statement, replacing the placeholder values with the monitor's data
 	result, err := d.Conn.Exec(`
 		UPDATE blnk.balance_monitors
-		SET balance_id = $2, field = $3, operator = $4, value = $5, description = $6, call_back_url = $7
+		SET balance_id = $2, field = $3, operator = $4, value = $5, description = $6, call_back_url = $7, conditions = $8, trigger_mode = $9, cooldown_seconds = $10,
+			call_back_secret = COALESCE($11, call_back_secret), max_retries = $12, retry_backoff_seconds = $13, actions = $14
 		WHERE monitor_id = $1
-	`, monitor.MonitorID, monitor.BalanceID, monitor.Condition.Field, monitor.Condition.Operator, monitor.Condition.Value, monitor.Description, monitor.CallBackURL)
+	`, monitor.MonitorID, monitor.BalanceID, nullIfEmpty(monitor.Condition.Field), nullIfEmpty(monitor.Condition.Operator), monitor.Condition.Value, monitor.Description, monitor.CallBackURL, conditionsJSON, monitor.TriggerMode, monitor.CooldownSeconds,
+		nullIfEmpty(monitor.CallBackSecret), monitor.MaxRetries, monitor.RetryBackoffSeconds, actionsJSON)
 
 	// If an error occurred during execution, return an internal server error
 	if err != nil {
@@ -0,0 +1,351 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// balanceDeltaQuery sums the effect of posted transactions on a balance, mirroring how the transaction
+// processor moves money:
+// - APPLIED transactions debit the source and credit the destination (with the exchange rate applied).
+// - INFLIGHT transactions add to the inflight debit and credit balances.
+// - Commits (APPLIED children of an inflight transaction) move the amount from inflight to regular balances.
+// - VOID transactions release the inflight amount.
+// Headers of atomic journal entries are skipped because their legs are stored as separate rows.
+//
+// A transaction is counted when its effective date is <= $2 and it is not already part of the base snapshot,
+// i.e. it is effective after the snapshot time ($3) or was inserted after the snapshot was taken ($4).
+// $5 bounds the rows considered, so a new snapshot covers exactly the rows it recorded.
+const balanceDeltaQuery = `
+	WITH postings AS (
+		SELECT t.source, t.destination, t.status,
+			COALESCE(t.precise_amount, 0) AS debit_amount,
+			TRUNC(COALESCE(t.precise_amount, 0) * COALESCE(NULLIF(t.rate, 0), 1)) AS credit_amount,
+			EXISTS (
+				SELECT 1 FROM blnk.transactions p
+				WHERE p.transaction_id = t.parent_transaction AND p.status = 'INFLIGHT' AND p.atomic = false
+			) AS settles_inflight
+		FROM blnk.transactions t
+		WHERE (t.source = $1 OR t.destination = $1)
+			AND t.atomic = false
+			AND t.status IN ('APPLIED', 'INFLIGHT', 'VOID')
+			AND t.effective_date <= $2
+			AND (t.effective_date > $3 OR t.id > $4)
+			AND t.id <= $5
+	)
+	SELECT
+		COALESCE(SUM(CASE WHEN destination = $1 AND status = 'APPLIED' THEN
+			CASE WHEN settles_inflight THEN debit_amount ELSE credit_amount END ELSE 0 END), 0)::TEXT,
+		COALESCE(SUM(CASE WHEN source = $1 AND status = 'APPLIED' THEN debit_amount ELSE 0 END), 0)::TEXT,
+		COALESCE(SUM(CASE WHEN destination = $1 THEN
+			CASE WHEN status = 'INFLIGHT' THEN credit_amount WHEN settles_inflight THEN -debit_amount ELSE 0 END ELSE 0 END), 0)::TEXT,
+		COALESCE(SUM(CASE WHEN source = $1 THEN
+			CASE WHEN status = 'INFLIGHT' THEN debit_amount WHEN settles_inflight THEN -debit_amount ELSE 0 END ELSE 0 END), 0)::TEXT
+	FROM postings
+`
+
+// balanceSnapshot is the latest snapshot of a balance at or before a point in time.
+type balanceSnapshot struct {
+	balance            *model.Balance
+	snapshotTime       time.Time
+	lastTransactionSeq int64
+}
+
+// GetBalanceAtTime computes a balance as it stood at the given time.
+// It starts from the latest snapshot taken at or before that time and adds the transactions posted since,
+// so only a bounded window of transactions is read no matter how large the ledger is.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - balanceID: The ID of the balance.
+// - asOf: The point in time to compute the balance for.
+// Returns:
+// - A pointer to the balance with its amounts as of the given time.
+// - An error if the balance does not exist or the computation fails.
+func (d Datasource) GetBalanceAtTime(ctx context.Context, balanceID string, asOf time.Time) (*model.Balance, error) {
+	ctx, span := otel.Tracer("balance.database").Start(ctx, "GetBalanceAtTime")
+	defer span.End()
+
+	balance, err := d.GetBalanceByIDLite(balanceID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	snapshot, err := latestBalanceSnapshot(ctx, d.Conn, balanceID, asOf)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	historical, err := applyBalanceDeltas(ctx, d.Conn, snapshot, balanceID, asOf, math.MaxInt64)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	balance.Balance = historical.Balance
+	balance.CreditBalance = historical.CreditBalance
+	balance.DebitBalance = historical.DebitBalance
+	balance.InflightBalance = historical.InflightBalance
+	balance.InflightCreditBalance = historical.InflightCreditBalance
+	balance.InflightDebitBalance = historical.InflightDebitBalance
+	balance.ComputeAvailableBalance()
+
+	span.AddEvent("Balance computed", trace.WithAttributes(
+		attribute.String("balance.id", balanceID),
+		attribute.String("balance.as_of", asOf.Format(time.RFC3339)),
+	))
+	return balance, nil
+}
+
+// snapshotCandidatesQuery pages through the balances and reports, for each, whether it has postings its latest snapshot
+// at or before the snapshot time ($3) does not cover, up to the highest transaction row of the run ($4). It uses the same
+// window as balanceDeltaQuery, so a balance without any has nothing new to snapshot.
+const snapshotCandidatesQuery = `
+	SELECT b.balance_id, EXISTS (
+		SELECT 1 FROM blnk.transactions t
+		WHERE (t.source = b.balance_id OR t.destination = b.balance_id)
+			AND t.atomic = false
+			AND t.status IN ('APPLIED', 'INFLIGHT', 'VOID')
+			AND t.effective_date <= $3
+			AND (s.snapshot_time IS NULL OR t.effective_date > s.snapshot_time OR t.id > s.last_transaction_seq)
+			AND t.id <= $4
+	)
+	FROM (SELECT balance_id FROM blnk.balances WHERE balance_id > $1 ORDER BY balance_id LIMIT $2) b
+	LEFT JOIN LATERAL (
+		SELECT snapshot_time, last_transaction_seq
+		FROM blnk.balance_snapshots
+		WHERE balance_id = b.balance_id AND snapshot_time <= $3
+		ORDER BY snapshot_time DESC, id DESC
+		LIMIT 1
+	) s ON true
+	ORDER BY b.balance_id
+`
+
+// TakeBalanceSnapshots records a snapshot as of the given time of every balance posted to since its latest snapshot.
+// Each snapshot is built from the previous one plus the transactions posted since, and remembers the highest
+// transaction row it covered so that transactions persisted later with an earlier effective date are still counted.
+// A balance with nothing new keeps its latest snapshot, and the snapshots of each page of balances are written in
+// a single database transaction.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - snapshotTime: The point in time the snapshots represent.
+// - batchSize: The number of balances read per page.
+// Returns:
+// - The number of snapshots recorded.
+// - An error if the snapshots could not be taken.
+func (d Datasource) TakeBalanceSnapshots(ctx context.Context, snapshotTime time.Time, batchSize int) (int, error) {
+	ctx, span := otel.Tracer("balance.database").Start(ctx, "TakeBalanceSnapshots")
+	defer span.End()
+
+	var lastSeq int64
+	if err := d.Conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM blnk.transactions`).Scan(&lastSeq); err != nil {
+		span.RecordError(err)
+		return 0, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to read transaction sequence", err)
+	}
+
+	count := 0
+	lastBalanceID := ""
+	for {
+		rows, err := d.Conn.QueryContext(ctx, snapshotCandidatesQuery, lastBalanceID, batchSize, snapshotTime, lastSeq)
+		if err != nil {
+			span.RecordError(err)
+			return count, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve balances", err)
+		}
+
+		var ids []string
+		read := 0
+		for rows.Next() {
+			var id string
+			var changed bool
+			if err := rows.Scan(&id, &changed); err != nil {
+				rows.Close()
+				span.RecordError(err)
+				return count, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan balance", err)
+			}
+			read++
+			lastBalanceID = id
+			if changed {
+				ids = append(ids, id)
+			}
+		}
+		rows.Close()
+		if read == 0 {
+			break
+		}
+
+		if len(ids) > 0 {
+			if err := d.takeBalanceSnapshots(ctx, ids, snapshotTime, lastSeq); err != nil {
+				span.RecordError(err)
+				return count, err
+			}
+			count += len(ids)
+		}
+	}
+
+	span.AddEvent("Balance snapshots taken", trace.WithAttributes(attribute.Int("snapshot.count", count)))
+	return count, nil
+}
+
+// takeBalanceSnapshots computes and stores the snapshots of a page of balances in a single database transaction.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - balanceIDs: The IDs of the balances.
+// - snapshotTime: The point in time the snapshots represent.
+// - lastSeq: The highest transaction row covered by the snapshots.
+// Returns:
+// - An error if a snapshot could not be computed or stored, in which case none of the page is stored.
+func (d Datasource) takeBalanceSnapshots(ctx context.Context, balanceIDs []string, snapshotTime time.Time, lastSeq int64) error {
+	tx, err := d.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to begin transaction", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, balanceID := range balanceIDs {
+		previous, err := latestBalanceSnapshot(ctx, tx, balanceID, snapshotTime)
+		if err != nil {
+			return err
+		}
+
+		balance, err := applyBalanceDeltas(ctx, tx, previous, balanceID, snapshotTime, lastSeq)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO blnk.balance_snapshots (balance_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, snapshot_time, last_transaction_seq)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, balanceID, balance.Balance.String(), balance.CreditBalance.String(), balance.DebitBalance.String(), balance.InflightBalance.String(),
+			balance.InflightCreditBalance.String(), balance.InflightDebitBalance.String(), snapshotTime, lastSeq)
+		if err != nil {
+			return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record balance snapshot", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to commit transaction", err)
+	}
+	return nil
+}
+
+// latestBalanceSnapshot returns the most recent snapshot of a balance taken at or before the given time.
+// If there is none, an empty snapshot starting from zero is returned.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - q: The connection or database transaction to read with.
+// - balanceID: The ID of the balance.
+// - at: The upper bound for the snapshot time.
+// Returns:
+// - The snapshot to build on.
+// - An error if the snapshot could not be read.
+func latestBalanceSnapshot(ctx context.Context, q rowQuerier, balanceID string, at time.Time) (*balanceSnapshot, error) {
+	snapshot := &balanceSnapshot{balance: &model.Balance{}}
+	var balance, credit, debit, inflight, inflightCredit, inflightDebit string
+
+	err := q.QueryRowContext(ctx, `
+		SELECT balance::TEXT, credit_balance::TEXT, debit_balance::TEXT, inflight_balance::TEXT, inflight_credit_balance::TEXT, inflight_debit_balance::TEXT, snapshot_time, last_transaction_seq
+		FROM blnk.balance_snapshots
+		WHERE balance_id = $1 AND snapshot_time <= $2
+		ORDER BY snapshot_time DESC, id DESC
+		LIMIT 1
+	`, balanceID, at).Scan(&balance, &credit, &debit, &inflight, &inflightCredit, &inflightDebit, &snapshot.snapshotTime, &snapshot.lastTransactionSeq)
+	if err == sql.ErrNoRows {
+		snapshot.balance.InitializeBalanceFields()
+		return snapshot, nil
+	}
+	if err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve balance snapshot", err)
+	}
+
+	values := []*string{&balance, &credit, &debit, &inflight, &inflightCredit, &inflightDebit}
+	parsed := make([]*big.Int, len(values))
+	for i, value := range values {
+		if parsed[i], err = parseBigInt(*value); err != nil {
+			return nil, err
+		}
+	}
+	snapshot.balance.Balance, snapshot.balance.CreditBalance, snapshot.balance.DebitBalance = parsed[0], parsed[1], parsed[2]
+	snapshot.balance.InflightBalance, snapshot.balance.InflightCreditBalance, snapshot.balance.InflightDebitBalance = parsed[3], parsed[4], parsed[5]
+	return snapshot, nil
+}
+
+// applyBalanceDeltas adds the transactions posted after a snapshot, up to the given time, to the snapshot amounts.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - q: The connection or database transaction to read with.
+// - snapshot: The snapshot to start from.
+// - balanceID: The ID of the balance.
+// - until: The upper bound for transaction creation time.
+// - maxSeq: The highest transaction row to consider.
+// Returns:
+// - A balance holding the resulting amounts.
+// - An error if the transactions could not be summed.
+func applyBalanceDeltas(ctx context.Context, q rowQuerier, snapshot *balanceSnapshot, balanceID string, until time.Time, maxSeq int64) (*model.Balance, error) {
+	var credit, debit, inflightCredit, inflightDebit string
+	err := q.QueryRowContext(ctx, balanceDeltaQuery, balanceID, until, snapshot.snapshotTime, snapshot.lastTransactionSeq, maxSeq).
+		Scan(&credit, &debit, &inflightCredit, &inflightDebit)
+	if err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to compute balance history", err)
+	}
+
+	deltas := make([]*big.Int, 4)
+	for i, value := range []string{credit, debit, inflightCredit, inflightDebit} {
+		if deltas[i], err = parseBigInt(value); err != nil {
+			return nil, err
+		}
+	}
+
+	base := snapshot.balance
+	balance := &model.Balance{
+		CreditBalance:         new(big.Int).Add(base.CreditBalance, deltas[0]),
+		DebitBalance:          new(big.Int).Add(base.DebitBalance, deltas[1]),
+		InflightCreditBalance: new(big.Int).Add(base.InflightCreditBalance, deltas[2]),
+		InflightDebitBalance:  new(big.Int).Add(base.InflightDebitBalance, deltas[3]),
+	}
+	balance.Balance = new(big.Int).Sub(balance.CreditBalance, balance.DebitBalance)
+	balance.InflightBalance = new(big.Int).Sub(balance.InflightCreditBalance, balance.InflightDebitBalance)
+	return balance, nil
+}
+
+// parseBigInt parses an integer NUMERIC value returned as text.
+// Parameters:
+// - value: The textual value.
+// Returns:
+// - The parsed value.
+// - An error if the value is not an integer.
+func parseBigInt(value string) (*big.Int, error) {
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, fmt.Sprintf("Invalid balance amount '%s'", value), nil)
+	}
+	return parsed, nil
+}
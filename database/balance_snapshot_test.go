@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBalanceAtTime_FromSnapshot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	asOf := time.Date(2024, 9, 30, 23, 59, 59, 0, time.UTC)
+	snapshotTime := asOf.Add(-time.Hour)
+
+	mock.ExpectQuery("SELECT balance_id, indicator, currency").
+		WithArgs("bln1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version", "overdraft_limit", "status", "account_type", "normal_side"}).
+			AddRow("bln1", nil, "USD", 100, "ldg1", 9000, 10000, 1000, 0, 0, 0, time.Now(), 4, 0, "ACTIVE", nil, nil))
+
+	mock.ExpectQuery("FROM blnk.balance_snapshots").
+		WithArgs("bln1", asOf).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "snapshot_time", "last_transaction_seq"}).
+			AddRow("500", "700", "200", "-50", "0", "50", snapshotTime, 42))
+
+	mock.ExpectQuery("WITH postings AS").
+		WithArgs("bln1", asOf, snapshotTime, int64(42), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"credit", "debit", "inflight_credit", "inflight_debit"}).
+			AddRow("300", "100", "0", "-50"))
+
+	balance, err := ds.GetBalanceAtTime(context.Background(), "bln1", asOf)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), balance.CreditBalance)
+	assert.Equal(t, big.NewInt(300), balance.DebitBalance)
+	assert.Equal(t, big.NewInt(700), balance.Balance)
+	assert.Equal(t, "0", balance.InflightDebitBalance.String())
+	assert.Equal(t, "0", balance.InflightBalance.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBalanceAtTime_WithoutSnapshot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	asOf := time.Date(2024, 9, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT balance_id, indicator, currency").
+		WithArgs("bln1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version", "overdraft_limit", "status", "account_type", "normal_side"}).
+			AddRow("bln1", "@world", "USD", 100, "ldg1", 9000, 10000, 1000, 0, 0, 0, time.Now(), 4, 0, "ACTIVE", nil, nil))
+
+	mock.ExpectQuery("FROM blnk.balance_snapshots").
+		WithArgs("bln1", asOf).
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectQuery("WITH postings AS").
+		WithArgs("bln1", asOf, time.Time{}, int64(0), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"credit", "debit", "inflight_credit", "inflight_debit"}).
+			AddRow("2500", "500", "100", "0"))
+
+	balance, err := ds.GetBalanceAtTime(context.Background(), "bln1", asOf)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(2000), balance.Balance)
+	assert.Equal(t, big.NewInt(100), balance.InflightBalance)
+	assert.Equal(t, "USD", balance.Currency)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTakeBalanceSnapshots_SkipsUnchangedBalances(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	snapshotTime := time.Date(2024, 9, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(id\\), 0\\) FROM blnk.transactions").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(50))
+
+	mock.ExpectQuery("SELECT b.balance_id, EXISTS").
+		WithArgs("", 2, snapshotTime, int64(50)).
+		WillReturnRows(sqlmock.NewRows([]string{"balance_id", "changed"}).
+			AddRow("bln1", false).
+			AddRow("bln2", true))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM blnk.balance_snapshots").
+		WithArgs("bln2", snapshotTime).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("WITH postings AS").
+		WithArgs("bln2", snapshotTime, sqlmock.AnyArg(), int64(0), int64(50)).
+		WillReturnRows(sqlmock.NewRows([]string{"credit", "debit", "inflight_credit", "inflight_debit"}).
+			AddRow("300", "100", "0", "0"))
+	mock.ExpectExec("INSERT INTO blnk.balance_snapshots").
+		WithArgs("bln2", "200", "300", "100", "0", "0", "0", snapshotTime, int64(50)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectQuery("SELECT b.balance_id, EXISTS").
+		WithArgs("bln2", 2, snapshotTime, int64(50)).
+		WillReturnRows(sqlmock.NewRows([]string{"balance_id", "changed"}))
+
+	count, err := ds.TakeBalanceSnapshots(context.Background(), snapshotTime, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+)
+
+// ChangeBalanceStatus moves a balance to a new status and records the change with its reason.
+// The balance row is locked while the change is checked, so a balance cannot be closed while its amounts change.
+// The balance version is bumped, so a transaction validated against the old status fails its optimistic lock.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - balanceID: The ID of the balance.
+// - status: The new status.
+// - reason: Why the status is changed.
+// Returns:
+// - The recorded change.
+// - An error if the balance does not exist, cannot move to the status, or the change could not be recorded.
+func (d Datasource) ChangeBalanceStatus(ctx context.Context, balanceID, status, reason string) (*model.BalanceStatusChange, error) {
+	ctx, span := otel.Tracer("balance.database").Start(ctx, "ChangeBalanceStatus")
+	defer span.End()
+
+	tx, err := d.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to begin transaction", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	balance := model.Balance{BalanceID: balanceID}
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, balance, inflight_credit_balance, inflight_debit_balance
+		FROM blnk.balances
+		WHERE balance_id = $1
+		FOR UPDATE
+	`, balanceID).Scan(&balance.Status, bigIntScanner{&balance.Balance}, bigIntScanner{&balance.InflightCreditBalance}, bigIntScanner{&balance.InflightDebitBalance})
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Balance with ID '%s' not found", balanceID), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve balance status", err)
+	}
+
+	if err := balance.CanChangeStatus(status); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrConflict, err.Error(), err)
+	}
+
+	change := &model.BalanceStatusChange{
+		ChangeID:   model.GenerateUUIDWithSuffix("bsc"),
+		BalanceID:  balanceID,
+		FromStatus: balance.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE blnk.balances SET status = $2, version = version + 1 WHERE balance_id = $1
+	`, balanceID, status)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to update balance status", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO blnk.balance_status_changes (change_id, balance_id, from_status, to_status, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, change.ChangeID, change.BalanceID, change.FromStatus, change.ToStatus, change.Reason, change.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record balance status change", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to commit transaction", err)
+	}
+	return change, nil
+}
+
+// GetBalanceStatusChanges retrieves the status history of a balance, newest first.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - balanceID: The ID of the balance.
+// - limit: The maximum number of changes to return.
+// - offset: The offset to start fetching changes from.
+// Returns:
+// - The changes, or an error if they could not be retrieved.
+func (d Datasource) GetBalanceStatusChanges(ctx context.Context, balanceID string, limit, offset int) ([]model.BalanceStatusChange, error) {
+	ctx, span := otel.Tracer("balance.database").Start(ctx, "GetBalanceStatusChanges")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	rows, err := d.Conn.QueryContext(ctx, `
+		SELECT change_id, balance_id, from_status, to_status, reason, created_at
+		FROM blnk.balance_status_changes
+		WHERE balance_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, balanceID, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve balance status changes", err)
+	}
+	defer rows.Close()
+
+	changes := []model.BalanceStatusChange{}
+	for rows.Next() {
+		var change model.BalanceStatusChange
+		if err := rows.Scan(&change.ChangeID, &change.BalanceID, &change.FromStatus, &change.ToStatus, &change.Reason, &change.CreatedAt); err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan balance status change", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over balance status changes", err)
+	}
+	return changes, nil
+}
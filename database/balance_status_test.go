@@ -0,0 +1,117 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeBalanceStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status, balance, inflight_credit_balance, inflight_debit_balance FROM blnk.balances").
+		WithArgs("bln_1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "balance", "inflight_credit_balance", "inflight_debit_balance"}).
+			AddRow("ACTIVE", "0", "0", "0"))
+	mock.ExpectExec("UPDATE blnk.balances SET status = \\$2, version = version \\+ 1").
+		WithArgs("bln_1", model.BalanceStatusClosed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO blnk.balance_status_changes").
+		WithArgs(sqlmock.AnyArg(), "bln_1", model.BalanceStatusActive, model.BalanceStatusClosed, "customer offboarded", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	change, err := ds.ChangeBalanceStatus(context.Background(), "bln_1", model.BalanceStatusClosed, "customer offboarded")
+	assert.NoError(t, err)
+	if assert.NotNil(t, change) {
+		assert.Equal(t, model.BalanceStatusActive, change.FromStatus)
+		assert.Equal(t, model.BalanceStatusClosed, change.ToStatus)
+		assert.NotEmpty(t, change.ChangeID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangeBalanceStatus_CannotCloseFundedBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status, balance, inflight_credit_balance, inflight_debit_balance FROM blnk.balances").
+		WithArgs("bln_1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "balance", "inflight_credit_balance", "inflight_debit_balance"}).
+			AddRow("FROZEN", "2500", "0", "0"))
+	mock.ExpectRollback()
+
+	_, err = ds.ChangeBalanceStatus(context.Background(), "bln_1", model.BalanceStatusClosed, "customer offboarded")
+	assert.Error(t, err)
+	assert.Equal(t, apierror.ErrConflict, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangeBalanceStatus_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status, balance, inflight_credit_balance, inflight_debit_balance FROM blnk.balances").
+		WithArgs("bln_missing").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "balance", "inflight_credit_balance", "inflight_debit_balance"}))
+	mock.ExpectRollback()
+
+	_, err = ds.ChangeBalanceStatus(context.Background(), "bln_missing", model.BalanceStatusFrozen, "fraud")
+	assert.Error(t, err)
+	assert.Equal(t, apierror.ErrNotFound, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBalanceStatusChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+	mock.ExpectQuery("SELECT change_id, balance_id, from_status, to_status, reason, created_at FROM blnk.balance_status_changes").
+		WithArgs("bln_1", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"change_id", "balance_id", "from_status", "to_status", "reason", "created_at"}).
+			AddRow("bsc_2", "bln_1", "FROZEN", "ACTIVE", "cleared by fraud team", now).
+			AddRow("bsc_1", "bln_1", "ACTIVE", "FROZEN", "card reported stolen", now.Add(-time.Hour)))
+
+	changes, err := ds.GetBalanceStatusChanges(context.Background(), "bln_1", 0, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, "bsc_2", changes[0].ChangeID)
+		assert.Equal(t, model.BalanceStatusFrozen, changes[1].ToStatus)
+		assert.Equal(t, "card reported stolen", changes[1].Reason)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+// currencyColumns lists the columns read by scanCurrency.
+const currencyColumns = `code, name, precision, rounding, created_at`
+
+// scanCurrency scans a row selected with currencyColumns into a custom Currency.
+func scanCurrency(row rowScanner) (*model.Currency, error) {
+	currency := &model.Currency{Custom: true}
+	var createdAt time.Time
+	err := row.Scan(&currency.Code, stringScanner{&currency.Name}, &currency.Precision, &currency.Rounding, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	currency.CreatedAt = &createdAt
+	return currency, nil
+}
+
+// CreateCurrency adds a custom asset to the currency registry.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - currency: The currency to add. Its creation time is set here.
+// Returns:
+// - The added currency, or an error if the code is already registered or it could not be added.
+func (d Datasource) CreateCurrency(ctx context.Context, currency model.Currency) (*model.Currency, error) {
+	ctx, span := otel.Tracer("currency.database").Start(ctx, "CreateCurrency")
+	defer span.End()
+
+	createdAt := time.Now()
+	currency.Custom = true
+	currency.CreatedAt = &createdAt
+	_, err := d.Conn.ExecContext(ctx, `
+		INSERT INTO blnk.currencies (code, name, precision, rounding, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, currency.Code, nullIfEmpty(currency.Name), currency.Precision, currency.Rounding, createdAt)
+	if err != nil {
+		span.RecordError(err)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, apierror.NewAPIError(apierror.ErrConflict, fmt.Sprintf("Currency '%s' is already registered", currency.Code), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to register currency", err)
+	}
+	return &currency, nil
+}
+
+// GetCurrency retrieves a custom asset of the currency registry by its code.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - code: The currency code.
+// Returns:
+// - The currency, or an error if it is not registered or could not be retrieved.
+func (d Datasource) GetCurrency(ctx context.Context, code string) (*model.Currency, error) {
+	ctx, span := otel.Tracer("currency.database").Start(ctx, "GetCurrency")
+	defer span.End()
+
+	currency, err := scanCurrency(d.Conn.QueryRowContext(ctx, `
+		SELECT `+currencyColumns+`
+		FROM blnk.currencies
+		WHERE code = $1
+	`, code))
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Currency '%s' not found", code), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve currency", err)
+	}
+	return currency, nil
+}
+
+// GetCurrencies retrieves the custom assets of the currency registry, ordered by code.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// Returns:
+// - The currencies, or an error if they could not be retrieved.
+func (d Datasource) GetCurrencies(ctx context.Context) ([]model.Currency, error) {
+	ctx, span := otel.Tracer("currency.database").Start(ctx, "GetCurrencies")
+	defer span.End()
+
+	rows, err := d.Conn.QueryContext(ctx, `
+		SELECT `+currencyColumns+`
+		FROM blnk.currencies
+		ORDER BY code
+	`)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve currencies", err)
+	}
+	defer rows.Close()
+
+	currencies := []model.Currency{}
+	for rows.Next() {
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan currency", err)
+		}
+		currencies = append(currencies, *currency)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over currencies", err)
+	}
+	return currencies, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var currencyColumnNames = []string{"code", "name", "precision", "rounding", "created_at"}
+
+func TestCreateCurrency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+
+	mock.ExpectExec("INSERT INTO blnk.currencies").
+		WithArgs("PTS", "Loyalty points", float64(100), model.RoundingDown, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	currency, err := ds.CreateCurrency(context.Background(), model.Currency{Code: "PTS", Name: "Loyalty points", Precision: 100, Rounding: model.RoundingDown})
+	assert.NoError(t, err)
+	assert.True(t, currency.Custom)
+	assert.NotNil(t, currency.CreatedAt)
+
+	mock.ExpectExec("INSERT INTO blnk.currencies").
+		WithArgs("PTS", "Loyalty points", float64(100), model.RoundingDown, sqlmock.AnyArg()).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	_, err = ds.CreateCurrency(context.Background(), model.Currency{Code: "PTS", Name: "Loyalty points", Precision: 100, Rounding: model.RoundingDown})
+	assert.Equal(t, apierror.ErrConflict, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCurrency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM blnk.currencies\\s+WHERE code = \\$1").
+		WithArgs("USDT").
+		WillReturnRows(sqlmock.NewRows(currencyColumnNames).AddRow("USDT", "Tether", 1e6, model.RoundingHalfEven, createdAt))
+
+	currency, err := ds.GetCurrency(context.Background(), "USDT")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1e6), currency.Precision)
+	assert.Equal(t, model.RoundingHalfEven, currency.Rounding)
+	assert.True(t, currency.Custom)
+
+	mock.ExpectQuery("FROM blnk.currencies").
+		WithArgs("XYZ").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = ds.GetCurrency(context.Background(), "XYZ")
+	assert.Equal(t, apierror.ErrNotFound, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCurrencies(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM blnk.currencies\\s+ORDER BY code").
+		WillReturnRows(sqlmock.NewRows(currencyColumnNames).
+			AddRow("PTS", "Loyalty points", 1, model.RoundingNone, createdAt).
+			AddRow("USDT", "Tether", 1e6, model.RoundingHalfEven, createdAt))
+
+	currencies, err := ds.GetCurrencies(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, currencies, 2)
+	assert.Equal(t, "USDT", currencies[1].Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
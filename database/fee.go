@@ -0,0 +1,215 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+)
+
+// feeRuleColumns lists the columns read by scanFeeRule.
+const feeRuleColumns = `rule_id, name, type, percentage, amount, tiers, min_amount, max_amount, fee_balance, ledger_id,
+		currency, meta_data, identity_category, active, created_at`
+
+// scanFeeRule scans a row selected with feeRuleColumns into a FeeRule.
+func scanFeeRule(row rowScanner) (*model.FeeRule, error) {
+	rule := &model.FeeRule{}
+	var tiersJSON, metaDataJSON []byte
+	err := row.Scan(&rule.RuleID, &rule.Name, &rule.Type, &rule.Percentage, &rule.Amount, &tiersJSON, &rule.MinAmount,
+		&rule.MaxAmount, &rule.FeeBalance, stringScanner{&rule.LedgerID}, stringScanner{&rule.Currency}, &metaDataJSON,
+		stringScanner{&rule.IdentityCategory}, &rule.Active, &rule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(tiersJSON) > 0 {
+		if err := json.Unmarshal(tiersJSON, &rule.Tiers); err != nil {
+			return nil, err
+		}
+	}
+	if len(metaDataJSON) > 0 {
+		if err := json.Unmarshal(metaDataJSON, &rule.MetaData); err != nil {
+			return nil, err
+		}
+	}
+	return rule, nil
+}
+
+// feeTiersJSON marshals the tiers of a fee rule, storing NULL when the rule has none.
+func feeTiersJSON(tiers []model.FeeTier) (interface{}, error) {
+	if len(tiers) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(tiers)
+}
+
+// CreateFeeRule records a fee rule. New rules are active.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - rule: The rule to record. Its ID, creation time and status are set here.
+// Returns:
+// - The recorded rule, or an error if it could not be recorded.
+func (d Datasource) CreateFeeRule(ctx context.Context, rule model.FeeRule) (*model.FeeRule, error) {
+	ctx, span := otel.Tracer("fee.database").Start(ctx, "CreateFeeRule")
+	defer span.End()
+
+	tiersJSON, err := feeTiersJSON(rule.Tiers)
+	if err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal fee tiers", err)
+	}
+	metaDataJSON, err := json.Marshal(rule.MetaData)
+	if err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal metadata", err)
+	}
+
+	rule.RuleID = model.GenerateUUIDWithSuffix("fee")
+	rule.Active = true
+	rule.CreatedAt = time.Now()
+	_, err = d.Conn.ExecContext(ctx, `
+		INSERT INTO blnk.fee_rules (rule_id, name, type, percentage, amount, tiers, min_amount, max_amount, fee_balance,
+			ledger_id, currency, meta_data, identity_category, active, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`, rule.RuleID, rule.Name, rule.Type, rule.Percentage, rule.Amount, tiersJSON, rule.MinAmount, rule.MaxAmount, rule.FeeBalance,
+		nullIfEmpty(rule.LedgerID), nullIfEmpty(rule.Currency), metaDataJSON, nullIfEmpty(rule.IdentityCategory), rule.Active, rule.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record fee rule", err)
+	}
+	return &rule, nil
+}
+
+// GetFeeRule retrieves a fee rule by its ID.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - ruleID: The ID of the rule.
+// Returns:
+// - The rule, or an error if it does not exist or could not be retrieved.
+func (d Datasource) GetFeeRule(ctx context.Context, ruleID string) (*model.FeeRule, error) {
+	ctx, span := otel.Tracer("fee.database").Start(ctx, "GetFeeRule")
+	defer span.End()
+
+	rule, err := scanFeeRule(d.Conn.QueryRowContext(ctx, `
+		SELECT `+feeRuleColumns+`
+		FROM blnk.fee_rules
+		WHERE rule_id = $1
+	`, ruleID))
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Fee rule with ID '%s' not found", ruleID), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve fee rule", err)
+	}
+	return rule, nil
+}
+
+// GetFeeRules retrieves fee rules, newest first.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - limit: The maximum number of rules to return.
+// - offset: The offset to start fetching rules from.
+// Returns:
+// - The rules, or an error if they could not be retrieved.
+func (d Datasource) GetFeeRules(ctx context.Context, limit, offset int) ([]model.FeeRule, error) {
+	ctx, span := otel.Tracer("fee.database").Start(ctx, "GetFeeRules")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	return d.queryFeeRules(ctx, `
+		SELECT `+feeRuleColumns+`
+		FROM blnk.fee_rules
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+}
+
+// GetActiveFeeRules retrieves the active fee rules that can apply to a transfer in a currency, which are the
+// rules of that currency and the rules of no particular currency, oldest first.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - currency: The currency of the transfer.
+// Returns:
+// - The rules, or an error if they could not be retrieved.
+func (d Datasource) GetActiveFeeRules(ctx context.Context, currency string) ([]model.FeeRule, error) {
+	ctx, span := otel.Tracer("fee.database").Start(ctx, "GetActiveFeeRules")
+	defer span.End()
+
+	return d.queryFeeRules(ctx, `
+		SELECT `+feeRuleColumns+`
+		FROM blnk.fee_rules
+		WHERE active AND (currency IS NULL OR currency = $1)
+		ORDER BY id
+	`, currency)
+}
+
+// queryFeeRules runs a query selecting feeRuleColumns and scans the fee rules it returns.
+func (d Datasource) queryFeeRules(ctx context.Context, query string, args ...interface{}) ([]model.FeeRule, error) {
+	rows, err := d.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve fee rules", err)
+	}
+	defer rows.Close()
+
+	rules := []model.FeeRule{}
+	for rows.Next() {
+		rule, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan fee rule", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over fee rules", err)
+	}
+	return rules, nil
+}
+
+// UpdateFeeRuleStatus activates or deactivates a fee rule. Deactivated rules are kept so the fees they charged
+// can still be traced back to them.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - ruleID: The ID of the rule.
+// - active: Whether the rule applies to new transfers.
+// Returns:
+// - The updated rule, or an error if it does not exist or could not be updated.
+func (d Datasource) UpdateFeeRuleStatus(ctx context.Context, ruleID string, active bool) (*model.FeeRule, error) {
+	ctx, span := otel.Tracer("fee.database").Start(ctx, "UpdateFeeRuleStatus")
+	defer span.End()
+
+	rule, err := scanFeeRule(d.Conn.QueryRowContext(ctx, `
+		UPDATE blnk.fee_rules
+		SET active = $2
+		WHERE rule_id = $1
+		RETURNING `+feeRuleColumns, ruleID, active))
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Fee rule with ID '%s' not found", ruleID), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to update fee rule", err)
+	}
+	return rule, nil
+}
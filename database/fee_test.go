@@ -0,0 +1,130 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var feeRuleColumnNames = []string{"rule_id", "name", "type", "percentage", "amount", "tiers", "min_amount", "max_amount", "fee_balance",
+	"ledger_id", "currency", "meta_data", "identity_category", "active", "created_at"}
+
+func TestCreateFeeRule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+
+	mock.ExpectExec("INSERT INTO blnk.fee_rules").
+		WithArgs(sqlmock.AnyArg(), "Transfer fee", model.FeeTypeTiered, float64(0), float64(0), []byte(`[{"up_to":100,"amount":1},{"percentage":0.5}]`),
+			float64(0), float64(0), "@Fees", nil, "USD", []byte(`{"plan":"basic"}`), nil, true, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	rule, err := ds.CreateFeeRule(context.Background(), model.FeeRule{Name: "Transfer fee", Type: model.FeeTypeTiered, FeeBalance: "@Fees", Currency: "USD",
+		Tiers: []model.FeeTier{{UpTo: 100, Amount: 1}, {Percentage: 0.5}}, MetaData: map[string]interface{}{"plan": "basic"}})
+	assert.NoError(t, err)
+	assert.Contains(t, rule.RuleID, "fee_")
+	assert.True(t, rule.Active)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFeeRule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM blnk.fee_rules\\s+WHERE rule_id = \\$1").
+		WithArgs("fee_1").
+		WillReturnRows(sqlmock.NewRows(feeRuleColumnNames).
+			AddRow("fee_1", "Transfer fee", model.FeeTypeTiered, 0, 0, []byte(`[{"up_to":100,"amount":1},{"percentage":0.5}]`), 0, 0, "@Fees",
+				"ldg_1", "USD", []byte(`{"plan":"basic"}`), nil, true, createdAt))
+
+	rule, err := ds.GetFeeRule(context.Background(), "fee_1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.FeeTier{{UpTo: 100, Amount: 1}, {Percentage: 0.5}}, rule.Tiers)
+	assert.Equal(t, "basic", rule.MetaData["plan"])
+	assert.Equal(t, "ldg_1", rule.LedgerID)
+	assert.Empty(t, rule.IdentityCategory)
+
+	mock.ExpectQuery("FROM blnk.fee_rules").
+		WithArgs("fee_2").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = ds.GetFeeRule(context.Background(), "fee_2")
+	assert.Equal(t, apierror.ErrNotFound, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetActiveFeeRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM blnk.fee_rules\\s+WHERE active AND \\(currency IS NULL OR currency = \\$1\\)").
+		WithArgs("USD").
+		WillReturnRows(sqlmock.NewRows(feeRuleColumnNames).
+			AddRow("fee_1", "Card fee", model.FeeTypePercentage, 1.5, 0, nil, 0, 0, "@Fees", nil, nil, nil, "merchant", true, createdAt))
+
+	rules, err := ds.GetActiveFeeRules(context.Background(), "USD")
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, 1.5, rules[0].Percentage)
+	assert.Equal(t, "merchant", rules[0].IdentityCategory)
+	assert.Nil(t, rules[0].Tiers)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateFeeRuleStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("UPDATE blnk.fee_rules\\s+SET active = \\$2").
+		WithArgs("fee_1", false).
+		WillReturnRows(sqlmock.NewRows(feeRuleColumnNames).
+			AddRow("fee_1", "Card fee", model.FeeTypePercentage, 1.5, 0, nil, 0, 0, "@Fees", nil, nil, nil, nil, false, createdAt))
+
+	rule, err := ds.UpdateFeeRuleStatus(context.Background(), "fee_1", false)
+	assert.NoError(t, err)
+	assert.False(t, rule.Active)
+
+	mock.ExpectQuery("UPDATE blnk.fee_rules").
+		WithArgs("fee_2", true).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = ds.UpdateFeeRuleStatus(context.Background(), "fee_2", true)
+	assert.Equal(t, apierror.ErrNotFound, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
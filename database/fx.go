@@ -0,0 +1,276 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+)
+
+// fxRateColumns lists the columns read by scanFXRate.
+const fxRateColumns = `rate_id, base_currency, quote_currency, rate, effective_from, source, created_at`
+
+// scanFXRate scans a row selected with fxRateColumns into an FXRate.
+func scanFXRate(row rowScanner) (*model.FXRate, error) {
+	rate := &model.FXRate{}
+	err := row.Scan(&rate.RateID, &rate.BaseCurrency, &rate.QuoteCurrency, &rate.Rate, &rate.EffectiveFrom,
+		stringScanner{&rate.Source}, &rate.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// CreateFXRate records the rate of a currency pair. Rates are never updated in place; a new rate takes over
+// from its effective time, so transactions posted earlier keep converting at the rate in effect back then.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - rate: The rate to record. Its ID and creation time are set here.
+// Returns:
+// - The recorded rate, or an error if it could not be recorded.
+func (d Datasource) CreateFXRate(ctx context.Context, rate model.FXRate) (*model.FXRate, error) {
+	ctx, span := otel.Tracer("fx.database").Start(ctx, "CreateFXRate")
+	defer span.End()
+
+	rate.RateID = model.GenerateUUIDWithSuffix("fxr")
+	rate.CreatedAt = time.Now()
+	_, err := d.Conn.ExecContext(ctx, `
+		INSERT INTO blnk.fx_rates (rate_id, base_currency, quote_currency, rate, effective_from, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, rate.RateID, rate.BaseCurrency, rate.QuoteCurrency, rate.Rate, rate.EffectiveFrom, nullIfEmpty(rate.Source), rate.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record FX rate", err)
+	}
+	return &rate, nil
+}
+
+// GetFXRate retrieves the rate of a currency pair in effect at a time, which is the latest rate that took effect
+// at or before it.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - base: The currency converted from.
+// - quote: The currency converted to.
+// - at: The time the rate must be in effect at.
+// Returns:
+// - The rate, or nil if no rate of the pair was in effect.
+// - An error if the rates could not be retrieved.
+func (d Datasource) GetFXRate(ctx context.Context, base, quote string, at time.Time) (*model.FXRate, error) {
+	ctx, span := otel.Tracer("fx.database").Start(ctx, "GetFXRate")
+	defer span.End()
+
+	rate, err := scanFXRate(d.Conn.QueryRowContext(ctx, `
+		SELECT `+fxRateColumns+`
+		FROM blnk.fx_rates
+		WHERE base_currency = $1 AND quote_currency = $2 AND effective_from <= $3
+		ORDER BY effective_from DESC, id DESC
+		LIMIT 1
+	`, base, quote, at))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve FX rate", err)
+	}
+	return rate, nil
+}
+
+// GetFXRates retrieves recorded FX rates, latest effective first. An empty base or quote currency matches any currency.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - base: The currency converted from, or empty for all.
+// - quote: The currency converted to, or empty for all.
+// - limit: The maximum number of rates to return.
+// - offset: The offset to start fetching rates from.
+// Returns:
+// - The rates, or an error if they could not be retrieved.
+func (d Datasource) GetFXRates(ctx context.Context, base, quote string, limit, offset int) ([]model.FXRate, error) {
+	ctx, span := otel.Tracer("fx.database").Start(ctx, "GetFXRates")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	rows, err := d.Conn.QueryContext(ctx, `
+		SELECT `+fxRateColumns+`
+		FROM blnk.fx_rates
+		WHERE ($1 = '' OR base_currency = $1) AND ($2 = '' OR quote_currency = $2)
+		ORDER BY effective_from DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, base, quote, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve FX rates", err)
+	}
+	defer rows.Close()
+
+	rates := []model.FXRate{}
+	for rows.Next() {
+		rate, err := scanFXRate(rows)
+		if err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan FX rate", err)
+		}
+		rates = append(rates, *rate)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over FX rates", err)
+	}
+	return rates, nil
+}
+
+// fxQuoteColumns lists the columns read by scanFXQuote.
+const fxQuoteColumns = `quote_id, base_currency, quote_currency, rate, market_rate, rate_id, status, expires_at, transaction_id, used_at, created_at`
+
+// scanFXQuote scans a row selected with fxQuoteColumns into an FXQuote.
+func scanFXQuote(row rowScanner) (*model.FXQuote, error) {
+	quote := &model.FXQuote{}
+	var usedAt sql.NullTime
+	err := row.Scan(&quote.QuoteID, &quote.BaseCurrency, &quote.QuoteCurrency, &quote.Rate, &quote.MarketRate, &quote.RateID,
+		&quote.Status, &quote.ExpiresAt, stringScanner{&quote.TransactionID}, &usedAt, &quote.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		quote.UsedAt = &usedAt.Time
+	}
+	return quote, nil
+}
+
+// CreateFXQuote records a quote locking the rate of a currency pair until it expires.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - quote: The quote to record. Its ID, status and creation time are set here.
+// Returns:
+// - The recorded quote, or an error if it could not be recorded.
+func (d Datasource) CreateFXQuote(ctx context.Context, quote model.FXQuote) (*model.FXQuote, error) {
+	ctx, span := otel.Tracer("fx.database").Start(ctx, "CreateFXQuote")
+	defer span.End()
+
+	quote.QuoteID = model.GenerateUUIDWithSuffix("fxq")
+	quote.Status = model.FXQuoteStatusOpen
+	quote.CreatedAt = time.Now()
+	_, err := d.Conn.ExecContext(ctx, `
+		INSERT INTO blnk.fx_quotes (quote_id, base_currency, quote_currency, rate, market_rate, rate_id, status, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, quote.QuoteID, quote.BaseCurrency, quote.QuoteCurrency, quote.Rate, quote.MarketRate, quote.RateID, quote.Status, quote.ExpiresAt, quote.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record FX quote", err)
+	}
+	return &quote, nil
+}
+
+// GetFXQuote retrieves an FX quote by its ID.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - quoteID: The ID of the quote.
+// Returns:
+// - The quote, or an error if it does not exist or could not be retrieved.
+func (d Datasource) GetFXQuote(ctx context.Context, quoteID string) (*model.FXQuote, error) {
+	ctx, span := otel.Tracer("fx.database").Start(ctx, "GetFXQuote")
+	defer span.End()
+
+	quote, err := scanFXQuote(d.Conn.QueryRowContext(ctx, `
+		SELECT `+fxQuoteColumns+`
+		FROM blnk.fx_quotes
+		WHERE quote_id = $1
+	`, quoteID))
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("FX quote with ID '%s' not found", quoteID), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve FX quote", err)
+	}
+	return quote, nil
+}
+
+// UseFXQuote marks an open, unexpired FX quote as used by a transaction. The check and the update are a single
+// statement, so a quote cannot be used twice.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - quoteID: The ID of the quote.
+// - transactionID: The ID of the transaction converted at the quote.
+// Returns:
+// - The used quote.
+// - An error if the quote does not exist, has already been used, has expired, or could not be updated.
+func (d Datasource) UseFXQuote(ctx context.Context, quoteID, transactionID string) (*model.FXQuote, error) {
+	ctx, span := otel.Tracer("fx.database").Start(ctx, "UseFXQuote")
+	defer span.End()
+
+	now := time.Now()
+	quote, err := scanFXQuote(d.Conn.QueryRowContext(ctx, `
+		UPDATE blnk.fx_quotes
+		SET status = $2, transaction_id = $3, used_at = $4
+		WHERE quote_id = $1 AND status = $5 AND expires_at > $4
+		RETURNING `+fxQuoteColumns,
+		quoteID, model.FXQuoteStatusUsed, transactionID, now, model.FXQuoteStatusOpen))
+	if err == nil {
+		return quote, nil
+	}
+	if err != sql.ErrNoRows {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to use FX quote", err)
+	}
+
+	// Nothing was updated, find out why
+	quote, err = d.GetFXQuote(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status == model.FXQuoteStatusUsed {
+		err = fmt.Errorf("FX quote %s has already been used by transaction %s", quoteID, quote.TransactionID)
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrConflict, err.Error(), err)
+	}
+	err = fmt.Errorf("FX quote %s expired at %s", quoteID, quote.ExpiresAt.Format(time.RFC3339))
+	span.RecordError(err)
+	return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+}
+
+// ReleaseFXQuote reopens a quote used by a transaction that was never queued, so it can still be used until it expires.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - quoteID: The ID of the quote.
+// - transactionID: The ID of the transaction that used it.
+// Returns:
+// - An error if the quote could not be updated.
+func (d Datasource) ReleaseFXQuote(ctx context.Context, quoteID, transactionID string) error {
+	ctx, span := otel.Tracer("fx.database").Start(ctx, "ReleaseFXQuote")
+	defer span.End()
+
+	_, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.fx_quotes
+		SET status = $3, transaction_id = NULL, used_at = NULL
+		WHERE quote_id = $1 AND transaction_id = $2
+	`, quoteID, transactionID, model.FXQuoteStatusOpen)
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to release FX quote", err)
+	}
+	return nil
+}
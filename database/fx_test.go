@@ -0,0 +1,170 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var fxRateColumnNames = []string{"rate_id", "base_currency", "quote_currency", "rate", "effective_from", "source", "created_at"}
+
+var fxQuoteColumnNames = []string{"quote_id", "base_currency", "quote_currency", "rate", "market_rate", "rate_id", "status", "expires_at", "transaction_id", "used_at", "created_at"}
+
+func TestCreateFXRate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO blnk.fx_rates").
+		WithArgs(sqlmock.AnyArg(), "USD", "NGN", 1550.5, from, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	rate, err := ds.CreateFXRate(context.Background(), model.FXRate{BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1550.5, EffectiveFrom: from})
+	assert.NoError(t, err)
+	assert.Contains(t, rate.RateID, "fxr_")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFXRate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	at := time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM blnk.fx_rates\\s+WHERE base_currency = \\$1 AND quote_currency = \\$2 AND effective_from <= \\$3").
+		WithArgs("USD", "NGN", at).
+		WillReturnRows(sqlmock.NewRows(fxRateColumnNames).AddRow("fxr_1", "USD", "NGN", 1550.5, from, "cbn", from))
+
+	rate, err := ds.GetFXRate(context.Background(), "USD", "NGN", at)
+	assert.NoError(t, err)
+	assert.Equal(t, 1550.5, rate.Rate)
+	assert.Equal(t, "cbn", rate.Source)
+
+	// No rate of the pair was in effect yet
+	mock.ExpectQuery("FROM blnk.fx_rates").
+		WithArgs("USD", "GHS", at).
+		WillReturnError(sql.ErrNoRows)
+
+	rate, err = ds.GetFXRate(context.Background(), "USD", "GHS", at)
+	assert.NoError(t, err)
+	assert.Nil(t, rate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFXRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM blnk.fx_rates").
+		WithArgs("USD", "", 20, 0).
+		WillReturnRows(sqlmock.NewRows(fxRateColumnNames).
+			AddRow("fxr_2", "USD", "NGN", 1560.0, from.Add(24*time.Hour), nil, from).
+			AddRow("fxr_1", "USD", "EUR", 0.92, from, nil, from))
+
+	rates, err := ds.GetFXRates(context.Background(), "USD", "", 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, rates, 2)
+	assert.Equal(t, "fxr_2", rates[0].RateID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUseFXQuote(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+	expiresAt := now.Add(time.Minute)
+
+	mock.ExpectQuery("UPDATE blnk.fx_quotes\\s+SET status = \\$2, transaction_id = \\$3, used_at = \\$4").
+		WithArgs("fxq_1", model.FXQuoteStatusUsed, "txn_1", sqlmock.AnyArg(), model.FXQuoteStatusOpen).
+		WillReturnRows(sqlmock.NewRows(fxQuoteColumnNames).AddRow("fxq_1", "USD", "NGN", 1534.5, 1550.0, "fxr_1", model.FXQuoteStatusUsed, expiresAt, "txn_1", now, now))
+
+	quote, err := ds.UseFXQuote(context.Background(), "fxq_1", "txn_1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1534.5, quote.Rate)
+	assert.Equal(t, "txn_1", quote.TransactionID)
+	assert.NotNil(t, quote.UsedAt)
+
+	// Already used by another transaction
+	mock.ExpectQuery("UPDATE blnk.fx_quotes").
+		WithArgs("fxq_1", model.FXQuoteStatusUsed, "txn_2", sqlmock.AnyArg(), model.FXQuoteStatusOpen).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM blnk.fx_quotes\\s+WHERE quote_id = \\$1").
+		WithArgs("fxq_1").
+		WillReturnRows(sqlmock.NewRows(fxQuoteColumnNames).AddRow("fxq_1", "USD", "NGN", 1534.5, 1550.0, "fxr_1", model.FXQuoteStatusUsed, expiresAt, "txn_1", now, now))
+
+	_, err = ds.UseFXQuote(context.Background(), "fxq_1", "txn_2")
+	assert.Equal(t, apierror.ErrConflict, err.(apierror.APIError).Code)
+
+	// Expired before it was used
+	mock.ExpectQuery("UPDATE blnk.fx_quotes").
+		WithArgs("fxq_2", model.FXQuoteStatusUsed, "txn_2", sqlmock.AnyArg(), model.FXQuoteStatusOpen).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM blnk.fx_quotes").
+		WithArgs("fxq_2").
+		WillReturnRows(sqlmock.NewRows(fxQuoteColumnNames).AddRow("fxq_2", "USD", "NGN", 1534.5, 1550.0, "fxr_1", model.FXQuoteStatusOpen, now.Add(-time.Minute), nil, nil, now))
+
+	_, err = ds.UseFXQuote(context.Background(), "fxq_2", "txn_2")
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+	assert.Contains(t, err.Error(), "expired")
+
+	// Unknown quote
+	mock.ExpectQuery("UPDATE blnk.fx_quotes").
+		WithArgs("fxq_3", model.FXQuoteStatusUsed, "txn_2", sqlmock.AnyArg(), model.FXQuoteStatusOpen).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM blnk.fx_quotes").
+		WithArgs("fxq_3").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = ds.UseFXQuote(context.Background(), "fxq_3", "txn_2")
+	assert.Equal(t, apierror.ErrNotFound, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReleaseFXQuote(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectExec("UPDATE blnk.fx_quotes\\s+SET status = \\$3, transaction_id = NULL, used_at = NULL").
+		WithArgs("fxq_1", "txn_1", model.FXQuoteStatusOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, ds.ReleaseFXQuote(context.Background(), "fxq_1", "txn_1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+)
+
+// IdempotencyKeyTTL is how long an idempotency key is remembered. After it expires the key can be reused.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyReservationTimeout is how long a key can stay reserved without a recorded response. A reservation older
+// than this belongs to a request whose process died before completing or releasing it, and is treated as released.
+const IdempotencyReservationTimeout = 5 * time.Minute
+
+// ReserveIdempotencyKey claims an idempotency key for a request. If the key is new (or its previous use has expired,
+// or its reservation was abandoned) it is recorded with the request hash and reserved is true. Otherwise the existing
+// key is returned so the caller can compare the request hash and replay the recorded response.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - key: The idempotency key supplied by the client.
+// - requestHash: A hash identifying the request the key is used for.
+// Returns:
+// - The idempotency key as stored.
+// - Whether the key was reserved for this request.
+// - An error if the key could not be reserved or retrieved.
+func (d Datasource) ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (*model.IdempotencyKey, bool, error) {
+	ctx, span := otel.Tracer("idempotency.database").Start(ctx, "ReserveIdempotencyKey")
+	defer span.End()
+
+	now := time.Now()
+	_, err := d.Conn.ExecContext(ctx, `
+		DELETE FROM blnk.idempotency_keys
+		WHERE key = $1 AND (created_at < $2 OR (completed_at IS NULL AND created_at < $3))
+	`, key, now.Add(-IdempotencyKeyTTL), now.Add(-IdempotencyReservationTimeout))
+	if err != nil {
+		span.RecordError(err)
+		return nil, false, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to expire idempotency key", err)
+	}
+
+	createdAt := time.Now()
+	result, err := d.Conn.ExecContext(ctx, `
+		INSERT INTO blnk.idempotency_keys (key, request_hash, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, key, requestHash, createdAt)
+	if err != nil {
+		span.RecordError(err)
+		return nil, false, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to reserve idempotency key", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return nil, false, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+	if rowsAffected == 1 {
+		return &model.IdempotencyKey{Key: key, RequestHash: requestHash, CreatedAt: createdAt}, true, nil
+	}
+
+	existing := &model.IdempotencyKey{}
+	var statusCode sql.NullInt64
+	var completedAt sql.NullTime
+	err = d.Conn.QueryRowContext(ctx, `
+		SELECT key, request_hash, status_code, response_body, created_at, completed_at
+		FROM blnk.idempotency_keys
+		WHERE key = $1
+	`, key).Scan(&existing.Key, &existing.RequestHash, &statusCode, &existing.ResponseBody, &existing.CreatedAt, &completedAt)
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			// The key was released between the insert and the select; let the client retry.
+			return nil, false, apierror.NewAPIError(apierror.ErrConflict, fmt.Sprintf("Idempotency key '%s' is being released", key), err)
+		}
+		return nil, false, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve idempotency key", err)
+	}
+	existing.StatusCode = int(statusCode.Int64)
+	if completedAt.Valid {
+		existing.CompletedAt = &completedAt.Time
+	}
+
+	return existing, false, nil
+}
+
+// CompleteIdempotencyKey records the response returned for a reserved idempotency key so it can be replayed.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - key: The idempotency key.
+// - statusCode: The HTTP status code of the response.
+// - body: The response body.
+// Returns:
+// - An error if the key does not exist or the response could not be recorded.
+func (d Datasource) CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, body []byte) error {
+	ctx, span := otel.Tracer("idempotency.database").Start(ctx, "CompleteIdempotencyKey")
+	defer span.End()
+
+	result, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.idempotency_keys
+		SET status_code = $2, response_body = $3, completed_at = $4
+		WHERE key = $1
+	`, key, statusCode, body, time.Now())
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to complete idempotency key", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Idempotency key '%s' not found", key), nil)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey removes a reserved idempotency key whose request did not complete, so the client can retry it.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - key: The idempotency key.
+// Returns:
+// - An error if the key could not be removed.
+func (d Datasource) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	ctx, span := otel.Tracer("idempotency.database").Start(ctx, "ReleaseIdempotencyKey")
+	defer span.End()
+
+	_, err := d.Conn.ExecContext(ctx, `
+		DELETE FROM blnk.idempotency_keys WHERE key = $1 AND completed_at IS NULL
+	`, key)
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to release idempotency key", err)
+	}
+
+	return nil
+}
+
+// PurgeIdempotencyKeys removes the idempotency keys that have expired and the reservations that were abandoned.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// Returns:
+// - The number of keys removed.
+// - An error if the keys could not be removed.
+func (d Datasource) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, span := otel.Tracer("idempotency.database").Start(ctx, "PurgeIdempotencyKeys")
+	defer span.End()
+
+	now := time.Now()
+	result, err := d.Conn.ExecContext(ctx, `
+		DELETE FROM blnk.idempotency_keys
+		WHERE created_at < $1 OR (completed_at IS NULL AND created_at < $2)
+	`, now.Add(-IdempotencyKeyTTL), now.Add(-IdempotencyReservationTimeout))
+	if err != nil {
+		span.RecordError(err)
+		return 0, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to purge idempotency keys", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return 0, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+
+	return rowsAffected, nil
+}
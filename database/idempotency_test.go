@@ -0,0 +1,125 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveIdempotencyKey_New(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectExec("DELETE FROM blnk.idempotency_keys").
+		WithArgs("key1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO blnk.idempotency_keys").
+		WithArgs("key1", "hash1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	key, reserved, err := ds.ReserveIdempotencyKey(context.Background(), "key1", "hash1")
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, "hash1", key.RequestHash)
+	assert.False(t, key.Completed())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReserveIdempotencyKey_Existing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	completedAt := time.Now()
+	mock.ExpectExec("DELETE FROM blnk.idempotency_keys").
+		WithArgs("key1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO blnk.idempotency_keys").
+		WithArgs("key1", "hash2", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT key, request_hash, status_code, response_body, created_at, completed_at").
+		WithArgs("key1").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "request_hash", "status_code", "response_body", "created_at", "completed_at"}).
+			AddRow("key1", "hash1", 201, []byte(`{"ledger_id":"ldg1"}`), completedAt.Add(-time.Minute), completedAt))
+
+	key, reserved, err := ds.ReserveIdempotencyKey(context.Background(), "key1", "hash2")
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, "hash1", key.RequestHash)
+	assert.Equal(t, 201, key.StatusCode)
+	assert.Equal(t, `{"ledger_id":"ldg1"}`, string(key.ResponseBody))
+	assert.True(t, key.Completed())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteIdempotencyKey_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectExec("UPDATE blnk.idempotency_keys").
+		WithArgs("key1", 201, []byte("{}"), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = ds.CompleteIdempotencyKey(context.Background(), "key1", 201, []byte("{}"))
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReserveIdempotencyKey_ReleasesAbandonedReservation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectExec("completed_at IS NULL AND created_at < \\$3").
+		WithArgs("key1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO blnk.idempotency_keys").
+		WithArgs("key1", "hash1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, reserved, err := ds.ReserveIdempotencyKey(context.Background(), "key1", "hash1")
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeIdempotencyKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectExec("DELETE FROM blnk.idempotency_keys").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	count, err := ds.PurgeIdempotencyKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
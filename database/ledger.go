@@ -17,6 +17,7 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"time"
@@ -42,15 +43,20 @@ func (d Datasource) CreateLedger(ledger model.Ledger) (model.Ledger, error) {
 		return model.Ledger{}, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal metadata", err)
 	}
 
+	transferRulesJSON, err := transferRulesJSON(ledger.TransferRules)
+	if err != nil {
+		return model.Ledger{}, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal transfer rules", err)
+	}
+
 	// Assign a unique ledger ID and record the creation time
 	ledger.LedgerID = model.GenerateUUIDWithSuffix("ldg")
 	ledger.CreatedAt = time.Now()
 
 	// Insert the ledger into the database
 	_, err = d.Conn.Exec(`
-		INSERT INTO blnk.ledgers (meta_data, name, ledger_id)
-		VALUES ($1, $2, $3)
-	`, metaDataJSON, ledger.Name, ledger.LedgerID)
+		INSERT INTO blnk.ledgers (meta_data, name, ledger_id, transfer_rules)
+		VALUES ($1, $2, $3, $4)
+	`, metaDataJSON, ledger.Name, ledger.LedgerID, transferRulesJSON)
 
 	// Handle database errors, specifically unique constraint violations
 	if err != nil {
@@ -86,7 +92,7 @@ func (d Datasource) GetAllLedgers(limit, offset int) ([]model.Ledger, error) {
 
 	// Execute a paginated query to select ledgers from the database
 	query := `
-		SELECT ledger_id, name, created_at, meta_data
+		SELECT ledger_id, name, created_at, meta_data, transfer_rules
 		FROM blnk.ledgers
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -103,8 +109,8 @@ func (d Datasource) GetAllLedgers(limit, offset int) ([]model.Ledger, error) {
 	// Iterate through the query results, scanning each row into a ledger object
 	for rows.Next() {
 		ledger := model.Ledger{}
-		var metaDataJSON []byte
-		err = rows.Scan(&ledger.LedgerID, &ledger.Name, &ledger.CreatedAt, &metaDataJSON)
+		var metaDataJSON, transferRulesJSON []byte
+		err = rows.Scan(&ledger.LedgerID, &ledger.Name, &ledger.CreatedAt, &metaDataJSON, &transferRulesJSON)
 		if err != nil {
 			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan ledger data", err)
 		}
@@ -115,6 +121,12 @@ func (d Datasource) GetAllLedgers(limit, offset int) ([]model.Ledger, error) {
 			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to unmarshal metadata", err)
 		}
 
+		if len(transferRulesJSON) > 0 {
+			if err = json.Unmarshal(transferRulesJSON, &ledger.TransferRules); err != nil {
+				return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to unmarshal transfer rules", err)
+			}
+		}
+
 		ledgers = append(ledgers, ledger)
 	}
 
@@ -140,13 +152,13 @@ func (d Datasource) GetLedgerByID(id string) (*model.Ledger, error) {
 
 	// Query the database to find the ledger by its ID
 	row := d.Conn.QueryRow(`
-		SELECT ledger_id, name, created_at, meta_data
+		SELECT ledger_id, name, created_at, meta_data, transfer_rules
 		FROM blnk.ledgers
 		WHERE ledger_id = $1
 	`, id)
 
-	var metaDataJSON []byte
-	err := row.Scan(&ledger.LedgerID, &ledger.Name, &ledger.CreatedAt, &metaDataJSON)
+	var metaDataJSON, transferRulesJSON []byte
+	err := row.Scan(&ledger.LedgerID, &ledger.Name, &ledger.CreatedAt, &metaDataJSON, &transferRulesJSON)
 	if err != nil {
 		// Handle case where the ledger is not found
 		if err == sql.ErrNoRows {
@@ -161,5 +173,54 @@ func (d Datasource) GetLedgerByID(id string) (*model.Ledger, error) {
 		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to unmarshal metadata", err)
 	}
 
+	if len(transferRulesJSON) > 0 {
+		if err = json.Unmarshal(transferRulesJSON, &ledger.TransferRules); err != nil {
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to unmarshal transfer rules", err)
+		}
+	}
+
 	return &ledger, nil
 }
+
+// UpdateLedgerTransferRules replaces the account type pairs a ledger allows money to move between.
+//
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - id: The unique ID of the ledger to update.
+// - rules: The new transfer rules. An empty list lets any account types transact.
+//
+// Returns:
+// - error: An error if the ledger is not found or if the update fails.
+func (d Datasource) UpdateLedgerTransferRules(ctx context.Context, id string, rules []model.TransferRule) error {
+	rulesJSON, err := transferRulesJSON(rules)
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal transfer rules", err)
+	}
+
+	result, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.ledgers
+		SET transfer_rules = $2
+		WHERE ledger_id = $1
+	`, id, rulesJSON)
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to update transfer rules", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return apierror.NewAPIError(apierror.ErrNotFound, "Ledger not found", nil)
+	}
+
+	return nil
+}
+
+// transferRulesJSON marshals a ledger's transfer rules, storing NULL when the ledger has none.
+func transferRulesJSON(rules []model.TransferRule) (interface{}, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(rules)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"testing"
@@ -48,7 +49,7 @@ func TestCreateLedger_Success(t *testing.T) {
 	assert.NoError(t, err)
 
 	mock.ExpectExec("INSERT INTO blnk.ledgers").
-		WithArgs(metaDataJSON, ledger.Name, sqlmock.AnyArg()).
+		WithArgs(metaDataJSON, ledger.Name, sqlmock.AnyArg(), nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	createdLedger, err := ds.CreateLedger(ledger)
@@ -75,7 +76,7 @@ func TestCreateLedger_UniqueViolation(t *testing.T) {
 	assert.NoError(t, err)
 
 	mock.ExpectExec("INSERT INTO blnk.ledgers").
-		WithArgs(metaDataJSON, ledger.Name, sqlmock.AnyArg()).
+		WithArgs(metaDataJSON, ledger.Name, sqlmock.AnyArg(), nil).
 		WillReturnError(&pq.Error{Code: "23505", Message: "unique_violation"})
 
 	_, err = ds.CreateLedger(ledger)
@@ -98,11 +99,11 @@ func TestGetAllLedgers_Success(t *testing.T) {
 	metaDataJSON, err := json.Marshal(metaData)
 	assert.NoError(t, err)
 
-	rows := sqlmock.NewRows([]string{"ledger_id", "name", "created_at", "meta_data"}).
-		AddRow("ldg1", "Ledger 1", time.Now(), metaDataJSON).
-		AddRow("ldg2", "Ledger 2", time.Now(), metaDataJSON)
+	rows := sqlmock.NewRows([]string{"ledger_id", "name", "created_at", "meta_data", "transfer_rules"}).
+		AddRow("ldg1", "Ledger 1", time.Now(), metaDataJSON, nil).
+		AddRow("ldg2", "Ledger 2", time.Now(), metaDataJSON, nil)
 
-	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data FROM blnk.ledgers ORDER BY created_at DESC LIMIT \\$1 OFFSET \\$2").
+	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data, transfer_rules FROM blnk.ledgers ORDER BY created_at DESC LIMIT \\$1 OFFSET \\$2").
 		WithArgs(2, 0).
 		WillReturnRows(rows)
 	ledgers, err := ds.GetAllLedgers(2, 0)
@@ -124,16 +125,17 @@ func TestGetLedgerByID_Success(t *testing.T) {
 	metaDataJSON, err := json.Marshal(metaData)
 	assert.NoError(t, err)
 
-	row := sqlmock.NewRows([]string{"ledger_id", "name", "created_at", "meta_data"}).
-		AddRow("ldg1", "Ledger 1", time.Now(), metaDataJSON)
+	row := sqlmock.NewRows([]string{"ledger_id", "name", "created_at", "meta_data", "transfer_rules"}).
+		AddRow("ldg1", "Ledger 1", time.Now(), metaDataJSON, []byte(`[{"source_type":"asset","destination_type":"liability"}]`))
 
-	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data FROM blnk.ledgers WHERE ledger_id = ?").
+	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data, transfer_rules FROM blnk.ledgers WHERE ledger_id = ?").
 		WithArgs("ldg1").
 		WillReturnRows(row)
 
 	ledger, err := ds.GetLedgerByID("ldg1")
 	assert.NoError(t, err)
 	assert.Equal(t, "Ledger 1", ledger.Name)
+	assert.Equal(t, []model.TransferRule{{SourceType: model.AccountTypeAsset, DestinationType: model.AccountTypeLiability}}, ledger.TransferRules)
 }
 
 func TestGetLedgerByID_NotFound(t *testing.T) {
@@ -143,7 +145,7 @@ func TestGetLedgerByID_NotFound(t *testing.T) {
 
 	ds := Datasource{Conn: db}
 
-	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data FROM blnk.ledgers WHERE ledger_id = ?").
+	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data, transfer_rules FROM blnk.ledgers WHERE ledger_id = ?").
 		WithArgs("ldg1").
 		WillReturnError(sql.ErrNoRows)
 
@@ -153,3 +155,36 @@ func TestGetLedgerByID_NotFound(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, apierror.ErrNotFound, apiErr.Code)
 }
+
+func TestUpdateLedgerTransferRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	rules := []model.TransferRule{{SourceType: model.AccountTypeLiability, DestinationType: model.AccountTypeLiability}}
+
+	mock.ExpectExec("UPDATE blnk.ledgers").
+		WithArgs("ldg1", []byte(`[{"source_type":"liability","destination_type":"liability"}]`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = ds.UpdateLedgerTransferRules(context.Background(), "ldg1", rules)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateLedgerTransferRules_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+
+	mock.ExpectExec("UPDATE blnk.ledgers").
+		WithArgs("ldg1", nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = ds.UpdateLedgerTransferRules(context.Background(), "ldg1", nil)
+	assert.Error(t, err)
+	assert.Equal(t, apierror.ErrNotFound, err.(apierror.APIError).Code)
+}
@@ -17,6 +17,8 @@ package mocks
 
 import (
 	"context"
+	"math/big"
+	"time"
 
 	"github.com/jerry-enebeli/blnk/model"
 	"github.com/stretchr/testify/mock"
@@ -34,6 +36,11 @@ func (m *MockDataSource) RecordTransaction(ctx context.Context, txn *model.Trans
 	return args.Get(0).(*model.Transaction), args.Error(1)
 }
 
+func (m *MockDataSource) RecordJournalEntry(ctx context.Context, parent *model.Transaction, legs []*model.Transaction, balances []*model.Balance) error {
+	args := m.Called(ctx, parent, legs, balances)
+	return args.Error(0)
+}
+
 func (m *MockDataSource) GetTransaction(ctx context.Context, id string) (*model.Transaction, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*model.Transaction), args.Error(1)
@@ -64,9 +71,24 @@ func (m *MockDataSource) GetAllTransactions(ctx context.Context, limit, offset i
 	return args.Get(0).([]model.Transaction), args.Error(1)
 }
 
-func (m *MockDataSource) GetTotalCommittedTransactions(ctx context.Context, parentID string) (int64, error) {
+func (m *MockDataSource) ListTransactions(ctx context.Context, filter model.TransactionFilter) (*model.TransactionPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(*model.TransactionPage), args.Error(1)
+}
+
+func (m *MockDataSource) GetTotalCommittedTransactions(ctx context.Context, parentID string) (*big.Int, error) {
 	args := m.Called(ctx, parentID)
-	return args.Get(0).(int64), args.Error(1)
+	return args.Get(0).(*big.Int), args.Error(1)
+}
+
+func (m *MockDataSource) ReserveRefund(ctx context.Context, transactionID string, amount *big.Int) (bool, error) {
+	args := m.Called(ctx, transactionID, amount)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDataSource) ReleaseRefund(ctx context.Context, transactionID string, amount *big.Int) error {
+	args := m.Called(ctx, transactionID, amount)
+	return args.Error(0)
 }
 
 func (m *MockDataSource) GetTransactionsPaginated(ctx context.Context, id string, batchSize int, offset int64) ([]*model.Transaction, error) {
@@ -106,6 +128,16 @@ func (m *MockDataSource) GetLedgerByID(id string) (*model.Ledger, error) {
 	return args.Get(0).(*model.Ledger), args.Error(1)
 }
 
+func (m *MockDataSource) UpdateLedgerTransferRules(ctx context.Context, id string, rules []model.TransferRule) error {
+	args := m.Called(ctx, id, rules)
+	return args.Error(0)
+}
+
+func (m *MockDataSource) GetLedgerActivity(ctx context.Context, ledgerID string, from, to time.Time) ([]model.BalanceActivity, error) {
+	args := m.Called(ctx, ledgerID, from, to)
+	return args.Get(0).([]model.BalanceActivity), args.Error(1)
+}
+
 // Balance methods
 
 func (m *MockDataSource) CreateBalance(balance model.Balance) (model.Balance, error) {
@@ -133,11 +165,46 @@ func (m *MockDataSource) UpdateBalance(balance *model.Balance) error {
 	return args.Error(0)
 }
 
+func (m *MockDataSource) UpdateOverdraftLimit(ctx context.Context, balanceID string, limit *big.Int) error {
+	args := m.Called(ctx, balanceID, limit)
+	return args.Error(0)
+}
+
+func (m *MockDataSource) UpdateBalanceAccountType(ctx context.Context, balanceID, accountType, normalSide string) error {
+	args := m.Called(ctx, balanceID, accountType, normalSide)
+	return args.Error(0)
+}
+
+func (m *MockDataSource) ChangeBalanceStatus(ctx context.Context, balanceID, status, reason string) (*model.BalanceStatusChange, error) {
+	args := m.Called(ctx, balanceID, status, reason)
+	return args.Get(0).(*model.BalanceStatusChange), args.Error(1)
+}
+
+func (m *MockDataSource) GetBalanceStatusChanges(ctx context.Context, balanceID string, limit, offset int) ([]model.BalanceStatusChange, error) {
+	args := m.Called(ctx, balanceID, limit, offset)
+	return args.Get(0).([]model.BalanceStatusChange), args.Error(1)
+}
+
 func (m *MockDataSource) GetBalanceByIndicator(indicator, currency string) (*model.Balance, error) {
 	args := m.Called(indicator, currency)
 	return args.Get(0).(*model.Balance), args.Error(1)
 }
 
+func (m *MockDataSource) GetBalanceAtTime(ctx context.Context, balanceID string, asOf time.Time) (*model.Balance, error) {
+	args := m.Called(ctx, balanceID, asOf)
+	return args.Get(0).(*model.Balance), args.Error(1)
+}
+
+func (m *MockDataSource) GetStatementEntries(ctx context.Context, balanceID string, from, to time.Time) ([]model.StatementEntry, error) {
+	args := m.Called(ctx, balanceID, from, to)
+	return args.Get(0).([]model.StatementEntry), args.Error(1)
+}
+
+func (m *MockDataSource) TakeBalanceSnapshots(ctx context.Context, snapshotTime time.Time, batchSize int) (int, error) {
+	args := m.Called(ctx, snapshotTime, batchSize)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockDataSource) UpdateBalances(ctx context.Context, sourceBalance, destinationBalance *model.Balance) error {
 	args := m.Called(ctx, sourceBalance, destinationBalance)
 	return args.Error(0)
@@ -212,6 +279,21 @@ func (m *MockDataSource) DeleteMonitor(id string) error {
 	return args.Error(0)
 }
 
+func (m *MockDataSource) RecordMonitorEvaluation(ctx context.Context, monitor *model.BalanceMonitor, met bool, balance *model.Balance, transactionID string, at time.Time) (*model.MonitorEvent, error) {
+	args := m.Called(ctx, monitor, met, balance, transactionID, at)
+	return args.Get(0).(*model.MonitorEvent), args.Error(1)
+}
+
+func (m *MockDataSource) GetMonitorEvents(ctx context.Context, monitorID string, limit, offset int) ([]model.MonitorEvent, error) {
+	args := m.Called(ctx, monitorID, limit, offset)
+	return args.Get(0).([]model.MonitorEvent), args.Error(1)
+}
+
+func (m *MockDataSource) SetMonitorEventActions(ctx context.Context, eventID string, results []model.MonitorActionResult) error {
+	args := m.Called(ctx, eventID, results)
+	return args.Error(0)
+}
+
 // Identity methods
 
 func (m *MockDataSource) CreateIdentity(identity model.Identity) (model.Identity, error) {
@@ -330,3 +412,202 @@ func (m *MockDataSource) FetchAndGroupExternalTransactions(ctx context.Context,
 	args := m.Called(ctx, uploadID, groupCriteria, batchSize, offset)
 	return args.Get(0).(map[string][]*model.Transaction), args.Error(1)
 }
+
+func (m *MockDataSource) ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (*model.IdempotencyKey, bool, error) {
+	args := m.Called(ctx, key, requestHash)
+	return args.Get(0).(*model.IdempotencyKey), args.Bool(1), args.Error(2)
+}
+
+func (m *MockDataSource) CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, body []byte) error {
+	args := m.Called(ctx, key, statusCode, body)
+	return args.Error(0)
+}
+
+func (m *MockDataSource) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockDataSource) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDataSource) CreateSchedule(ctx context.Context, schedule *model.Schedule) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *MockDataSource) GetScheduleByID(ctx context.Context, id string) (*model.Schedule, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*model.Schedule), args.Error(1)
+}
+
+func (m *MockDataSource) GetAllSchedules(ctx context.Context, limit, offset int) ([]model.Schedule, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]model.Schedule), args.Error(1)
+}
+
+func (m *MockDataSource) GetDueSchedules(ctx context.Context, now time.Time, limit int) ([]model.Schedule, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]model.Schedule), args.Error(1)
+}
+
+func (m *MockDataSource) UpdateScheduleStatus(ctx context.Context, id, status string, nextRunAt time.Time) error {
+	args := m.Called(ctx, id, status, nextRunAt)
+	return args.Error(0)
+}
+
+func (m *MockDataSource) AdvanceSchedule(ctx context.Context, schedule *model.Schedule, previousRunAt time.Time) (bool, error) {
+	args := m.Called(ctx, schedule, previousRunAt)
+	return args.Bool(0), args.Error(1)
+}
+
+// Accounting period methods
+
+func (m *MockDataSource) ClosePeriod(ctx context.Context, period model.AccountingPeriod) (*model.AccountingPeriod, error) {
+	args := m.Called(ctx, period)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AccountingPeriod), args.Error(1)
+}
+
+func (m *MockDataSource) ReopenPeriod(ctx context.Context, periodID, reason string) (*model.AccountingPeriod, error) {
+	args := m.Called(ctx, periodID, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AccountingPeriod), args.Error(1)
+}
+
+func (m *MockDataSource) GetAccountingPeriod(ctx context.Context, periodID string) (*model.AccountingPeriod, error) {
+	args := m.Called(ctx, periodID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AccountingPeriod), args.Error(1)
+}
+
+func (m *MockDataSource) GetAccountingPeriods(ctx context.Context, ledgerID string) ([]model.AccountingPeriod, error) {
+	args := m.Called(ctx, ledgerID)
+	return args.Get(0).([]model.AccountingPeriod), args.Error(1)
+}
+
+func (m *MockDataSource) GetClosedPeriod(ctx context.Context, ledgerID string, at time.Time) (*model.AccountingPeriod, error) {
+	args := m.Called(ctx, ledgerID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AccountingPeriod), args.Error(1)
+}
+
+func (m *MockDataSource) GetPeriodBalances(ctx context.Context, periodID string, limit, offset int) ([]model.PeriodBalance, error) {
+	args := m.Called(ctx, periodID, limit, offset)
+	return args.Get(0).([]model.PeriodBalance), args.Error(1)
+}
+
+func (m *MockDataSource) CreateFXRate(ctx context.Context, rate model.FXRate) (*model.FXRate, error) {
+	args := m.Called(ctx, rate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FXRate), args.Error(1)
+}
+
+func (m *MockDataSource) GetFXRate(ctx context.Context, base, quote string, at time.Time) (*model.FXRate, error) {
+	args := m.Called(ctx, base, quote, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FXRate), args.Error(1)
+}
+
+func (m *MockDataSource) GetFXRates(ctx context.Context, base, quote string, limit, offset int) ([]model.FXRate, error) {
+	args := m.Called(ctx, base, quote, limit, offset)
+	return args.Get(0).([]model.FXRate), args.Error(1)
+}
+
+func (m *MockDataSource) CreateFXQuote(ctx context.Context, quote model.FXQuote) (*model.FXQuote, error) {
+	args := m.Called(ctx, quote)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FXQuote), args.Error(1)
+}
+
+func (m *MockDataSource) GetFXQuote(ctx context.Context, quoteID string) (*model.FXQuote, error) {
+	args := m.Called(ctx, quoteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FXQuote), args.Error(1)
+}
+
+func (m *MockDataSource) UseFXQuote(ctx context.Context, quoteID, transactionID string) (*model.FXQuote, error) {
+	args := m.Called(ctx, quoteID, transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FXQuote), args.Error(1)
+}
+
+func (m *MockDataSource) ReleaseFXQuote(ctx context.Context, quoteID, transactionID string) error {
+	args := m.Called(ctx, quoteID, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockDataSource) CreateCurrency(ctx context.Context, currency model.Currency) (*model.Currency, error) {
+	args := m.Called(ctx, currency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Currency), args.Error(1)
+}
+
+func (m *MockDataSource) GetCurrency(ctx context.Context, code string) (*model.Currency, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Currency), args.Error(1)
+}
+
+func (m *MockDataSource) GetCurrencies(ctx context.Context) ([]model.Currency, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Currency), args.Error(1)
+}
+
+func (m *MockDataSource) CreateFeeRule(ctx context.Context, rule model.FeeRule) (*model.FeeRule, error) {
+	args := m.Called(ctx, rule)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FeeRule), args.Error(1)
+}
+
+func (m *MockDataSource) GetFeeRule(ctx context.Context, ruleID string) (*model.FeeRule, error) {
+	args := m.Called(ctx, ruleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FeeRule), args.Error(1)
+}
+
+func (m *MockDataSource) GetFeeRules(ctx context.Context, limit, offset int) ([]model.FeeRule, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]model.FeeRule), args.Error(1)
+}
+
+func (m *MockDataSource) GetActiveFeeRules(ctx context.Context, currency string) ([]model.FeeRule, error) {
+	args := m.Called(ctx, currency)
+	return args.Get(0).([]model.FeeRule), args.Error(1)
+}
+
+func (m *MockDataSource) UpdateFeeRuleStatus(ctx context.Context, ruleID string, active bool) (*model.FeeRule, error) {
+	args := m.Called(ctx, ruleID, active)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FeeRule), args.Error(1)
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+)
+
+const monitorColumns = `monitor_id, balance_id, field, operator, value, precision, precise_value, description, call_back_url, created_at, conditions, trigger_mode, cooldown_seconds, last_state, last_triggered_at, call_back_secret, max_retries, retry_backoff_seconds, actions`
+
+// scanMonitor scans a row selected with monitorColumns into a BalanceMonitor. The field and operator of the
+// single condition are NULL for monitors with a condition group.
+func scanMonitor(row rowScanner) (*model.BalanceMonitor, error) {
+	monitor := &model.BalanceMonitor{}
+	condition := &monitor.Condition
+	var value, precision sql.NullFloat64
+	var conditionsJSON, actionsJSON []byte
+	var lastTriggeredAt sql.NullTime
+	err := row.Scan(&monitor.MonitorID, &monitor.BalanceID, stringScanner{&condition.Field}, stringScanner{&condition.Operator}, &value, &precision,
+		bigIntScanner{&condition.PreciseValue}, stringScanner{&monitor.Description}, stringScanner{&monitor.CallBackURL}, &monitor.CreatedAt,
+		&conditionsJSON, &monitor.TriggerMode, &monitor.CooldownSeconds, &monitor.LastState, &lastTriggeredAt, stringScanner{&monitor.CallBackSecret},
+		&monitor.MaxRetries, &monitor.RetryBackoffSeconds, &actionsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	condition.Value = value.Float64
+	condition.Precision = precision.Float64
+	monitor.LastTriggeredAt = lastTriggeredAt.Time
+	if len(conditionsJSON) > 0 {
+		if err := json.Unmarshal(conditionsJSON, &monitor.Conditions); err != nil {
+			return nil, err
+		}
+	}
+	if len(actionsJSON) > 0 {
+		if err := json.Unmarshal(actionsJSON, &monitor.Actions); err != nil {
+			return nil, err
+		}
+	}
+	return monitor, nil
+}
+
+// collectMonitors scans every row of a balance monitors query.
+func collectMonitors(rows *sql.Rows) ([]model.BalanceMonitor, error) {
+	var monitors []model.BalanceMonitor
+	for rows.Next() {
+		monitor, err := scanMonitor(rows)
+		if err != nil {
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan monitor data", err)
+		}
+		monitors = append(monitors, *monitor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over monitors", err)
+	}
+	return monitors, nil
+}
+
+// monitorConditionsJSON marshals the condition group of a monitor, or returns nil for a single condition monitor.
+func monitorConditionsJSON(monitor *model.BalanceMonitor) (interface{}, error) {
+	if monitor.Conditions == nil {
+		return nil, nil
+	}
+	return json.Marshal(monitor.Conditions)
+}
+
+// monitorActionsJSON marshals the actions of a monitor, or returns nil for a monitor that only alerts.
+func monitorActionsJSON(monitor *model.BalanceMonitor) (interface{}, error) {
+	if len(monitor.Actions) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(monitor.Actions)
+}
+
+// RecordMonitorEvaluation records whether a monitor's condition is met after a balance update and decides whether
+// the monitor fires. The monitor row is locked while its state is read and written, so concurrent balance updates
+// cannot both fire an edge-triggered monitor or fire within its cooldown. Every firing is added to the monitor's history.
+// The version of the balance last evaluated is stored with the state, and an evaluation of the same or an older version,
+// such as a retried or late check, is ignored so it cannot overwrite the state of a newer one.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - monitor: The monitor that was evaluated. Its state is updated from the database.
+// - met: Whether the monitor's condition is met.
+// - balance: The updated balance the monitor was evaluated against.
+// - transactionID: The transaction that updated the balance.
+// - at: The time of the evaluation.
+// Returns:
+// - The recorded event if the monitor fired, nil otherwise.
+// - An error if the monitor does not exist or its state could not be recorded.
+func (d Datasource) RecordMonitorEvaluation(ctx context.Context, monitor *model.BalanceMonitor, met bool, balance *model.Balance, transactionID string, at time.Time) (*model.MonitorEvent, error) {
+	ctx, span := otel.Tracer("monitor.database").Start(ctx, "RecordMonitorEvaluation")
+	defer span.End()
+
+	tx, err := d.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to begin transaction", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var lastTriggeredAt sql.NullTime
+	var lastBalanceVersion int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT trigger_mode, cooldown_seconds, last_state, last_triggered_at, last_balance_version
+		FROM blnk.balance_monitors
+		WHERE monitor_id = $1
+		FOR UPDATE
+	`, monitor.MonitorID).Scan(&monitor.TriggerMode, &monitor.CooldownSeconds, &monitor.LastState, &lastTriggeredAt, &lastBalanceVersion)
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Monitor with ID '%s' not found", monitor.MonitorID), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve monitor state", err)
+	}
+	monitor.LastTriggeredAt = lastTriggeredAt.Time
+
+	// The monitor has already been evaluated against this or a newer version of the balance
+	if balance.Version <= lastBalanceVersion {
+		return nil, nil
+	}
+
+	fire := monitor.ShouldTrigger(met, monitor.LastState, monitor.LastTriggeredAt, at)
+	monitor.LastState = met
+	if fire {
+		monitor.LastTriggeredAt = at
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE blnk.balance_monitors SET last_state = $2, last_triggered_at = $3, last_balance_version = $4 WHERE monitor_id = $1
+	`, monitor.MonitorID, monitor.LastState, nullIfZeroTime(monitor.LastTriggeredAt), balance.Version)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to update monitor state", err)
+	}
+
+	var event *model.MonitorEvent
+	if fire {
+		event = &model.MonitorEvent{
+			EventID:       model.GenerateUUIDWithSuffix("mev"),
+			MonitorID:     monitor.MonitorID,
+			BalanceID:     balance.BalanceID,
+			TransactionID: transactionID,
+			Balance:       balance,
+			TriggeredAt:   at,
+		}
+		balanceJSON, err := json.Marshal(balance)
+		if err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal balance", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO blnk.monitor_events (event_id, monitor_id, balance_id, transaction_id, balance, triggered_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, event.EventID, event.MonitorID, event.BalanceID, nullIfEmpty(event.TransactionID), balanceJSON, event.TriggeredAt)
+		if err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record monitor event", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to commit transaction", err)
+	}
+	return event, nil
+}
+
+// GetMonitorEvents retrieves the history of a monitor firing, newest first.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - monitorID: The monitor ID.
+// - limit: The maximum number of events to return.
+// - offset: The offset to start fetching events from.
+// Returns:
+// - The events, or an error if they could not be retrieved.
+func (d Datasource) GetMonitorEvents(ctx context.Context, monitorID string, limit, offset int) ([]model.MonitorEvent, error) {
+	ctx, span := otel.Tracer("monitor.database").Start(ctx, "GetMonitorEvents")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	rows, err := d.Conn.QueryContext(ctx, `
+		SELECT event_id, monitor_id, balance_id, transaction_id, balance, triggered_at, actions
+		FROM blnk.monitor_events
+		WHERE monitor_id = $1
+		ORDER BY triggered_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, monitorID, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve monitor events", err)
+	}
+	defer rows.Close()
+
+	events := []model.MonitorEvent{}
+	for rows.Next() {
+		var event model.MonitorEvent
+		var balanceJSON, actionsJSON []byte
+		if err := rows.Scan(&event.EventID, &event.MonitorID, &event.BalanceID, stringScanner{&event.TransactionID}, &balanceJSON, &event.TriggeredAt, &actionsJSON); err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan monitor event", err)
+		}
+		if err := json.Unmarshal(balanceJSON, &event.Balance); err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to unmarshal balance", err)
+		}
+		if len(actionsJSON) > 0 {
+			if err := json.Unmarshal(actionsJSON, &event.Actions); err != nil {
+				span.RecordError(err)
+				return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to unmarshal monitor event actions", err)
+			}
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over monitor events", err)
+	}
+	return events, nil
+}
+
+// SetMonitorEventActions records the outcome of the actions a monitor took when it fired.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - eventID: The monitor event ID.
+// - results: The outcome of each action.
+// Returns:
+// - An error if the event does not exist or the results could not be recorded.
+func (d Datasource) SetMonitorEventActions(ctx context.Context, eventID string, results []model.MonitorActionResult) error {
+	ctx, span := otel.Tracer("monitor.database").Start(ctx, "SetMonitorEventActions")
+	defer span.End()
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal monitor event actions", err)
+	}
+
+	result, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.monitor_events SET actions = $2 WHERE event_id = $1
+	`, eventID, resultsJSON)
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record monitor event actions", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Monitor event with ID '%s' not found", eventID), nil)
+	}
+	return nil
+}
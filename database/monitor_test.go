@@ -0,0 +1,229 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var monitorColumnNames = []string{"monitor_id", "balance_id", "field", "operator", "value", "precision", "precise_value", "description",
+	"call_back_url", "created_at", "conditions", "trigger_mode", "cooldown_seconds", "last_state", "last_triggered_at", "call_back_secret", "max_retries",
+	"retry_backoff_seconds", "actions"}
+
+func TestGetMonitorByID_ConditionGroup(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+	conditions := []byte(`{"logic":"AND","conditions":[{"field":"balance","operator":"<","precise_value":1000},{"field":"debit_balance","operator":">","precise_value":500}]}`)
+	actions := []byte(`[{"type":"top_up","counterparty_balance_id":"bln_funding","target":50,"precision":100,"precise_target":5000}]`)
+	mock.ExpectQuery("SELECT .* FROM blnk.balance_monitors WHERE monitor_id =").
+		WithArgs("mon_1").
+		WillReturnRows(sqlmock.NewRows(monitorColumnNames).
+			AddRow("mon_1", "bln_1", nil, nil, 0, 0, 0, nil, "https://example.com/hooks", now, conditions, "edge", 300, true, now, "whsec", 3, 10, actions))
+
+	monitor, err := ds.GetMonitorByID("mon_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "", monitor.Condition.Field)
+	assert.Equal(t, model.MonitorTriggerEdge, monitor.TriggerMode)
+	assert.Equal(t, int64(300), monitor.CooldownSeconds)
+	assert.True(t, monitor.LastState)
+	assert.Equal(t, "https://example.com/hooks", monitor.CallBackURL)
+	assert.Equal(t, "whsec", monitor.CallBackSecret)
+	assert.Equal(t, 3, monitor.MaxRetries)
+	assert.Equal(t, int64(10), monitor.RetryBackoffSeconds)
+	if assert.NotNil(t, monitor.Conditions) {
+		assert.Equal(t, model.ConditionLogicAnd, monitor.Conditions.Logic)
+		assert.Len(t, monitor.Conditions.Conditions, 2)
+		assert.Equal(t, big.NewInt(1000), monitor.Conditions.Conditions[0].PreciseValue)
+	}
+	if assert.Len(t, monitor.Actions, 1) {
+		assert.Equal(t, model.MonitorActionTopUp, monitor.Actions[0].Type)
+		assert.Equal(t, "bln_funding", monitor.Actions[0].CounterpartyBalanceID)
+		assert.Equal(t, big.NewInt(5000), monitor.Actions[0].PreciseTarget)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordMonitorEvaluation_EdgeFiresOnRisingEdge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+	balance := &model.Balance{BalanceID: "bln_1", Balance: big.NewInt(100), Version: 4}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT trigger_mode, cooldown_seconds, last_state, last_triggered_at, last_balance_version FROM blnk.balance_monitors").
+		WithArgs("mon_1").
+		WillReturnRows(sqlmock.NewRows([]string{"trigger_mode", "cooldown_seconds", "last_state", "last_triggered_at", "last_balance_version"}).
+			AddRow("edge", 0, false, nil, 3))
+	mock.ExpectExec("UPDATE blnk.balance_monitors SET last_state").
+		WithArgs("mon_1", true, now, int64(4)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO blnk.monitor_events").
+		WithArgs(sqlmock.AnyArg(), "mon_1", "bln_1", "txn_1", sqlmock.AnyArg(), now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	monitor := &model.BalanceMonitor{MonitorID: "mon_1"}
+	event, err := ds.RecordMonitorEvaluation(context.Background(), monitor, true, balance, "txn_1", now)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, "mon_1", event.MonitorID)
+		assert.Equal(t, "txn_1", event.TransactionID)
+		assert.Equal(t, now, event.TriggeredAt)
+	}
+	assert.True(t, monitor.LastState)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordMonitorEvaluation_EdgeStaysQuietWhileMet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT trigger_mode, cooldown_seconds, last_state, last_triggered_at, last_balance_version FROM blnk.balance_monitors").
+		WithArgs("mon_1").
+		WillReturnRows(sqlmock.NewRows([]string{"trigger_mode", "cooldown_seconds", "last_state", "last_triggered_at", "last_balance_version"}).
+			AddRow("edge", 0, true, now.Add(-time.Hour), 3))
+	// Only the version of the balance is recorded
+	mock.ExpectExec("UPDATE blnk.balance_monitors SET last_state").
+		WithArgs("mon_1", true, now.Add(-time.Hour), int64(4)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	event, err := ds.RecordMonitorEvaluation(context.Background(), &model.BalanceMonitor{MonitorID: "mon_1"}, true, &model.Balance{BalanceID: "bln_1", Version: 4}, "txn_1", now)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordMonitorEvaluation_CooldownRecordsStateOnly(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+	lastTriggeredAt := now.Add(-30 * time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT trigger_mode, cooldown_seconds, last_state, last_triggered_at, last_balance_version FROM blnk.balance_monitors").
+		WithArgs("mon_1").
+		WillReturnRows(sqlmock.NewRows([]string{"trigger_mode", "cooldown_seconds", "last_state", "last_triggered_at", "last_balance_version"}).
+			AddRow("edge", 60, false, lastTriggeredAt, 3))
+	mock.ExpectExec("UPDATE blnk.balance_monitors SET last_state").
+		WithArgs("mon_1", true, lastTriggeredAt, int64(4)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	event, err := ds.RecordMonitorEvaluation(context.Background(), &model.BalanceMonitor{MonitorID: "mon_1"}, true, &model.Balance{BalanceID: "bln_1", Version: 4}, "txn_1", now)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordMonitorEvaluation_IgnoresOlderBalanceVersions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+
+	// A check of version 5 was processed before this late check of version 4
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT trigger_mode, cooldown_seconds, last_state, last_triggered_at, last_balance_version FROM blnk.balance_monitors").
+		WithArgs("mon_1").
+		WillReturnRows(sqlmock.NewRows([]string{"trigger_mode", "cooldown_seconds", "last_state", "last_triggered_at", "last_balance_version"}).
+			AddRow("edge", 0, false, nil, 5))
+	mock.ExpectRollback()
+
+	monitor := &model.BalanceMonitor{MonitorID: "mon_1"}
+	event, err := ds.RecordMonitorEvaluation(context.Background(), monitor, true, &model.Balance{BalanceID: "bln_1", Version: 4}, "txn_1", now)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMonitorEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+	mock.ExpectQuery("SELECT event_id, monitor_id, balance_id, transaction_id, balance, triggered_at, actions FROM blnk.monitor_events").
+		WithArgs("mon_1", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "monitor_id", "balance_id", "transaction_id", "balance", "triggered_at", "actions"}).
+			AddRow("mev_1", "mon_1", "bln_1", "txn_1", []byte(`{"balance_id":"bln_1","balance":100}`), now,
+				[]byte(`[{"type":"sweep","status":"queued","transaction_id":"txn_2","reference":"mev_1_0","precise_amount":40}]`)).
+			AddRow("mev_0", "mon_1", "bln_1", nil, []byte(`{"balance_id":"bln_1","balance":90}`), now.Add(-time.Hour), nil))
+
+	events, err := ds.GetMonitorEvents(context.Background(), "mon_1", 0, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "mev_1", events[0].EventID)
+		assert.Equal(t, big.NewInt(100), events[0].Balance.Balance)
+		if assert.Len(t, events[0].Actions, 1) {
+			assert.Equal(t, model.MonitorActionQueued, events[0].Actions[0].Status)
+			assert.Equal(t, "txn_2", events[0].Actions[0].TransactionID)
+			assert.Equal(t, big.NewInt(40), events[0].Actions[0].PreciseAmount)
+		}
+		assert.Empty(t, events[1].TransactionID)
+		assert.Nil(t, events[1].Actions)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetMonitorEventActions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	results := []model.MonitorActionResult{{Type: model.MonitorActionFreeze, Status: model.MonitorActionApplied}}
+	mock.ExpectExec("UPDATE blnk.monitor_events SET actions").
+		WithArgs("mev_1", []byte(`[{"type":"freeze","status":"applied"}]`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE blnk.monitor_events SET actions").
+		WithArgs("mev_missing", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, ds.SetMonitorEventActions(context.Background(), "mev_1", results))
+
+	err = ds.SetMonitorEventActions(context.Background(), "mev_missing", results)
+	assert.Error(t, err)
+	assert.Equal(t, apierror.ErrNotFound, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,370 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+)
+
+// periodColumns lists the columns read by scanAccountingPeriod.
+const periodColumns = `period_id, ledger_id, name, start_date, end_date, status, closed_at, reopened_at, reopen_reason`
+
+// scanAccountingPeriod scans a row selected with periodColumns into an AccountingPeriod.
+func scanAccountingPeriod(row rowScanner) (*model.AccountingPeriod, error) {
+	period := &model.AccountingPeriod{}
+	var reopenedAt sql.NullTime
+	err := row.Scan(&period.PeriodID, &period.LedgerID, &period.Name, &period.StartDate, &period.EndDate, &period.Status,
+		&period.ClosedAt, &reopenedAt, stringScanner{&period.ReopenReason})
+	if err != nil {
+		return nil, err
+	}
+	if reopenedAt.Valid {
+		period.ReopenedAt = &reopenedAt.Time
+	}
+	return period, nil
+}
+
+// ClosePeriod closes an accounting period of a ledger and snapshots the balance of each of its balances at the end
+// of the period. The ledger row is locked while the period is checked, so two overlapping periods cannot be closed at once,
+// and the close waits for postings to the ledger being written, which hold the row FOR SHARE.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - period: The period to close. Its ID, status and closing time are set here.
+// Returns:
+// - The closed period.
+// - An error if the ledger does not exist, the period overlaps a closed period of the ledger, or it could not be recorded.
+func (d Datasource) ClosePeriod(ctx context.Context, period model.AccountingPeriod) (*model.AccountingPeriod, error) {
+	ctx, span := otel.Tracer("ledger.database").Start(ctx, "ClosePeriod")
+	defer span.End()
+
+	tx, err := d.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to begin transaction", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var ledgerID string
+	err = tx.QueryRowContext(ctx, `SELECT ledger_id FROM blnk.ledgers WHERE ledger_id = $1 FOR UPDATE`, period.LedgerID).Scan(&ledgerID)
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Ledger with ID '%s' not found", period.LedgerID), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve ledger", err)
+	}
+
+	var overlapping string
+	err = tx.QueryRowContext(ctx, `
+		SELECT period_id
+		FROM blnk.accounting_periods
+		WHERE ledger_id = $1 AND status = $2 AND start_date < $4 AND end_date > $3
+		LIMIT 1
+	`, period.LedgerID, model.PeriodStatusClosed, period.StartDate, period.EndDate).Scan(&overlapping)
+	if err == nil {
+		err = fmt.Errorf("the period overlaps closed period %s", overlapping)
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrConflict, err.Error(), err)
+	}
+	if err != sql.ErrNoRows {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to check closed periods", err)
+	}
+
+	period.PeriodID = model.GenerateUUIDWithSuffix("per")
+	period.Status = model.PeriodStatusClosed
+	period.ClosedAt = time.Now()
+	period.ReopenedAt = nil
+	period.ReopenReason = ""
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO blnk.accounting_periods (period_id, ledger_id, name, start_date, end_date, status, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, period.PeriodID, period.LedgerID, period.Name, period.StartDate, period.EndDate, period.Status, period.ClosedAt)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record accounting period", err)
+	}
+
+	// Everything up to the end of the period falls after the zero time, so credits and debits hold the full totals.
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO blnk.period_balances (period_id, balance_id, currency, currency_multiplier, account_type, normal_side, balance, credit_balance, debit_balance)
+		SELECT $5, a.balance_id, a.currency, a.currency_multiplier, a.account_type, a.normal_side,
+			a.credits::NUMERIC - a.debits::NUMERIC, a.credits::NUMERIC, a.debits::NUMERIC
+		FROM (`+ledgerActivityQuery+`) AS a(balance_id, indicator, currency, currency_multiplier, account_type, normal_side, opening_credits, opening_debits, credits, debits)
+	`, period.LedgerID, time.Time{}, period.EndDate, int64(math.MaxInt64), period.PeriodID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to snapshot period balances", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to commit transaction", err)
+	}
+	return &period, nil
+}
+
+// ReopenPeriod reopens a closed accounting period. The period keeps its snapshot, and the reason and time are recorded on it.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - periodID: The ID of the period.
+// - reason: Why the period is reopened.
+// Returns:
+// - The reopened period.
+// - An error if the period does not exist, is not closed, or could not be updated.
+func (d Datasource) ReopenPeriod(ctx context.Context, periodID, reason string) (*model.AccountingPeriod, error) {
+	ctx, span := otel.Tracer("ledger.database").Start(ctx, "ReopenPeriod")
+	defer span.End()
+
+	period, err := scanAccountingPeriod(d.Conn.QueryRowContext(ctx, `
+		UPDATE blnk.accounting_periods
+		SET status = $2, reopened_at = $3, reopen_reason = $4
+		WHERE period_id = $1 AND status = $5
+		RETURNING `+periodColumns,
+		periodID, model.PeriodStatusReopened, time.Now(), reason, model.PeriodStatusClosed))
+	if err == nil {
+		return period, nil
+	}
+	span.RecordError(err)
+	if err != sql.ErrNoRows {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to reopen accounting period", err)
+	}
+
+	// Nothing was updated, tell a missing period from one that is already open.
+	if _, err := d.GetAccountingPeriod(ctx, periodID); err != nil {
+		return nil, err
+	}
+	err = fmt.Errorf("accounting period %s is not closed", periodID)
+	return nil, apierror.NewAPIError(apierror.ErrConflict, err.Error(), err)
+}
+
+// GetAccountingPeriod retrieves an accounting period by its ID.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - periodID: The ID of the period.
+// Returns:
+// - The period, or an error if it does not exist or could not be retrieved.
+func (d Datasource) GetAccountingPeriod(ctx context.Context, periodID string) (*model.AccountingPeriod, error) {
+	ctx, span := otel.Tracer("ledger.database").Start(ctx, "GetAccountingPeriod")
+	defer span.End()
+
+	period, err := scanAccountingPeriod(d.Conn.QueryRowContext(ctx, `
+		SELECT `+periodColumns+`
+		FROM blnk.accounting_periods
+		WHERE period_id = $1
+	`, periodID))
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Accounting period with ID '%s' not found", periodID), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve accounting period", err)
+	}
+	return period, nil
+}
+
+// GetAccountingPeriods retrieves the accounting periods of a ledger, latest first, including reopened ones.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - ledgerID: The ID of the ledger.
+// Returns:
+// - The periods, or an error if they could not be retrieved.
+func (d Datasource) GetAccountingPeriods(ctx context.Context, ledgerID string) ([]model.AccountingPeriod, error) {
+	ctx, span := otel.Tracer("ledger.database").Start(ctx, "GetAccountingPeriods")
+	defer span.End()
+
+	rows, err := d.Conn.QueryContext(ctx, `
+		SELECT `+periodColumns+`
+		FROM blnk.accounting_periods
+		WHERE ledger_id = $1
+		ORDER BY end_date DESC, id DESC
+	`, ledgerID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve accounting periods", err)
+	}
+	defer rows.Close()
+
+	periods := []model.AccountingPeriod{}
+	for rows.Next() {
+		period, err := scanAccountingPeriod(rows)
+		if err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan accounting period", err)
+		}
+		periods = append(periods, *period)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over accounting periods", err)
+	}
+	return periods, nil
+}
+
+// GetClosedPeriod retrieves the closed accounting period of a ledger that contains a posting date.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - ledgerID: The ID of the ledger.
+// - at: The posting date.
+// Returns:
+// - The closed period, or nil if the date does not fall in one.
+// - An error if the periods could not be retrieved.
+func (d Datasource) GetClosedPeriod(ctx context.Context, ledgerID string, at time.Time) (*model.AccountingPeriod, error) {
+	ctx, span := otel.Tracer("ledger.database").Start(ctx, "GetClosedPeriod")
+	defer span.End()
+
+	period, err := getClosedPeriod(ctx, d.Conn, ledgerID, at)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return period, nil
+}
+
+// getClosedPeriod retrieves the closed accounting period of a ledger that contains a posting date, using the
+// provided connection or database transaction.
+func getClosedPeriod(ctx context.Context, q rowQuerier, ledgerID string, at time.Time) (*model.AccountingPeriod, error) {
+	period, err := scanAccountingPeriod(q.QueryRowContext(ctx, `
+		SELECT `+periodColumns+`
+		FROM blnk.accounting_periods
+		WHERE ledger_id = $1 AND status = $2 AND start_date < $3 AND end_date >= $3
+		ORDER BY end_date DESC
+		LIMIT 1
+	`, ledgerID, model.PeriodStatusClosed, at))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve closed accounting period", err)
+	}
+	return period, nil
+}
+
+// checkPeriodsOpen returns an error if a posting date falls in a closed accounting period of any of the ledgers.
+// Each ledger row is locked FOR SHARE until the database transaction ends, and ClosePeriod locks it FOR UPDATE,
+// so a period cannot be closed between this check and the commit of the postings it clears.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - tx: The database transaction writing the postings.
+// - ledgerIDs: The ledgers of the balances posted to.
+// - dates: The posting dates.
+// Returns:
+// - An error wrapping model.ErrPeriodClosed if a ledger closed the period, or if the ledgers could not be checked.
+func checkPeriodsOpen(ctx context.Context, tx *sql.Tx, ledgerIDs []string, dates []time.Time) error {
+	// Lock the ledgers in a fixed order
+	ledgerIDs = append([]string(nil), ledgerIDs...)
+	sort.Strings(ledgerIDs)
+	for i, ledgerID := range ledgerIDs {
+		if ledgerID == "" || (i > 0 && ledgerID == ledgerIDs[i-1]) {
+			continue
+		}
+		var locked string
+		err := tx.QueryRowContext(ctx, `SELECT ledger_id FROM blnk.ledgers WHERE ledger_id = $1 FOR SHARE`, ledgerID).Scan(&locked)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to lock ledger", err)
+		}
+
+		for _, at := range dates {
+			period, err := getClosedPeriod(ctx, tx, ledgerID, at)
+			if err != nil {
+				return err
+			}
+			if period != nil {
+				return fmt.Errorf("%w: ledger %s closed the period %s (%s)", model.ErrPeriodClosed, ledgerID, period.PeriodID, period.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// GetPeriodBalances retrieves the balances snapshotted when an accounting period was closed.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - periodID: The ID of the period.
+// - limit: The maximum number of balances to return.
+// - offset: The offset to start fetching balances from.
+// Returns:
+// - The balances ordered by balance ID, or an error if they could not be retrieved.
+func (d Datasource) GetPeriodBalances(ctx context.Context, periodID string, limit, offset int) ([]model.PeriodBalance, error) {
+	ctx, span := otel.Tracer("ledger.database").Start(ctx, "GetPeriodBalances")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	rows, err := d.Conn.QueryContext(ctx, `
+		SELECT period_id, balance_id, currency, currency_multiplier, account_type, normal_side,
+			balance::TEXT, credit_balance::TEXT, debit_balance::TEXT
+		FROM blnk.period_balances
+		WHERE period_id = $1
+		ORDER BY balance_id
+		LIMIT $2 OFFSET $3
+	`, periodID, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve period balances", err)
+	}
+	defer rows.Close()
+
+	balances := []model.PeriodBalance{}
+	for rows.Next() {
+		var b model.PeriodBalance
+		var balance, credits, debits string
+		if err := rows.Scan(&b.PeriodID, &b.BalanceID, &b.Currency, &b.Precision, stringScanner{&b.AccountType}, stringScanner{&b.NormalSide},
+			&balance, &credits, &debits); err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan period balance", err)
+		}
+		for _, amount := range []struct {
+			value  string
+			target **big.Int
+		}{
+			{balance, &b.Balance},
+			{credits, &b.CreditBalance},
+			{debits, &b.DebitBalance},
+		} {
+			parsed, err := parseBigInt(amount.value)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+			*amount.target = parsed
+		}
+		balances = append(balances, b)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over period balances", err)
+	}
+	return balances, nil
+}
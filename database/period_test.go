@@ -0,0 +1,167 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var accountingPeriodColumns = []string{"period_id", "ledger_id", "name", "start_date", "end_date", "status", "closed_at", "reopened_at", "reopen_reason"}
+
+func TestClosePeriod(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	start := time.Date(2026, 8, 31, 23, 59, 59, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ledger_id FROM blnk.ledgers WHERE ledger_id = \\$1 FOR UPDATE").
+		WithArgs("ldg_1").
+		WillReturnRows(sqlmock.NewRows([]string{"ledger_id"}).AddRow("ldg_1"))
+	mock.ExpectQuery("SELECT period_id\\s+FROM blnk.accounting_periods").
+		WithArgs("ldg_1", model.PeriodStatusClosed, start, end).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO blnk.accounting_periods").
+		WithArgs(sqlmock.AnyArg(), "ldg_1", "September 2026", start, end, model.PeriodStatusClosed, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO blnk.period_balances").
+		WithArgs("ldg_1", time.Time{}, end, int64(math.MaxInt64), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	period, err := ds.ClosePeriod(context.Background(), model.AccountingPeriod{LedgerID: "ldg_1", Name: "September 2026", StartDate: start, EndDate: end})
+	assert.NoError(t, err)
+	assert.Contains(t, period.PeriodID, "per_")
+	assert.Equal(t, model.PeriodStatusClosed, period.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClosePeriod_Overlapping(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ledger_id FROM blnk.ledgers").
+		WithArgs("ldg_1").
+		WillReturnRows(sqlmock.NewRows([]string{"ledger_id"}).AddRow("ldg_1"))
+	mock.ExpectQuery("SELECT period_id\\s+FROM blnk.accounting_periods").
+		WithArgs("ldg_1", model.PeriodStatusClosed, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"period_id"}).AddRow("per_september"))
+	mock.ExpectRollback()
+
+	end := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	_, err = ds.ClosePeriod(context.Background(), model.AccountingPeriod{LedgerID: "ldg_1", StartDate: end.AddDate(0, -1, 0), EndDate: end})
+	assert.Error(t, err)
+	assert.Equal(t, apierror.ErrConflict, err.(apierror.APIError).Code)
+	assert.Contains(t, err.Error(), "per_september")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReopenPeriod(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	start := time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	reopenedAt := time.Now()
+
+	mock.ExpectQuery("UPDATE blnk.accounting_periods").
+		WithArgs("per_1", model.PeriodStatusReopened, sqlmock.AnyArg(), "late invoice", model.PeriodStatusClosed).
+		WillReturnRows(sqlmock.NewRows(accountingPeriodColumns).
+			AddRow("per_1", "ldg_1", "September 2026", start, end, model.PeriodStatusReopened, end, reopenedAt, "late invoice"))
+
+	period, err := ds.ReopenPeriod(context.Background(), "per_1", "late invoice")
+	assert.NoError(t, err)
+	assert.Equal(t, model.PeriodStatusReopened, period.Status)
+	assert.Equal(t, "late invoice", period.ReopenReason)
+	assert.NotNil(t, period.ReopenedAt)
+
+	// A period that is already open cannot be reopened again
+	mock.ExpectQuery("UPDATE blnk.accounting_periods").
+		WithArgs("per_1", model.PeriodStatusReopened, sqlmock.AnyArg(), "again", model.PeriodStatusClosed).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM blnk.accounting_periods\\s+WHERE period_id = \\$1").
+		WithArgs("per_1").
+		WillReturnRows(sqlmock.NewRows(accountingPeriodColumns).
+			AddRow("per_1", "ldg_1", "September 2026", start, end, model.PeriodStatusReopened, end, reopenedAt, "late invoice"))
+
+	_, err = ds.ReopenPeriod(context.Background(), "per_1", "again")
+	assert.Error(t, err)
+	assert.Equal(t, apierror.ErrConflict, err.(apierror.APIError).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetClosedPeriod(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	at := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM blnk.accounting_periods").
+		WithArgs("ldg_1", model.PeriodStatusClosed, at).
+		WillReturnError(sql.ErrNoRows)
+	period, err := ds.GetClosedPeriod(context.Background(), "ldg_1", at)
+	assert.NoError(t, err)
+	assert.Nil(t, period)
+
+	mock.ExpectQuery("FROM blnk.accounting_periods").
+		WithArgs("ldg_1", model.PeriodStatusClosed, at).
+		WillReturnRows(sqlmock.NewRows(accountingPeriodColumns).
+			AddRow("per_1", "ldg_1", "September 2026", at.AddDate(0, 0, -15), at.AddDate(0, 0, 15), model.PeriodStatusClosed, at.AddDate(0, 1, 0), nil, nil))
+	period, err = ds.GetClosedPeriod(context.Background(), "ldg_1", at)
+	assert.NoError(t, err)
+	assert.Equal(t, "per_1", period.PeriodID)
+	assert.Nil(t, period.ReopenedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPeriodBalances(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectQuery("FROM blnk.period_balances").
+		WithArgs("per_1", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"period_id", "balance_id", "currency", "currency_multiplier", "account_type", "normal_side", "balance", "credit_balance", "debit_balance"}).
+			AddRow("per_1", "bln_cash", "USD", 100, "asset", "debit", "-100200", "300", "100500"))
+
+	balances, err := ds.GetPeriodBalances(context.Background(), "per_1", 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, balances, 1)
+	assert.Equal(t, "-100200", balances[0].Balance.String())
+	assert.Equal(t, "100500", balances[0].DebitBalance.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxReportBalances bounds the number of balances on a single ledger report.
+const maxReportBalances = 10000
+
+// ledgerActivityQuery sums the applied postings to every balance of a ledger up to the start of a period ($2)
+// and during it, up to its end ($3). Amounts are computed the same way as in statementEntriesQuery.
+const ledgerActivityQuery = `
+	WITH ledger_balances AS (
+		SELECT balance_id, indicator, currency, currency_multiplier, account_type, normal_side
+		FROM blnk.balances
+		WHERE ledger_id = $1
+		ORDER BY balance_id
+		LIMIT $4
+	),
+	postings AS (
+		SELECT t.destination AS balance_id, t.effective_date,
+			CASE WHEN EXISTS (
+				SELECT 1 FROM blnk.transactions p
+				WHERE p.transaction_id = t.parent_transaction AND p.status = 'INFLIGHT' AND p.atomic = false
+			) THEN COALESCE(t.precise_amount, 0)
+			ELSE TRUNC(COALESCE(t.precise_amount, 0) * COALESCE(NULLIF(t.rate, 0), 1)) END AS credit,
+			0 AS debit
+		FROM blnk.transactions t
+		JOIN ledger_balances b ON b.balance_id = t.destination
+		WHERE t.atomic = false AND t.status = 'APPLIED' AND t.effective_date <= $3
+		UNION ALL
+		SELECT t.source, t.effective_date, 0, COALESCE(t.precise_amount, 0)
+		FROM blnk.transactions t
+		JOIN ledger_balances b ON b.balance_id = t.source
+		WHERE t.atomic = false AND t.status = 'APPLIED' AND t.effective_date <= $3
+	)
+	SELECT b.balance_id, b.indicator, b.currency, b.currency_multiplier, b.account_type, b.normal_side,
+		COALESCE(SUM(p.credit) FILTER (WHERE p.effective_date <= $2), 0)::TEXT,
+		COALESCE(SUM(p.debit) FILTER (WHERE p.effective_date <= $2), 0)::TEXT,
+		COALESCE(SUM(p.credit) FILTER (WHERE p.effective_date > $2), 0)::TEXT,
+		COALESCE(SUM(p.debit) FILTER (WHERE p.effective_date > $2), 0)::TEXT
+	FROM ledger_balances b
+	LEFT JOIN postings p ON p.balance_id = b.balance_id
+	GROUP BY b.balance_id, b.indicator, b.currency, b.currency_multiplier, b.account_type, b.normal_side
+	ORDER BY b.balance_id
+`
+
+// GetLedgerActivity retrieves what was posted to every balance of a ledger up to from and between from and to.
+// Only applied postings count, so inflight amounts are left out until they are committed.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - ledgerID: The ID of the ledger.
+// - from: The start of the period, exclusive.
+// - to: The end of the period, inclusive.
+// Returns:
+// - The activity of each balance of the ledger, ordered by balance ID.
+// - An error if the ledger holds more than maxReportBalances balances or the query fails.
+func (d Datasource) GetLedgerActivity(ctx context.Context, ledgerID string, from, to time.Time) ([]model.BalanceActivity, error) {
+	ctx, span := otel.Tracer("ledger.database").Start(ctx, "GetLedgerActivity")
+	defer span.End()
+
+	rows, err := d.Conn.QueryContext(ctx, ledgerActivityQuery, ledgerID, from, to, maxReportBalances+1)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve ledger activity", err)
+	}
+	defer rows.Close()
+
+	activity := []model.BalanceActivity{}
+	for rows.Next() {
+		var a model.BalanceActivity
+		var openingCredits, openingDebits, credits, debits string
+		if err := rows.Scan(&a.BalanceID, stringScanner{&a.Indicator}, &a.Currency, &a.Precision, stringScanner{&a.AccountType}, stringScanner{&a.NormalSide},
+			&openingCredits, &openingDebits, &credits, &debits); err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan ledger activity", err)
+		}
+
+		if len(activity) == maxReportBalances {
+			err := fmt.Errorf("the ledger holds more than %d balances", maxReportBalances)
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+		}
+
+		for _, amount := range []struct {
+			value  string
+			target **big.Int
+		}{
+			{openingCredits, &a.OpeningCredits},
+			{openingDebits, &a.OpeningDebits},
+			{credits, &a.Credits},
+			{debits, &a.Debits},
+		} {
+			parsed, err := parseBigInt(amount.value)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+			*amount.target = parsed
+		}
+		activity = append(activity, a)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over ledger activity", err)
+	}
+
+	span.AddEvent("Ledger activity retrieved", trace.WithAttributes(
+		attribute.String("ledger.id", ledgerID),
+		attribute.Int("report.balances", len(activity)),
+	))
+	return activity, nil
+}
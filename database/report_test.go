@@ -0,0 +1,75 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/stretchr/testify/assert"
+)
+
+var ledgerActivityColumns = []string{"balance_id", "indicator", "currency", "currency_multiplier", "account_type", "normal_side",
+	"opening_credits", "opening_debits", "credits", "debits"}
+
+func TestGetLedgerActivity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	mock.ExpectQuery("WITH ledger_balances AS").
+		WithArgs("ldg_1", from, to, maxReportBalances+1).
+		WillReturnRows(sqlmock.NewRows(ledgerActivityColumns).
+			AddRow("bln_cash", nil, "USD", 100, "asset", "debit", "0", "100000", "300", "500").
+			AddRow("bln_world", "@world", "USD", 100, nil, nil, "100000", "0", "0", "0"))
+
+	activity, err := ds.GetLedgerActivity(context.Background(), "ldg_1", from, to)
+	assert.NoError(t, err)
+	assert.Len(t, activity, 2)
+	assert.Equal(t, "asset", activity[0].AccountType)
+	assert.Equal(t, "-100000", activity[0].OpeningBalance().String())
+	assert.Equal(t, "-100200", activity[0].ClosingBalance().String())
+	assert.Equal(t, "@world", activity[1].Indicator)
+	assert.Equal(t, "", activity[1].AccountType)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLedgerActivity_TooManyBalances(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	rows := sqlmock.NewRows(ledgerActivityColumns)
+	for i := 0; i <= maxReportBalances; i++ {
+		rows.AddRow("bln", nil, "USD", 100, nil, nil, "0", "0", "0", "0")
+	}
+	mock.ExpectQuery("WITH ledger_balances AS").
+		WithArgs("ldg_1", sqlmock.AnyArg(), sqlmock.AnyArg(), maxReportBalances+1).
+		WillReturnRows(rows)
+
+	_, err = ds.GetLedgerActivity(context.Background(), "ldg_1", time.Time{}, time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+}
@@ -18,6 +18,8 @@ package database
 
 import (
 	"context"
+	"math/big"
+	"time"
 
 	"github.com/jerry-enebeli/blnk/model"
 )
@@ -31,6 +33,12 @@ type IDataSource interface {
 	balanceMonitor // Interface for balance monitoring operations
 	account        // Interface for account-related operations
 	reconciliation // Interface for reconciliation-related operations
+	idempotency    // Interface for idempotency key operations
+	schedule       // Interface for recurring transaction schedules
+	period         // Interface for accounting period operations
+	fx             // Interface for FX rate operations
+	currency       // Interface for currency registry operations
+	fee            // Interface for fee rule operations
 }
 
 // transaction defines methods for handling transactions.
@@ -42,30 +50,43 @@ type transaction interface {
 	TransactionExistsByRef(ctx context.Context, reference string) (bool, error)                                                                     // Checks if a transaction exists by reference
 	UpdateTransactionStatus(cxt context.Context, id string, status string) error                                                                    // Updates the status of a transaction
 	GetAllTransactions(cxt context.Context, limit, offset int) ([]model.Transaction, error)                                                         // Retrieves all transactions
-	GetTotalCommittedTransactions(cxt context.Context, parentID string) (int64, error)                                                              // Gets the total count of committed transactions for a parent
+	ListTransactions(ctx context.Context, filter model.TransactionFilter) (*model.TransactionPage, error)                                           // Retrieves a page of transactions matching a filter
+	GetTotalCommittedTransactions(cxt context.Context, parentID string) (*big.Int, error)                                                           // Gets the total count of committed transactions for a parent
+	ReserveRefund(ctx context.Context, transactionID string, amount *big.Int) (bool, error)                                                         // Adds a refund to the refunded total of a transaction if it stays within its amount
+	ReleaseRefund(ctx context.Context, transactionID string, amount *big.Int) error                                                                 // Removes a refund that was never applied from the refunded total of a transaction
 	GetTransactionsPaginated(ctx context.Context, id string, batchSize int, offset int64) ([]*model.Transaction, error)                             // Retrieves transactions in a paginated manner
 	GetInflightTransactionsByParentID(ctx context.Context, parentTransactionID string, batchSize int, offset int64) ([]*model.Transaction, error)   // Retrieves inflight transactions by parent ID
 	GetRefundableTransactionsByParentID(ctx context.Context, parentTransactionID string, batchSize int, offset int64) ([]*model.Transaction, error) // Retrieves refundable transactions by parent ID
 	GroupTransactions(ctx context.Context, groupCriteria string, batchSize int, offset int64) (map[string][]*model.Transaction, error)              // Groups transactions based on specified criteria
+	RecordJournalEntry(ctx context.Context, parent *model.Transaction, legs []*model.Transaction, balances []*model.Balance) error                  // Records an atomic journal entry
 }
 
 // ledger defines methods for handling ledgers.
 type ledger interface {
 	CreateLedger(ledger model.Ledger) (model.Ledger, error) // Creates a new ledger
 	GetAllLedgers(limit, offset int) ([]model.Ledger, error)
-	GetLedgerByID(id string) (*model.Ledger, error) // Retrieves a ledger by ID
+	GetLedgerByID(id string) (*model.Ledger, error)                                                              // Retrieves a ledger by ID
+	UpdateLedgerTransferRules(ctx context.Context, id string, rules []model.TransferRule) error                  // Replaces the account types a ledger lets transact
+	GetLedgerActivity(ctx context.Context, ledgerID string, from, to time.Time) ([]model.BalanceActivity, error) // Sums the postings to every balance of a ledger over a period
 }
 
 // balance defines methods for handling balances.
 type balance interface {
-	CreateBalance(balance model.Balance) (model.Balance, error)                                 // Creates a new balance
-	GetBalanceByID(id string, include []string) (*model.Balance, error)                         // Retrieves a balance by ID with additional data
-	GetBalanceByIDLite(id string) (*model.Balance, error)                                       // Retrieves a balance by ID with minimal data
-	GetAllBalances(limit, offset int) ([]model.Balance, error)                                  // Retrieves all balances
-	UpdateBalance(balance *model.Balance) error                                                 // Updates a balance
-	GetBalanceByIndicator(indicator, currency string) (*model.Balance, error)                   // Retrieves a balance by indicator and currency
-	UpdateBalances(ctx context.Context, sourceBalance, destinationBalance *model.Balance) error // Updates multiple balances
-	GetSourceDestination(sourceId, destinationId string) ([]*model.Balance, error)              // Retrieves balances between source and destination
+	CreateBalance(balance model.Balance) (model.Balance, error)                                                            // Creates a new balance
+	GetBalanceByID(id string, include []string) (*model.Balance, error)                                                    // Retrieves a balance by ID with additional data
+	GetBalanceByIDLite(id string) (*model.Balance, error)                                                                  // Retrieves a balance by ID with minimal data
+	GetAllBalances(limit, offset int) ([]model.Balance, error)                                                             // Retrieves all balances
+	UpdateBalance(balance *model.Balance) error                                                                            // Updates a balance
+	UpdateOverdraftLimit(ctx context.Context, balanceID string, limit *big.Int) error                                      // Sets how far below zero a balance may go
+	UpdateBalanceAccountType(ctx context.Context, balanceID, accountType, normalSide string) error                         // Places a balance in the chart of accounts
+	ChangeBalanceStatus(ctx context.Context, balanceID, status, reason string) (*model.BalanceStatusChange, error)         // Moves a balance to a new status and records why
+	GetBalanceStatusChanges(ctx context.Context, balanceID string, limit, offset int) ([]model.BalanceStatusChange, error) // Retrieves the status history of a balance
+	GetBalanceByIndicator(indicator, currency string) (*model.Balance, error)                                              // Retrieves a balance by indicator and currency
+	UpdateBalances(ctx context.Context, sourceBalance, destinationBalance *model.Balance) error                            // Updates multiple balances
+	GetBalanceAtTime(ctx context.Context, balanceID string, asOf time.Time) (*model.Balance, error)                        // Retrieves a balance as it stood at a point in time
+	GetStatementEntries(ctx context.Context, balanceID string, from, to time.Time) ([]model.StatementEntry, error)         // Retrieves the postings to a balance over a period
+	TakeBalanceSnapshots(ctx context.Context, snapshotTime time.Time, batchSize int) (int, error)                          // Records a snapshot of every balance
+	GetSourceDestination(sourceId, destinationId string) ([]*model.Balance, error)                                         // Retrieves balances between source and destination
 }
 
 // account defines methods for handling accounts.
@@ -80,12 +101,15 @@ type account interface {
 
 // balanceMonitor defines methods for monitoring balances.
 type balanceMonitor interface {
-	CreateMonitor(monitor model.BalanceMonitor) (model.BalanceMonitor, error) // Creates a new balance monitor
-	GetMonitorByID(id string) (*model.BalanceMonitor, error)                  // Retrieves a balance monitor by ID
-	GetAllMonitors() ([]model.BalanceMonitor, error)                          // Retrieves all balance monitors
-	GetBalanceMonitors(balanceID string) ([]model.BalanceMonitor, error)      // Retrieves monitors for a specific balance
-	UpdateMonitor(monitor *model.BalanceMonitor) error                        // Updates a balance monitor
-	DeleteMonitor(id string) error                                            // Deletes a balance monitor
+	CreateMonitor(monitor model.BalanceMonitor) (model.BalanceMonitor, error)                                                                                                      // Creates a new balance monitor
+	GetMonitorByID(id string) (*model.BalanceMonitor, error)                                                                                                                       // Retrieves a balance monitor by ID
+	GetAllMonitors() ([]model.BalanceMonitor, error)                                                                                                                               // Retrieves all balance monitors
+	GetBalanceMonitors(balanceID string) ([]model.BalanceMonitor, error)                                                                                                           // Retrieves monitors for a specific balance
+	UpdateMonitor(monitor *model.BalanceMonitor) error                                                                                                                             // Updates a balance monitor
+	DeleteMonitor(id string) error                                                                                                                                                 // Deletes a balance monitor
+	RecordMonitorEvaluation(ctx context.Context, monitor *model.BalanceMonitor, met bool, balance *model.Balance, transactionID string, at time.Time) (*model.MonitorEvent, error) // Records a monitor evaluation and whether it fired
+	GetMonitorEvents(ctx context.Context, monitorID string, limit, offset int) ([]model.MonitorEvent, error)                                                                       // Retrieves the firing history of a monitor
+	SetMonitorEventActions(ctx context.Context, eventID string, results []model.MonitorActionResult) error                                                                         // Records the outcome of the actions of a monitor event
 }
 
 // identity defines methods for handling identities.
@@ -118,3 +142,58 @@ type reconciliation interface {
 	RecordUnmatched(ctx context.Context, reconciliationID string, results []string) error                                                                               // Records unmatched results for a reconciliation
 	FetchAndGroupExternalTransactions(ctx context.Context, uploadID string, groupCriteria string, batchSize int, offset int64) (map[string][]*model.Transaction, error) // Fetches and groups external transactions based on criteria
 }
+
+// idempotency defines methods for handling idempotency keys.
+type idempotency interface {
+	ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (*model.IdempotencyKey, bool, error) // Reserves a key for a request or returns its existing use
+	CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, body []byte) error               // Records the response for a reserved key
+	ReleaseIdempotencyKey(ctx context.Context, key string) error                                             // Removes a reserved key whose request did not complete
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)                                                 // Removes expired keys and abandoned reservations
+}
+
+// schedule defines methods for handling recurring transaction schedules.
+type schedule interface {
+	CreateSchedule(ctx context.Context, schedule *model.Schedule) error                                   // Creates a new schedule
+	GetScheduleByID(ctx context.Context, id string) (*model.Schedule, error)                              // Retrieves a schedule by ID
+	GetAllSchedules(ctx context.Context, limit, offset int) ([]model.Schedule, error)                     // Retrieves all schedules
+	GetDueSchedules(ctx context.Context, now time.Time, limit int) ([]model.Schedule, error)              // Retrieves active schedules due to run
+	UpdateScheduleStatus(ctx context.Context, id, status string, nextRunAt time.Time) error               // Updates the status and next run of a schedule
+	AdvanceSchedule(ctx context.Context, schedule *model.Schedule, previousRunAt time.Time) (bool, error) // Records a queued occurrence of a schedule
+}
+
+// period defines methods for handling accounting periods.
+type period interface {
+	ClosePeriod(ctx context.Context, period model.AccountingPeriod) (*model.AccountingPeriod, error)          // Closes a period of a ledger and snapshots its balances
+	ReopenPeriod(ctx context.Context, periodID, reason string) (*model.AccountingPeriod, error)               // Reopens a closed period
+	GetAccountingPeriod(ctx context.Context, periodID string) (*model.AccountingPeriod, error)                // Retrieves a period by ID
+	GetAccountingPeriods(ctx context.Context, ledgerID string) ([]model.AccountingPeriod, error)              // Retrieves the periods of a ledger
+	GetClosedPeriod(ctx context.Context, ledgerID string, at time.Time) (*model.AccountingPeriod, error)      // Retrieves the closed period of a ledger containing a date
+	GetPeriodBalances(ctx context.Context, periodID string, limit, offset int) ([]model.PeriodBalance, error) // Retrieves the balances snapshotted for a period
+}
+
+// fx defines methods for handling FX rates and quotes.
+type fx interface {
+	CreateFXRate(ctx context.Context, rate model.FXRate) (*model.FXRate, error)                    // Records a rate of a currency pair
+	GetFXRate(ctx context.Context, base, quote string, at time.Time) (*model.FXRate, error)        // Retrieves the rate of a pair in effect at a time
+	GetFXRates(ctx context.Context, base, quote string, limit, offset int) ([]model.FXRate, error) // Retrieves recorded rates, latest first
+	CreateFXQuote(ctx context.Context, quote model.FXQuote) (*model.FXQuote, error)                // Records a quote locking a rate until it expires
+	GetFXQuote(ctx context.Context, quoteID string) (*model.FXQuote, error)                        // Retrieves a quote by ID
+	UseFXQuote(ctx context.Context, quoteID, transactionID string) (*model.FXQuote, error)         // Marks an open, unexpired quote as used by a transaction
+	ReleaseFXQuote(ctx context.Context, quoteID, transactionID string) error                       // Reopens a quote whose transaction was never queued
+}
+
+// currency defines methods for handling the custom assets of the currency registry.
+type currency interface {
+	CreateCurrency(ctx context.Context, currency model.Currency) (*model.Currency, error) // Registers a custom asset
+	GetCurrency(ctx context.Context, code string) (*model.Currency, error)                // Retrieves a custom asset by code
+	GetCurrencies(ctx context.Context) ([]model.Currency, error)                          // Retrieves all custom assets
+}
+
+// fee defines methods for handling fee rules.
+type fee interface {
+	CreateFeeRule(ctx context.Context, rule model.FeeRule) (*model.FeeRule, error)               // Records a fee rule
+	GetFeeRule(ctx context.Context, ruleID string) (*model.FeeRule, error)                       // Retrieves a fee rule by ID
+	GetFeeRules(ctx context.Context, limit, offset int) ([]model.FeeRule, error)                 // Retrieves fee rules, newest first
+	GetActiveFeeRules(ctx context.Context, currency string) ([]model.FeeRule, error)             // Retrieves the active rules that can apply to a currency
+	UpdateFeeRuleStatus(ctx context.Context, ruleID string, active bool) (*model.FeeRule, error) // Activates or deactivates a fee rule
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+)
+
+const scheduleColumns = `schedule_id, transaction, cron, rrule, status, start_at, end_at, max_occurrences, occurrences, next_run_at, last_run_at, meta_data, created_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// nullIfZeroTime maps a zero time to SQL NULL.
+func nullIfZeroTime(value time.Time) interface{} {
+	if value.IsZero() {
+		return nil
+	}
+	return value
+}
+
+// scanSchedule scans a row selected with scheduleColumns into a Schedule.
+func scanSchedule(row rowScanner) (*model.Schedule, error) {
+	schedule := &model.Schedule{}
+	var transactionJSON, metaDataJSON []byte
+	var endAt, nextRunAt, lastRunAt sql.NullTime
+	err := row.Scan(&schedule.ScheduleID, &transactionJSON, stringScanner{&schedule.Cron}, stringScanner{&schedule.RRule}, &schedule.Status,
+		&schedule.StartAt, &endAt, &schedule.MaxOccurrences, &schedule.Occurrences, &nextRunAt, &lastRunAt, &metaDataJSON, &schedule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.EndAt = endAt.Time
+	schedule.NextRunAt = nextRunAt.Time
+	schedule.LastRunAt = lastRunAt.Time
+
+	if err := json.Unmarshal(transactionJSON, &schedule.Transaction); err != nil {
+		return nil, err
+	}
+	if len(metaDataJSON) > 0 {
+		if err := json.Unmarshal(metaDataJSON, &schedule.MetaData); err != nil {
+			return nil, err
+		}
+	}
+	return schedule, nil
+}
+
+// CreateSchedule inserts a new schedule into the database.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - schedule: The schedule to create. Its ID and creation time are set if missing.
+// Returns:
+// - An error if the schedule could not be created.
+func (d Datasource) CreateSchedule(ctx context.Context, schedule *model.Schedule) error {
+	ctx, span := otel.Tracer("schedule.database").Start(ctx, "CreateSchedule")
+	defer span.End()
+
+	if schedule.ScheduleID == "" {
+		schedule.ScheduleID = model.GenerateUUIDWithSuffix("sch")
+	}
+	if schedule.CreatedAt.IsZero() {
+		schedule.CreatedAt = time.Now()
+	}
+
+	transactionJSON, err := json.Marshal(schedule.Transaction)
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal transaction template", err)
+	}
+	metaDataJSON, err := json.Marshal(schedule.MetaData)
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal metadata", err)
+	}
+
+	_, err = d.Conn.ExecContext(ctx, `
+		INSERT INTO blnk.schedules (`+scheduleColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, schedule.ScheduleID, transactionJSON, nullIfEmpty(schedule.Cron), nullIfEmpty(schedule.RRule), schedule.Status, schedule.StartAt,
+		nullIfZeroTime(schedule.EndAt), schedule.MaxOccurrences, schedule.Occurrences, nullIfZeroTime(schedule.NextRunAt), nullIfZeroTime(schedule.LastRunAt),
+		metaDataJSON, schedule.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to create schedule", err)
+	}
+
+	return nil
+}
+
+// GetScheduleByID retrieves a schedule by its ID.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - id: The schedule ID.
+// Returns:
+// - The schedule, or an error if it does not exist or could not be retrieved.
+func (d Datasource) GetScheduleByID(ctx context.Context, id string) (*model.Schedule, error) {
+	ctx, span := otel.Tracer("schedule.database").Start(ctx, "GetScheduleByID")
+	defer span.End()
+
+	row := d.Conn.QueryRowContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM blnk.schedules
+		WHERE schedule_id = $1
+	`, id)
+
+	schedule, err := scanSchedule(row)
+	if err != nil {
+		span.RecordError(err)
+		if err == sql.ErrNoRows {
+			return nil, apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Schedule with ID '%s' not found", id), err)
+		}
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve schedule", err)
+	}
+
+	return schedule, nil
+}
+
+// GetAllSchedules retrieves a paginated list of schedules, newest first.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - limit: The maximum number of schedules to return.
+// - offset: The offset to start fetching schedules from.
+// Returns:
+// - The schedules, or an error if they could not be retrieved.
+func (d Datasource) GetAllSchedules(ctx context.Context, limit, offset int) ([]model.Schedule, error) {
+	ctx, span := otel.Tracer("schedule.database").Start(ctx, "GetAllSchedules")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	rows, err := d.Conn.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM blnk.schedules
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve schedules", err)
+	}
+	defer rows.Close()
+
+	return collectSchedules(rows)
+}
+
+// GetDueSchedules retrieves active schedules whose next run is at or before the given time.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - now: The time the schedules must be due by.
+// - limit: The maximum number of schedules to return.
+// Returns:
+// - The due schedules, earliest first, or an error if they could not be retrieved.
+func (d Datasource) GetDueSchedules(ctx context.Context, now time.Time, limit int) ([]model.Schedule, error) {
+	ctx, span := otel.Tracer("schedule.database").Start(ctx, "GetDueSchedules")
+	defer span.End()
+
+	rows, err := d.Conn.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM blnk.schedules
+		WHERE status = 'ACTIVE' AND next_run_at <= $1
+		ORDER BY next_run_at
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve due schedules", err)
+	}
+	defer rows.Close()
+
+	return collectSchedules(rows)
+}
+
+// collectSchedules scans every row of a schedules query.
+func collectSchedules(rows *sql.Rows) ([]model.Schedule, error) {
+	schedules := []model.Schedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan schedule data", err)
+		}
+		schedules = append(schedules, *schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over schedules", err)
+	}
+	return schedules, nil
+}
+
+// UpdateScheduleStatus sets the status and next run of a schedule.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - id: The schedule ID.
+// - status: The new status.
+// - nextRunAt: The next run, or the zero time if the schedule will not run again.
+// Returns:
+// - An error if the schedule does not exist or could not be updated.
+func (d Datasource) UpdateScheduleStatus(ctx context.Context, id, status string, nextRunAt time.Time) error {
+	ctx, span := otel.Tracer("schedule.database").Start(ctx, "UpdateScheduleStatus")
+	defer span.End()
+
+	result, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.schedules SET status = $2, next_run_at = $3 WHERE schedule_id = $1
+	`, id, status, nullIfZeroTime(nextRunAt))
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to update schedule", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return apierror.NewAPIError(apierror.ErrNotFound, fmt.Sprintf("Schedule with ID '%s' not found", id), nil)
+	}
+
+	return nil
+}
+
+// AdvanceSchedule records that an occurrence of a schedule has been queued. The update only applies while the
+// schedule is still active and due at previousRunAt, so concurrent runners cannot advance it twice.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - schedule: The schedule with its updated occurrences, status, next and last run.
+// - previousRunAt: The next run the schedule had when the occurrence was queued.
+// Returns:
+// - Whether the schedule was advanced.
+// - An error if the update failed.
+func (d Datasource) AdvanceSchedule(ctx context.Context, schedule *model.Schedule, previousRunAt time.Time) (bool, error) {
+	ctx, span := otel.Tracer("schedule.database").Start(ctx, "AdvanceSchedule")
+	defer span.End()
+
+	result, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.schedules
+		SET occurrences = $2, status = $3, next_run_at = $4, last_run_at = $5
+		WHERE schedule_id = $1 AND status = 'ACTIVE' AND next_run_at = $6
+	`, schedule.ScheduleID, schedule.Occurrences, schedule.Status, nullIfZeroTime(schedule.NextRunAt), nullIfZeroTime(schedule.LastRunAt), previousRunAt)
+	if err != nil {
+		span.RecordError(err)
+		return false, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to advance schedule", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return false, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var scheduleColumnNames = []string{"schedule_id", "transaction", "cron", "rrule", "status", "start_at", "end_at", "max_occurrences",
+	"occurrences", "next_run_at", "last_run_at", "meta_data", "created_at"}
+
+func TestCreateSchedule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	schedule := &model.Schedule{
+		Transaction: model.Transaction{Reference: "rent", Source: "bln_1", Destination: "bln_2", Amount: 100},
+		RRule:       "FREQ=MONTHLY",
+		Status:      model.ScheduleStatusActive,
+		StartAt:     time.Now(),
+		NextRunAt:   time.Now(),
+	}
+	mock.ExpectExec("INSERT INTO blnk.schedules").
+		WithArgs(anyArgs(13)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = ds.CreateSchedule(context.Background(), schedule)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, schedule.ScheduleID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetScheduleByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	now := time.Now()
+	mock.ExpectQuery("SELECT schedule_id, transaction, cron, rrule").
+		WithArgs("sch_1").
+		WillReturnRows(sqlmock.NewRows(scheduleColumnNames).
+			AddRow("sch_1", []byte(`{"reference":"rent","amount":100}`), nil, "FREQ=MONTHLY", "ACTIVE", now, nil, 0, 2, now, now, []byte(`{}`), now))
+
+	schedule, err := ds.GetScheduleByID(context.Background(), "sch_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "rent", schedule.Transaction.Reference)
+	assert.Equal(t, "", schedule.Cron)
+	assert.Equal(t, "FREQ=MONTHLY", schedule.RRule)
+	assert.Equal(t, 2, schedule.Occurrences)
+	assert.True(t, schedule.EndAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetScheduleByID_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	mock.ExpectQuery("SELECT schedule_id, transaction, cron, rrule").
+		WithArgs("sch_missing").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = ds.GetScheduleByID(context.Background(), "sch_missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdvanceSchedule_AlreadyAdvanced(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	previous := time.Now()
+	schedule := &model.Schedule{ScheduleID: "sch_1", Status: model.ScheduleStatusActive, Occurrences: 3, NextRunAt: previous.Add(time.Hour), LastRunAt: previous}
+	mock.ExpectExec("UPDATE blnk.schedules").
+		WithArgs("sch_1", 3, model.ScheduleStatusActive, schedule.NextRunAt, previous, previous).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	advanced, err := ds.AdvanceSchedule(context.Background(), schedule, previous)
+	assert.NoError(t, err)
+	assert.False(t, advanced)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxStatementEntries bounds the number of postings on a single statement.
+const maxStatementEntries = 10000
+
+// statementEntriesQuery lists the applied postings to a balance, with the amounts credited and debited by each one
+// computed the same way as in balanceDeltaQuery, so the entries add up to the balance history.
+const statementEntriesQuery = `
+	SELECT t.transaction_id, t.reference, t.description, t.source, t.destination, t.created_at, t.effective_date, t.precision,
+		(CASE WHEN t.destination = $1 THEN
+			CASE WHEN EXISTS (
+				SELECT 1 FROM blnk.transactions p
+				WHERE p.transaction_id = t.parent_transaction AND p.status = 'INFLIGHT' AND p.atomic = false
+			) THEN COALESCE(t.precise_amount, 0)
+			ELSE TRUNC(COALESCE(t.precise_amount, 0) * COALESCE(NULLIF(t.rate, 0), 1)) END
+		ELSE 0 END)::TEXT,
+		(CASE WHEN t.source = $1 THEN COALESCE(t.precise_amount, 0) ELSE 0 END)::TEXT
+	FROM blnk.transactions t
+	WHERE (t.source = $1 OR t.destination = $1)
+		AND t.atomic = false
+		AND t.status = 'APPLIED'
+		AND t.effective_date > $2
+		AND t.effective_date <= $3
+	ORDER BY t.effective_date, t.id
+	LIMIT $4
+`
+
+// GetStatementEntries retrieves the postings to a balance effective after from and up to to, oldest first.
+// A transaction moving money from a balance to itself yields both a debit and a credit entry.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - balanceID: The ID of the balance.
+// - from: The start of the period, exclusive.
+// - to: The end of the period, inclusive.
+// Returns:
+// - The statement entries without their running balance.
+// - An error if the period holds more than maxStatementEntries postings or the query fails.
+func (d Datasource) GetStatementEntries(ctx context.Context, balanceID string, from, to time.Time) ([]model.StatementEntry, error) {
+	ctx, span := otel.Tracer("balance.database").Start(ctx, "GetStatementEntries")
+	defer span.End()
+
+	rows, err := d.Conn.QueryContext(ctx, statementEntriesQuery, balanceID, from, to, maxStatementEntries+1)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve statement entries", err)
+	}
+	defer rows.Close()
+
+	entries := []model.StatementEntry{}
+	postings := 0
+	for rows.Next() {
+		var transactionID, reference, description, source, destination, credit, debit string
+		var createdAt, effectiveDate time.Time
+		var precision float64
+		if err := rows.Scan(&transactionID, stringScanner{&reference}, stringScanner{&description}, stringScanner{&source}, stringScanner{&destination},
+			&createdAt, &effectiveDate, &precision, &credit, &debit); err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan statement entry", err)
+		}
+
+		postings++
+		if postings > maxStatementEntries {
+			err := fmt.Errorf("the period holds more than %d postings, request a shorter period", maxStatementEntries)
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+		}
+
+		entry := model.StatementEntry{TransactionID: transactionID, Reference: reference, Description: description, Precision: precision, CreatedAt: createdAt, EffectiveDate: effectiveDate}
+		for _, side := range []struct {
+			amount, direction, counterparty string
+		}{
+			{debit, model.StatementEntryDebit, destination},
+			{credit, model.StatementEntryCredit, source},
+		} {
+			amount, err := parseBigInt(side.amount)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+			if amount.Sign() == 0 {
+				continue
+			}
+			sideEntry := entry
+			sideEntry.Amount = amount
+			sideEntry.Direction = side.direction
+			sideEntry.Counterparty = side.counterparty
+			entries = append(entries, sideEntry)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over statement entries", err)
+	}
+
+	span.AddEvent("Statement entries retrieved", trace.WithAttributes(
+		attribute.String("balance.id", balanceID),
+		attribute.Int("statement.entries", len(entries)),
+	))
+	return entries, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStatementEntries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	mock.ExpectQuery("SELECT t.transaction_id, t.reference, t.description").
+		WithArgs("bln_1", from, to, maxStatementEntries+1).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "reference", "description", "source", "destination", "created_at", "effective_date", "precision", "credit", "debit"}).
+			AddRow("txn_1", "inv_1", "Invoice", "bln_2", "bln_1", from.AddDate(0, 0, 2), from.Add(time.Hour), 100, "500", "0").
+			AddRow("txn_2", "move_1", nil, "bln_1", "bln_1", from.Add(2*time.Hour), from.Add(2*time.Hour), 100, "300", "300"))
+
+	entries, err := ds.GetStatementEntries(context.Background(), "bln_1", from, to)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, model.StatementEntryCredit, entries[0].Direction)
+	assert.Equal(t, "bln_2", entries[0].Counterparty)
+	assert.Equal(t, "500", entries[0].Amount.String())
+	assert.Equal(t, from.Add(time.Hour), entries[0].EffectiveDate)
+
+	// A transfer from the balance to itself is both a debit and a credit
+	assert.Equal(t, model.StatementEntryDebit, entries[1].Direction)
+	assert.Equal(t, model.StatementEntryCredit, entries[2].Direction)
+	assert.Equal(t, "txn_2", entries[2].TransactionID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
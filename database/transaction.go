@@ -23,6 +23,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/big"
+	"sort"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -36,6 +39,107 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// sqlExecutor is satisfied by both *sql.DB and *sql.Tx, so inserts can run on their own or as part of a database transaction.
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx, so single-row reads can run on their own or inside a database transaction.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// stringScanner scans a nullable text column into a plain string, leaving the string empty for NULL values.
+type stringScanner struct {
+	dest *string
+}
+
+// Scan implements the sql.Scanner interface.
+func (s stringScanner) Scan(value interface{}) error {
+	var ns sql.NullString
+	if err := ns.Scan(value); err != nil {
+		return err
+	}
+	*s.dest = ns.String
+	return nil
+}
+
+// bigIntScanner scans a nullable NUMERIC column into a *big.Int, leaving it nil for NULL values.
+type bigIntScanner struct {
+	dest **big.Int
+}
+
+// Scan implements the sql.Scanner interface.
+func (s bigIntScanner) Scan(value interface{}) error {
+	var ns sql.NullString
+	if err := ns.Scan(value); err != nil {
+		return err
+	}
+	if !ns.Valid {
+		*s.dest = nil
+		return nil
+	}
+	parsed, ok := new(big.Int).SetString(ns.String, 10)
+	if !ok {
+		return fmt.Errorf("invalid integer value %q", ns.String)
+	}
+	*s.dest = parsed
+	return nil
+}
+
+// nullableBigInt maps a nil *big.Int to SQL NULL and any other value to its decimal string.
+func nullableBigInt(value *big.Int) interface{} {
+	if value == nil {
+		return nil
+	}
+	return value.String()
+}
+
+// nullIfZero maps a zero version to SQL NULL, i.e. a transaction that did not touch the balance.
+func nullIfZero(value int64) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// nullIfEmpty maps an empty string to SQL NULL. The parent of an atomic journal entry only carries the side shared by all of its legs,
+// and an empty source or destination would otherwise violate the balance foreign keys.
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// insertTransaction writes a single transaction row using the provided executor.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - exec: The connection or database transaction to run the insert on.
+// - txn: The transaction object to be inserted.
+// Returns:
+// - An error if the metadata cannot be marshaled or the insert fails.
+func insertTransaction(ctx context.Context, exec sqlExecutor, txn *model.Transaction) error {
+	// Marshal transaction metadata into JSON format
+	metaDataJSON, err := json.Marshal(txn.MetaData)
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal metadata", err)
+	}
+
+	// Execute the SQL insert statement to record the transaction
+	_, err = exec.ExecContext(ctx,
+		`INSERT INTO blnk.transactions(transaction_id, parent_transaction, source, reference, amount, precise_amount, precision, rate, currency, destination, description, status, created_at, meta_data, scheduled_for, hash, atomic,
+			source_balance_before, source_balance_after, destination_balance_before, destination_balance_after, source_balance_version, destination_balance_version, schedule_id, refund, effective_date) 
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)`,
+		txn.TransactionID, txn.ParentTransaction, nullIfEmpty(txn.Source), txn.Reference, txn.Amount, nullableBigInt(txn.PreciseAmount), txn.Precision, txn.Rate, txn.Currency, nullIfEmpty(txn.Destination), txn.Description, txn.Status, txn.CreatedAt, metaDataJSON, txn.ScheduledFor, txn.Hash, txn.Atomic,
+		nullableBigInt(txn.SourceBalanceBefore), nullableBigInt(txn.SourceBalanceAfter), nullableBigInt(txn.DestinationBalanceBefore), nullableBigInt(txn.DestinationBalanceAfter), nullIfZero(txn.SourceBalanceVersion), nullIfZero(txn.DestinationBalanceVersion), nullIfEmpty(txn.ScheduleID), txn.Refund, txn.PostingDate(),
+	)
+	if err != nil {
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record transaction", err)
+	}
+	return nil
+}
+
 // RecordTransaction records a new transaction in the database.
 // It logs the transaction details using OpenTelemetry tracing.
 // Parameters:
@@ -48,24 +152,10 @@ func (d Datasource) RecordTransaction(ctx context.Context, txn *model.Transactio
 	ctx, span := otel.Tracer("transaction.database").Start(ctx, "RecordTransaction")
 	defer span.End()
 
-	// Marshal transaction metadata into JSON format
-	metaDataJSON, err := json.Marshal(txn.MetaData)
-	if err != nil {
+	// Insert the transaction row
+	if err := insertTransaction(ctx, d.Conn, txn); err != nil {
 		span.RecordError(err) // Record the error in the tracing span
-		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to marshal metadata", err)
-	}
-
-	// Execute the SQL insert statement to record the transaction
-	_, err = d.Conn.ExecContext(ctx,
-		`INSERT INTO blnk.transactions(transaction_id, parent_transaction, source, reference, amount, precise_amount, precision, rate, currency, destination, description, status, created_at, meta_data, scheduled_for, hash) 
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
-		txn.TransactionID, txn.ParentTransaction, txn.Source, txn.Reference, txn.Amount, txn.PreciseAmount, txn.Precision, txn.Rate, txn.Currency, txn.Destination, txn.Description, txn.Status, txn.CreatedAt, metaDataJSON, txn.ScheduledFor, txn.Hash,
-	)
-
-	// Handle errors that may occur during the execution of the query
-	if err != nil {
-		span.RecordError(err)
-		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to record transaction", err)
+		return nil, err
 	}
 
 	// Log the successful transaction recording as an event in the tracing span
@@ -77,6 +167,80 @@ func (d Datasource) RecordTransaction(ctx context.Context, txn *model.Transactio
 	return txn, nil
 }
 
+// RecordJournalEntry records an atomic journal entry. The updated balances, every leg and the parent transaction
+// are written in a single database transaction, so either the whole entry is posted or nothing is.
+// Balances are updated with the same optimistic locking as UpdateBalances. The accounting periods of the balances'
+// ledgers are checked again in the same database transaction, so an entry cannot land in a period closed after it
+// was validated. A single transfer is recorded as an entry without legs.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - parent: The parent transaction carrying the status of the whole entry.
+// - legs: The individual postings that make up the entry.
+// - balances: Every balance touched by the legs, with the legs already applied.
+// Returns:
+// - An error if any balance update or insert fails, in which case nothing is persisted.
+func (d Datasource) RecordJournalEntry(ctx context.Context, parent *model.Transaction, legs []*model.Transaction, balances []*model.Balance) error {
+	ctx, span := otel.Tracer("transaction.database").Start(ctx, "RecordJournalEntry")
+	defer span.End()
+
+	// Begin a new transaction
+	tx, err := d.Conn.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelDefault})
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to begin transaction", err)
+	}
+
+	// Ensure that the transaction is rolled back if an error occurs during execution
+	defer func(tx *sql.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	ledgerIDs := make([]string, 0, len(balances))
+	for _, balance := range balances {
+		ledgerIDs = append(ledgerIDs, balance.LedgerID)
+	}
+	dates := []time.Time{parent.PostingDate()}
+	for _, leg := range legs {
+		if at := leg.PostingDate(); !at.Equal(dates[len(dates)-1]) {
+			dates = append(dates, at)
+		}
+	}
+	if err := checkPeriodsOpen(ctx, tx, ledgerIDs, dates); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	for _, balance := range balances {
+		if err := updateBalance(ctx, tx, balance); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	for _, leg := range legs {
+		if err := insertTransaction(ctx, tx, leg); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	if err := insertTransaction(ctx, tx, parent); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to commit transaction", err)
+	}
+
+	span.AddEvent("Journal entry recorded", trace.WithAttributes(
+		attribute.String("transaction.id", parent.TransactionID),
+		attribute.Int("journal.legs", len(legs)),
+	))
+	return nil
+}
+
 // GetTransaction retrieves a transaction by its ID from the database.
 // It logs the transaction retrieval using OpenTelemetry tracing.
 // Parameters:
@@ -91,7 +255,9 @@ func (d Datasource) GetTransaction(ctx context.Context, id string) (*model.Trans
 
 	// Execute the SQL query to retrieve the transaction by its ID
 	row := d.Conn.QueryRowContext(ctx, `
-		SELECT transaction_id, source, reference, amount, precise_amount, precision, currency, destination, description, status, created_at, meta_data
+		SELECT transaction_id, source, reference, amount, precise_amount, precision, currency, destination, description, status, created_at, meta_data,
+			source_balance_before, source_balance_after, destination_balance_before, destination_balance_after, COALESCE(source_balance_version, 0), COALESCE(destination_balance_version, 0), schedule_id,
+			parent_transaction, atomic, refund, refunded_amount, effective_date
 		FROM blnk.transactions
 		WHERE transaction_id = $1
 	`, id)
@@ -99,7 +265,9 @@ func (d Datasource) GetTransaction(ctx context.Context, id string) (*model.Trans
 	// Initialize a Transaction model and scan the result into it
 	txn := &model.Transaction{}
 	var metaDataJSON []byte
-	err := row.Scan(&txn.TransactionID, &txn.Source, &txn.Reference, &txn.Amount, &txn.PreciseAmount, &txn.Precision, &txn.Currency, &txn.Destination, &txn.Description, &txn.Status, &txn.CreatedAt, &metaDataJSON)
+	err := row.Scan(&txn.TransactionID, stringScanner{&txn.Source}, &txn.Reference, &txn.Amount, bigIntScanner{&txn.PreciseAmount}, &txn.Precision, &txn.Currency, stringScanner{&txn.Destination}, &txn.Description, &txn.Status, &txn.CreatedAt, &metaDataJSON,
+		bigIntScanner{&txn.SourceBalanceBefore}, bigIntScanner{&txn.SourceBalanceAfter}, bigIntScanner{&txn.DestinationBalanceBefore}, bigIntScanner{&txn.DestinationBalanceAfter}, &txn.SourceBalanceVersion, &txn.DestinationBalanceVersion, stringScanner{&txn.ScheduleID},
+		&txn.ParentTransaction, &txn.Atomic, &txn.Refund, bigIntScanner{&txn.RefundedAmount}, &txn.EffectiveDate)
 
 	// Handle errors, including no rows found
 	if err != nil {
@@ -118,6 +286,11 @@ func (d Datasource) GetTransaction(ctx context.Context, id string) (*model.Trans
 		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to unmarshal metadata", err)
 	}
 
+	// Refunds themselves cannot be refunded, so they carry no refundable amount
+	if !txn.Refund && txn.RefundedAmount != nil && txn.PreciseAmount != nil {
+		txn.RefundableAmount = new(big.Int).Sub(txn.PreciseAmount, txn.RefundedAmount)
+	}
+
 	// Log the successful transaction retrieval as an event in the tracing span
 	span.AddEvent("Transaction retrieved", trace.WithAttributes(
 		attribute.String("transaction.id", txn.TransactionID),
@@ -225,7 +398,7 @@ func (d Datasource) GetTransactionByRef(ctx context.Context, reference string) (
 	// Initialize the transaction object and scan the query result into it
 	txn := model.Transaction{}
 	var metaDataJSON []byte
-	err := row.Scan(&txn.TransactionID, &txn.Source, &txn.Reference, &txn.Amount, &txn.PreciseAmount, &txn.Currency, &txn.Destination, &txn.Description, &txn.Status, &txn.CreatedAt, &metaDataJSON)
+	err := row.Scan(&txn.TransactionID, stringScanner{&txn.Source}, &txn.Reference, &txn.Amount, bigIntScanner{&txn.PreciseAmount}, &txn.Currency, stringScanner{&txn.Destination}, &txn.Description, &txn.Status, &txn.CreatedAt, &metaDataJSON)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			span.RecordError(err)
@@ -335,11 +508,11 @@ func (d Datasource) GetAllTransactions(ctx context.Context, limit, offset int) (
 		// Scan each row into the Transaction struct
 		err = rows.Scan(
 			&transaction.TransactionID,
-			&transaction.Source,
+			stringScanner{&transaction.Source},
 			&transaction.Reference,
 			&transaction.Amount,
 			&transaction.Currency,
-			&transaction.Destination,
+			stringScanner{&transaction.Destination},
 			&transaction.Description,
 			&transaction.Status,
 			&transaction.Hash,
@@ -383,8 +556,8 @@ func (d Datasource) GetAllTransactions(ctx context.Context, limit, offset int) (
 // - ctx: Context for managing the request and tracing.
 // - parentID: The ID of the parent transaction to retrieve totals for.
 // Returns:
-// - The total committed amount as int64, or 0 if no transactions are found, along with an error if the retrieval fails.
-func (d Datasource) GetTotalCommittedTransactions(ctx context.Context, parentID string) (int64, error) {
+// - The total committed amount, or 0 if no transactions are found, along with an error if the retrieval fails.
+func (d Datasource) GetTotalCommittedTransactions(ctx context.Context, parentID string) (*big.Int, error) {
 	// Start a new tracing span for the operation
 	ctx, span := otel.Tracer("transaction.database").Start(ctx, "GetTotalCommittedTransactions")
 	defer span.End()
@@ -393,35 +566,263 @@ func (d Datasource) GetTotalCommittedTransactions(ctx context.Context, parentID
 	query := `
 		SELECT SUM(precise_amount) AS total_amount
 		FROM blnk.transactions
-		WHERE parent_transaction = $1
+		WHERE parent_transaction = $1 AND refund = false
 		GROUP BY parent_transaction;
 	`
 
 	// Initialize the variable to store the total amount
-	var totalAmount int64
+	var totalAmount *big.Int
 
 	// Execute the query and scan the result into totalAmount
-	err := d.Conn.QueryRowContext(ctx, query, parentID).Scan(&totalAmount)
+	err := d.Conn.QueryRowContext(ctx, query, parentID).Scan(bigIntScanner{&totalAmount})
 	if err != nil {
 		// If no rows are found, return 0 without error
 		if errors.Is(err, sql.ErrNoRows) {
-			return 0, nil
+			return big.NewInt(0), nil
 		}
 		// Record the error in the tracing span and return the error
 		span.RecordError(err)
-		return 0, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get total committed transactions", err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get total committed transactions", err)
+	}
+	if totalAmount == nil {
+		totalAmount = big.NewInt(0)
 	}
 
 	// Log the successful retrieval of the total amount
 	span.AddEvent("Total committed transactions retrieved", trace.WithAttributes(
 		attribute.String("parent_transaction.id", parentID),
-		attribute.Int64("total_amount", totalAmount),
+		attribute.String("total_amount", totalAmount.String()),
 	))
 
 	// Return the total amount
 	return totalAmount, nil
 }
 
+const (
+	defaultTransactionListLimit = 20
+	maxTransactionListLimit     = 100
+)
+
+// ListTransactions retrieves a page of transactions matching a filter, newest first.
+// Pages are read with keyset pagination on (created_at, id), so deep pages cost the same as the first one.
+// Parameters:
+// - ctx: Context for managing the request and tracing.
+// - filter: The filters to apply, along with the page size and the cursor returned with the previous page.
+// Returns:
+// - The page of transactions and the cursor of the next page, or an error if the cursor is invalid or the query fails.
+func (d Datasource) ListTransactions(ctx context.Context, filter model.TransactionFilter) (*model.TransactionPage, error) {
+	ctx, span := otel.Tracer("transaction.database").Start(ctx, "ListTransactions")
+	defer span.End()
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultTransactionListLimit
+	}
+	if limit > maxTransactionListLimit {
+		limit = maxTransactionListLimit
+	}
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if filter.Source != "" {
+		addCondition("source = $%d", filter.Source)
+	}
+	if filter.Destination != "" {
+		addCondition("destination = $%d", filter.Destination)
+	}
+	if filter.BalanceID != "" {
+		addCondition("(source = $%d OR destination = $%d)", filter.BalanceID, filter.BalanceID)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.Currency != "" {
+		addCondition("currency = $%d", filter.Currency)
+	}
+	if filter.ReferencePrefix != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.ReferencePrefix)
+		addCondition(`reference LIKE $%d ESCAPE '\'`, escaped+"%")
+	}
+	if filter.ParentTransaction != "" {
+		addCondition("parent_transaction = $%d", filter.ParentTransaction)
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at <= $%d", filter.To)
+	}
+	if !filter.EffectiveFrom.IsZero() {
+		addCondition("effective_date >= $%d", filter.EffectiveFrom)
+	}
+	if !filter.EffectiveTo.IsZero() {
+		addCondition("effective_date <= $%d", filter.EffectiveTo)
+	}
+	keys := make([]string, 0, len(filter.MetaData))
+	for key := range filter.MetaData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		addCondition("meta_data ->> $%d = $%d", key, filter.MetaData[key])
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := model.DecodeTransactionCursor(filter.Cursor)
+		if err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+		}
+		addCondition("(created_at, id) < ($%d, $%d)", createdAt, id)
+	}
+
+	query := `
+		SELECT id, transaction_id, parent_transaction, source, reference, amount, precise_amount, precision, rate, currency, destination, description, status, hash, created_at, meta_data,
+			atomic, refund, schedule_id, effective_date
+		FROM blnk.transactions`
+	if len(conditions) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(conditions, " AND ")
+	}
+	// One extra row tells whether there is a next page
+	args = append(args, limit+1)
+	query += fmt.Sprintf("\n\t\tORDER BY created_at DESC, id DESC\n\t\tLIMIT $%d", len(args))
+
+	rows, err := d.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to retrieve transactions", err)
+	}
+	defer rows.Close()
+
+	page := &model.TransactionPage{Transactions: []model.Transaction{}}
+	for rows.Next() {
+		transaction := model.Transaction{}
+		var metaDataJSON []byte
+		err = rows.Scan(
+			&transaction.ID,
+			&transaction.TransactionID,
+			stringScanner{&transaction.ParentTransaction},
+			stringScanner{&transaction.Source},
+			&transaction.Reference,
+			&transaction.Amount,
+			bigIntScanner{&transaction.PreciseAmount},
+			&transaction.Precision,
+			&transaction.Rate,
+			&transaction.Currency,
+			stringScanner{&transaction.Destination},
+			&transaction.Description,
+			&transaction.Status,
+			&transaction.Hash,
+			&transaction.CreatedAt,
+			&metaDataJSON,
+			&transaction.Atomic,
+			&transaction.Refund,
+			stringScanner{&transaction.ScheduleID},
+			&transaction.EffectiveDate,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to scan transaction data", err)
+		}
+
+		if len(metaDataJSON) > 0 {
+			if err := json.Unmarshal(metaDataJSON, &transaction.MetaData); err != nil {
+				span.RecordError(err)
+				return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to unmarshal metadata", err)
+			}
+		}
+
+		page.Transactions = append(page.Transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrInternalServer, "Error occurred while iterating over transactions", err)
+	}
+
+	if len(page.Transactions) > limit {
+		page.Transactions = page.Transactions[:limit]
+		last := page.Transactions[limit-1]
+		page.NextCursor = model.EncodeTransactionCursor(last.CreatedAt, last.ID)
+	}
+
+	span.AddEvent("Transactions listed", trace.WithAttributes(
+		attribute.Int("transaction.count", len(page.Transactions)),
+		attribute.Bool("transaction.has_more", page.NextCursor != ""),
+	))
+	return page, nil
+}
+
+// ReserveRefund adds an amount to the refunded total of a transaction, as long as the total does not exceed the transaction amount.
+// The check and the update are a single statement, so concurrent refunds cannot over-refund a transaction.
+// Parameters:
+// - ctx: Context for managing request and tracing.
+// - transactionID: The ID of the transaction being refunded.
+// - amount: The precise amount of the refund.
+// Returns:
+// - Whether the amount was reserved, false if it exceeds the refundable amount or the transaction does not exist.
+// - An error if the update fails.
+func (d Datasource) ReserveRefund(ctx context.Context, transactionID string, amount *big.Int) (bool, error) {
+	ctx, span := otel.Tracer("transaction.database").Start(ctx, "ReserveRefund")
+	defer span.End()
+
+	result, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.transactions
+		SET refunded_amount = refunded_amount + $2
+		WHERE transaction_id = $1 AND refunded_amount + $2 <= precise_amount
+	`, transactionID, amount.String())
+	if err != nil {
+		span.RecordError(err)
+		return false, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to reserve refund", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return false, apierror.NewAPIError(apierror.ErrInternalServer, "Failed to get rows affected", err)
+	}
+
+	span.AddEvent("Refund reserved", trace.WithAttributes(
+		attribute.String("transaction.id", transactionID),
+		attribute.String("refund.amount", amount.String()),
+		attribute.Bool("refund.reserved", rowsAffected == 1),
+	))
+	return rowsAffected == 1, nil
+}
+
+// ReleaseRefund subtracts an amount from the refunded total of a transaction, for a refund that was never applied.
+// Parameters:
+// - ctx: Context for managing request and tracing.
+// - transactionID: The ID of the refunded transaction.
+// - amount: The precise amount of the refund.
+// Returns:
+// - An error if the update fails.
+func (d Datasource) ReleaseRefund(ctx context.Context, transactionID string, amount *big.Int) error {
+	ctx, span := otel.Tracer("transaction.database").Start(ctx, "ReleaseRefund")
+	defer span.End()
+
+	_, err := d.Conn.ExecContext(ctx, `
+		UPDATE blnk.transactions
+		SET refunded_amount = GREATEST(refunded_amount - $2, 0)
+		WHERE transaction_id = $1
+	`, transactionID, amount.String())
+	if err != nil {
+		span.RecordError(err)
+		return apierror.NewAPIError(apierror.ErrInternalServer, "Failed to release refund", err)
+	}
+
+	span.AddEvent("Refund released", trace.WithAttributes(attribute.String("transaction.id", transactionID)))
+	return nil
+}
+
 // GetTransactionsPaginated retrieves a batch of transactions from the database with pagination support and caches the result.
 // If the data is found in cache, it is returned from there; otherwise, it is fetched from the database and then cached.
 // Parameters:
@@ -469,14 +870,14 @@ func (d Datasource) GetTransactionsPaginated(ctx context.Context, _ string, batc
 		err = rows.Scan(
 			&transaction.TransactionID,
 			&transaction.ParentTransaction,
-			&transaction.Source,
+			stringScanner{&transaction.Source},
 			&transaction.Reference,
 			&transaction.Amount,
-			&transaction.PreciseAmount,
+			bigIntScanner{&transaction.PreciseAmount},
 			&transaction.Precision,
 			&transaction.Rate,
 			&transaction.Currency,
-			&transaction.Destination,
+			stringScanner{&transaction.Destination},
 			&transaction.Description,
 			&transaction.Status,
 			&transaction.CreatedAt,
@@ -583,14 +984,14 @@ func (d Datasource) GroupTransactions(ctx context.Context, groupCriteria string,
 			&groupKey,
 			&transaction.TransactionID,
 			&transaction.ParentTransaction,
-			&transaction.Source,
+			stringScanner{&transaction.Source},
 			&transaction.Reference,
 			&transaction.Amount,
-			&transaction.PreciseAmount,
+			bigIntScanner{&transaction.PreciseAmount},
 			&transaction.Precision,
 			&transaction.Rate,
 			&transaction.Currency,
-			&transaction.Destination,
+			stringScanner{&transaction.Destination},
 			&transaction.Description,
 			&transaction.Status,
 			&transaction.CreatedAt,
@@ -644,7 +1045,7 @@ func (d Datasource) GetInflightTransactionsByParentID(ctx context.Context, paren
 	rows, err := d.Conn.QueryContext(ctx, `
 		SELECT transaction_id, parent_transaction, source, reference, amount, precise_amount, precision, rate, currency, destination, description, status, created_at, meta_data, scheduled_for, hash
 		FROM blnk.transactions
-		WHERE transaction_id = $1 OR parent_transaction = $1 AND status = 'INFLIGHT'
+		WHERE transaction_id = $1 AND atomic = false OR parent_transaction = $1 AND status = 'INFLIGHT'
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`, parentTransactionID, batchSize, offset)
@@ -663,14 +1064,14 @@ func (d Datasource) GetInflightTransactionsByParentID(ctx context.Context, paren
 		err = rows.Scan(
 			&transaction.TransactionID,
 			&transaction.ParentTransaction,
-			&transaction.Source,
+			stringScanner{&transaction.Source},
 			&transaction.Reference,
 			&transaction.Amount,
-			&transaction.PreciseAmount,
+			bigIntScanner{&transaction.PreciseAmount},
 			&transaction.Precision,
 			&transaction.Rate,
 			&transaction.Currency,
-			&transaction.Destination,
+			stringScanner{&transaction.Destination},
 			&transaction.Description,
 			&transaction.Status,
 			&transaction.CreatedAt,
@@ -705,7 +1106,7 @@ func (d Datasource) GetInflightTransactionsByParentID(ctx context.Context, paren
 }
 
 // GetRefundableTransactionsByParentID retrieves transactions associated with a given parent transaction ID that are eligible for refunds.
-// Refundable transactions are those with status 'APPLIED' or 'VOID'. It supports pagination with batchSize and offset.
+// Refundable transactions are those with status 'APPLIED' or 'VOID' that are not refunds themselves. It supports pagination with batchSize and offset.
 // Parameters:
 // - ctx: Context for managing request and tracing.
 // - parentTransactionID: The ID of the parent transaction to filter by.
@@ -720,7 +1121,7 @@ func (d Datasource) GetRefundableTransactionsByParentID(ctx context.Context, par
 	rows, err := d.Conn.QueryContext(ctx, `
 		SELECT transaction_id, parent_transaction, source, reference, amount, precise_amount, precision, rate, currency, destination, description, status, created_at, meta_data, scheduled_for, hash
 		FROM blnk.transactions
-		WHERE transaction_id = $1 AND status = 'APPLIED' OR parent_transaction = $1 AND (status = 'VOID' OR status = 'APPLIED')
+		WHERE refund = false AND (transaction_id = $1 AND status = 'APPLIED' AND atomic = false OR parent_transaction = $1 AND (status = 'VOID' OR status = 'APPLIED'))
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`, parentTransactionID, batchSize, offset)
@@ -739,14 +1140,14 @@ func (d Datasource) GetRefundableTransactionsByParentID(ctx context.Context, par
 		err = rows.Scan(
 			&transaction.TransactionID,
 			&transaction.ParentTransaction,
-			&transaction.Source,
+			stringScanner{&transaction.Source},
 			&transaction.Reference,
 			&transaction.Amount,
-			&transaction.PreciseAmount,
+			bigIntScanner{&transaction.PreciseAmount},
 			&transaction.Precision,
 			&transaction.Rate,
 			&transaction.Currency,
-			&transaction.Destination,
+			stringScanner{&transaction.Destination},
 			&transaction.Description,
 			&transaction.Status,
 			&transaction.CreatedAt,
@@ -19,8 +19,10 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"math/big"
 	"testing"
 	"time"
 
@@ -52,10 +54,11 @@ func TestRecordTransaction_Success(t *testing.T) {
 		Description:       "Test Transaction",
 		Status:            "PENDING",
 		CreatedAt:         time.Now(),
+		EffectiveDate:     time.Now().AddDate(0, 0, -1),
 		MetaData:          map[string]interface{}{"key": "value"},
 		ScheduledFor:      time.Now(),
 		Hash:              "hash123",
-		PreciseAmount:     1000,
+		PreciseAmount:     big.NewInt(1000),
 		Precision:         2,
 		Rate:              1,
 		ParentTransaction: "parent123",
@@ -65,7 +68,7 @@ func TestRecordTransaction_Success(t *testing.T) {
 	assert.NoError(t, err)
 
 	mock.ExpectExec("INSERT INTO blnk.transactions").
-		WithArgs(transaction.TransactionID, transaction.ParentTransaction, transaction.Source, transaction.Reference, transaction.Amount, transaction.PreciseAmount, transaction.Precision, transaction.Rate, transaction.Currency, transaction.Destination, transaction.Description, transaction.Status, transaction.CreatedAt, metaDataJSON, transaction.ScheduledFor, transaction.Hash).
+		WithArgs(transaction.TransactionID, transaction.ParentTransaction, transaction.Source, transaction.Reference, transaction.Amount, transaction.PreciseAmount.String(), transaction.Precision, transaction.Rate, transaction.Currency, transaction.Destination, transaction.Description, transaction.Status, transaction.CreatedAt, metaDataJSON, transaction.ScheduledFor, transaction.Hash, transaction.Atomic, nil, nil, nil, nil, nil, nil, nil, transaction.Refund, transaction.EffectiveDate).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	result, err := ds.RecordTransaction(ctx, transaction)
@@ -97,7 +100,7 @@ func TestRecordTransaction_Failure(t *testing.T) {
 		MetaData:          map[string]interface{}{"key": "value"},
 		ScheduledFor:      time.Now(),
 		Hash:              "hash123",
-		PreciseAmount:     1000,
+		PreciseAmount:     big.NewInt(1000),
 		Precision:         2,
 		Rate:              1,
 		ParentTransaction: "parent123",
@@ -107,7 +110,7 @@ func TestRecordTransaction_Failure(t *testing.T) {
 	assert.NoError(t, err)
 
 	mock.ExpectExec("INSERT INTO blnk.transactions").
-		WithArgs(transaction.TransactionID, transaction.ParentTransaction, transaction.Source, transaction.Reference, transaction.Amount, transaction.PreciseAmount, transaction.Precision, transaction.Rate, transaction.Currency, transaction.Destination, transaction.Description, transaction.Status, transaction.CreatedAt, metaDataJSON, transaction.ScheduledFor, transaction.Hash).
+		WithArgs(transaction.TransactionID, transaction.ParentTransaction, transaction.Source, transaction.Reference, transaction.Amount, transaction.PreciseAmount.String(), transaction.Precision, transaction.Rate, transaction.Currency, transaction.Destination, transaction.Description, transaction.Status, transaction.CreatedAt, metaDataJSON, transaction.ScheduledFor, transaction.Hash, transaction.Atomic, nil, nil, nil, nil, nil, nil, nil, transaction.Refund, transaction.CreatedAt).
 		WillReturnError(errors.New("db error"))
 
 	_, err = ds.RecordTransaction(ctx, transaction)
@@ -130,10 +133,14 @@ func TestGetTransaction_Success(t *testing.T) {
 	metaDataJSON, err := json.Marshal(metaData)
 	assert.NoError(t, err)
 
-	rows := sqlmock.NewRows([]string{"transaction_id", "source", "reference", "amount", "precise_amount", "precision", "currency", "destination", "description", "status", "created_at", "meta_data"}).
-		AddRow("txn123", "src1", "ref123", 1000, 1000, 2, "USD", "dest1", "Test Transaction", "PENDING", time.Now(), metaDataJSON)
+	effectiveDate := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"transaction_id", "source", "reference", "amount", "precise_amount", "precision", "currency", "destination", "description", "status", "created_at", "meta_data",
+		"source_balance_before", "source_balance_after", "destination_balance_before", "destination_balance_after", "source_balance_version", "destination_balance_version", "schedule_id",
+		"parent_transaction", "atomic", "refund", "refunded_amount", "effective_date"}).
+		AddRow("txn123", "src1", "ref123", 1000, 1000, 2, "USD", "dest1", "Test Transaction", "PENDING", time.Now(), metaDataJSON, "5000", "4000", "0", "1000", 3, 8, nil,
+			"", false, false, "250", effectiveDate)
 
-	mock.ExpectQuery("SELECT transaction_id, source, reference, amount, precise_amount, precision, currency, destination, description, status, created_at, meta_data FROM blnk.transactions WHERE transaction_id = ?").
+	mock.ExpectQuery(`SELECT transaction_id, source, reference, amount, precise_amount, precision, currency, destination, description, status, created_at, meta_data, source_balance_before, source_balance_after, destination_balance_before, destination_balance_after, COALESCE\(source_balance_version, 0\), COALESCE\(destination_balance_version, 0\), schedule_id, parent_transaction, atomic, refund, refunded_amount, effective_date FROM blnk.transactions WHERE transaction_id = ?`).
 		WithArgs("txn123").
 		WillReturnRows(rows)
 
@@ -142,6 +149,12 @@ func TestGetTransaction_Success(t *testing.T) {
 	assert.Equal(t, "txn123", txn.TransactionID)
 	assert.Equal(t, "src1", txn.Source)
 	assert.Equal(t, "dest1", txn.Destination)
+	assert.Equal(t, big.NewInt(4000), txn.SourceBalanceAfter)
+	assert.Equal(t, big.NewInt(1000), txn.DestinationBalanceAfter)
+	assert.Equal(t, int64(3), txn.SourceBalanceVersion)
+	assert.Equal(t, big.NewInt(250), txn.RefundedAmount)
+	assert.Equal(t, big.NewInt(750), txn.RefundableAmount)
+	assert.Equal(t, effectiveDate, txn.EffectiveDate)
 }
 
 func TestGetTransaction_NotFound(t *testing.T) {
@@ -155,7 +168,7 @@ func TestGetTransaction_NotFound(t *testing.T) {
 
 	ds := Datasource{Conn: db}
 
-	mock.ExpectQuery("SELECT transaction_id, source, reference, amount, precise_amount, precision, currency, destination, description, status, created_at, meta_data FROM blnk.transactions WHERE transaction_id = ?").
+	mock.ExpectQuery(`SELECT transaction_id, source, reference, amount, precise_amount, precision, currency, destination, description, status, created_at, meta_data, source_balance_before, source_balance_after, destination_balance_before, destination_balance_after, COALESCE\(source_balance_version, 0\), COALESCE\(destination_balance_version, 0\), schedule_id, parent_transaction, atomic, refund, refunded_amount, effective_date FROM blnk.transactions WHERE transaction_id = ?`).
 		WithArgs("txn123").
 		WillReturnError(sql.ErrNoRows)
 
@@ -206,3 +219,162 @@ func TestTransactionExistsByRef_Failure(t *testing.T) {
 	assert.Error(t, err)
 	assert.IsType(t, apierror.APIError{}, err)
 }
+
+func TestRecordJournalEntry_RollsBackOnLegFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+
+	parent := &model.Transaction{TransactionID: "txn_parent", Destination: "bln_dest", Atomic: true}
+	legs := []*model.Transaction{
+		{TransactionID: "txn_leg1", ParentTransaction: "txn_parent", Source: "bln_a", Destination: "bln_dest"},
+		{TransactionID: "txn_leg2", ParentTransaction: "txn_parent", Source: "bln_b", Destination: "bln_dest"},
+	}
+	balances := []*model.Balance{
+		{BalanceID: "bln_a", Balance: big.NewInt(0), CreditBalance: big.NewInt(0), DebitBalance: big.NewInt(0), InflightBalance: big.NewInt(0), InflightCreditBalance: big.NewInt(0), InflightDebitBalance: big.NewInt(0)},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE blnk.balances").WithArgs(anyArgs(13)...).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO blnk.transactions").WithArgs(anyArgs(26)...).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO blnk.transactions").WithArgs(anyArgs(26)...).WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	err = ds.RecordJournalEntry(context.Background(), parent, legs, balances)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordJournalEntry_RejectsClosedPeriod(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+
+	postedAt := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	txn := &model.Transaction{TransactionID: "txn_1", Source: "bln_a", Destination: "bln_b", EffectiveDate: postedAt}
+	balances := []*model.Balance{{BalanceID: "bln_a", LedgerID: "ldg_1"}, {BalanceID: "bln_b", LedgerID: "ldg_1"}}
+
+	// The period was closed after the transfer was validated, so it is checked again under the ledger's lock
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ledger_id FROM blnk.ledgers WHERE ledger_id = \\$1 FOR SHARE").
+		WithArgs("ldg_1").
+		WillReturnRows(sqlmock.NewRows([]string{"ledger_id"}).AddRow("ldg_1"))
+	mock.ExpectQuery("SELECT (.+) FROM blnk.accounting_periods").
+		WithArgs("ldg_1", model.PeriodStatusClosed, postedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"period_id", "ledger_id", "name", "start_date", "end_date", "status", "closed_at", "reopened_at", "reopen_reason"}).
+			AddRow("per_1", "ldg_1", "Q1", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC), model.PeriodStatusClosed, time.Now(), nil, nil))
+	mock.ExpectRollback()
+
+	err = ds.RecordJournalEntry(context.Background(), txn, nil, balances)
+	assert.ErrorIs(t, err, model.ErrPeriodClosed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReserveRefund(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+
+	mock.ExpectExec("UPDATE blnk.transactions SET refunded_amount = refunded_amount \\+ \\$2").
+		WithArgs("txn123", "400").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE blnk.transactions SET refunded_amount = refunded_amount \\+ \\$2").
+		WithArgs("txn123", "700").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reserved, err := ds.ReserveRefund(context.Background(), "txn123", big.NewInt(400))
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	// The refunds would add up to more than the transaction amount
+	reserved, err = ds.ReserveRefund(context.Background(), "txn123", big.NewInt(700))
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListTransactions_FiltersAndCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	columns := []string{"id", "transaction_id", "parent_transaction", "source", "reference", "amount", "precise_amount", "precision", "rate", "currency", "destination", "description", "status", "hash", "created_at", "meta_data",
+		"atomic", "refund", "schedule_id", "effective_date"}
+	newer := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	older := newer.Add(-time.Hour)
+
+	mock.ExpectQuery(`SELECT id, transaction_id, .* FROM blnk.transactions WHERE \(source = \$1 OR destination = \$2\) AND status = \$3 AND reference LIKE \$4 ESCAPE '\\' AND meta_data ->> \$5 = \$6 ORDER BY created_at DESC, id DESC LIMIT \$7`).
+		WithArgs("bln_1", "bln_1", "APPLIED", `inv\_%`, "order_id", "42", 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, "txn_3", nil, "bln_1", "inv_3", 10, "1000", 100, 1, "USD", "bln_2", "", "APPLIED", "h3", newer, []byte(`{"order_id":"42"}`), false, false, nil, older).
+			AddRow(2, "txn_2", nil, "bln_2", "inv_2", 10, "1000", 100, 1, "USD", "bln_1", "", "APPLIED", "h2", older, []byte(`{"order_id":"42"}`), false, false, nil, older).
+			AddRow(1, "txn_1", nil, "bln_1", "inv_1", 10, "1000", 100, 1, "USD", "bln_2", "", "APPLIED", "h1", older, []byte(`{"order_id":"42"}`), false, false, nil, older))
+
+	page, err := ds.ListTransactions(context.Background(), model.TransactionFilter{
+		BalanceID:       "bln_1",
+		Status:          "APPLIED",
+		ReferencePrefix: "inv_",
+		MetaData:        map[string]string{"order_id": "42"},
+		Limit:           2,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, page.Transactions, 2)
+	assert.Equal(t, "txn_2", page.Transactions[1].TransactionID)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// The next page starts after the last transaction of this one
+	mock.ExpectQuery(`SELECT id, transaction_id, .* FROM blnk.transactions WHERE \(created_at, id\) < \(\$1, \$2\) ORDER BY created_at DESC, id DESC LIMIT \$3`).
+		WithArgs(older, int64(2), 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "txn_1", nil, "bln_1", "inv_1", 10, "1000", 100, 1, "USD", "bln_2", "", "APPLIED", "h1", older, []byte(`{}`), false, false, nil, older))
+
+	page, err = ds.ListTransactions(context.Background(), model.TransactionFilter{Limit: 2, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, page.Transactions, 1)
+	assert.Empty(t, page.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListTransactions_EffectiveDate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	mock.ExpectQuery(`SELECT id, transaction_id, .* FROM blnk.transactions WHERE effective_date >= \$1 AND effective_date <= \$2 ORDER BY created_at DESC, id DESC LIMIT \$3`).
+		WithArgs(from, to, 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	page, err := ds.ListTransactions(context.Background(), model.TransactionFilter{EffectiveFrom: from, EffectiveTo: to})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Transactions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListTransactions_InvalidCursor(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ds := Datasource{Conn: db}
+	_, err = ds.ListTransactions(context.Background(), model.TransactionFilter{Cursor: "not-a-cursor"})
+	assert.Error(t, err)
+}
+
+func anyArgs(n int) []driver.Value {
+	args := make([]driver.Value, n)
+	for i := range args {
+		args[i] = sqlmock.AnyArg()
+	}
+	return args
+}
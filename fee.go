@@ -0,0 +1,333 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// feeTracer is an OpenTelemetry tracer for tracking fee rules and the fees they charge.
+var (
+	feeTracer = otel.Tracer("blnk.fees")
+)
+
+// CreateFeeRule records a fee rule. From then on, every transfer the rule is scoped to is charged its fee.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - rule model.FeeRule: The rule to record.
+//
+// Returns:
+// - *model.FeeRule: A pointer to the recorded rule.
+// - error: An error if the rule is invalid or could not be recorded.
+func (l *Blnk) CreateFeeRule(ctx context.Context, rule model.FeeRule) (*model.FeeRule, error) {
+	ctx, span := feeTracer.Start(ctx, "CreateFeeRule")
+	defer span.End()
+
+	rule.Currency = strings.ToUpper(rule.Currency)
+	if err := validateFeeRule(&rule); err != nil {
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+
+	created, err := l.datasource.CreateFeeRule(ctx, rule)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.AddEvent("Fee rule created", trace.WithAttributes(attribute.String("fee_rule.id", created.RuleID)))
+	return created, nil
+}
+
+// validateFeeRule checks a fee rule charges something and can be worked out. Fixed and tiered fees, and the
+// minimum and maximum of a fee, are amounts of a currency, so their rules must be scoped to one.
+//
+// Parameters:
+// - rule *model.FeeRule: The rule to check.
+//
+// Returns:
+// - error: An error describing the first problem found.
+func validateFeeRule(rule *model.FeeRule) error {
+	if rule.FeeBalance == "" {
+		return errors.New("fee_balance is required")
+	}
+	if rule.MaxAmount > 0 && rule.MinAmount > rule.MaxAmount {
+		return errors.New("min_amount cannot be above max_amount")
+	}
+
+	switch rule.Type {
+	case model.FeeTypePercentage:
+		if rule.Percentage <= 0 || rule.Percentage > 100 {
+			return errors.New("percentage must be above 0 and at most 100")
+		}
+		if rule.MinAmount == 0 && rule.MaxAmount == 0 {
+			return nil
+		}
+	case model.FeeTypeFixed:
+		if rule.Amount <= 0 {
+			return errors.New("amount must be positive")
+		}
+	case model.FeeTypeTiered:
+		if len(rule.Tiers) == 0 {
+			return errors.New("a tiered fee needs at least one tier")
+		}
+		for i, tier := range rule.Tiers {
+			if tier.Percentage < 0 || tier.Percentage > 100 || tier.Amount < 0 {
+				return fmt.Errorf("tiers[%d]: percentage must be between 0 and 100 and amount cannot be negative", i)
+			}
+			if tier.UpTo == 0 && i != len(rule.Tiers)-1 {
+				return fmt.Errorf("tiers[%d]: only the last tier can be open-ended", i)
+			}
+			if i > 0 && tier.UpTo != 0 && tier.UpTo <= rule.Tiers[i-1].UpTo {
+				return fmt.Errorf("tiers[%d]: tiers must be ordered by up_to", i)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown fee type %s", rule.Type)
+	}
+
+	if rule.Currency == "" {
+		return errors.New("a fee with a fixed amount, tiers, min_amount or max_amount must be scoped to a currency")
+	}
+	return nil
+}
+
+// GetFeeRule retrieves a fee rule by its ID.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - ruleID string: The ID of the rule.
+//
+// Returns:
+// - *model.FeeRule: A pointer to the rule.
+// - error: An error if the rule could not be retrieved.
+func (l *Blnk) GetFeeRule(ctx context.Context, ruleID string) (*model.FeeRule, error) {
+	return l.datasource.GetFeeRule(ctx, ruleID)
+}
+
+// GetFeeRules retrieves fee rules, newest first.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - limit int: The maximum number of rules to return.
+// - offset int: The offset to start fetching rules from.
+//
+// Returns:
+// - []model.FeeRule: The rules.
+// - error: An error if the rules could not be retrieved.
+func (l *Blnk) GetFeeRules(ctx context.Context, limit, offset int) ([]model.FeeRule, error) {
+	return l.datasource.GetFeeRules(ctx, limit, offset)
+}
+
+// ActivateFeeRule makes a fee rule charge the transfers it is scoped to again.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - ruleID string: The ID of the rule.
+//
+// Returns:
+// - *model.FeeRule: A pointer to the activated rule.
+// - error: An error if the rule could not be updated.
+func (l *Blnk) ActivateFeeRule(ctx context.Context, ruleID string) (*model.FeeRule, error) {
+	return l.datasource.UpdateFeeRuleStatus(ctx, ruleID, true)
+}
+
+// DeactivateFeeRule stops a fee rule from charging new transfers. Fees already charged are left as they are.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - ruleID string: The ID of the rule.
+//
+// Returns:
+// - *model.FeeRule: A pointer to the deactivated rule.
+// - error: An error if the rule could not be updated.
+func (l *Blnk) DeactivateFeeRule(ctx context.Context, ruleID string) (*model.FeeRule, error) {
+	return l.datasource.UpdateFeeRuleStatus(ctx, ruleID, false)
+}
+
+// chargesFees reports whether fee rules apply to a transaction. Fees are charged to the single source of a transfer
+// when it is applied, so inflight transactions are charged when they are committed. Refunds, voids, transactions
+// derived from another one and transfers split across several sources or non-atomic destinations are not charged.
+//
+// Parameters:
+// - transaction *model.Transaction: The transaction to queue.
+//
+// Returns:
+// - bool: True if the transaction is charged the fees of the rules it matches.
+func chargesFees(transaction *model.Transaction) bool {
+	switch {
+	case transaction.Refund || transaction.Status == StatusVoid || len(transaction.Sources) > 0:
+		return false
+	case transaction.Status == StatusCommit:
+		return true
+	case transaction.Inflight || transaction.ParentTransaction != "":
+		return false
+	}
+	return len(transaction.Destinations) == 0 || transaction.Atomic
+}
+
+// applyFees works out the fees of the rules a transaction matches and adds them to it as fee legs. The legs move
+// each fee from the source of the transaction to the rule's fee balance and are posted in the same journal entry as
+// the transaction, so a source that cannot cover the transfer and its fees is charged neither.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction to queue.
+//
+// Returns:
+// - error: An error if the rules could not be read or a fee could not be worked out.
+func (l *Blnk) applyFees(ctx context.Context, transaction *model.Transaction) error {
+	if !chargesFees(transaction) {
+		return nil
+	}
+	ctx, span := feeTracer.Start(ctx, "ApplyFees")
+	defer span.End()
+
+	rules, err := l.datasource.GetActiveFeeRules(ctx, transaction.Currency)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	source, category, err := l.feePayer(ctx, transaction, rules)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	// Percentage fees are rounded like the currency's amounts
+	rounding := ""
+	currency, err := l.lookupCurrency(ctx, transaction.Currency)
+	if err != nil {
+		return err
+	}
+	if currency != nil {
+		rounding = currency.Rounding
+	}
+
+	transaction.Fees = nil
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Matches(transaction.Currency, source, category) {
+			continue
+		}
+		amount, err := rule.Calculate(transaction.PreciseAmount, transaction.Precision, rounding)
+		if err != nil {
+			span.RecordError(err)
+			return apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+		}
+		if amount.Sign() > 0 {
+			transaction.Fees = append(transaction.Fees, newFeeTransaction(transaction, rule, amount))
+		}
+	}
+
+	span.AddEvent("Fees applied", trace.WithAttributes(attribute.Int("fee.count", len(transaction.Fees))))
+	return nil
+}
+
+// feePayer retrieves the source balance of a transaction and the category of its identity, which the scopes of
+// fee rules are matched against. They are only looked up when a rule is scoped by them, and a source indicator
+// whose balance does not exist yet matches no ledger, metadata or category scope.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction to queue.
+// - rules []model.FeeRule: The rules that may apply.
+//
+// Returns:
+// - *model.Balance: A pointer to the source balance, or nil if no rule needs it or it does not exist yet.
+// - string: The category of the source balance's identity, if any.
+// - error: An error if the balance or identity could not be retrieved.
+func (l *Blnk) feePayer(ctx context.Context, transaction *model.Transaction, rules []model.FeeRule) (*model.Balance, string, error) {
+	needsBalance, needsCategory := false, false
+	for _, rule := range rules {
+		needsBalance = needsBalance || rule.LedgerID != "" || len(rule.MetaData) > 0 || rule.IdentityCategory != ""
+		needsCategory = needsCategory || rule.IdentityCategory != ""
+	}
+	if !needsBalance {
+		return nil, "", nil
+	}
+
+	balanceID := transaction.Source
+	if strings.HasPrefix(balanceID, "@") {
+		indicator, err := l.datasource.GetBalanceByIndicator(balanceID, transaction.Currency)
+		if err != nil {
+			var apiErr apierror.APIError
+			if errors.As(err, &apiErr) && apiErr.Code == apierror.ErrNotFound {
+				return nil, "", nil
+			}
+			return nil, "", err
+		}
+		balanceID = indicator.BalanceID
+	}
+	source, err := l.datasource.GetBalanceByID(balanceID, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if !needsCategory || source.IdentityID == "" {
+		return source, "", nil
+	}
+
+	identity, err := l.datasource.GetIdentityByID(source.IdentityID)
+	if err != nil {
+		return nil, "", err
+	}
+	return source, identity.Category, nil
+}
+
+// newFeeTransaction builds the fee leg a rule charges on a transaction.
+//
+// Parameters:
+// - transaction *model.Transaction: The transaction charged.
+// - rule *model.FeeRule: The rule charging the fee.
+// - amount *big.Int: The fee in minor units.
+//
+// Returns:
+// - *model.Transaction: A pointer to the fee leg, ready to be posted with the transaction.
+func newFeeTransaction(transaction *model.Transaction, rule *model.FeeRule, amount *big.Int) *model.Transaction {
+	fee := &model.Transaction{
+		TransactionID:     model.GenerateUUIDWithSuffix("txn"),
+		ParentTransaction: transaction.TransactionID,
+		Reference:         fmt.Sprintf("%s_fee_%s", transaction.TransactionID, rule.RuleID),
+		Source:            transaction.Source,
+		Destination:       rule.FeeBalance,
+		Currency:          transaction.Currency,
+		Precision:         transaction.Precision,
+		PreciseAmount:     amount,
+		Amount:            model.FromPreciseAmount(amount, transaction.Precision),
+		AllowOverdraft:    transaction.AllowOverdraft,
+		Status:            StatusQueued,
+		SkipBalanceUpdate: true,
+		CreatedAt:         transaction.CreatedAt,
+		EffectiveDate:     transaction.EffectiveDate,
+		Description:       fmt.Sprintf("%s on %s", rule.Name, transaction.TransactionID),
+		MetaData: map[string]interface{}{
+			"fee_rule_id": rule.RuleID,
+		},
+	}
+	fee.Hash = fee.HashTxn()
+	return fee
+}
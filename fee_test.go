@@ -0,0 +1,157 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jerry-enebeli/blnk/database/mocks"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateFeeRule(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	invalid := []model.FeeRule{
+		{Name: "No balance", Type: model.FeeTypePercentage, Percentage: 1},
+		{Name: "Too much", Type: model.FeeTypePercentage, Percentage: 150, FeeBalance: "@Fees"},
+		{Name: "No currency", Type: model.FeeTypeFixed, Amount: 1, FeeBalance: "@Fees"},
+		{Name: "Capped without currency", Type: model.FeeTypePercentage, Percentage: 1, MaxAmount: 10, FeeBalance: "@Fees"},
+		{Name: "Unordered", Type: model.FeeTypeTiered, Currency: "USD", FeeBalance: "@Fees", Tiers: []model.FeeTier{{UpTo: 100}, {UpTo: 50}}},
+		{Name: "Open middle", Type: model.FeeTypeTiered, Currency: "USD", FeeBalance: "@Fees", Tiers: []model.FeeTier{{Amount: 1}, {UpTo: 50}}},
+		{Name: "Unknown", Type: "flat", FeeBalance: "@Fees"},
+	}
+	for _, rule := range invalid {
+		_, err := blnk.CreateFeeRule(ctx, rule)
+		assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code, rule.Name)
+	}
+
+	rule := model.FeeRule{Name: "Transfer fee", Type: model.FeeTypeFixed, Amount: 1, Currency: "USD", FeeBalance: "@Fees"}
+	mockDS.On("CreateFeeRule", mock.Anything, rule).Return(&model.FeeRule{RuleID: "fee_1", Active: true}, nil)
+
+	rule.Currency = "usd"
+	created, err := blnk.CreateFeeRule(ctx, rule)
+	assert.NoError(t, err)
+	assert.Equal(t, "fee_1", created.RuleID)
+	mockDS.AssertExpectations(t)
+}
+
+func TestChargesFees(t *testing.T) {
+	assert.True(t, chargesFees(&model.Transaction{Status: StatusQueued}))
+	assert.True(t, chargesFees(&model.Transaction{Status: StatusCommit, ParentTransaction: "txn_inflight"}))
+	assert.True(t, chargesFees(&model.Transaction{Status: StatusQueued, Atomic: true, Destinations: []model.Distribution{{Identifier: "bln_1"}}}))
+	assert.False(t, chargesFees(&model.Transaction{Status: StatusInflight, Inflight: true}))
+	assert.False(t, chargesFees(&model.Transaction{Status: StatusQueued, Refund: true, ParentTransaction: "txn_1"}))
+	assert.False(t, chargesFees(&model.Transaction{Status: StatusQueued, ParentTransaction: "txn_1"}))
+	assert.False(t, chargesFees(&model.Transaction{Status: StatusQueued, Destinations: []model.Distribution{{Identifier: "bln_1"}}}))
+	assert.False(t, chargesFees(&model.Transaction{Status: StatusQueued, Atomic: true, Sources: []model.Distribution{{Identifier: "bln_1"}}}))
+}
+
+func TestApplyFees(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+
+	rules := []model.FeeRule{
+		{RuleID: "fee_1", Name: "Transfer fee", Type: model.FeeTypePercentage, Percentage: 1.5, FeeBalance: "@Fees", Active: true},
+		{RuleID: "fee_2", Name: "Merchant fee", Type: model.FeeTypeFixed, Amount: 0.3, Currency: "USD", FeeBalance: "@MerchantFees", IdentityCategory: "merchant", Active: true},
+		{RuleID: "fee_3", Name: "Premium fee", Type: model.FeeTypeFixed, Amount: 5, Currency: "USD", FeeBalance: "@Fees", LedgerID: "ldg_premium", Active: true},
+	}
+	mockDS.On("GetActiveFeeRules", mock.Anything, "USD").Return(rules, nil)
+	mockDS.On("GetBalanceByID", "bln_source", []string(nil)).Return(&model.Balance{BalanceID: "bln_source", LedgerID: "ldg_1", IdentityID: "idt_1"}, nil)
+	mockDS.On("GetIdentityByID", "idt_1").Return(&model.Identity{IdentityID: "idt_1", Category: "merchant"}, nil)
+
+	txn := &model.Transaction{TransactionID: "txn_1", Source: "bln_source", Destination: "bln_dest", Currency: "USD", Precision: 100,
+		PreciseAmount: big.NewInt(10101), Status: StatusQueued}
+	assert.NoError(t, blnk.applyFees(context.Background(), txn))
+
+	// 1.5% of 101.01 is 151.515 cents, rounded half up
+	assert.Len(t, txn.Fees, 2)
+	assert.Equal(t, "152", txn.Fees[0].PreciseAmount.String())
+	assert.Equal(t, "@Fees", txn.Fees[0].Destination)
+	assert.Equal(t, "30", txn.Fees[1].PreciseAmount.String())
+	assert.Equal(t, "@MerchantFees", txn.Fees[1].Destination)
+	for _, fee := range txn.Fees {
+		assert.Equal(t, "txn_1", fee.ParentTransaction)
+		assert.Equal(t, "bln_source", fee.Source)
+		assert.NotEmpty(t, fee.TransactionID)
+		assert.NotEmpty(t, fee.Hash)
+	}
+	assert.Equal(t, "fee_2", txn.Fees[1].MetaData["fee_rule_id"])
+	mockDS.AssertExpectations(t)
+}
+
+func TestFeePayer_SourceIndicator(t *testing.T) {
+	rules := []model.FeeRule{{RuleID: "fee_1", Type: model.FeeTypeFixed, Amount: 1, Currency: "USD", FeeBalance: "@Fees", LedgerID: "ldg_premium", Active: true}}
+	txn := &model.Transaction{TransactionID: "txn_1", Source: "@Customer", Destination: "bln_dest", Currency: "USD"}
+
+	// A source indicator without a balance yet matches no scoped rule
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	mockDS.On("GetBalanceByIndicator", "@Customer", "USD").Return(&model.Balance{}, apierror.NewAPIError(apierror.ErrNotFound, "Balance with indicator '@Customer' not found", nil))
+	source, category, err := blnk.feePayer(context.Background(), txn, rules)
+	assert.NoError(t, err)
+	assert.Nil(t, source)
+	assert.Empty(t, category)
+
+	// Any other failure is returned rather than skipping the rule
+	mockDS = new(mocks.MockDataSource)
+	blnk = &Blnk{datasource: mockDS}
+	mockDS.On("GetBalanceByIndicator", "@Customer", "USD").Return((*model.Balance)(nil), errors.New("connection refused"))
+	_, _, err = blnk.feePayer(context.Background(), txn, rules)
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestRecordTransaction_FeesFailWithTransfer(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when starting miniredis", err)
+	}
+	defer mr.Close()
+
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS, redis: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+
+	// The source covers the transfer of 100.00 but not its fee of 1.00 on top
+	mockDS.On("TransactionExistsByRef", mock.Anything, "ref_1").Return(false, nil)
+	mockDS.On("GetBalanceByIDLite", "bln_source").Return(&model.Balance{BalanceID: "bln_source", Currency: "USD", Balance: big.NewInt(10000), CreditBalance: big.NewInt(10000), DebitBalance: big.NewInt(0)}, nil)
+	mockDS.On("GetBalanceByIDLite", "bln_dest").Return(&model.Balance{BalanceID: "bln_dest", Currency: "USD", Balance: big.NewInt(0), CreditBalance: big.NewInt(0), DebitBalance: big.NewInt(0)}, nil)
+	mockDS.On("GetBalanceByIDLite", "bln_fees").Return(&model.Balance{BalanceID: "bln_fees", Currency: "USD", Balance: big.NewInt(0), CreditBalance: big.NewInt(0), DebitBalance: big.NewInt(0)}, nil)
+	mockDS.On("GetClosedPeriod", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	txn := &model.Transaction{TransactionID: "txn_1", Reference: "ref_1", Source: "bln_source", Destination: "bln_dest", Currency: "USD",
+		Precision: 100, Amount: 100, PreciseAmount: big.NewInt(10000), Status: StatusQueued}
+	rule := &model.FeeRule{RuleID: "fee_1", Name: "Transfer fee", FeeBalance: "bln_fees"}
+	txn.Fees = []*model.Transaction{newFeeTransaction(txn, rule, big.NewInt(100))}
+
+	_, err = blnk.RecordTransaction(context.Background(), txn)
+	assert.Error(t, err)
+
+	// Neither the transfer nor its fee was posted
+	mockDS.AssertNotCalled(t, "RecordJournalEntry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockDS.AssertNotCalled(t, "RecordTransaction", mock.Anything, mock.Anything)
+	mockDS.AssertNotCalled(t, "UpdateBalances", mock.Anything, mock.Anything, mock.Anything)
+}
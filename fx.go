@@ -0,0 +1,440 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/config"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/internal/notification"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fxTracer is an OpenTelemetry tracer for tracking FX rates and conversions.
+var (
+	fxTracer = otel.Tracer("blnk.fx")
+)
+
+// DefaultFXQuoteTTL is how long an FX quote can be used when no expiry is requested.
+const DefaultFXQuoteTTL = time.Minute
+
+// CreateFXRate records the rate of a currency pair from a point in time. The rate applies to transactions posted
+// from then on until a later rate of the pair takes effect, and its inverse applies to the reverse pair when that
+// pair has no rate of its own.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - base string: The currency converted from.
+// - quote string: The currency converted to.
+// - rate float64: How many units of the quote currency one unit of the base currency buys.
+// - effectiveFrom time.Time: When the rate takes effect. Defaults to now.
+// - source string: Where the rate comes from, e.g. a rate provider.
+//
+// Returns:
+// - *model.FXRate: A pointer to the recorded rate.
+// - error: An error if the rate is invalid or could not be recorded.
+func (l *Blnk) CreateFXRate(ctx context.Context, base, quote string, rate float64, effectiveFrom time.Time, source string) (*model.FXRate, error) {
+	ctx, span := fxTracer.Start(ctx, "CreateFXRate")
+	defer span.End()
+
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	if base == quote {
+		err := errors.New("base_currency and quote_currency must differ")
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+	if rate <= 0 {
+		err := errors.New("rate must be greater than zero")
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+	if effectiveFrom.IsZero() {
+		effectiveFrom = time.Now()
+	}
+
+	fxRate, err := l.datasource.CreateFXRate(ctx, model.FXRate{BaseCurrency: base, QuoteCurrency: quote, Rate: rate, EffectiveFrom: effectiveFrom, Source: source})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.AddEvent("FX rate recorded", trace.WithAttributes(
+		attribute.String("fx.rate_id", fxRate.RateID),
+		attribute.String("fx.pair", base+"/"+quote),
+	))
+	return fxRate, nil
+}
+
+// GetFXRate retrieves the rate that converts one currency to another at a point in time.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - base string: The currency converted from.
+// - quote string: The currency converted to.
+// - at time.Time: The time the rate must be in effect at.
+//
+// Returns:
+// - *model.FXRate: A pointer to the rate, inverted when only the reverse pair has one.
+// - error: An error if no rate applies or the rates could not be retrieved.
+func (l *Blnk) GetFXRate(ctx context.Context, base, quote string, at time.Time) (*model.FXRate, error) {
+	ctx, span := fxTracer.Start(ctx, "GetFXRate")
+	defer span.End()
+
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	rate, err := l.resolveFXRate(ctx, base, quote, at)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if rate == nil {
+		err := fmt.Errorf("no FX rate from %s to %s applies at %s", base, quote, at.Format(time.RFC3339))
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrNotFound, err.Error(), err)
+	}
+	return rate, nil
+}
+
+// GetFXRates retrieves recorded FX rates, latest effective first.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - base string: The currency converted from, or empty for all.
+// - quote string: The currency converted to, or empty for all.
+// - limit int: The maximum number of rates to return.
+// - offset int: The offset to start fetching rates from.
+//
+// Returns:
+// - []model.FXRate: The rates.
+// - error: An error if the rates could not be retrieved.
+func (l *Blnk) GetFXRates(ctx context.Context, base, quote string, limit, offset int) ([]model.FXRate, error) {
+	return l.datasource.GetFXRates(ctx, strings.ToUpper(base), strings.ToUpper(quote), limit, offset)
+}
+
+// CreateFXQuote quotes a customer a rate for a currency pair and locks it until the quote expires. The quoted rate is
+// the rate in effect now less a margin, and a transaction referencing the quote converts at exactly that rate.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - base string: The currency converted from.
+// - quote string: The currency converted to.
+// - margin float64: The share of the rate kept as spread, e.g. 0.01 for 1%.
+// - ttl time.Duration: How long the quote can be used. Defaults to DefaultFXQuoteTTL.
+//
+// Returns:
+// - *model.FXQuote: A pointer to the quote.
+// - error: An error if the quote is invalid, no rate of the pair applies, or it could not be recorded.
+func (l *Blnk) CreateFXQuote(ctx context.Context, base, quote string, margin float64, ttl time.Duration) (*model.FXQuote, error) {
+	ctx, span := fxTracer.Start(ctx, "CreateFXQuote")
+	defer span.End()
+
+	if margin < 0 || margin >= 1 {
+		err := errors.New("margin must be at least 0 and less than 1")
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+	if ttl <= 0 {
+		ttl = DefaultFXQuoteTTL
+	}
+
+	market, err := l.GetFXRate(ctx, base, quote, time.Now())
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	fxQuote, err := l.datasource.CreateFXQuote(ctx, model.FXQuote{
+		BaseCurrency:  market.BaseCurrency,
+		QuoteCurrency: market.QuoteCurrency,
+		Rate:          math.Round(market.Rate*(1-margin)*1e8) / 1e8,
+		MarketRate:    market.Rate,
+		RateID:        market.RateID,
+		ExpiresAt:     time.Now().Add(ttl),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.AddEvent("FX quote created", trace.WithAttributes(
+		attribute.String("fx.quote_id", fxQuote.QuoteID),
+		attribute.String("fx.pair", fxQuote.BaseCurrency+"/"+fxQuote.QuoteCurrency),
+	))
+	return fxQuote, nil
+}
+
+// GetFXQuote retrieves an FX quote by its ID.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - quoteID string: The ID of the quote.
+//
+// Returns:
+// - *model.FXQuote: A pointer to the quote.
+// - error: An error if the quote could not be retrieved.
+func (l *Blnk) GetFXQuote(ctx context.Context, quoteID string) (*model.FXQuote, error) {
+	return l.datasource.GetFXQuote(ctx, quoteID)
+}
+
+// useFXQuote uses up the FX quote a transaction references before it is queued and sets the quoted rate on it.
+// Commits, voids and refunds of the transaction are converted as it was and do not use the quote again.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction to queue.
+//
+// Returns:
+// - error: An error if the quote cannot convert the transaction, does not exist, has expired or has already been used.
+func (l *Blnk) useFXQuote(ctx context.Context, transaction *model.Transaction) error {
+	if transaction.QuoteID == "" || transaction.Refund || transaction.Status == StatusCommit || transaction.Status == StatusVoid {
+		return nil
+	}
+	if transaction.Rate != 0 {
+		err := errors.New("a rate cannot be given with an FX quote")
+		return apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+	if len(transaction.Sources) > 0 || len(transaction.Destinations) > 0 {
+		err := errors.New("an FX quote converts a single transfer, not one with multiple sources or destinations")
+		return apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+
+	quote, err := l.datasource.UseFXQuote(ctx, transaction.QuoteID, transaction.TransactionID)
+	if err != nil {
+		return err
+	}
+	if quote.BaseCurrency != transaction.Currency {
+		if releaseErr := l.datasource.ReleaseFXQuote(ctx, quote.QuoteID, transaction.TransactionID); releaseErr != nil {
+			notification.NotifyError(releaseErr)
+		}
+		err := fmt.Errorf("FX quote %s converts from %s, not %s", quote.QuoteID, quote.BaseCurrency, transaction.Currency)
+		return apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+	transaction.Rate = quote.Rate
+	return nil
+}
+
+// applyFXQuote converts a transaction at the rate of the FX quote it used.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction to convert. Its rate is set to the quoted rate.
+// - source *model.Balance: The source balance.
+// - destination *model.Balance: The destination balance.
+//
+// Returns:
+// - error: An error wrapping model.ErrCurrencyMismatch if the quote was not used by the transaction or does not
+// convert between the currencies of its balances.
+func (l *Blnk) applyFXQuote(ctx context.Context, transaction *model.Transaction, source, destination *model.Balance) error {
+	quote, err := l.datasource.GetFXQuote(ctx, transaction.QuoteID)
+	if err != nil {
+		return err
+	}
+	if quote.TransactionID != transaction.TransactionID {
+		return fmt.Errorf("%w: FX quote %s was not used by transaction %s", model.ErrCurrencyMismatch, quote.QuoteID, transaction.TransactionID)
+	}
+	if quote.BaseCurrency != source.Currency || quote.QuoteCurrency != destination.Currency {
+		return fmt.Errorf("%w: FX quote %s converts %s to %s, not %s to %s", model.ErrCurrencyMismatch, quote.QuoteID,
+			quote.BaseCurrency, quote.QuoteCurrency, source.Currency, destination.Currency)
+	}
+	return l.convertAtRate(ctx, transaction, destination, quote.Rate)
+}
+
+// resolveFXRate finds the rate of a currency pair in effect at a time, falling back to the inverse of the
+// reverse pair's rate.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - base string: The currency converted from.
+// - quote string: The currency converted to.
+// - at time.Time: The time the rate must be in effect at.
+//
+// Returns:
+// - *model.FXRate: A pointer to the rate, or nil if neither pair has a rate in effect.
+// - error: An error if the rates could not be retrieved.
+func (l *Blnk) resolveFXRate(ctx context.Context, base, quote string, at time.Time) (*model.FXRate, error) {
+	rate, err := l.datasource.GetFXRate(ctx, base, quote, at)
+	if err != nil || rate != nil {
+		return rate, err
+	}
+	reverse, err := l.datasource.GetFXRate(ctx, quote, base, at)
+	if err != nil || reverse == nil {
+		return nil, err
+	}
+	return reverse.Invert(), nil
+}
+
+// applyFXRate checks the currencies of a transaction against its balances. The transaction is in the currency of
+// its source. A transfer into a balance of another currency converts at the rate of its FX quote, the rate the caller
+// gave or, failing both, the rate in effect at the posting date. Whichever it is, the rate is between major units and is
+// scaled to the currencies' minor units before it is set on the transaction. Refunds reverse the original booking
+// as it was made and are not checked again.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction to check. Its rate is set when it is resolved here.
+// - source *model.Balance: The source balance.
+// - destination *model.Balance: The destination balance.
+//
+// Returns:
+// - error: An error wrapping model.ErrCurrencyMismatch if the currencies do not line up and no rate applies.
+func (l *Blnk) applyFXRate(ctx context.Context, transaction *model.Transaction, source, destination *model.Balance) error {
+	if transaction.Refund {
+		return nil
+	}
+	if transaction.Currency != source.Currency {
+		return fmt.Errorf("%w: transaction currency %s does not match the %s of balance %s", model.ErrCurrencyMismatch, transaction.Currency, source.Currency, source.BalanceID)
+	}
+	if transaction.QuoteID != "" {
+		return l.applyFXQuote(ctx, transaction, source, destination)
+	}
+	if source.Currency == destination.Currency {
+		if transaction.Rate != 0 && transaction.Rate != 1 {
+			return fmt.Errorf("%w: a rate of %v cannot apply between two %s balances", model.ErrCurrencyMismatch, transaction.Rate, source.Currency)
+		}
+		return nil
+	}
+	if transaction.Rate != 0 {
+		// Commits and voids carry the rate their inflight transaction was already converted at
+		if transaction.Status == StatusCommit || transaction.Status == StatusVoid {
+			return nil
+		}
+		return l.convertAtRate(ctx, transaction, destination, transaction.Rate)
+	}
+
+	rate, err := l.resolveFXRate(ctx, source.Currency, destination.Currency, transaction.PostingDate())
+	if err != nil {
+		return err
+	}
+	if rate == nil {
+		return fmt.Errorf("%w: no FX rate from %s to %s applies at %s", model.ErrCurrencyMismatch, source.Currency, destination.Currency, transaction.PostingDate().Format(time.RFC3339))
+	}
+	return l.convertAtRate(ctx, transaction, destination, rate.Rate)
+}
+
+// convertAtRate sets the rate of a transaction from a rate between major units. The transaction's amount is in
+// minor units, so the rate is scaled when the two currencies have different precisions, e.g. a USD to JPY rate
+// of 150 converts each cent into 1.5 yen.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction to convert.
+// - destination *model.Balance: The destination balance.
+// - rate float64: The rate between a major unit of the transaction's currency and the destination's currency.
+//
+// Returns:
+// - error: An error if the precision of the destination's currency could not be read.
+func (l *Blnk) convertAtRate(ctx context.Context, transaction *model.Transaction, destination *model.Balance, rate float64) error {
+	precision, err := l.currencyPrecision(ctx, destination.Currency, transaction.Precision)
+	if err != nil {
+		return err
+	}
+	transaction.Rate = model.MinorUnitRate(rate, transaction.Precision, precision)
+	return nil
+}
+
+// bookFXSpread books the spread of a cross-currency transfer converted at a rate other than the one in effect.
+// The difference between the destination amount at the market rate and the amount actually credited moves
+// between the configured FX position and FX gain/loss balances of the destination currency: to gain/loss when
+// the rate given was below the market rate, and back out of it when it was above.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The applied transaction.
+// - source *model.Balance: The source balance.
+// - destination *model.Balance: The destination balance.
+func (l *Blnk) bookFXSpread(ctx context.Context, transaction *model.Transaction, source, destination *model.Balance) {
+	ctx, span := fxTracer.Start(ctx, "BookFXSpread")
+	defer span.End()
+
+	if transaction.Status != StatusApplied || transaction.Refund || source.Currency == destination.Currency {
+		return
+	}
+	cnf, err := config.Fetch()
+	if err != nil || cnf.FX.GainLossBalance == "" || cnf.FX.PositionBalance == "" {
+		return
+	}
+
+	market, err := l.resolveFXRate(ctx, source.Currency, destination.Currency, transaction.PostingDate())
+	if err != nil {
+		span.RecordError(err)
+		notification.NotifyError(err)
+		return
+	}
+	if market == nil {
+		return
+	}
+	// The spread is booked in the destination's currency, in its minor units
+	precision, err := l.currencyPrecision(ctx, destination.Currency, transaction.Precision)
+	if err != nil {
+		span.RecordError(err)
+		notification.NotifyError(err)
+		return
+	}
+	marketRate := model.MinorUnitRate(market.Rate, transaction.Precision, precision)
+	if marketRate == transaction.Rate {
+		return
+	}
+
+	spread := new(big.Int).Sub(
+		model.ApplyRateToPreciseAmount(transaction.PreciseAmount, marketRate),
+		model.ApplyRateToPreciseAmount(transaction.PreciseAmount, transaction.Rate),
+	)
+	if spread.Sign() == 0 {
+		return
+	}
+
+	from, to := cnf.FX.PositionBalance, cnf.FX.GainLossBalance
+	if spread.Sign() < 0 {
+		from, to = to, from
+	}
+	spreadTxn := &model.Transaction{
+		ParentTransaction: transaction.TransactionID,
+		Reference:         transaction.TransactionID + "_fx_spread",
+		Source:            from,
+		Destination:       to,
+		Currency:          destination.Currency,
+		Precision:         precision,
+		PreciseAmount:     spread.Abs(spread),
+		AllowOverdraft:    true,
+		EffectiveDate:     transaction.PostingDate(),
+		Description:       fmt.Sprintf("FX spread on %s", transaction.TransactionID),
+		MetaData: map[string]interface{}{
+			"fx_rate_id":      market.RateID,
+			"fx_market_rate":  marketRate,
+			"fx_applied_rate": transaction.Rate,
+		},
+	}
+	if _, err := l.QueueTransaction(ctx, spreadTxn); err != nil {
+		span.RecordError(err)
+		notification.NotifyError(err)
+		return
+	}
+
+	span.AddEvent("FX spread booked", trace.WithAttributes(
+		attribute.String("transaction.id", transaction.TransactionID),
+		attribute.String("fx.spread", spreadTxn.PreciseAmount.String()),
+	))
+}
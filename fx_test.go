@@ -0,0 +1,186 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/database/mocks"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateFXRate(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := blnk.CreateFXRate(ctx, "usd", "USD", 1, from, "")
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+
+	_, err = blnk.CreateFXRate(ctx, "USD", "NGN", 0, from, "")
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+
+	recorded := &model.FXRate{RateID: "fxr_1", BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1550, EffectiveFrom: from}
+	mockDS.On("CreateFXRate", mock.Anything, model.FXRate{BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1550, EffectiveFrom: from, Source: "cbn"}).Return(recorded, nil)
+
+	rate, err := blnk.CreateFXRate(ctx, "usd", "ngn", 1550, from, "cbn")
+	assert.NoError(t, err)
+	assert.Equal(t, recorded, rate)
+	mockDS.AssertExpectations(t)
+}
+
+func TestGetFXRate_Inverse(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	at := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+
+	mockDS.On("GetFXRate", mock.Anything, "NGN", "USD", at).Return(nil, nil)
+	mockDS.On("GetFXRate", mock.Anything, "USD", "NGN", at).Return(&model.FXRate{RateID: "fxr_1", BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1600}, nil)
+	mockDS.On("GetFXRate", mock.Anything, "USD", "GHS", at).Return(nil, nil)
+	mockDS.On("GetFXRate", mock.Anything, "GHS", "USD", at).Return(nil, nil)
+
+	rate, err := blnk.GetFXRate(context.Background(), "NGN", "USD", at)
+	assert.NoError(t, err)
+	assert.Equal(t, "NGN", rate.BaseCurrency)
+	assert.Equal(t, "USD", rate.QuoteCurrency)
+	assert.Equal(t, 1.0/1600, rate.Rate)
+
+	_, err = blnk.GetFXRate(context.Background(), "USD", "GHS", at)
+	assert.Equal(t, apierror.ErrNotFound, err.(apierror.APIError).Code)
+}
+
+func TestApplyFXRate(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	postedAt := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+
+	usd := &model.Balance{BalanceID: "bln_usd", Currency: "USD"}
+	usd2 := &model.Balance{BalanceID: "bln_usd_2", Currency: "USD"}
+	ngn := &model.Balance{BalanceID: "bln_ngn", Currency: "NGN"}
+	ghs := &model.Balance{BalanceID: "bln_ghs", Currency: "GHS"}
+
+	mockDS.On("GetFXRate", mock.Anything, "USD", "NGN", postedAt).Return(&model.FXRate{RateID: "fxr_1", Rate: 1550}, nil)
+	mockDS.On("GetFXRate", mock.Anything, "USD", "GHS", postedAt).Return(nil, nil)
+	mockDS.On("GetFXRate", mock.Anything, "GHS", "USD", postedAt).Return(nil, nil)
+
+	// Same currency, no conversion
+	txn := &model.Transaction{Currency: "USD", EffectiveDate: postedAt}
+	assert.NoError(t, blnk.applyFXRate(ctx, txn, usd, usd2))
+
+	// The transaction is in the currency of its source
+	txn = &model.Transaction{Currency: "NGN", EffectiveDate: postedAt}
+	assert.ErrorIs(t, blnk.applyFXRate(ctx, txn, usd, usd2), model.ErrCurrencyMismatch)
+
+	// A rate cannot apply between balances of one currency
+	txn = &model.Transaction{Currency: "USD", Rate: 1.5, EffectiveDate: postedAt}
+	assert.ErrorIs(t, blnk.applyFXRate(ctx, txn, usd, usd2), model.ErrCurrencyMismatch)
+
+	// Without a rate from the caller, the rate in effect at the posting date applies
+	txn = &model.Transaction{Currency: "USD", EffectiveDate: postedAt}
+	assert.NoError(t, blnk.applyFXRate(ctx, txn, usd, ngn))
+	assert.Equal(t, 1550.0, txn.Rate)
+
+	// A rate given by the caller is used instead
+	txn = &model.Transaction{Currency: "USD", Rate: 1540, EffectiveDate: postedAt}
+	assert.NoError(t, blnk.applyFXRate(ctx, txn, usd, ngn))
+	assert.Equal(t, 1540.0, txn.Rate)
+
+	// No rate applies either way
+	txn = &model.Transaction{Currency: "USD", EffectiveDate: postedAt}
+	err := blnk.applyFXRate(ctx, txn, usd, ghs)
+	assert.ErrorIs(t, err, model.ErrCurrencyMismatch)
+	assert.ErrorContains(t, err, "no FX rate from USD to GHS")
+}
+
+func TestCreateFXQuote(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	_, err := blnk.CreateFXQuote(ctx, "USD", "NGN", 1, 0)
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+
+	mockDS.On("GetFXRate", mock.Anything, "USD", "NGN", mock.Anything).Return(&model.FXRate{RateID: "fxr_1", BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1550}, nil)
+	mockDS.On("CreateFXQuote", mock.Anything, mock.MatchedBy(func(q model.FXQuote) bool {
+		return q.Rate == 1534.5 && q.MarketRate == 1550 && q.RateID == "fxr_1" && time.Until(q.ExpiresAt) > 50*time.Second
+	})).Return(&model.FXQuote{QuoteID: "fxq_1", Rate: 1534.5}, nil)
+
+	quote, err := blnk.CreateFXQuote(ctx, "USD", "NGN", 0.01, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "fxq_1", quote.QuoteID)
+	mockDS.AssertExpectations(t)
+}
+
+func TestUseFXQuote(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	quote := &model.FXQuote{QuoteID: "fxq_1", BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1534.5, TransactionID: "txn_1"}
+	mockDS.On("UseFXQuote", ctx, "fxq_1", "txn_1").Return(quote, nil)
+
+	txn := &model.Transaction{TransactionID: "txn_1", QuoteID: "fxq_1", Currency: "USD"}
+	assert.NoError(t, blnk.useFXQuote(ctx, txn))
+	assert.Equal(t, 1534.5, txn.Rate)
+
+	// Commits of the transaction were converted when it was recorded
+	commit := &model.Transaction{TransactionID: "txn_2", QuoteID: "fxq_1", Currency: "USD", Rate: 1534.5, Status: StatusCommit}
+	assert.NoError(t, blnk.useFXQuote(ctx, commit))
+
+	// The quoted rate cannot be overridden
+	mockDS.On("GetActiveFeeRules", mock.Anything, "USD").Return([]model.FeeRule{}, nil)
+	_, err := blnk.QueueTransaction(ctx, &model.Transaction{QuoteID: "fxq_1", Currency: "USD", Rate: 1600})
+	assert.ErrorContains(t, err, "a rate cannot be given with an FX quote")
+
+	// A quote from another currency is released again
+	mockDS.On("UseFXQuote", ctx, "fxq_1", "txn_3").Return(quote, nil)
+	mockDS.On("ReleaseFXQuote", ctx, "fxq_1", "txn_3").Return(nil)
+	err = blnk.useFXQuote(ctx, &model.Transaction{TransactionID: "txn_3", QuoteID: "fxq_1", Currency: "EUR"})
+	assert.ErrorContains(t, err, "converts from USD, not EUR")
+
+	mockDS.AssertExpectations(t)
+}
+
+func TestApplyFXQuote(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	usd := &model.Balance{BalanceID: "bln_usd", Currency: "USD"}
+	ngn := &model.Balance{BalanceID: "bln_ngn", Currency: "NGN"}
+	ghs := &model.Balance{BalanceID: "bln_ghs", Currency: "GHS"}
+	mockDS.On("GetFXQuote", mock.Anything, "fxq_1").Return(&model.FXQuote{QuoteID: "fxq_1", BaseCurrency: "USD", QuoteCurrency: "NGN", Rate: 1534.5, TransactionID: "txn_1"}, nil)
+
+	// The quoted rate applies whatever the rates in effect
+	txn := &model.Transaction{TransactionID: "txn_1", QuoteID: "fxq_1", Currency: "USD"}
+	assert.NoError(t, blnk.applyFXRate(ctx, txn, usd, ngn))
+	assert.Equal(t, 1534.5, txn.Rate)
+	assert.Equal(t, 153450.0, model.ApplyRate(&model.Transaction{Amount: 100, Rate: txn.Rate}))
+
+	txn = &model.Transaction{TransactionID: "txn_1", QuoteID: "fxq_1", Currency: "USD"}
+	assert.ErrorIs(t, blnk.applyFXRate(ctx, txn, usd, ghs), model.ErrCurrencyMismatch)
+
+	txn = &model.Transaction{TransactionID: "txn_2", QuoteID: "fxq_1", Currency: "USD"}
+	assert.ErrorIs(t, blnk.applyFXRate(ctx, txn, usd, ngn), model.ErrCurrencyMismatch)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+
+	"github.com/jerry-enebeli/blnk/model"
+)
+
+// ReserveIdempotencyKey claims an idempotency key for a request, or returns the existing use of the key.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - key string: The idempotency key supplied by the client.
+// - requestHash string: A hash identifying the request the key is used for.
+//
+// Returns:
+// - *model.IdempotencyKey: The idempotency key as stored.
+// - bool: True if the key was reserved for this request.
+// - error: An error if the key could not be reserved or retrieved.
+func (l *Blnk) ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (*model.IdempotencyKey, bool, error) {
+	return l.datasource.ReserveIdempotencyKey(ctx, key, requestHash)
+}
+
+// CompleteIdempotencyKey records the response returned for a reserved idempotency key so it can be replayed.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - key string: The idempotency key.
+// - statusCode int: The HTTP status code of the response.
+// - body []byte: The response body.
+//
+// Returns:
+// - error: An error if the response could not be recorded.
+func (l *Blnk) CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, body []byte) error {
+	return l.datasource.CompleteIdempotencyKey(ctx, key, statusCode, body)
+}
+
+// ReleaseIdempotencyKey removes a reserved idempotency key whose request did not complete, so the client can retry it.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - key string: The idempotency key.
+//
+// Returns:
+// - error: An error if the key could not be removed.
+func (l *Blnk) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	return l.datasource.ReleaseIdempotencyKey(ctx, key)
+}
+
+// PurgeIdempotencyKeys removes the idempotency keys that have expired and the reservations that were abandoned,
+// so the keys table does not grow without bound.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+//
+// Returns:
+// - int64: The number of keys removed.
+// - error: An error if the keys could not be removed.
+func (l *Blnk) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	return l.datasource.PurgeIdempotencyKeys(ctx)
+}
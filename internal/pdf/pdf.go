@@ -0,0 +1,206 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// A4 landscape, in points
+	pageWidth  = 842.0
+	pageHeight = 595.0
+	margin     = 36.0
+
+	// printableWidth is the width a line can take between the left and right margins
+	printableWidth = pageWidth - 2*margin
+
+	fontBody  = "F1" // Courier, so columns padded with spaces stay aligned
+	fontTitle = "F2" // Helvetica-Bold
+)
+
+type placedLine struct {
+	font string
+	size float64
+	y    float64
+	text string
+}
+
+// Document is a text-only PDF document laid out from top to bottom, which starts a new page whenever the current
+// one is full. It only uses the standard PDF fonts, so no font has to be embedded.
+type Document struct {
+	pages [][]placedLine
+	y     float64
+}
+
+// New creates an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// Heading adds a line of bold text, wrapped onto further lines if it is wider than the page.
+func (d *Document) Heading(text string, size float64) {
+	d.add(fontTitle, size, text)
+}
+
+// Text adds a line of monospaced text, wrapped onto further lines if it is wider than the page.
+func (d *Document) Text(text string, size float64) {
+	d.add(fontBody, size, text)
+}
+
+// Space adds vertical space, in points, below the last line.
+func (d *Document) Space(points float64) {
+	d.y -= points
+}
+
+func (d *Document) add(font string, size float64, text string) {
+	leading := size * 1.3
+	for _, line := range wrap(font, size, text) {
+		if len(d.pages) == 0 || d.y-leading < margin {
+			d.pages = append(d.pages, nil)
+			d.y = pageHeight - margin
+		}
+		d.y -= leading
+		d.pages[len(d.pages)-1] = append(d.pages[len(d.pages)-1], placedLine{font: font, size: size, y: d.y, text: line})
+	}
+}
+
+// wrap splits text into lines that fit the printable width, breaking at the last space that fits or, in a word
+// longer than a line, at the last character that fits.
+func wrap(font string, size float64, text string) []string {
+	var lines []string
+	runes := []rune(text)
+	for {
+		n, width := 0, 0.0
+		for n < len(runes) {
+			width += glyphWidth(font, runes[n]) * size / 1000
+			if width > printableWidth {
+				break
+			}
+			n++
+		}
+		if n == len(runes) {
+			return append(lines, string(runes))
+		}
+
+		cut := max(n, 1)
+		for i := n; i > 0; i-- {
+			if runes[i] == ' ' && strings.TrimSpace(string(runes[:i])) != "" {
+				cut = i
+				break
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(runes[:cut]), " "))
+		for cut < len(runes) && runes[cut] == ' ' {
+			cut++
+		}
+		runes = runes[cut:]
+	}
+}
+
+// helveticaBoldWidths holds the widths of the printable ASCII characters in Helvetica-Bold, in thousandths of the
+// font size, starting at the space.
+var helveticaBoldWidths = [...]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611, // 0 to ?
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, // P to _
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, // ` to o
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584, // p to ~
+}
+
+// glyphWidth returns the width of a character in thousandths of the font size. Courier is monospaced, and
+// characters outside ASCII are taken to be as wide as most lowercase letters of Helvetica-Bold.
+func glyphWidth(font string, r rune) float64 {
+	if font == fontBody {
+		return 600
+	}
+	if r >= ' ' && int(r-' ') < len(helveticaBoldWidths) {
+		return float64(helveticaBoldWidths[r-' '])
+	}
+	return 611
+}
+
+// WriteTo writes the document in PDF format.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = [][]placedLine{nil}
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 4 are the catalog, the page tree and the two fonts, followed by a page and its content per page
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for _, page := range pages {
+		var content bytes.Buffer
+		for _, line := range page {
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", line.font, line.size, margin, line.y, escape(line.text))
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontBody, fontTitle, len(offsets)+2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// escape encodes text as the content of a PDF string. Characters outside Latin-1 cannot be shown
+// with the standard fonts and are replaced with a question mark.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
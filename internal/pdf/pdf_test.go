@@ -0,0 +1,99 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := New()
+	doc.Heading("Statement (May)", 14)
+	for i := 0; i < 100; i++ {
+		doc.Text(fmt.Sprintf("line %d", i), 9)
+	}
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	assert.NoError(t, err)
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(out, "%%EOF\n"))
+	assert.Contains(t, out, `(Statement \(May\)) Tj`)
+
+	// 100 lines do not fit on one page
+	assert.Contains(t, out, "/Count 3")
+
+	// Every xref entry points at the start of its object
+	xref := regexp.MustCompile(`(?s)xref\n0 (\d+)\n(.*?)trailer`).FindStringSubmatch(out)
+	assert.Len(t, xref, 3)
+	entries := strings.Split(strings.TrimSpace(xref[2]), "\n")[1:]
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(entry[:10])
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(out[offset:], fmt.Sprintf("%d 0 obj", i+1)), "object %d", i+1)
+	}
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	offset, _ := strconv.Atoi(startxref[1])
+	assert.True(t, strings.HasPrefix(out[offset:], "xref"))
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a\\b \(c\) caf`+"\xe9 ?", escape(`a\b (c) café €`))
+}
+
+func TestDocument_WrapsLongLines(t *testing.T) {
+	description := strings.Repeat("Payment for invoice 2024-0042 ", 12)
+	counterparty := "bln_" + strings.Repeat("0123456789abcdef", 14)
+
+	doc := New()
+	doc.Heading("Statement for balance "+counterparty, 12)
+	doc.Text(fmt.Sprintf("2024-05-01 10:00:00 txn_1 %s %s", description, counterparty), 7)
+
+	for _, page := range doc.pages {
+		for _, line := range page {
+			width := 0.0
+			for _, r := range line.text {
+				width += glyphWidth(line.font, r) * line.size / 1000
+			}
+			assert.LessOrEqual(t, width, printableWidth, line.text)
+		}
+	}
+
+	// Nothing is lost: apart from the spaces lines were broken at, the lines add up to the original text
+	var heading, body string
+	for _, line := range doc.pages[0] {
+		if line.font == fontTitle {
+			heading += line.text
+		} else {
+			body += line.text
+		}
+	}
+	noSpaces := func(text string) string { return strings.ReplaceAll(text, " ", "") }
+	assert.Greater(t, len(doc.pages[0]), 2)
+	assert.Equal(t, noSpaces("Statement for balance "+counterparty), noSpaces(heading))
+	assert.Equal(t, noSpaces(fmt.Sprintf("2024-05-01 10:00:00 txn_1 %s %s", description, counterparty)), noSpaces(body))
+}
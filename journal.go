@@ -0,0 +1,334 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	redlock "github.com/jerry-enebeli/blnk/internal/lock"
+	"github.com/jerry-enebeli/blnk/internal/notification"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// isJournalEntry reports whether a transaction should be posted as a single atomic journal entry
+// rather than being split into independently processed child transactions. A transfer charged fees
+// is posted together with its fee legs, so the transfer and its fees succeed or fail together.
+//
+// Parameters:
+// - transaction *model.Transaction: The transaction to check.
+//
+// Returns:
+// - bool: True if the transaction is atomic and has sources or destinations, or if it is charged fees.
+func isJournalEntry(transaction *model.Transaction) bool {
+	return len(transaction.Fees) > 0 || transaction.Atomic && (len(transaction.Sources) > 0 || len(transaction.Destinations) > 0)
+}
+
+// RecordJournalEntry records a multi-leg transaction as one atomic journal entry.
+// Every leg is validated and applied in memory against locked balances, then the balances, the legs and the parent
+// are persisted in a single database transaction. If any leg fails (e.g. insufficient funds) nothing is posted
+// and the error is returned for the parent as a whole. The fee legs of the transaction are posted in the same entry,
+// and a single transfer charged fees is itself the first posting of its entry.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The parent transaction with its sources or destinations.
+//
+// Returns:
+// - *model.Transaction: A pointer to the recorded parent Transaction model.
+// - error: An error if any leg could not be applied or the entry could not be persisted.
+func (l *Blnk) RecordJournalEntry(ctx context.Context, transaction *model.Transaction) (*model.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "RecordJournalEntry")
+	defer span.End()
+
+	if err := l.validateTxn(ctx, transaction); err != nil {
+		return nil, l.logAndRecordError(span, "transaction validation failed", err)
+	}
+
+	// Work on a copy so a failed attempt leaves the queued transaction untouched for retries
+	parent := *transaction
+	parent.Sources = append([]model.Distribution(nil), transaction.Sources...)
+	parent.Destinations = append([]model.Distribution(nil), transaction.Destinations...)
+
+	legs, err := parent.SplitTransaction(ctx)
+	if err != nil {
+		return nil, l.logAndRecordError(span, "failed to split journal entry", err)
+	}
+	single := len(legs) == 0
+	if single {
+		legs = []*model.Transaction{&parent}
+	}
+	for _, fee := range transaction.Fees {
+		feeLeg := *fee
+		legs = append(legs, &feeLeg)
+	}
+
+	// Resolve indicators to balance IDs before locking, so every leg locks the same keys
+	if err := l.resolveJournalBalances(ctx, &parent, legs); err != nil {
+		return nil, l.logAndRecordError(span, "failed to get journal entry balances", err)
+	}
+
+	lockers, err := l.acquireJournalLocks(ctx, legs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer func() {
+		for _, locker := range lockers {
+			l.releaseLock(ctx, locker)
+		}
+	}()
+
+	balances, err := l.applyJournalLegs(ctx, legs)
+	if err != nil {
+		return nil, l.logAndRecordError(span, "failed to apply journal entry to balances", err)
+	}
+
+	postings := legs
+	if single {
+		// The transfer was applied as the first leg, the legs left are its fees
+		parent = *legs[0]
+		legs = legs[1:]
+	} else {
+		parent.Status = StatusApplied
+		if parent.Inflight {
+			parent.Status = StatusInflight
+		}
+	}
+	parent.Fees = legs[len(legs)-len(transaction.Fees):]
+
+	if err := l.datasource.RecordJournalEntry(ctx, &parent, legs, balances); err != nil {
+		return nil, l.logAndRecordError(span, "failed to persist journal entry", err)
+	}
+
+	l.postJournalEntryActions(ctx, &parent, legs, balances)
+	l.postTransactionActions(ctx, &parent)
+	l.bookJournalFXSpreads(ctx, postings, balances)
+
+	span.AddEvent("Journal entry recorded", trace.WithAttributes(
+		attribute.String("transaction.id", parent.TransactionID),
+		attribute.Int("journal.legs", len(legs)),
+	))
+	return &parent, nil
+}
+
+// resolveJournalBalances replaces balance indicators on the parent and its legs with balance IDs,
+// creating indicator balances in the transaction currency where needed.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - parent *model.Transaction: The parent transaction.
+// - legs []*model.Transaction: The legs of the journal entry.
+//
+// Returns:
+// - error: An error if an indicator balance could not be retrieved or created.
+func (l *Blnk) resolveJournalBalances(ctx context.Context, parent *model.Transaction, legs []*model.Transaction) error {
+	resolved := make(map[string]string)
+	resolve := func(id string) (string, error) {
+		if !strings.HasPrefix(id, "@") {
+			return id, nil
+		}
+		if balanceID, ok := resolved[id]; ok {
+			return balanceID, nil
+		}
+		balance, err := l.getOrCreateBalanceByIndicator(ctx, id, parent.Currency)
+		if err != nil {
+			return "", err
+		}
+		resolved[id] = balance.BalanceID
+		return balance.BalanceID, nil
+	}
+
+	var err error
+	for _, leg := range legs {
+		if leg.Source, err = resolve(leg.Source); err != nil {
+			return err
+		}
+		if leg.Destination, err = resolve(leg.Destination); err != nil {
+			return err
+		}
+	}
+	if parent.Source, err = resolve(parent.Source); err != nil {
+		return err
+	}
+	if parent.Destination, err = resolve(parent.Destination); err != nil {
+		return err
+	}
+	return nil
+}
+
+// acquireJournalLocks locks every balance touched by the legs of a journal entry.
+// Locks are taken in sorted order so concurrent entries sharing balances cannot deadlock,
+// and any locks already held are released if one of them cannot be acquired.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - legs []*model.Transaction: The legs of the journal entry.
+//
+// Returns:
+// - []*redlock.Locker: The acquired lockers.
+// - error: An error if any of the locks could not be acquired.
+func (l *Blnk) acquireJournalLocks(ctx context.Context, legs []*model.Transaction) ([]*redlock.Locker, error) {
+	ctx, span := tracer.Start(ctx, "Acquiring Journal Locks")
+	defer span.End()
+
+	keys := journalBalanceIDs(legs)
+	lockers := make([]*redlock.Locker, 0, len(keys))
+	for _, key := range keys {
+		locker := redlock.NewLocker(l.redis, key, model.GenerateUUIDWithSuffix("loc"))
+		if err := locker.Lock(ctx, time.Minute*30); err != nil {
+			span.RecordError(err)
+			for _, held := range lockers {
+				l.releaseLock(ctx, held)
+			}
+			return nil, err
+		}
+		lockers = append(lockers, locker)
+	}
+
+	span.AddEvent("Journal locks acquired", trace.WithAttributes(attribute.Int("lock.count", len(lockers))))
+	return lockers, nil
+}
+
+// journalBalanceIDs returns the sorted, de-duplicated balance IDs used by the legs of a journal entry.
+//
+// Parameters:
+// - legs []*model.Transaction: The legs of the journal entry.
+//
+// Returns:
+// - []string: The balance IDs.
+func journalBalanceIDs(legs []*model.Transaction) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, leg := range legs {
+		for _, id := range []string{leg.Source, leg.Destination} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// applyJournalLegs loads the balances of a journal entry and applies every leg to them in order.
+// A balance shared by several legs is loaded once, so each leg sees the effect of the previous ones.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - legs []*model.Transaction: The legs to apply. Their statuses are updated to the final leg status.
+//
+// Returns:
+// - []*model.Balance: The updated balances.
+// - error: An error if a balance could not be loaded or a leg could not be applied.
+func (l *Blnk) applyJournalLegs(ctx context.Context, legs []*model.Transaction) ([]*model.Balance, error) {
+	ctx, span := tracer.Start(ctx, "Applying Journal Legs")
+	defer span.End()
+
+	ids := journalBalanceIDs(legs)
+	balances := make([]*model.Balance, 0, len(ids))
+	byID := make(map[string]*model.Balance, len(ids))
+	for _, id := range ids {
+		balance, err := l.datasource.GetBalanceByIDLite(id)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		byID[id] = balance
+		balances = append(balances, balance)
+	}
+
+	for i, leg := range legs {
+		source, destination := byID[leg.Source], byID[leg.Destination]
+		if err := source.CanDebit(); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("leg %s: %w", leg.TransactionID, err)
+		}
+		if err := destination.CanCredit(); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("leg %s: %w", leg.TransactionID, err)
+		}
+		if err := l.checkTransferRules(source, destination); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("leg %s: %w", leg.TransactionID, err)
+		}
+		if err := l.checkPeriodOpen(ctx, leg.PostingDate(), source, destination); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("leg %s: %w", leg.TransactionID, err)
+		}
+		if err := l.applyFXRate(ctx, leg, source, destination); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("leg %s: %w", leg.TransactionID, err)
+		}
+		sourceBefore, destinationBefore := balanceAmount(source), balanceAmount(destination)
+		if err := l.applyTransactionToBalances(ctx, []*model.Balance{source, destination}, leg); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("leg %s: %w", leg.TransactionID, err)
+		}
+		legs[i] = l.updateTransactionDetails(ctx, leg, source, destination)
+		setRunningBalances(legs[i], source, destination, sourceBefore, destinationBefore)
+	}
+
+	span.AddEvent("Journal legs applied", trace.WithAttributes(attribute.Int("journal.legs", len(legs))))
+	return balances, nil
+}
+
+// postJournalEntryActions queues checks of the balance monitors and queues the balances and legs of a recorded journal
+// entry for indexing.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - parent *model.Transaction: The journal entry.
+// - legs []*model.Transaction: The recorded legs.
+// - balances []*model.Balance: The updated balances.
+func (l *Blnk) postJournalEntryActions(ctx context.Context, parent *model.Transaction, legs []*model.Transaction, balances []*model.Balance) {
+	_, span := tracer.Start(ctx, "Post Journal Entry Actions")
+	defer span.End()
+
+	l.queueBalanceMonitorChecks(ctx, parent, balances...)
+
+	go func() {
+		for _, leg := range legs {
+			if err := l.queue.queueIndexData(leg.TransactionID, "transactions", leg); err != nil {
+				span.RecordError(err)
+				notification.NotifyError(err)
+			}
+		}
+	}()
+}
+
+// bookJournalFXSpreads books the FX spread of every cross-currency leg of a recorded journal entry,
+// as RecordTransaction does for a single transfer.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - legs []*model.Transaction: The recorded legs.
+// - balances []*model.Balance: The balances the legs were applied to.
+func (l *Blnk) bookJournalFXSpreads(ctx context.Context, legs []*model.Transaction, balances []*model.Balance) {
+	byID := make(map[string]*model.Balance, len(balances))
+	for _, balance := range balances {
+		byID[balance.BalanceID] = balance
+	}
+	for _, leg := range legs {
+		l.bookFXSpread(ctx, leg, byID[leg.Source], byID[leg.Destination])
+	}
+}
@@ -18,6 +18,7 @@ package blnk
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jerry-enebeli/blnk/internal/notification"
 	"github.com/jerry-enebeli/blnk/model"
@@ -86,3 +87,61 @@ func (l *Blnk) GetAllLedgers(limit, offset int) ([]model.Ledger, error) {
 func (l *Blnk) GetLedgerByID(id string) (*model.Ledger, error) {
 	return l.datasource.GetLedgerByID(id)
 }
+
+// UpdateLedgerTransferRules replaces the account type pairs a ledger lets money move between.
+// It returns the updated ledger.
+//
+// Parameters:
+// - ctx: The context for the operation.
+// - id: A string representing the ID of the ledger to update.
+// - rules: The new transfer rules. An empty list lets any account types transact.
+//
+// Returns:
+// - *model.Ledger: A pointer to the updated Ledger model.
+// - error: An error if the ledger could not be updated.
+func (l *Blnk) UpdateLedgerTransferRules(ctx context.Context, id string, rules []model.TransferRule) (*model.Ledger, error) {
+	if err := l.datasource.UpdateLedgerTransferRules(ctx, id, rules); err != nil {
+		return nil, err
+	}
+	ledger, err := l.datasource.GetLedgerByID(id)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := l.queue.queueIndexData(ledger.LedgerID, "ledgers", ledger); err != nil {
+			notification.NotifyError(err)
+		}
+	}()
+	return ledger, nil
+}
+
+// checkTransferRules returns an error if the ledgers of the source or destination balance do not allow money to
+// move between their account types. Rules only apply when both balances are in a chart of accounts.
+//
+// Parameters:
+// - source *model.Balance: The balance being debited.
+// - destination *model.Balance: The balance being credited.
+//
+// Returns:
+// - error: An error wrapping model.ErrTransferNotAllowed if a ledger forbids the transfer, or if a ledger could not be retrieved.
+func (l *Blnk) checkTransferRules(source, destination *model.Balance) error {
+	if source.AccountType == "" || destination.AccountType == "" {
+		return nil
+	}
+
+	ledgerIDs := []string{source.LedgerID}
+	if destination.LedgerID != source.LedgerID {
+		ledgerIDs = append(ledgerIDs, destination.LedgerID)
+	}
+
+	for _, ledgerID := range ledgerIDs {
+		ledger, err := l.datasource.GetLedgerByID(ledgerID)
+		if err != nil {
+			return err
+		}
+		if !ledger.AllowsTransfer(source.AccountType, destination.AccountType) {
+			return fmt.Errorf("%w: ledger %s does not allow transfers from %s to %s balances", model.ErrTransferNotAllowed, ledgerID, source.AccountType, destination.AccountType)
+		}
+	}
+	return nil
+}
@@ -64,7 +64,7 @@ func TestCreateLedger(t *testing.T) {
 
 	// Set expectations on mock
 	mock.ExpectExec("INSERT INTO blnk.ledgers").
-		WithArgs(metaDataJSON, ledger.Name, sqlmock.AnyArg()).
+		WithArgs(metaDataJSON, ledger.Name, sqlmock.AnyArg(), nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Execute the test function
@@ -92,10 +92,10 @@ func TestGetAllLedgers(t *testing.T) {
 		t.Fatalf("Error creating Blnk instance: %s", err)
 	}
 
-	rows := sqlmock.NewRows([]string{"ledger_id", "name", "created_at", "meta_data"}).
-		AddRow("ldg_1234567", "general ledger", time.Now(), `{"key":"value"}`)
+	rows := sqlmock.NewRows([]string{"ledger_id", "name", "created_at", "meta_data", "transfer_rules"}).
+		AddRow("ldg_1234567", "general ledger", time.Now(), `{"key":"value"}`, nil)
 
-	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data FROM blnk.ledgers ORDER BY created_at DESC LIMIT \\$1 OFFSET \\$2").
+	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data, transfer_rules FROM blnk.ledgers ORDER BY created_at DESC LIMIT \\$1 OFFSET \\$2").
 		WithArgs(1, 1).
 		WillReturnRows(rows)
 
@@ -121,10 +121,10 @@ func TestGetLedgerByID(t *testing.T) {
 		t.Fatalf("Error creating Blnk instance: %s", err)
 	}
 	testID := gofakeit.UUID()
-	row := sqlmock.NewRows([]string{gofakeit.UUID(), "name", "created_at", "meta_data"}).
-		AddRow(testID, "test-name", time.Now(), `{"key":"value"}`)
+	row := sqlmock.NewRows([]string{gofakeit.UUID(), "name", "created_at", "meta_data", "transfer_rules"}).
+		AddRow(testID, "test-name", time.Now(), `{"key":"value"}`, nil)
 
-	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data FROM blnk.ledgers WHERE ledger_id =").
+	mock.ExpectQuery("SELECT ledger_id, name, created_at, meta_data, transfer_rules FROM blnk.ledgers WHERE ledger_id =").
 		WithArgs(testID).
 		WillReturnRows(row)
 
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// decimalPattern matches a plain decimal number. big.Rat also parses Go number syntax such as "0x10", "0b11",
+// "1_000", fractions and exponents, none of which are amounts.
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// ParseDecimal parses a decimal string (e.g. "19.99") into an exact rational number.
+func ParseDecimal(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, errors.New("empty decimal value")
+	}
+	if !decimalPattern.MatchString(value) {
+		return nil, fmt.Errorf("invalid decimal value %s", value)
+	}
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal value %s", value)
+	}
+	return r, nil
+}
+
+// decimalFromFloat returns the exact decimal a float64 was written as, using its shortest round-trip representation.
+// 19.99 is stored as 19.989999999999998436805981327779591083526611328125, but its shortest representation is "19.99".
+func decimalFromFloat(value float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
+
+// ParseAmount parses a decimal amount and checks it can be held by a float64 Amount without losing any digit.
+func ParseAmount(value string) (float64, error) {
+	exact, err := ParseDecimal(value)
+	if err != nil {
+		return 0, err
+	}
+	amount, _ := exact.Float64()
+	if decimalFromFloat(amount).Cmp(exact) != 0 {
+		return 0, fmt.Errorf("amount %s has too many significant digits", value)
+	}
+	return amount, nil
+}
+
+// ToPreciseAmount converts an amount into minor units (amount * precision) exactly.
+// It returns an error if the amount has more decimal places than the precision allows.
+func ToPreciseAmount(amount, precision float64) (*big.Int, error) {
+	if precision == 0 {
+		precision = 1
+	}
+	precise := new(big.Rat).Mul(decimalFromFloat(amount), decimalFromFloat(precision))
+	if !precise.IsInt() {
+		return nil, fmt.Errorf("amount %s has more decimal places than precision %s allows",
+			strconv.FormatFloat(amount, 'f', -1, 64), strconv.FormatFloat(precision, 'f', -1, 64))
+	}
+	return new(big.Int).Set(precise.Num()), nil
+}
+
+// FromPreciseAmount converts minor units back into an amount. The result round-trips through ToPreciseAmount
+// as long as the amount fits in a float64 without losing significant digits.
+func FromPreciseAmount(precise *big.Int, precision float64) float64 {
+	if precise == nil {
+		return 0
+	}
+	if precision == 0 {
+		precision = 1
+	}
+	amount, _ := new(big.Rat).Quo(new(big.Rat).SetInt(precise), decimalFromFloat(precision)).Float64()
+	return amount
+}
+
+// FormatPreciseAmount formats minor units as a decimal string with as many decimal places as the precision has zeros,
+// e.g. 1999 with precision 100 as "19.99". Unlike FromPreciseAmount it never loses digits.
+func FormatPreciseAmount(precise *big.Int, precision float64) string {
+	if precise == nil {
+		precise = new(big.Int)
+	}
+	if precision == 0 {
+		precision = 1
+	}
+	decimals := 0
+	for p := precision; p >= 10; p /= 10 {
+		decimals++
+	}
+	return new(big.Rat).Quo(new(big.Rat).SetInt(precise), decimalFromFloat(precision)).FloatString(decimals)
+}
+
+// ApplyRateToPreciseAmount converts a precise amount with an exchange rate, truncating any fraction of a minor unit.
+// If no rate is provided, it defaults to 1.
+func ApplyRateToPreciseAmount(precise *big.Int, rate float64) *big.Int {
+	if rate == 0 || rate == 1 {
+		return new(big.Int).Set(precise)
+	}
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt(precise), decimalFromFloat(rate))
+	return new(big.Int).Quo(converted.Num(), converted.Denom())
+}
+
+// ParsePreciseAmount parses an amount already expressed in minor units, e.g. "1500000000000000000" wei.
+func ParsePreciseAmount(value string) (*big.Int, error) {
+	precise, ok := new(big.Int).SetString(strings.TrimSpace(value), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid precise amount %s", value)
+	}
+	return precise, nil
+}
+
+// preciseAmountOf returns the precise amount of a transaction, deriving it from Amount when it has not been set.
+func preciseAmountOf(transaction *Transaction) *big.Int {
+	if transaction.PreciseAmount != nil {
+		return transaction.PreciseAmount
+	}
+	return ApplyPrecision(transaction)
+}
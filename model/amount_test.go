@@ -0,0 +1,95 @@
+package model
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPrecision_ExactDecimal(t *testing.T) {
+	// 19.99 * 100 is 1998.9999999999998 in float64 arithmetic
+	assert.Equal(t, "1999", ApplyPrecision(&Transaction{Amount: 19.99, Precision: 100}).String())
+	assert.Equal(t, "29", ApplyPrecision(&Transaction{Amount: 0.29, Precision: 100}).String())
+	assert.Equal(t, "1005", ApplyPrecision(&Transaction{Amount: 1.005, Precision: 1000}).String())
+}
+
+func TestToPreciseAmount(t *testing.T) {
+	precise, err := ToPreciseAmount(19.99, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, "1999", precise.String())
+
+	_, err = ToPreciseAmount(19.999, 100)
+	assert.Error(t, err)
+
+	precise, err = ToPreciseAmount(42, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", precise.String())
+
+	// 18-decimal tokens overflow int64 from 9.3 units upwards
+	precise, err = ToPreciseAmount(1500000, 1e18)
+	assert.NoError(t, err)
+	assert.Equal(t, "1500000000000000000000000", precise.String())
+}
+
+func TestFromPreciseAmount_RoundTrips(t *testing.T) {
+	for _, value := range []int64{1, 29, 1999, 123456789} {
+		amount := FromPreciseAmount(big.NewInt(value), 100)
+		back, err := ToPreciseAmount(amount, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(value).String(), back.String())
+	}
+	assert.Equal(t, float64(0), FromPreciseAmount(nil, 100))
+}
+
+func TestParseAmount(t *testing.T) {
+	amount, err := ParseAmount("19.99")
+	assert.NoError(t, err)
+	assert.Equal(t, 19.99, amount)
+
+	_, err = ParseAmount("12345678901234567.89")
+	assert.Error(t, err)
+
+	_, err = ParseAmount("1e3")
+	assert.Error(t, err)
+}
+
+func TestParseDecimal_RejectsGoNumberSyntax(t *testing.T) {
+	for _, value := range []string{"0x10", "0b11", "0o17", "1_000", "1/2", "1e3", "+5", ".5", "5.", "1.2.3", "Inf", "NaN"} {
+		_, err := ParseDecimal(value)
+		assert.Error(t, err, value)
+	}
+
+	for value, want := range map[string]string{"16": "16/1", "-19.99": "-1999/100", " 0.50 ": "1/2", "007": "7/1"} {
+		r, err := ParseDecimal(value)
+		if assert.NoError(t, err, value) {
+			assert.Equal(t, want, r.String(), value)
+		}
+	}
+}
+
+func TestParsePreciseAmount(t *testing.T) {
+	precise, err := ParsePreciseAmount("1234567890123456789012345")
+	assert.NoError(t, err)
+	assert.Equal(t, "1234567890123456789012345", precise.String())
+
+	_, err = ParsePreciseAmount("12.5")
+	assert.Error(t, err)
+}
+
+func TestApplyRateToPreciseAmount(t *testing.T) {
+	assert.Equal(t, "1999", ApplyRateToPreciseAmount(big.NewInt(1999), 0).String())
+	assert.Equal(t, "2198", ApplyRateToPreciseAmount(big.NewInt(1999), 1.1).String())
+}
+
+func TestUpdateBalances_HighPrecisionAmount(t *testing.T) {
+	precise, _ := new(big.Int).SetString("25000000000000000000", 10) // 25 tokens with 18 decimals
+	source := &Balance{Balance: new(big.Int).Mul(precise, big.NewInt(2))}
+	destination := &Balance{}
+	txn := &Transaction{Amount: 25, Precision: 1e18, PreciseAmount: precise}
+
+	err := UpdateBalances(txn, source, destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "25000000000000000000", destination.Balance.String())
+	assert.Equal(t, "25000000000000000000", source.DebitBalance.String())
+}
@@ -21,6 +21,18 @@ import (
 	"time"
 )
 
+const (
+	// BalanceStatusActive is the status of a balance that accepts transactions.
+	BalanceStatusActive = "ACTIVE"
+	// BalanceStatusFrozen is the status of a balance that rejects transactions in either direction.
+	BalanceStatusFrozen = "FROZEN"
+	// BalanceStatusDebitBlocked is the status of a balance that can be credited but not debited.
+	BalanceStatusDebitBlocked = "DEBIT_BLOCKED"
+	// BalanceStatusClosed is the final status of a balance. Only a balance of zero can be closed,
+	// and it rejects transactions in either direction from then on.
+	BalanceStatusClosed = "CLOSED"
+)
+
 type Balance struct {
 	ID                    int64                  `json:"-"`
 	Balance               *big.Int               `json:"balance"`
@@ -30,12 +42,17 @@ type Balance struct {
 	InflightCreditBalance *big.Int               `json:"inflight_credit_balance"`
 	DebitBalance          *big.Int               `json:"debit_balance"`
 	InflightDebitBalance  *big.Int               `json:"inflight_debit_balance"`
+	AvailableBalance      *big.Int               `json:"available_balance"`
+	OverdraftLimit        *big.Int               `json:"overdraft_limit"`
 	CurrencyMultiplier    float64                `json:"currency_multiplier"`
 	LedgerID              string                 `json:"ledger_id"`
 	IdentityID            string                 `json:"identity_id"`
 	BalanceID             string                 `json:"balance_id"`
 	Indicator             string                 `json:"indicator,omitempty"`
 	Currency              string                 `json:"currency"`
+	Status                string                 `json:"status"`
+	AccountType           string                 `json:"account_type,omitempty"`
+	NormalSide            string                 `json:"normal_side,omitempty"`
 	Identity              *Identity              `json:"identity,omitempty"`
 	Ledger                *Ledger                `json:"ledger,omitempty"`
 	CreatedAt             time.Time              `json:"created_at"`
@@ -44,12 +61,31 @@ type Balance struct {
 }
 
 type BalanceMonitor struct {
-	MonitorID   string         `json:"monitor_id"`
-	BalanceID   string         `json:"balance_id"`
-	Description string         `json:"description,omitempty"`
-	CallBackURL string         `json:"-"`
-	CreatedAt   time.Time      `json:"created_at"`
-	Condition   AlertCondition `json:"condition"`
+	MonitorID           string          `json:"monitor_id"`
+	BalanceID           string          `json:"balance_id"`
+	Description         string          `json:"description,omitempty"`
+	CallBackURL         string          `json:"call_back_url,omitempty"`
+	CallBackSecret      string          `json:"-"`
+	MaxRetries          int             `json:"max_retries"`
+	RetryBackoffSeconds int64           `json:"retry_backoff_seconds"`
+	CreatedAt           time.Time       `json:"created_at"`
+	Condition           AlertCondition  `json:"condition"`
+	Conditions          *ConditionGroup `json:"conditions,omitempty"`
+	TriggerMode         string          `json:"trigger_mode"`
+	CooldownSeconds     int64           `json:"cooldown_seconds"`
+	LastState           bool            `json:"last_state"`
+	LastTriggeredAt     time.Time       `json:"last_triggered_at,omitempty"`
+	Actions             []MonitorAction `json:"actions,omitempty"`
+}
+
+// BalanceStatusChange records a change of a balance's status and why it was made.
+type BalanceStatusChange struct {
+	ChangeID   string    `json:"change_id"`
+	BalanceID  string    `json:"balance_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type BalanceFilter struct {
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+)
+
+const (
+	RoundingNone     = "NONE"      // Amounts must convert into whole minor units exactly
+	RoundingDown     = "DOWN"      // Towards zero
+	RoundingUp       = "UP"        // Away from zero
+	RoundingHalfUp   = "HALF_UP"   // To the nearest minor unit, halves away from zero
+	RoundingHalfEven = "HALF_EVEN" // To the nearest minor unit, halves to the even neighbour
+)
+
+// Currency is an entry of the currency registry. Precision is the number of minor units in a major unit,
+// e.g. 100 for USD, and Rounding decides how an amount falling between two minor units is converted.
+type Currency struct {
+	Code      string     `json:"code"`
+	Name      string     `json:"name,omitempty"`
+	Precision float64    `json:"precision"`
+	Rounding  string     `json:"rounding"`
+	Custom    bool       `json:"custom"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// isoMinorUnits holds the number of decimal places of ISO 4217 currencies that do not use two.
+var isoMinorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// isoTwoDecimalCurrencies lists the ISO 4217 currencies with two decimal places.
+var isoTwoDecimalCurrencies = []string{
+	"AED", "AFN", "ALL", "AMD", "AOA", "ARS", "AUD", "AWG", "AZN", "BAM", "BBD", "BDT", "BGN", "BMD", "BND", "BOB",
+	"BRL", "BSD", "BTN", "BWP", "BYN", "BZD", "CAD", "CDF", "CHF", "CNY", "COP", "CRC", "CUP", "CVE", "CZK", "DKK",
+	"DOP", "DZD", "EGP", "ERN", "ETB", "EUR", "FJD", "FKP", "GBP", "GEL", "GHS", "GIP", "GMD", "GTQ", "GYD", "HKD",
+	"HNL", "HTG", "HUF", "IDR", "ILS", "INR", "IRR", "JMD", "KES", "KGS", "KHR", "KPW", "KYD", "KZT", "LAK", "LBP",
+	"LKR", "LRD", "LSL", "MAD", "MDL", "MGA", "MKD", "MMK", "MNT", "MOP", "MRU", "MUR", "MVR", "MWK", "MXN", "MYR",
+	"MZN", "NAD", "NGN", "NIO", "NOK", "NPR", "NZD", "PAB", "PEN", "PGK", "PHP", "PKR", "PLN", "QAR", "RON", "RSD",
+	"RUB", "SAR", "SBD", "SCR", "SDG", "SEK", "SGD", "SHP", "SLE", "SOS", "SRD", "SSP", "STN", "SVC", "SYP", "SZL",
+	"THB", "TJS", "TMT", "TOP", "TRY", "TTD", "TWD", "TZS", "UAH", "USD", "UYU", "UZS", "VES", "WST", "XCD", "XCG",
+	"YER", "ZAR", "ZMW", "ZWG",
+}
+
+func init() {
+	for _, code := range isoTwoDecimalCurrencies {
+		isoMinorUnits[code] = 2
+	}
+}
+
+// IsValidRounding reports whether a rounding mode is known.
+func IsValidRounding(rounding string) bool {
+	switch rounding {
+	case RoundingNone, RoundingDown, RoundingUp, RoundingHalfUp, RoundingHalfEven:
+		return true
+	}
+	return false
+}
+
+// ISOCurrency returns the registry entry of an ISO 4217 currency. ISO currencies take no rounding by default,
+// so an amount with more decimal places than the currency has is rejected.
+func ISOCurrency(code string) (*Currency, bool) {
+	decimals, ok := isoMinorUnits[code]
+	if !ok {
+		return nil, false
+	}
+	return &Currency{Code: code, Precision: math.Pow10(decimals), Rounding: RoundingNone}, true
+}
+
+// ISOCurrencies returns the registry entries of all ISO 4217 currencies, ordered by code.
+func ISOCurrencies() []Currency {
+	currencies := make([]Currency, 0, len(isoMinorUnits))
+	for code := range isoMinorUnits {
+		currency, _ := ISOCurrency(code)
+		currencies = append(currencies, *currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies
+}
+
+// IsValidPrecision reports whether a precision is a power of ten, the only precisions a decimal currency can have.
+func IsValidPrecision(precision float64) bool {
+	if precision < 1 {
+		return false
+	}
+	for precision >= 10 {
+		precision /= 10
+	}
+	return precision == 1
+}
+
+// ToPreciseAmount converts an amount into the currency's minor units, rounding it with the currency's rounding
+// when it falls between two minor units.
+func (c *Currency) ToPreciseAmount(amount float64) (*big.Int, error) {
+	precise, err := ToPreciseAmount(amount, c.Precision)
+	if err == nil || c.Rounding == "" || c.Rounding == RoundingNone {
+		return precise, err
+	}
+	return RoundRat(new(big.Rat).Mul(decimalFromFloat(amount), decimalFromFloat(c.Precision)), c.Rounding)
+}
+
+// RoundRat rounds a fractional number of minor units to a whole one.
+func RoundRat(value *big.Rat, rounding string) (*big.Int, error) {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient, nil
+	}
+
+	// Compare twice the remainder to the denominator to tell which neighbour is nearer
+	half := new(big.Int).Abs(remainder)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(value.Denom())
+
+	awayFromZero := false
+	switch rounding {
+	case RoundingDown:
+	case RoundingUp:
+		awayFromZero = true
+	case RoundingHalfUp:
+		awayFromZero = cmp >= 0
+	case RoundingHalfEven:
+		awayFromZero = cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1)
+	default:
+		return nil, fmt.Errorf("%s cannot be expressed in whole minor units without a rounding", value.FloatString(8))
+	}
+	if awayFromZero {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return quotient, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestISOCurrency(t *testing.T) {
+	usd, ok := ISOCurrency("USD")
+	assert.True(t, ok)
+	assert.Equal(t, float64(100), usd.Precision)
+
+	jpy, _ := ISOCurrency("JPY")
+	assert.Equal(t, float64(1), jpy.Precision)
+
+	kwd, _ := ISOCurrency("KWD")
+	assert.Equal(t, float64(1000), kwd.Precision)
+
+	_, ok = ISOCurrency("BTC")
+	assert.False(t, ok)
+}
+
+func TestIsValidPrecision(t *testing.T) {
+	for _, precision := range []float64{1, 10, 100, 1e8, 1e18} {
+		assert.True(t, IsValidPrecision(precision), precision)
+	}
+	for _, precision := range []float64{0, -100, 0.1, 50, 250} {
+		assert.False(t, IsValidPrecision(precision), precision)
+	}
+}
+
+func TestRoundRat(t *testing.T) {
+	tests := []struct {
+		value    *big.Rat
+		rounding string
+		expected int64
+	}{
+		{big.NewRat(25, 10), RoundingDown, 2},
+		{big.NewRat(21, 10), RoundingUp, 3},
+		{big.NewRat(25, 10), RoundingHalfUp, 3},
+		{big.NewRat(24, 10), RoundingHalfUp, 2},
+		{big.NewRat(25, 10), RoundingHalfEven, 2},
+		{big.NewRat(35, 10), RoundingHalfEven, 4},
+		{big.NewRat(-25, 10), RoundingHalfUp, -3},
+		{big.NewRat(-25, 10), RoundingDown, -2},
+		{big.NewRat(4, 1), RoundingNone, 4},
+	}
+	for _, tt := range tests {
+		rounded, err := RoundRat(tt.value, tt.rounding)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, rounded.Int64(), "%s %s", tt.value.FloatString(2), tt.rounding)
+	}
+
+	_, err := RoundRat(big.NewRat(25, 10), RoundingNone)
+	assert.Error(t, err)
+}
+
+func TestCurrency_ToPreciseAmount(t *testing.T) {
+	usd, _ := ISOCurrency("USD")
+	_, err := usd.ToPreciseAmount(10.555)
+	assert.Error(t, err)
+
+	points := &Currency{Code: "PTS", Precision: 100, Rounding: RoundingHalfEven}
+	precise, err := points.ToPreciseAmount(10.555)
+	assert.NoError(t, err)
+	assert.Equal(t, "1056", precise.String())
+
+	precise, err = points.ToPreciseAmount(10.5)
+	assert.NoError(t, err)
+	assert.Equal(t, "1050", precise.String())
+}
+
+func TestMinorUnitRate(t *testing.T) {
+	// 150 yen to the dollar is 1.5 yen to the cent
+	assert.Equal(t, 1.5, MinorUnitRate(150, 100, 1))
+	// 0.0065 dollars to the yen is 0.65 cents to the yen
+	assert.Equal(t, 0.65, MinorUnitRate(0.0065, 1, 100))
+	assert.Equal(t, 1550.0, MinorUnitRate(1550, 100, 100))
+	assert.Equal(t, 1550.0, MinorUnitRate(1550, 0, 100))
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	FeeTypePercentage = "percentage" // A percentage of the amount
+	FeeTypeFixed      = "fixed"      // A fixed amount
+	FeeTypeTiered     = "tiered"     // A percentage and/or fixed amount that depends on the band the amount falls in
+)
+
+// FeeRule charges a fee on the transfers it is scoped to. The fee is paid by the source of the transfer into
+// FeeBalance. Scopes left empty match any transfer, so a rule without scopes applies to every transfer.
+type FeeRule struct {
+	RuleID           string                 `json:"rule_id"`
+	Name             string                 `json:"name"`
+	Type             string                 `json:"type"`
+	Percentage       float64                `json:"percentage,omitempty"` // e.g. 1.5 for 1.5%
+	Amount           float64                `json:"amount,omitempty"`     // Fixed fee in major units of Currency
+	Tiers            []FeeTier              `json:"tiers,omitempty"`
+	MinAmount        float64                `json:"min_amount,omitempty"`
+	MaxAmount        float64                `json:"max_amount,omitempty"`
+	FeeBalance       string                 `json:"fee_balance"` // Balance ID or indicator receiving the fee
+	LedgerID         string                 `json:"ledger_id,omitempty"`
+	Currency         string                 `json:"currency,omitempty"`
+	MetaData         map[string]interface{} `json:"meta_data,omitempty"` // Must all match the source balance's metadata
+	IdentityCategory string                 `json:"identity_category,omitempty"`
+	Active           bool                   `json:"active"`
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+// FeeTier is a band of a tiered fee. It covers amounts up to and including UpTo, with a zero UpTo covering
+// every amount above the previous band.
+type FeeTier struct {
+	UpTo       float64 `json:"up_to,omitempty"`
+	Percentage float64 `json:"percentage,omitempty"`
+	Amount     float64 `json:"amount,omitempty"`
+}
+
+// Matches reports whether the rule applies to a transfer in a currency from a source balance whose identity
+// is in a category. The balance is nil when the source does not exist yet, e.g. a new indicator balance.
+func (r *FeeRule) Matches(currency string, source *Balance, category string) bool {
+	if !r.Active || (r.Currency != "" && r.Currency != currency) {
+		return false
+	}
+	if r.LedgerID != "" && (source == nil || source.LedgerID != r.LedgerID) {
+		return false
+	}
+	for key, value := range r.MetaData {
+		if source == nil || source.MetaData == nil {
+			return false
+		}
+		actual, ok := source.MetaData[key]
+		if !ok || fmt.Sprint(actual) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return r.IdentityCategory == "" || r.IdentityCategory == category
+}
+
+// Calculate works out the fee the rule charges on an amount in minor units. A percentage fee falling between two
+// minor units is rounded with the currency's rounding, half up for currencies without one, and the fee is kept
+// between the rule's minimum and maximum.
+func (r *FeeRule) Calculate(amount *big.Int, precision float64, rounding string) (*big.Int, error) {
+	if rounding == "" || rounding == RoundingNone {
+		rounding = RoundingHalfUp
+	}
+
+	percentage, fixed := r.Percentage, r.Amount
+	if r.Type == FeeTypeTiered {
+		tier, err := r.tierFor(amount, precision)
+		if err != nil {
+			return nil, err
+		}
+		percentage, fixed = tier.Percentage, tier.Amount
+	} else if r.Type == FeeTypePercentage {
+		fixed = 0
+	} else {
+		percentage = 0
+	}
+
+	fee, err := ToPreciseAmount(fixed, precision)
+	if err != nil {
+		return nil, fmt.Errorf("fee rule %s: %w", r.RuleID, err)
+	}
+	if percentage != 0 {
+		share := new(big.Rat).Mul(new(big.Rat).SetInt(amount), decimalFromFloat(percentage))
+		rounded, err := RoundRat(share.Quo(share, big.NewRat(100, 1)), rounding)
+		if err != nil {
+			return nil, err
+		}
+		fee.Add(fee, rounded)
+	}
+
+	if r.MinAmount > 0 {
+		minimum, err := ToPreciseAmount(r.MinAmount, precision)
+		if err != nil {
+			return nil, fmt.Errorf("fee rule %s: %w", r.RuleID, err)
+		}
+		if fee.Cmp(minimum) < 0 {
+			fee = minimum
+		}
+	}
+	if r.MaxAmount > 0 {
+		maximum, err := ToPreciseAmount(r.MaxAmount, precision)
+		if err != nil {
+			return nil, fmt.Errorf("fee rule %s: %w", r.RuleID, err)
+		}
+		if fee.Cmp(maximum) > 0 {
+			fee = maximum
+		}
+	}
+	return fee, nil
+}
+
+// tierFor finds the band of a tiered rule an amount in minor units falls in. Bands are ordered by UpTo.
+func (r *FeeRule) tierFor(amount *big.Int, precision float64) (FeeTier, error) {
+	for _, tier := range r.Tiers {
+		if tier.UpTo == 0 {
+			return tier, nil
+		}
+		upTo, err := ToPreciseAmount(tier.UpTo, precision)
+		if err != nil {
+			return FeeTier{}, fmt.Errorf("fee rule %s: %w", r.RuleID, err)
+		}
+		if amount.Cmp(upTo) <= 0 {
+			return tier, nil
+		}
+	}
+	// Amounts above the last band are charged at the last band
+	if len(r.Tiers) == 0 {
+		return FeeTier{}, fmt.Errorf("fee rule %s has no tiers", r.RuleID)
+	}
+	return r.Tiers[len(r.Tiers)-1], nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeRule_Calculate(t *testing.T) {
+	percentage := &FeeRule{Type: FeeTypePercentage, Percentage: 1.5}
+	fee, err := percentage.Calculate(big.NewInt(10000), 100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "150", fee.String())
+
+	// 1.5% of 1.01 is 1.515 cents, rounded half up without a currency rounding
+	fee, err = percentage.Calculate(big.NewInt(101), 100, RoundingNone)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", fee.String())
+
+	fee, err = percentage.Calculate(big.NewInt(101), 100, RoundingDown)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", fee.String())
+
+	capped := &FeeRule{Type: FeeTypePercentage, Percentage: 1.5, MinAmount: 0.5, MaxAmount: 20}
+	fee, _ = capped.Calculate(big.NewInt(1000), 100, "")
+	assert.Equal(t, "50", fee.String())
+	fee, _ = capped.Calculate(big.NewInt(1000000), 100, "")
+	assert.Equal(t, "2000", fee.String())
+
+	fixed := &FeeRule{Type: FeeTypeFixed, Amount: 0.25, Percentage: 10}
+	fee, err = fixed.Calculate(big.NewInt(1000000), 100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "25", fee.String())
+}
+
+func TestFeeRule_CalculateTiered(t *testing.T) {
+	rule := &FeeRule{Type: FeeTypeTiered, Tiers: []FeeTier{
+		{UpTo: 100, Amount: 1},
+		{UpTo: 1000, Percentage: 1, Amount: 0.5},
+		{Percentage: 0.5},
+	}}
+
+	fee, err := rule.Calculate(big.NewInt(10000), 100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", fee.String())
+
+	fee, _ = rule.Calculate(big.NewInt(50000), 100, "")
+	assert.Equal(t, "550", fee.String())
+
+	fee, _ = rule.Calculate(big.NewInt(1000000), 100, "")
+	assert.Equal(t, "5000", fee.String())
+}
+
+func TestFeeRule_Matches(t *testing.T) {
+	source := &Balance{LedgerID: "ldg_1", MetaData: map[string]interface{}{"plan": "basic", "tier": float64(2)}}
+
+	assert.True(t, (&FeeRule{Active: true}).Matches("USD", nil, ""))
+	assert.False(t, (&FeeRule{}).Matches("USD", source, ""))
+	assert.False(t, (&FeeRule{Active: true, Currency: "EUR"}).Matches("USD", source, ""))
+	assert.True(t, (&FeeRule{Active: true, LedgerID: "ldg_1"}).Matches("USD", source, ""))
+	assert.False(t, (&FeeRule{Active: true, LedgerID: "ldg_1"}).Matches("USD", nil, ""))
+	assert.True(t, (&FeeRule{Active: true, MetaData: map[string]interface{}{"plan": "basic", "tier": 2}}).Matches("USD", source, ""))
+	assert.False(t, (&FeeRule{Active: true, MetaData: map[string]interface{}{"plan": "premium"}}).Matches("USD", source, ""))
+	assert.True(t, (&FeeRule{Active: true, IdentityCategory: "merchant"}).Matches("USD", source, "merchant"))
+	assert.False(t, (&FeeRule{Active: true, IdentityCategory: "merchant"}).Matches("USD", source, "individual"))
+}
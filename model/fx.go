@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+const (
+	FXQuoteStatusOpen = "OPEN"
+	FXQuoteStatusUsed = "USED"
+)
+
+// ErrCurrencyMismatch is returned when the currencies of a transaction and its balances do not line up
+// and no exchange rate applies to the transfer.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// FXRate is the rate of a currency pair from a point in time: one unit of BaseCurrency buys Rate units of QuoteCurrency.
+// A rate applies until a later rate of the same pair takes effect.
+type FXRate struct {
+	RateID        string    `json:"rate_id"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          float64   `json:"rate"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Source        string    `json:"source,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Invert returns the rate of the reverse pair.
+func (r *FXRate) Invert() *FXRate {
+	inverted := *r
+	inverted.BaseCurrency, inverted.QuoteCurrency = r.QuoteCurrency, r.BaseCurrency
+	inverted.Rate = 1 / r.Rate
+	return &inverted
+}
+
+// FXQuote locks the rate of a currency pair for a customer until it expires. A quote converts exactly one transaction,
+// which gets the quoted rate whatever the rates in effect when it is recorded.
+type FXQuote struct {
+	QuoteID       string     `json:"quote_id"`
+	BaseCurrency  string     `json:"base_currency"`
+	QuoteCurrency string     `json:"quote_currency"`
+	Rate          float64    `json:"rate"`
+	MarketRate    float64    `json:"market_rate"`
+	RateID        string     `json:"rate_id"`
+	Status        string     `json:"status"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	TransactionID string     `json:"transaction_id,omitempty"`
+	UsedAt        *time.Time `json:"used_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Expired reports whether the quote has expired at a time.
+func (q *FXQuote) Expired(at time.Time) bool {
+	return !at.Before(q.ExpiresAt)
+}
+
+// MinorUnitRate converts a rate between major units into the rate between the minor units of two currencies,
+// e.g. 150 JPY to the dollar is 1.5 yen to the cent.
+func MinorUnitRate(rate, basePrecision, quotePrecision float64) float64 {
+	if basePrecision <= 0 || quotePrecision <= 0 || basePrecision == quotePrecision {
+		return rate
+	}
+	scale := new(big.Rat).Quo(decimalFromFloat(quotePrecision), decimalFromFloat(basePrecision))
+	scaled, _ := new(big.Rat).Mul(decimalFromFloat(rate), scale).Float64()
+	return scaled
+}
@@ -17,12 +17,35 @@ package model
 
 import "time"
 
+const (
+	AccountTypeAsset     = "asset"
+	AccountTypeLiability = "liability"
+	AccountTypeEquity    = "equity"
+	AccountTypeIncome    = "income"
+	AccountTypeExpense   = "expense"
+
+	// NormalSideDebit is the normal side of balances that grow with debits, e.g. assets and expenses.
+	NormalSideDebit = "debit"
+	// NormalSideCredit is the normal side of balances that grow with credits, e.g. liabilities, equity and income.
+	NormalSideCredit = "credit"
+)
+
+// AccountTypes lists the account types of a chart of accounts.
+var AccountTypes = []string{AccountTypeAsset, AccountTypeLiability, AccountTypeEquity, AccountTypeIncome, AccountTypeExpense}
+
 type Ledger struct {
-	ID        int64                  `json:"-"`
-	LedgerID  string                 `json:"ledger_id"`
-	Name      string                 `json:"name"`
-	CreatedAt time.Time              `json:"created_at"`
-	MetaData  map[string]interface{} `json:"meta_data"`
+	ID            int64                  `json:"-"`
+	LedgerID      string                 `json:"ledger_id"`
+	Name          string                 `json:"name"`
+	TransferRules []TransferRule         `json:"transfer_rules,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	MetaData      map[string]interface{} `json:"meta_data"`
+}
+
+// TransferRule lets balances of the source account type send money to balances of the destination account type.
+type TransferRule struct {
+	SourceType      string `json:"source_type"`
+	DestinationType string `json:"destination_type"`
 }
 
 type LedgerFilter struct {
@@ -30,3 +53,39 @@ type LedgerFilter struct {
 	From time.Time `json:"from"`
 	To   time.Time `json:"to"`
 }
+
+// IsAccountType reports whether accountType is one of the account types of a chart of accounts.
+func IsAccountType(accountType string) bool {
+	for _, t := range AccountTypes {
+		if t == accountType {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultNormalSide returns the side that increases balances of an account type: debit for assets and expenses,
+// credit for liabilities, equity and income. It returns an empty side for an unknown type.
+func DefaultNormalSide(accountType string) string {
+	switch accountType {
+	case AccountTypeAsset, AccountTypeExpense:
+		return NormalSideDebit
+	case AccountTypeLiability, AccountTypeEquity, AccountTypeIncome:
+		return NormalSideCredit
+	}
+	return ""
+}
+
+// AllowsTransfer reports whether the ledger lets a balance of the source account type send money to a balance
+// of the destination account type. A ledger without rules allows every transfer.
+func (l *Ledger) AllowsTransfer(sourceType, destinationType string) bool {
+	if len(l.TransferRules) == 0 {
+		return true
+	}
+	for _, rule := range l.TransferRules {
+		if rule.SourceType == sourceType && rule.DestinationType == destinationType {
+			return true
+		}
+	}
+	return false
+}
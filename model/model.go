@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -76,29 +77,30 @@ func (balance *Balance) InitializeBalanceFields() {
 	if balance.Balance == nil {
 		balance.Balance = big.NewInt(0)
 	}
+	if balance.OverdraftLimit == nil {
+		balance.OverdraftLimit = big.NewInt(0)
+	}
 }
 
 // addCredit adds the specified amount to the credit balances (either inflight or regular).
 // inflight indicates whether the credit is inflight or not.
-func (balance *Balance) addCredit(amount int64, inflight bool) {
-	balance.InitializeBalanceFields()     // Ensure balance fields are initialized.
-	amountBigInt := Int64ToBigInt(amount) // Convert the amount to *big.Int.
+func (balance *Balance) addCredit(amount *big.Int, inflight bool) {
+	balance.InitializeBalanceFields() // Ensure balance fields are initialized.
 	if inflight {
-		balance.InflightCreditBalance.Add(balance.InflightCreditBalance, amountBigInt)
+		balance.InflightCreditBalance.Add(balance.InflightCreditBalance, amount)
 	} else {
-		balance.CreditBalance.Add(balance.CreditBalance, amountBigInt)
+		balance.CreditBalance.Add(balance.CreditBalance, amount)
 	}
 }
 
 // addDebit adds the specified amount to the debit balances (either inflight or regular).
 // inflight indicates whether the debit is inflight or not.
-func (balance *Balance) addDebit(amount int64, inflight bool) {
+func (balance *Balance) addDebit(amount *big.Int, inflight bool) {
 	balance.InitializeBalanceFields()
-	amountBigInt := Int64ToBigInt(amount)
 	if inflight {
-		balance.InflightDebitBalance.Add(balance.InflightDebitBalance, amountBigInt)
+		balance.InflightDebitBalance.Add(balance.InflightDebitBalance, amount)
 	} else {
-		balance.DebitBalance.Add(balance.DebitBalance, amountBigInt)
+		balance.DebitBalance.Add(balance.DebitBalance, amount)
 	}
 }
 
@@ -108,23 +110,32 @@ func (balance *Balance) computeBalance(inflight bool) {
 	balance.InitializeBalanceFields()
 	if inflight {
 		balance.InflightBalance.Sub(balance.InflightCreditBalance, balance.InflightDebitBalance)
-		return
+	} else {
+		balance.Balance.Sub(balance.CreditBalance, balance.DebitBalance)
 	}
-	balance.Balance.Sub(balance.CreditBalance, balance.DebitBalance)
+	balance.ComputeAvailableBalance()
+}
+
+// ComputeAvailableBalance computes the funds that can be spent from the balance, i.e. the balance minus inflight debits.
+// Inflight debits are held until they are committed or voided, so they cannot be spent again in the meantime.
+func (balance *Balance) ComputeAvailableBalance() *big.Int {
+	balance.InitializeBalanceFields()
+	balance.AvailableBalance = new(big.Int).Sub(balance.Balance, balance.InflightDebitBalance)
+	return balance.AvailableBalance
 }
 
 // canProcessTransaction checks if a transaction can be processed given the source balance.
-// It returns an error if the balance is insufficient and overdraft is not allowed.
+// The available balance (balance minus inflight debits) may go down to minus its overdraft limit (zero by default).
+// It returns an error if the available balance and overdraft limit are insufficient and overdraft is not allowed.
 func canProcessTransaction(transaction *Transaction, sourceBalance *Balance) error {
 	if transaction.AllowOverdraft {
 		// Overdraft allowed, skip balance check.
 		return nil
 	}
 
-	// Convert transaction.PreciseAmount to *big.Int for comparison.
-	transactionAmount := new(big.Int).SetInt64(transaction.PreciseAmount)
+	available := new(big.Int).Add(sourceBalance.ComputeAvailableBalance(), sourceBalance.OverdraftLimit)
 
-	if sourceBalance.Balance.Cmp(transactionAmount) < 0 {
+	if available.Cmp(preciseAmountOf(transaction)) < 0 {
 		// Insufficient funds.
 		return fmt.Errorf("insufficient funds in source balance")
 	}
@@ -132,12 +143,79 @@ func canProcessTransaction(transaction *Transaction, sourceBalance *Balance) err
 	return nil
 }
 
+// ErrBalanceUnavailable is returned when a balance's status does not allow a transaction.
+var ErrBalanceUnavailable = errors.New("balance unavailable")
+
+// ErrNothingToMove is returned when a monitor action's transaction is recorded after its balance already reached the
+// action's target.
+var ErrNothingToMove = errors.New("nothing to move")
+
+// ErrTransferNotAllowed is returned when a ledger's transfer rules do not allow a transaction between two balances.
+var ErrTransferNotAllowed = errors.New("transfer not allowed")
+
+// NormalBalance returns the balance signed by its normal side, so it is positive when the balance has grown on
+// that side. Balances are credits minus debits, so the balance of a debit-normal account is negated.
+func (balance *Balance) NormalBalance() *big.Int {
+	balance.InitializeBalanceFields()
+	return SignByNormalSide(balance.Balance, balance.NormalSide)
+}
+
+// SignByNormalSide signs an amount of credits less debits by a normal side, negating it for the debit side.
+func SignByNormalSide(amount *big.Int, normalSide string) *big.Int {
+	if normalSide == NormalSideDebit {
+		return new(big.Int).Neg(amount)
+	}
+	return new(big.Int).Set(amount)
+}
+
+// CanDebit returns an error if the balance's status does not let it be debited.
+func (balance *Balance) CanDebit() error {
+	switch balance.Status {
+	case BalanceStatusFrozen, BalanceStatusClosed:
+		return fmt.Errorf("%w: balance %s is %s", ErrBalanceUnavailable, balance.BalanceID, strings.ToLower(balance.Status))
+	case BalanceStatusDebitBlocked:
+		return fmt.Errorf("%w: balance %s is blocked from debits", ErrBalanceUnavailable, balance.BalanceID)
+	}
+	return nil
+}
+
+// CanCredit returns an error if the balance's status does not let it be credited.
+func (balance *Balance) CanCredit() error {
+	switch balance.Status {
+	case BalanceStatusFrozen, BalanceStatusClosed:
+		return fmt.Errorf("%w: balance %s is %s", ErrBalanceUnavailable, balance.BalanceID, strings.ToLower(balance.Status))
+	}
+	return nil
+}
+
+// CanChangeStatus returns an error if the balance cannot move to the given status.
+// A closed balance cannot be reopened, and only a balance of zero with nothing inflight can be closed.
+func (balance *Balance) CanChangeStatus(status string) error {
+	switch status {
+	case BalanceStatusActive, BalanceStatusFrozen, BalanceStatusDebitBlocked, BalanceStatusClosed:
+	default:
+		return fmt.Errorf("unknown balance status %q", status)
+	}
+	if balance.Status == status {
+		return fmt.Errorf("balance %s is already %s", balance.BalanceID, status)
+	}
+	if balance.Status == BalanceStatusClosed {
+		return fmt.Errorf("balance %s is closed and cannot be reopened", balance.BalanceID)
+	}
+	if status == BalanceStatusClosed {
+		balance.InitializeBalanceFields()
+		if balance.Balance.Sign() != 0 || balance.InflightCreditBalance.Sign() != 0 || balance.InflightDebitBalance.Sign() != 0 {
+			return fmt.Errorf("balance %s must be zero with nothing inflight to be closed", balance.BalanceID)
+		}
+	}
+	return nil
+}
+
 // CommitInflightDebit commits a debit from the inflight balance and adds it to the debit balance.
 // This is part of the finalization process for inflight transactions.
 func (balance *Balance) CommitInflightDebit(transaction *Transaction) {
 	balance.InitializeBalanceFields()
-	preciseAmount := ApplyPrecision(transaction)              // Apply precision to the transaction amount.
-	transactionAmount := new(big.Int).SetInt64(preciseAmount) // Convert to *big.Int.
+	transactionAmount := preciseAmountOf(transaction) // Amount to commit in minor units.
 
 	if balance.InflightDebitBalance.Cmp(transactionAmount) >= 0 {
 		// Deduct from inflight and add to regular debit balance.
@@ -151,8 +229,7 @@ func (balance *Balance) CommitInflightDebit(transaction *Transaction) {
 // CommitInflightCredit commits a credit from the inflight balance and adds it to the credit balance.
 func (balance *Balance) CommitInflightCredit(transaction *Transaction) {
 	balance.InitializeBalanceFields()
-	preciseAmount := ApplyPrecision(transaction)
-	transactionAmount := new(big.Int).SetInt64(preciseAmount)
+	transactionAmount := preciseAmountOf(transaction)
 
 	if balance.InflightCreditBalance.Cmp(transactionAmount) >= 0 {
 		// Deduct from inflight and add to regular credit balance.
@@ -182,11 +259,14 @@ func (balance *Balance) RollbackInflightDebit(amount *big.Int) {
 }
 
 // ApplyPrecision applies precision to the transaction amount by multiplying it by the transaction precision value.
-func ApplyPrecision(transaction *Transaction) int64 {
+// The multiplication is done on exact decimals, so 19.99 with a precision of 100 gives 1999 rather than 1998.
+// Any fraction of a minor unit left over is truncated; QueueTransaction rejects such amounts up front.
+func ApplyPrecision(transaction *Transaction) *big.Int {
 	if transaction.Precision == 0 {
 		transaction.Precision = 1
 	}
-	return int64(transaction.Amount * transaction.Precision)
+	precise := new(big.Rat).Mul(decimalFromFloat(transaction.Amount), decimalFromFloat(transaction.Precision))
+	return new(big.Int).Quo(precise.Num(), precise.Denom())
 }
 
 // ApplyRate applies the exchange rate to the transaction amount.
@@ -200,7 +280,7 @@ func ApplyRate(transaction *Transaction) float64 {
 
 // validate checks if the transaction is valid (e.g., ensuring positive amount).
 func (transaction *Transaction) validate() error {
-	if transaction.Amount <= 0 {
+	if preciseAmountOf(transaction).Sign() <= 0 {
 		return errors.New("transaction amount must be positive")
 	}
 	return nil
@@ -208,9 +288,9 @@ func (transaction *Transaction) validate() error {
 
 // UpdateBalances updates the balances for both the source and destination based on the transaction details.
 // It ensures precision is applied and checks for overdraft before updating.
+// A precise amount set on the transaction takes precedence over Amount, which cannot hold every digit of high-decimal assets.
 func UpdateBalances(transaction *Transaction, source, destination *Balance) error {
-	transaction.PreciseAmount = ApplyPrecision(transaction)
-	originalAmount := transaction.Amount
+	transaction.PreciseAmount = preciseAmountOf(transaction)
 	err := transaction.validate()
 	if err != nil {
 		return err
@@ -230,35 +310,21 @@ func UpdateBalances(transaction *Transaction, source, destination *Balance) erro
 	source.computeBalance(transaction.Inflight)
 
 	// Apply exchange rate to the destination if needed.
-	transaction.Amount = ApplyRate(transaction)
-	transaction.PreciseAmount = ApplyPrecision(transaction)
-	destination.addCredit(transaction.PreciseAmount, transaction.Inflight)
+	if transaction.Rate == 0 {
+		transaction.Rate = 1
+	}
+	destination.addCredit(ApplyRateToPreciseAmount(transaction.PreciseAmount, transaction.Rate), transaction.Inflight)
 	destination.computeBalance(transaction.Inflight)
-
-	// Revert the transaction amount to its original state.
-	transaction.Amount = originalAmount
-	transaction.PreciseAmount = ApplyPrecision(transaction)
 	return nil
 }
 
 // CheckCondition checks if a balance meets the condition specified by a BalanceMonitor.
-// It compares various balance fields (e.g., debit balance, credit balance) against the precise value.
+// Monitors with a condition group are checked against the group, others against their single condition.
 func (bm *BalanceMonitor) CheckCondition(b *Balance) bool {
-	switch bm.Condition.Field {
-	case "debit_balance":
-		return compare(b.DebitBalance, bm.Condition.Operator, bm.Condition.PreciseValue)
-	case "credit_balance":
-		return compare(b.CreditBalance, bm.Condition.Operator, bm.Condition.PreciseValue)
-	case "balance":
-		return compare(b.Balance, bm.Condition.Operator, bm.Condition.PreciseValue)
-	case "inflight_debit_balance":
-		return compare(b.InflightDebitBalance, bm.Condition.Operator, bm.Condition.PreciseValue)
-	case "inflight_credit_balance":
-		return compare(b.InflightCreditBalance, bm.Condition.Operator, bm.Condition.PreciseValue)
-	case "inflight_balance":
-		return compare(b.InflightBalance, bm.Condition.Operator, bm.Condition.PreciseValue)
+	if bm.Conditions != nil {
+		return bm.Conditions.Check(b)
 	}
-	return false
+	return bm.Condition.Check(b)
 }
 
 // ToInternalTransaction converts an ExternalTransaction to an InternalTransaction.
@@ -55,7 +55,7 @@ func TestBalance_AddCredit(t *testing.T) {
 	balance := &Balance{
 		CreditBalance: big.NewInt(0),
 	}
-	amount := int64(500)
+	amount := big.NewInt(500)
 	balance.addCredit(amount, false)
 	expected := big.NewInt(500)
 	assert.Equal(t, expected, balance.CreditBalance)
@@ -65,7 +65,7 @@ func TestBalance_AddDebit(t *testing.T) {
 	balance := &Balance{
 		DebitBalance: big.NewInt(0),
 	}
-	amount := int64(300)
+	amount := big.NewInt(300)
 	balance.addDebit(amount, false)
 	expected := big.NewInt(300)
 	assert.Equal(t, expected, balance.DebitBalance)
@@ -87,17 +87,69 @@ func TestCanProcessTransaction(t *testing.T) {
 		Balance: big.NewInt(500),
 	}
 	txn := &Transaction{
-		PreciseAmount: 400,
+		PreciseAmount: big.NewInt(400),
 	}
 	err := canProcessTransaction(txn, sourceBalance)
 	assert.NoError(t, err)
 
-	txn.PreciseAmount = 600
+	txn.PreciseAmount = big.NewInt(600)
 	err = canProcessTransaction(txn, sourceBalance)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "insufficient funds in source balance")
 }
 
+func TestCanProcessTransaction_OverdraftLimit(t *testing.T) {
+	// A credit card balance at -4,000.00 with a 5,000.00 limit
+	sourceBalance := &Balance{
+		Balance:        big.NewInt(-400000),
+		DebitBalance:   big.NewInt(400000),
+		OverdraftLimit: big.NewInt(500000),
+	}
+	txn := &Transaction{
+		PreciseAmount: big.NewInt(100000),
+	}
+	assert.NoError(t, canProcessTransaction(txn, sourceBalance))
+
+	txn.PreciseAmount = big.NewInt(100001)
+	assert.EqualError(t, canProcessTransaction(txn, sourceBalance), "insufficient funds in source balance")
+
+	destination := &Balance{}
+	txn.PreciseAmount = big.NewInt(100000)
+	assert.NoError(t, UpdateBalances(txn, sourceBalance, destination))
+	assert.Equal(t, "-500000", sourceBalance.Balance.String())
+}
+
+func TestCanProcessTransaction_InflightHolds(t *testing.T) {
+	source := &Balance{
+		Balance:       big.NewInt(1000),
+		CreditBalance: big.NewInt(1000),
+	}
+	destination := &Balance{}
+
+	hold := &Transaction{PreciseAmount: big.NewInt(700), Inflight: true}
+	assert.NoError(t, UpdateBalances(hold, source, destination))
+	assert.Equal(t, "1000", source.Balance.String())
+	assert.Equal(t, "300", source.AvailableBalance.String())
+
+	// The held funds cannot be spent again, whether by a normal or an inflight transaction
+	assert.EqualError(t, UpdateBalances(&Transaction{PreciseAmount: big.NewInt(400)}, source, destination), "insufficient funds in source balance")
+	assert.EqualError(t, UpdateBalances(&Transaction{PreciseAmount: big.NewInt(400), Inflight: true}, source, destination), "insufficient funds in source balance")
+	assert.NoError(t, UpdateBalances(&Transaction{PreciseAmount: big.NewInt(300)}, source, destination))
+	assert.Equal(t, "0", source.AvailableBalance.String())
+
+	// Voiding the hold releases the funds
+	source.RollbackInflightDebit(big.NewInt(700))
+	assert.Equal(t, "700", source.AvailableBalance.String())
+}
+
+func TestCheckCondition_AvailableBalance(t *testing.T) {
+	monitor := &BalanceMonitor{Condition: AlertCondition{Field: "available_balance", Operator: "<", PreciseValue: big.NewInt(500)}}
+	balance := &Balance{Balance: big.NewInt(1000), InflightDebitBalance: big.NewInt(600)}
+	assert.True(t, monitor.CheckCondition(balance))
+	balance.InflightDebitBalance = big.NewInt(400)
+	assert.False(t, monitor.CheckCondition(balance))
+}
+
 func TestBalance_CommitInflightDebit(t *testing.T) {
 	balance := &Balance{
 		InflightDebitBalance: big.NewInt(500),
@@ -161,7 +213,7 @@ func TestApplyPrecision(t *testing.T) {
 		Precision: 100,
 	}
 	preciseAmount := ApplyPrecision(txn)
-	expected := int64(12345)
+	expected := big.NewInt(12345)
 	assert.Equal(t, expected, preciseAmount)
 }
 
@@ -244,3 +296,197 @@ func TestExternalTransaction_ToInternalTransaction(t *testing.T) {
 	assert.Equal(t, extTxn.Date, intTxn.CreatedAt)
 	assert.Equal(t, extTxn.Description, intTxn.Description)
 }
+
+func TestBalanceMonitor_CheckCondition_Group(t *testing.T) {
+	balance := &Balance{Balance: big.NewInt(100), DebitBalance: big.NewInt(900), CreditBalance: big.NewInt(1000)}
+	low := AlertCondition{Field: "balance", Operator: "<", PreciseValue: big.NewInt(500)}
+	highDebit := AlertCondition{Field: "debit_balance", Operator: ">", PreciseValue: big.NewInt(1000)}
+	highCredit := AlertCondition{Field: "credit_balance", Operator: ">=", PreciseValue: big.NewInt(1000)}
+
+	monitor := &BalanceMonitor{Conditions: &ConditionGroup{Logic: ConditionLogicAnd, Conditions: []AlertCondition{low, highDebit}}}
+	assert.False(t, monitor.CheckCondition(balance))
+
+	monitor.Conditions.Logic = ConditionLogicOr
+	assert.True(t, monitor.CheckCondition(balance))
+
+	// low AND (highDebit OR highCredit)
+	monitor.Conditions = &ConditionGroup{
+		Logic:      ConditionLogicAnd,
+		Conditions: []AlertCondition{low},
+		Groups:     []ConditionGroup{{Logic: ConditionLogicOr, Conditions: []AlertCondition{highDebit, highCredit}}},
+	}
+	assert.True(t, monitor.CheckCondition(balance))
+
+	monitor.Conditions = &ConditionGroup{Logic: ConditionLogicOr}
+	assert.False(t, monitor.CheckCondition(balance))
+}
+
+func TestBalanceMonitor_ShouldTrigger(t *testing.T) {
+	now := time.Now()
+	level := &BalanceMonitor{TriggerMode: MonitorTriggerLevel}
+	edge := &BalanceMonitor{TriggerMode: MonitorTriggerEdge}
+
+	assert.False(t, level.ShouldTrigger(false, false, time.Time{}, now))
+	assert.True(t, level.ShouldTrigger(true, true, now.Add(-time.Second), now))
+	assert.True(t, edge.ShouldTrigger(true, false, time.Time{}, now))
+	assert.False(t, edge.ShouldTrigger(true, true, time.Time{}, now))
+
+	level.CooldownSeconds = 60
+	assert.False(t, level.ShouldTrigger(true, true, now.Add(-30*time.Second), now))
+	assert.True(t, level.ShouldTrigger(true, true, now.Add(-time.Minute), now))
+}
+
+func TestMonitorAction_Transaction(t *testing.T) {
+	balance := &Balance{BalanceID: "bln_1", Currency: "USD", Balance: big.NewInt(150000), InflightDebitBalance: big.NewInt(20000)}
+	event := &MonitorEvent{EventID: "mev_1", MonitorID: "mon_1", BalanceID: "bln_1", Balance: balance}
+
+	sweep := MonitorAction{Type: MonitorActionSweep, CounterpartyBalanceID: "bln_savings", Target: 1000, Precision: 100}
+	assert.NoError(t, sweep.ApplyPrecision())
+	txn := sweep.Transaction(event, balance, 0, 1)
+	if assert.NotNil(t, txn) {
+		// Only the available balance above the target is swept
+		assert.Equal(t, big.NewInt(30000), txn.PreciseAmount)
+		assert.Equal(t, "bln_1", txn.Source)
+		assert.Equal(t, "bln_savings", txn.Destination)
+		assert.Equal(t, "mev_1_0", txn.Reference)
+		assert.Equal(t, "USD", txn.Currency)
+		assert.Equal(t, "mon_1", txn.MetaData["monitor_id"])
+		assert.Equal(t, 2, MonitorActionDepth(txn))
+		n, ok := MonitorActionIndex(txn)
+		assert.True(t, ok)
+		assert.Equal(t, 0, n)
+	}
+
+	topUp := MonitorAction{Type: MonitorActionTopUp, CounterpartyBalanceID: "bln_funding", Target: 2000, Precision: 100}
+	assert.NoError(t, topUp.ApplyPrecision())
+	txn = topUp.Transaction(event, balance, 1, 0)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, big.NewInt(50000), txn.PreciseAmount)
+		assert.Equal(t, "bln_funding", txn.Source)
+		assert.Equal(t, "bln_1", txn.Destination)
+		assert.Equal(t, "mev_1_1", txn.Reference)
+	}
+
+	// Nothing to move once the balance is on the other side of the target
+	topUp.Target = 1000
+	assert.NoError(t, topUp.ApplyPrecision())
+	assert.Nil(t, topUp.Transaction(event, balance, 1, 0))
+
+	freeze := MonitorAction{Type: MonitorActionFreeze}
+	assert.NoError(t, freeze.ApplyPrecision())
+	assert.Nil(t, freeze.Transaction(event, balance, 2, 0))
+
+	// The amount comes from the balance passed in, not the copy on the event
+	event.Balance = &Balance{BalanceID: "bln_1", Currency: "USD", Balance: big.NewInt(500000)}
+	txn = sweep.Transaction(event, balance, 0, 1)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, big.NewInt(30000), txn.PreciseAmount)
+	}
+}
+
+func TestMonitorActionDepth(t *testing.T) {
+	assert.Equal(t, 0, MonitorActionDepth(nil))
+	assert.Equal(t, 0, MonitorActionDepth(&Transaction{}))
+	assert.Equal(t, 2, MonitorActionDepth(&Transaction{MetaData: map[string]interface{}{"monitor_action_depth": 2}}))
+	// Depths read back from JSON are float64
+	assert.Equal(t, 3, MonitorActionDepth(&Transaction{MetaData: map[string]interface{}{"monitor_action_depth": float64(3)}}))
+
+	_, ok := MonitorActionIndex(&Transaction{MetaData: map[string]interface{}{"monitor_action": 1}})
+	assert.False(t, ok)
+	n, ok := MonitorActionIndex(&Transaction{MetaData: map[string]interface{}{"monitor_id": "mon_1", "monitor_action": float64(1)}})
+	assert.True(t, ok)
+	assert.Equal(t, 1, n)
+}
+
+func TestBalance_CanDebitAndCredit(t *testing.T) {
+	tests := []struct {
+		status        string
+		debit, credit bool
+	}{
+		{"", true, true},
+		{BalanceStatusActive, true, true},
+		{BalanceStatusFrozen, false, false},
+		{BalanceStatusDebitBlocked, false, true},
+		{BalanceStatusClosed, false, false},
+	}
+	for _, tt := range tests {
+		balance := &Balance{BalanceID: "bln_1", Status: tt.status}
+		debitErr, creditErr := balance.CanDebit(), balance.CanCredit()
+		assert.Equal(t, tt.debit, debitErr == nil, tt.status)
+		assert.Equal(t, tt.credit, creditErr == nil, tt.status)
+		if debitErr != nil {
+			assert.ErrorIs(t, debitErr, ErrBalanceUnavailable)
+		}
+	}
+	assert.EqualError(t, (&Balance{BalanceID: "bln_1", Status: BalanceStatusDebitBlocked}).CanDebit(), "balance unavailable: balance bln_1 is blocked from debits")
+}
+
+func TestBalance_CanChangeStatus(t *testing.T) {
+	active := &Balance{BalanceID: "bln_1", Status: BalanceStatusActive, Balance: big.NewInt(0)}
+	assert.NoError(t, active.CanChangeStatus(BalanceStatusFrozen))
+	assert.NoError(t, active.CanChangeStatus(BalanceStatusDebitBlocked))
+	assert.NoError(t, active.CanChangeStatus(BalanceStatusClosed))
+	assert.Error(t, active.CanChangeStatus(BalanceStatusActive))
+	assert.Error(t, active.CanChangeStatus("DORMANT"))
+
+	funded := &Balance{BalanceID: "bln_2", Status: BalanceStatusFrozen, Balance: big.NewInt(100)}
+	assert.NoError(t, funded.CanChangeStatus(BalanceStatusActive))
+	assert.Error(t, funded.CanChangeStatus(BalanceStatusClosed))
+
+	inflight := &Balance{BalanceID: "bln_3", Status: BalanceStatusActive, InflightDebitBalance: big.NewInt(100)}
+	assert.Error(t, inflight.CanChangeStatus(BalanceStatusClosed))
+
+	closed := &Balance{BalanceID: "bln_4", Status: BalanceStatusClosed}
+	assert.EqualError(t, closed.CanChangeStatus(BalanceStatusActive), "balance bln_4 is closed and cannot be reopened")
+}
+
+func TestBalance_NormalBalance(t *testing.T) {
+	asset := &Balance{AccountType: AccountTypeAsset, NormalSide: NormalSideDebit, Balance: big.NewInt(-500)}
+	assert.Equal(t, big.NewInt(500), asset.NormalBalance())
+
+	liability := &Balance{AccountType: AccountTypeLiability, NormalSide: NormalSideCredit, Balance: big.NewInt(500)}
+	assert.Equal(t, big.NewInt(500), liability.NormalBalance())
+
+	untyped := &Balance{Balance: big.NewInt(-200)}
+	assert.Equal(t, big.NewInt(-200), untyped.NormalBalance())
+}
+
+func TestDefaultNormalSide(t *testing.T) {
+	assert.Equal(t, NormalSideDebit, DefaultNormalSide(AccountTypeAsset))
+	assert.Equal(t, NormalSideDebit, DefaultNormalSide(AccountTypeExpense))
+	assert.Equal(t, NormalSideCredit, DefaultNormalSide(AccountTypeLiability))
+	assert.Equal(t, NormalSideCredit, DefaultNormalSide(AccountTypeEquity))
+	assert.Equal(t, NormalSideCredit, DefaultNormalSide(AccountTypeIncome))
+	assert.Equal(t, "", DefaultNormalSide("revenue"))
+}
+
+func TestLedger_AllowsTransfer(t *testing.T) {
+	open := &Ledger{}
+	assert.True(t, open.AllowsTransfer(AccountTypeIncome, AccountTypeExpense))
+
+	restricted := &Ledger{TransferRules: []TransferRule{
+		{SourceType: AccountTypeLiability, DestinationType: AccountTypeLiability},
+		{SourceType: AccountTypeAsset, DestinationType: AccountTypeLiability},
+	}}
+	assert.True(t, restricted.AllowsTransfer(AccountTypeLiability, AccountTypeLiability))
+	assert.True(t, restricted.AllowsTransfer(AccountTypeAsset, AccountTypeLiability))
+	assert.False(t, restricted.AllowsTransfer(AccountTypeLiability, AccountTypeAsset))
+	assert.False(t, restricted.AllowsTransfer(AccountTypeIncome, AccountTypeExpense))
+}
+
+func TestAccountingPeriod_Contains(t *testing.T) {
+	start := time.Date(2026, 8, 31, 23, 59, 59, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+	period := &AccountingPeriod{StartDate: start, EndDate: end}
+
+	assert.False(t, period.Contains(start))
+	assert.True(t, period.Contains(start.Add(time.Second)))
+	assert.True(t, period.Contains(end))
+	assert.False(t, period.Contains(end.Add(time.Second)))
+}
+
+func TestTransaction_PostingDate(t *testing.T) {
+	createdAt := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, createdAt, (&Transaction{CreatedAt: createdAt}).PostingDate())
+	assert.WithinDuration(t, time.Now(), (&Transaction{}).PostingDate(), time.Second)
+}
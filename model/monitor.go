@@ -0,0 +1,270 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// MonitorTriggerLevel fires a monitor on every balance update while its condition holds.
+	MonitorTriggerLevel = "level"
+	// MonitorTriggerEdge fires a monitor only when its condition goes from false to true.
+	MonitorTriggerEdge = "edge"
+
+	ConditionLogicAnd = "AND"
+	ConditionLogicOr  = "OR"
+
+	// DefaultMonitorMaxRetries is how many times a failed callback delivery is retried by default.
+	DefaultMonitorMaxRetries = 5
+	// DefaultMonitorRetryBackoffSeconds is the delay before the first retry of a failed callback delivery by default.
+	// The delay doubles on every further retry.
+	DefaultMonitorRetryBackoffSeconds = 30
+
+	// MonitorActionSweep moves whatever the balance holds above the target to the counterparty balance.
+	MonitorActionSweep = "sweep"
+	// MonitorActionTopUp moves whatever the balance is short of the target from the counterparty balance.
+	MonitorActionTopUp = "top_up"
+	// MonitorActionFreeze freezes the balance so it rejects further transactions.
+	MonitorActionFreeze = "freeze"
+
+	MonitorActionQueued  = "queued"
+	MonitorActionApplied = "applied"
+	MonitorActionSkipped = "skipped"
+	MonitorActionFailed  = "failed"
+
+	// MaxMonitorActionDepth is how many monitor actions can be chained off one another before further actions
+	// are skipped. A transaction queued by a monitor action has a depth one more than the transaction that fired it.
+	MaxMonitorActionDepth = 3
+)
+
+// MonitorAction is something a monitor does on its own when it fires, besides sending an alert.
+type MonitorAction struct {
+	Type                  string   `json:"type"`
+	CounterpartyBalanceID string   `json:"counterparty_balance_id,omitempty"`
+	Target                float64  `json:"target,omitempty"`
+	Precision             float64  `json:"precision,omitempty"`
+	PreciseTarget         *big.Int `json:"precise_target,omitempty"`
+	Description           string   `json:"description,omitempty"`
+}
+
+// MonitorActionResult records what came of one action of a monitor event.
+type MonitorActionResult struct {
+	Type          string   `json:"type"`
+	Status        string   `json:"status"`
+	TransactionID string   `json:"transaction_id,omitempty"`
+	Reference     string   `json:"reference,omitempty"`
+	PreciseAmount *big.Int `json:"precise_amount,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+}
+
+// ConditionGroup combines conditions and nested groups with AND or OR logic.
+type ConditionGroup struct {
+	Logic      string           `json:"logic"`
+	Conditions []AlertCondition `json:"conditions,omitempty"`
+	Groups     []ConditionGroup `json:"groups,omitempty"`
+}
+
+// MonitorEvent records a balance monitor firing, with the balance and the transaction that triggered it.
+type MonitorEvent struct {
+	EventID       string                `json:"event_id"`
+	MonitorID     string                `json:"monitor_id"`
+	BalanceID     string                `json:"balance_id"`
+	TransactionID string                `json:"transaction_id,omitempty"`
+	Balance       *Balance              `json:"balance"`
+	TriggeredAt   time.Time             `json:"triggered_at"`
+	Actions       []MonitorActionResult `json:"actions,omitempty"`
+}
+
+// Check reports whether a balance meets the condition. Unknown fields never match.
+func (c *AlertCondition) Check(b *Balance) bool {
+	switch c.Field {
+	case "debit_balance":
+		return compare(b.DebitBalance, c.Operator, c.PreciseValue)
+	case "credit_balance":
+		return compare(b.CreditBalance, c.Operator, c.PreciseValue)
+	case "balance":
+		return compare(b.Balance, c.Operator, c.PreciseValue)
+	case "inflight_debit_balance":
+		return compare(b.InflightDebitBalance, c.Operator, c.PreciseValue)
+	case "inflight_credit_balance":
+		return compare(b.InflightCreditBalance, c.Operator, c.PreciseValue)
+	case "inflight_balance":
+		return compare(b.InflightBalance, c.Operator, c.PreciseValue)
+	case "available_balance":
+		return compare(b.ComputeAvailableBalance(), c.Operator, c.PreciseValue)
+	}
+	return false
+}
+
+// Check reports whether a balance meets the group. An AND group needs every condition and group to match,
+// an OR group needs at least one. An empty group never matches.
+func (g *ConditionGroup) Check(b *Balance) bool {
+	if len(g.Conditions) == 0 && len(g.Groups) == 0 {
+		return false
+	}
+	// An OR group stops at the first match and an AND group at the first mismatch
+	matchAny := g.Logic == ConditionLogicOr
+	for i := range g.Conditions {
+		if g.Conditions[i].Check(b) == matchAny {
+			return matchAny
+		}
+	}
+	for i := range g.Groups {
+		if g.Groups[i].Check(b) == matchAny {
+			return matchAny
+		}
+	}
+	return !matchAny
+}
+
+// ApplyPrecision sets the precise value of every condition in the group from its value and precision.
+func (g *ConditionGroup) ApplyPrecision() error {
+	for i := range g.Conditions {
+		amount, err := ToPreciseAmount(g.Conditions[i].Value, g.Conditions[i].Precision)
+		if err != nil {
+			return err
+		}
+		g.Conditions[i].PreciseValue = amount
+	}
+	for i := range g.Groups {
+		if err := g.Groups[i].ApplyPrecision(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ShouldTrigger decides whether a monitor fires given whether its condition is met now and the state it had
+// after the previous evaluation. Level monitors fire whenever the condition is met, edge monitors only when it
+// was not met before, and neither fires again within its cooldown of the last time it fired.
+func (bm *BalanceMonitor) ShouldTrigger(met, previouslyMet bool, lastTriggeredAt, now time.Time) bool {
+	if !met {
+		return false
+	}
+	if bm.TriggerMode == MonitorTriggerEdge && previouslyMet {
+		return false
+	}
+	if bm.CooldownSeconds > 0 && !lastTriggeredAt.IsZero() && now.Before(lastTriggeredAt.Add(time.Duration(bm.CooldownSeconds)*time.Second)) {
+		return false
+	}
+	return true
+}
+
+// ApplyPrecision sets the precise target of the action from its target and precision.
+func (a *MonitorAction) ApplyPrecision() error {
+	if a.Type == MonitorActionFreeze {
+		return nil
+	}
+	target, err := ToPreciseAmount(a.Target, a.Precision)
+	if err != nil {
+		return err
+	}
+	a.PreciseTarget = target
+	return nil
+}
+
+// Amount returns how much a sweep or top-up moves to bring the balance back to the target. A sweep only moves
+// the available balance, so funds held by inflight debits stay put. It returns nil when the balance is already
+// at the target or on the other side of it, and for a freeze.
+func (a *MonitorAction) Amount(b *Balance) *big.Int {
+	if a.PreciseTarget == nil || b == nil {
+		return nil
+	}
+	var amount *big.Int
+	switch a.Type {
+	case MonitorActionSweep:
+		amount = new(big.Int).Sub(b.ComputeAvailableBalance(), a.PreciseTarget)
+	case MonitorActionTopUp:
+		b.InitializeBalanceFields()
+		amount = new(big.Int).Sub(a.PreciseTarget, b.Balance)
+	default:
+		return nil
+	}
+	if amount.Sign() <= 0 {
+		return nil
+	}
+	return amount
+}
+
+// Transaction builds the transaction for the nth action of a monitor event from the balance as it is now, or
+// returns nil when the action has nothing to move. The reference is derived from the event ID and the action's
+// position, so an action queued twice is rejected as a duplicate, and the transaction links back to the monitor,
+// its event and the action, so its amount can be worked out again when it is recorded.
+func (a *MonitorAction) Transaction(event *MonitorEvent, balance *Balance, n int, depth int) *Transaction {
+	amount := a.Amount(balance)
+	if amount == nil {
+		return nil
+	}
+	txn := &Transaction{
+		Source:        event.BalanceID,
+		Destination:   a.CounterpartyBalanceID,
+		Reference:     fmt.Sprintf("%s_%d", event.EventID, n),
+		PreciseAmount: amount,
+		Precision:     a.Precision,
+		Currency:      balance.Currency,
+		Description:   a.Description,
+		MetaData: map[string]interface{}{
+			"monitor_id":           event.MonitorID,
+			"monitor_event_id":     event.EventID,
+			"monitor_action":       n,
+			"monitor_action_depth": depth + 1,
+		},
+	}
+	if a.Type == MonitorActionTopUp {
+		txn.Source, txn.Destination = a.CounterpartyBalanceID, event.BalanceID
+	}
+	return txn
+}
+
+// MonitorActionIndex returns the position of the monitor action that queued a transaction, and false when it was
+// not queued by a monitor action. The position may have been decoded from JSON, so any number type is accepted.
+func MonitorActionIndex(txn *Transaction) (int, bool) {
+	if txn == nil {
+		return 0, false
+	}
+	if _, ok := txn.MetaData["monitor_id"].(string); !ok {
+		return 0, false
+	}
+	switch n := txn.MetaData["monitor_action"].(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// MonitorActionDepth returns how many monitor actions a transaction was chained through, 0 when it was not
+// queued by a monitor action. The depth may have been decoded from JSON, so any number type is accepted.
+func MonitorActionDepth(txn *Transaction) int {
+	if txn == nil {
+		return 0
+	}
+	switch depth := txn.MetaData["monitor_action_depth"].(type) {
+	case int:
+		return depth
+	case int64:
+		return int(depth)
+	case float64:
+		return int(depth)
+	}
+	return 0
+}
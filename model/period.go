@@ -0,0 +1,62 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+const (
+	PeriodStatusClosed   = "CLOSED"
+	PeriodStatusReopened = "REOPENED"
+)
+
+// ErrPeriodClosed is returned when a transaction falls in a closed accounting period of one of its ledgers.
+var ErrPeriodClosed = errors.New("accounting period closed")
+
+// AccountingPeriod is a closed span of a ledger's books. A transaction belongs to the period when its posting date
+// is after StartDate and up to EndDate, the same bounds a trial balance for the period uses.
+type AccountingPeriod struct {
+	PeriodID     string     `json:"period_id"`
+	LedgerID     string     `json:"ledger_id"`
+	Name         string     `json:"name"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      time.Time  `json:"end_date"`
+	Status       string     `json:"status"`
+	ClosedAt     time.Time  `json:"closed_at"`
+	ReopenedAt   *time.Time `json:"reopened_at,omitempty"`
+	ReopenReason string     `json:"reopen_reason,omitempty"`
+}
+
+// Contains reports whether a posting date falls in the period.
+func (p *AccountingPeriod) Contains(date time.Time) bool {
+	return date.After(p.StartDate) && !date.After(p.EndDate)
+}
+
+// PeriodBalance is a balance as it stood at the end of a closed period. Amounts are in the balance's minor units.
+type PeriodBalance struct {
+	PeriodID      string   `json:"period_id"`
+	BalanceID     string   `json:"balance_id"`
+	Currency      string   `json:"currency"`
+	Precision     float64  `json:"precision"`
+	AccountType   string   `json:"account_type,omitempty"`
+	NormalSide    string   `json:"normal_side,omitempty"`
+	Balance       *big.Int `json:"balance"`
+	CreditBalance *big.Int `json:"credit_balance"`
+	DebitBalance  *big.Int `json:"debit_balance"`
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"math/big"
+	"time"
+)
+
+// BalanceActivity is what was posted to a balance of a ledger up to the start of a period and during it.
+// Amounts are in the balance's minor units.
+type BalanceActivity struct {
+	BalanceID      string
+	Indicator      string
+	Currency       string
+	Precision      float64
+	AccountType    string
+	NormalSide     string
+	OpeningCredits *big.Int
+	OpeningDebits  *big.Int
+	Credits        *big.Int
+	Debits         *big.Int
+}
+
+// OpeningBalance returns the balance at the start of the period.
+func (a *BalanceActivity) OpeningBalance() *big.Int {
+	return new(big.Int).Sub(a.OpeningCredits, a.OpeningDebits)
+}
+
+// ClosingBalance returns the balance at the end of the period.
+func (a *BalanceActivity) ClosingBalance() *big.Int {
+	closing := a.OpeningBalance()
+	closing.Add(closing, a.Credits)
+	return closing.Sub(closing, a.Debits)
+}
+
+// TrialBalance lists every balance of a ledger with the postings made to it over a period.
+// Totals are kept per currency and precision, since minor units only add up within one.
+type TrialBalance struct {
+	LedgerID string              `json:"ledger_id"`
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Balanced bool                `json:"balanced"`
+	Totals   []TrialBalanceTotal `json:"totals"`
+	Lines    []TrialBalanceLine  `json:"lines"`
+}
+
+// TrialBalanceLine is a balance on a trial balance. Debits and Credits are the postings made in the period.
+type TrialBalanceLine struct {
+	BalanceID      string   `json:"balance_id"`
+	Indicator      string   `json:"indicator,omitempty"`
+	Currency       string   `json:"currency"`
+	Precision      float64  `json:"precision"`
+	AccountType    string   `json:"account_type,omitempty"`
+	NormalSide     string   `json:"normal_side,omitempty"`
+	OpeningBalance *big.Int `json:"opening_balance"`
+	Debits         *big.Int `json:"debits"`
+	Credits        *big.Int `json:"credits"`
+	ClosingBalance *big.Int `json:"closing_balance"`
+}
+
+// TrialBalanceTotal adds up the lines of a trial balance in one currency and precision.
+// DebitBalances and CreditBalances add up the closing balances below and above zero; in a ledger where every
+// posting has both sides they match, as do Debits and Credits. Imbalance is the difference, credits less debits.
+type TrialBalanceTotal struct {
+	Currency       string   `json:"currency"`
+	Precision      float64  `json:"precision"`
+	Debits         *big.Int `json:"debits"`
+	Credits        *big.Int `json:"credits"`
+	DebitBalances  *big.Int `json:"debit_balances"`
+	CreditBalances *big.Int `json:"credit_balances"`
+	Imbalance      *big.Int `json:"imbalance"`
+	Balanced       bool     `json:"balanced"`
+}
+
+// ReportSection groups the balances of one account type on a financial statement.
+// Each line is signed by its own normal side; contra balances, whose normal side differs from their type's,
+// reduce the section total.
+type ReportSection struct {
+	AccountType string       `json:"account_type"`
+	Total       *big.Int     `json:"total"`
+	Lines       []ReportLine `json:"lines"`
+}
+
+// ReportLine is a balance on a financial statement.
+type ReportLine struct {
+	BalanceID  string   `json:"balance_id"`
+	Indicator  string   `json:"indicator,omitempty"`
+	NormalSide string   `json:"normal_side"`
+	Contra     bool     `json:"contra,omitempty"`
+	Amount     *big.Int `json:"amount"`
+}
+
+// NewReportSection returns an empty section for an account type.
+func NewReportSection(accountType string) ReportSection {
+	return ReportSection{AccountType: accountType, Total: new(big.Int), Lines: []ReportLine{}}
+}
+
+// Add puts a balance on the section with the given amount, signed by the balance's normal side.
+func (s *ReportSection) Add(balanceID, indicator, normalSide string, amount *big.Int) {
+	line := ReportLine{BalanceID: balanceID, Indicator: indicator, NormalSide: normalSide, Amount: amount}
+	line.Contra = normalSide != DefaultNormalSide(s.AccountType)
+	if line.Contra {
+		s.Total.Sub(s.Total, amount)
+	} else {
+		s.Total.Add(s.Total, amount)
+	}
+	s.Lines = append(s.Lines, line)
+}
+
+// BalanceSheet lists the assets, liabilities and equity of a ledger at a point in time.
+// NetIncome is the income less expenses posted up to then, which belongs to equity until it is closed into it.
+// The sheet balances when assets equal liabilities, equity and net income together.
+type BalanceSheet struct {
+	LedgerID    string        `json:"ledger_id"`
+	Currency    string        `json:"currency"`
+	Precision   float64       `json:"precision"`
+	AsOf        time.Time     `json:"as_of"`
+	Assets      ReportSection `json:"assets"`
+	Liabilities ReportSection `json:"liabilities"`
+	Equity      ReportSection `json:"equity"`
+	NetIncome   *big.Int      `json:"net_income"`
+	Imbalance   *big.Int      `json:"imbalance"`
+	Balanced    bool          `json:"balanced"`
+}
+
+// IncomeStatement lists the income and expenses of a ledger over a period.
+type IncomeStatement struct {
+	LedgerID  string        `json:"ledger_id"`
+	Currency  string        `json:"currency"`
+	Precision float64       `json:"precision"`
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Income    ReportSection `json:"income"`
+	Expenses  ReportSection `json:"expenses"`
+	NetIncome *big.Int      `json:"net_income"`
+}
@@ -0,0 +1,380 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRRulePeriods bounds how many consecutive periods without an occurrence are scanned, so a rule that can never
+// match (e.g. the 30th of February) stops instead of looping forever.
+const maxRRulePeriods = 100000
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// rruleDay is a BYDAY entry, e.g. MO, 1MO (first Monday) or -1FR (last Friday).
+type rruleDay struct {
+	weekday time.Weekday
+	nth     int
+}
+
+// RRule is a recurrence rule following RFC 5545. The supported parts are FREQ (HOURLY, DAILY, WEEKLY, MONTHLY
+// or YEARLY), INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and BYMONTH. Occurrences keep the time of day of the start,
+// and YEARLY rules are expanded within their BYMONTH months (the month of the start by default).
+type RRule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []rruleDay
+	byMonthDay []int
+	byMonth    []time.Month
+	dtstart    time.Time
+}
+
+// ParseRRule parses a recurrence rule such as "FREQ=MONTHLY;BYMONTHDAY=-1" starting at dtstart.
+func ParseRRule(rule string, dtstart time.Time) (*RRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	r := &RRule{interval: 1, dtstart: dtstart}
+
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return nil, fmt.Errorf("invalid rrule part %s", part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(value)
+			switch r.freq {
+			case "HOURLY", "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+			default:
+				return nil, fmt.Errorf("unsupported rrule frequency %s", value)
+			}
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(value)
+			if err == nil && r.interval < 1 {
+				err = fmt.Errorf("interval must be at least 1")
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(value)
+			if err == nil && r.count < 1 {
+				err = fmt.Errorf("count must be at least 1")
+			}
+		case "UNTIL":
+			r.until, err = parseRRuleTime(value)
+		case "BYDAY":
+			r.byDay, err = parseRRuleDays(value)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseRRuleInts(value, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseRRuleInts(value, 1, 12)
+			for _, m := range months {
+				r.byMonth = append(r.byMonth, time.Month(m))
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				err = fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rrule %s: %w", key, err)
+		}
+	}
+
+	if r.freq == "" {
+		return nil, fmt.Errorf("rrule FREQ is required")
+	}
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, fmt.Errorf("rrule cannot have both COUNT and UNTIL")
+	}
+	for _, day := range r.byDay {
+		if day.nth != 0 && r.freq != "MONTHLY" && r.freq != "YEARLY" {
+			return nil, fmt.Errorf("numbered BYDAY is only supported with FREQ=MONTHLY or FREQ=YEARLY")
+		}
+	}
+	return r, nil
+}
+
+// After returns the first occurrence strictly after the given time, or false if the rule has no more occurrences.
+// Rules without COUNT are scanned from the period containing the given time, as the occurrences of earlier periods
+// all precede it; rules with COUNT are scanned from the start to number their occurrences.
+func (r *RRule) After(after time.Time) (time.Time, bool) {
+	period := 0
+	if r.count == 0 {
+		period = r.periodOf(after)
+	}
+
+	count, empty := 0, 0
+	for ; empty < maxRRulePeriods; period++ {
+		occurrences := r.expand(period)
+		matched := false
+		for _, occurrence := range occurrences {
+			if occurrence.Before(r.dtstart) {
+				continue
+			}
+			if !r.until.IsZero() && occurrence.After(r.until) {
+				return time.Time{}, false
+			}
+			matched = true
+			count++
+			if r.count > 0 && count > r.count {
+				return time.Time{}, false
+			}
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+		if matched {
+			empty = 0
+		} else {
+			empty++
+		}
+	}
+	return time.Time{}, false
+}
+
+// periodOf returns the period of the rule that contains t, or the one before it where daylight saving time
+// shifts the boundary, so no occurrence after t is skipped. Times before the start are in period 0.
+func (r *RRule) periodOf(t time.Time) int {
+	start := r.dtstart
+	if !t.After(start) {
+		return 0
+	}
+	t = t.In(start.Location())
+
+	var periods int
+	switch r.freq {
+	case "HOURLY":
+		periods = int(t.Sub(start) / time.Hour)
+	case "DAILY":
+		periods = daysBetween(start, t)
+	case "WEEKLY":
+		periods = (daysBetween(start, t) + mondayOffset(start.Weekday())) / 7
+	case "MONTHLY":
+		periods = (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	case "YEARLY":
+		periods = t.Year() - start.Year()
+	}
+
+	period := periods/r.interval - 1
+	if period < 0 {
+		return 0
+	}
+	return period
+}
+
+// daysBetween returns the number of calendar days from the date of a to the date of b.
+func daysBetween(a, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
+
+// expand returns the sorted candidate occurrences of the nth period of the rule.
+func (r *RRule) expand(period int) []time.Time {
+	start := r.dtstart
+	step := period * r.interval
+	var candidates []time.Time
+
+	switch r.freq {
+	case "HOURLY":
+		candidates = []time.Time{start.Add(time.Duration(step) * time.Hour)}
+	case "DAILY":
+		candidates = []time.Time{start.AddDate(0, 0, step)}
+	case "WEEKLY":
+		weekStart := start.AddDate(0, 0, -mondayOffset(start.Weekday())+7*step)
+		if len(r.byDay) == 0 {
+			candidates = []time.Time{weekStart.AddDate(0, 0, mondayOffset(start.Weekday()))}
+		}
+		for _, day := range r.byDay {
+			candidates = append(candidates, weekStart.AddDate(0, 0, mondayOffset(day.weekday)))
+		}
+	case "MONTHLY":
+		candidates = r.monthDays(start.Year(), start.Month()+time.Month(step))
+	case "YEARLY":
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		for _, month := range months {
+			candidates = append(candidates, r.monthDays(start.Year()+step, month)...)
+		}
+	}
+
+	var occurrences []time.Time
+	for _, candidate := range candidates {
+		if r.matches(candidate) {
+			occurrences = append(occurrences, candidate)
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+	return occurrences
+}
+
+// monthDays returns the candidate days of a month at the start's time of day. Months are normalised,
+// so month 14 of a year is February of the next one.
+func (r *RRule) monthDays(year int, month time.Month) []time.Time {
+	start := r.dtstart
+	first := time.Date(year, month, 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	var days []int
+	switch {
+	case len(r.byMonthDay) > 0:
+		for _, d := range r.byMonthDay {
+			if d < 0 {
+				d = daysInMonth + 1 + d
+			}
+			days = append(days, d)
+		}
+	case len(r.byDay) > 0:
+		for _, day := range r.byDay {
+			var matching []int
+			for d := 1; d <= daysInMonth; d++ {
+				if first.AddDate(0, 0, d-1).Weekday() == day.weekday {
+					matching = append(matching, d)
+				}
+			}
+			switch {
+			case day.nth == 0:
+				days = append(days, matching...)
+			case day.nth > 0 && day.nth <= len(matching):
+				days = append(days, matching[day.nth-1])
+			case day.nth < 0 && -day.nth <= len(matching):
+				days = append(days, matching[len(matching)+day.nth])
+			}
+		}
+	default:
+		days = []int{start.Day()}
+	}
+
+	var candidates []time.Time
+	for _, d := range days {
+		// Days that do not exist in the month (e.g. the 31st of April) are skipped
+		if d >= 1 && d <= daysInMonth {
+			candidates = append(candidates, first.AddDate(0, 0, d-1))
+		}
+	}
+	return candidates
+}
+
+// matches applies the BYMONTH, BYMONTHDAY and BYDAY filters that were not used to expand the period.
+func (r *RRule) matches(t time.Time) bool {
+	if len(r.byMonth) > 0 && !containsMonth(r.byMonth, t.Month()) {
+		return false
+	}
+	if len(r.byMonthDay) > 0 && r.freq != "MONTHLY" && r.freq != "YEARLY" {
+		daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		found := false
+		for _, d := range r.byMonthDay {
+			if d == t.Day() || daysInMonth+1+d == t.Day() {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	// BYDAY expands weekly periods, and monthly or yearly periods without BYMONTHDAY; otherwise it filters.
+	if len(r.byDay) > 0 && (r.freq == "HOURLY" || r.freq == "DAILY" || len(r.byMonthDay) > 0) {
+		found := false
+		for _, day := range r.byDay {
+			if day.weekday == t.Weekday() {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// mondayOffset returns how many days a weekday is after Monday.
+func mondayOffset(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRRuleTime parses an UNTIL value in the RFC 5545 date or date-time format.
+func parseRRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %s", value)
+}
+
+// parseRRuleDays parses a BYDAY list such as "MO,WE,FR" or "-1FR".
+func parseRRuleDays(value string) ([]rruleDay, error) {
+	var days []rruleDay
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid day %s", item)
+		}
+		weekday, ok := rruleWeekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %s", item)
+		}
+		day := rruleDay{weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			nth, err := strconv.Atoi(prefix)
+			if err != nil || nth == 0 || nth < -5 || nth > 5 {
+				return nil, fmt.Errorf("invalid day %s", item)
+			}
+			day.nth = nth
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// parseRRuleInts parses a comma separated list of non-zero integers within [lo, hi].
+func parseRRuleInts(value string, lo, hi int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		v, err := strconv.Atoi(item)
+		if err != nil || v == 0 || v < lo || v > hi {
+			return nil, fmt.Errorf("invalid value %s", item)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
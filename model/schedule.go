@@ -0,0 +1,126 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	ScheduleStatusActive    = "ACTIVE"
+	ScheduleStatusPaused    = "PAUSED"
+	ScheduleStatusCancelled = "CANCELLED"
+	ScheduleStatusCompleted = "COMPLETED"
+)
+
+// Schedule queues a copy of its transaction template on every occurrence of a cron expression or an RRULE.
+type Schedule struct {
+	ID             int64                  `json:"-"`
+	ScheduleID     string                 `json:"schedule_id"`
+	Transaction    Transaction            `json:"transaction"`
+	Cron           string                 `json:"cron,omitempty"`
+	RRule          string                 `json:"rrule,omitempty"`
+	Status         string                 `json:"status"`
+	StartAt        time.Time              `json:"start_at"`
+	EndAt          time.Time              `json:"end_at,omitempty"`
+	MaxOccurrences int                    `json:"max_occurrences,omitempty"`
+	Occurrences    int                    `json:"occurrences"`
+	NextRunAt      time.Time              `json:"next_run_at,omitempty"`
+	LastRunAt      time.Time              `json:"last_run_at,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	MetaData       map[string]interface{} `json:"meta_data,omitempty"`
+}
+
+// ValidateRecurrence checks the schedule has exactly one valid cron expression or RRULE.
+func (s *Schedule) ValidateRecurrence() error {
+	if (s.Cron == "") == (s.RRule == "") {
+		return errors.New("exactly one of cron or rrule is required")
+	}
+	if s.Cron != "" {
+		if _, err := cron.ParseStandard(s.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression: %w", err)
+		}
+		return nil
+	}
+	_, err := ParseRRule(s.RRule, s.StartAt)
+	return err
+}
+
+// NextOccurrence returns the first occurrence of the schedule strictly after the given time,
+// or false once the schedule has reached its end date or maximum number of occurrences.
+func (s *Schedule) NextOccurrence(after time.Time) (time.Time, bool, error) {
+	if s.MaxOccurrences > 0 && s.Occurrences >= s.MaxOccurrences {
+		return time.Time{}, false, nil
+	}
+
+	var next time.Time
+	if s.Cron != "" {
+		expression, err := cron.ParseStandard(s.Cron)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		// Occurrences start at StartAt, which itself is a valid occurrence
+		if after.Before(s.StartAt) {
+			after = s.StartAt.Add(-time.Second)
+		}
+		next = expression.Next(after)
+		if next.IsZero() {
+			return time.Time{}, false, nil
+		}
+	} else {
+		rule, err := ParseRRule(s.RRule, s.StartAt)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		var ok bool
+		if next, ok = rule.After(after); !ok {
+			return time.Time{}, false, nil
+		}
+	}
+
+	if !s.EndAt.IsZero() && next.After(s.EndAt) {
+		return time.Time{}, false, nil
+	}
+	return next, true, nil
+}
+
+// OccurrenceTransaction builds the transaction for the nth occurrence of the schedule from its template.
+// The reference is derived from the template reference and the occurrence number, so an occurrence queued twice
+// is rejected as a duplicate, and the transaction links back to the schedule.
+func (s *Schedule) OccurrenceTransaction(occurrence int) *Transaction {
+	txn := s.Transaction
+	txn.TransactionID = ""
+	txn.Status = ""
+	txn.ScheduledFor = time.Time{}
+	txn.ScheduleID = s.ScheduleID
+	txn.Reference = fmt.Sprintf("%s_%d", s.Transaction.Reference, occurrence)
+	if s.Transaction.PreciseAmount != nil {
+		txn.PreciseAmount = new(big.Int).Set(s.Transaction.PreciseAmount)
+	}
+	txn.Sources = append([]Distribution(nil), s.Transaction.Sources...)
+	txn.Destinations = append([]Distribution(nil), s.Transaction.Destinations...)
+	txn.MetaData = make(map[string]interface{}, len(s.Transaction.MetaData)+1)
+	for k, v := range s.Transaction.MetaData {
+		txn.MetaData[k] = v
+	}
+	txn.MetaData["schedule_occurrence"] = occurrence
+	return &txn
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collectOccurrences(t *testing.T, schedule *Schedule, from time.Time, n int) []time.Time {
+	var occurrences []time.Time
+	after := from
+	for len(occurrences) < n {
+		next, ok, err := schedule.NextOccurrence(after)
+		assert.NoError(t, err)
+		if !ok {
+			break
+		}
+		occurrences = append(occurrences, next)
+		schedule.Occurrences++
+		after = next
+	}
+	return occurrences
+}
+
+func TestRRule_MonthlyLastDay(t *testing.T) {
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	schedule := &Schedule{RRule: "FREQ=MONTHLY;BYMONTHDAY=-1", StartAt: start}
+	assert.NoError(t, schedule.ValidateRecurrence())
+
+	occurrences := collectOccurrences(t, schedule, start, 3)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+	}, occurrences)
+}
+
+func TestRRule_WeeklyByDayWithCount(t *testing.T) {
+	// 2024-01-01 is a Monday
+	start := time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)
+	schedule := &Schedule{RRule: "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=3", StartAt: start}
+
+	occurrences := collectOccurrences(t, schedule, start.Add(-time.Second), 10)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC),
+		time.Date(2024, 1, 5, 8, 30, 0, 0, time.UTC),
+		time.Date(2024, 1, 8, 8, 30, 0, 0, time.UTC),
+	}, occurrences)
+}
+
+func TestRRule_LastFridayOfMonth(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rule, err := ParseRRule("FREQ=MONTHLY;BYDAY=-1FR", start)
+	assert.NoError(t, err)
+
+	next, ok := rule.After(start)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 26, 12, 0, 0, 0, time.UTC), next)
+
+	next, ok = rule.After(next)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 2, 23, 12, 0, 0, 0, time.UTC), next)
+}
+
+func TestRRule_HourlyFarFromStart(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	rule, err := ParseRRule("FREQ=HOURLY;INTERVAL=5", start)
+	assert.NoError(t, err)
+
+	// More than 100000 hourly periods after the start, the rule still has occurrences
+	after := time.Date(2040, 6, 1, 10, 0, 0, 0, time.UTC)
+	next, ok := rule.After(after)
+	assert.True(t, ok)
+	assert.True(t, next.After(after))
+	assert.True(t, next.Sub(after) <= 5*time.Hour)
+	assert.Zero(t, int(next.Sub(start).Hours())%5)
+}
+
+func TestRRule_AfterFromLaterPeriods(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	rule, err := ParseRRule("FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=31", start)
+	assert.NoError(t, err)
+	next, ok := rule.After(time.Date(2030, 2, 15, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2030, 3, 31, 9, 0, 0, 0, time.UTC), next)
+
+	// 2024-01-31 is a Wednesday
+	rule, err = ParseRRule("FREQ=WEEKLY;INTERVAL=3;BYDAY=MO", start)
+	assert.NoError(t, err)
+	next, ok = rule.After(time.Date(2024, 2, 12, 9, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 2, 19, 9, 0, 0, 0, time.UTC), next)
+
+	// A rule that can never match has no occurrences
+	rule, err = ParseRRule("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", start)
+	assert.NoError(t, err)
+	_, ok = rule.After(start)
+	assert.False(t, ok)
+}
+
+func TestParseRRule_Invalid(t *testing.T) {
+	for _, rule := range []string{"", "INTERVAL=2", "FREQ=SECONDLY", "FREQ=DAILY;COUNT=2;UNTIL=20240101", "FREQ=WEEKLY;BYDAY=1MO", "FREQ=MONTHLY;BYMONTHDAY=32"} {
+		_, err := ParseRRule(rule, time.Now())
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestSchedule_CronRespectsEndAt(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	schedule := &Schedule{Cron: "0 9 * * *", StartAt: start, EndAt: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)}
+	assert.NoError(t, schedule.ValidateRecurrence())
+
+	occurrences := collectOccurrences(t, schedule, start, 10)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+	}, occurrences)
+}
+
+func TestSchedule_ValidateRecurrence(t *testing.T) {
+	assert.Error(t, (&Schedule{}).ValidateRecurrence())
+	assert.Error(t, (&Schedule{Cron: "* * * * *", RRule: "FREQ=DAILY"}).ValidateRecurrence())
+	assert.Error(t, (&Schedule{Cron: "not a cron"}).ValidateRecurrence())
+}
+
+func TestSchedule_MaxOccurrences(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	schedule := &Schedule{RRule: "FREQ=DAILY", StartAt: start, MaxOccurrences: 2}
+	assert.Len(t, collectOccurrences(t, schedule, start.Add(-time.Second), 10), 2)
+}
+
+func TestSchedule_OccurrenceTransaction(t *testing.T) {
+	schedule := &Schedule{
+		ScheduleID: "sch_1",
+		Transaction: Transaction{
+			TransactionID: "txn_template",
+			Reference:     "payroll",
+			PreciseAmount: big.NewInt(1000),
+			ScheduledFor:  time.Now(),
+			MetaData:      map[string]interface{}{"team": "ops"},
+		},
+	}
+
+	txn := schedule.OccurrenceTransaction(3)
+	assert.Equal(t, "payroll_3", txn.Reference)
+	assert.Equal(t, "sch_1", txn.ScheduleID)
+	assert.Empty(t, txn.TransactionID)
+	assert.True(t, txn.ScheduledFor.IsZero())
+	assert.Equal(t, 3, txn.MetaData["schedule_occurrence"])
+
+	// The template is left untouched
+	txn.PreciseAmount.SetInt64(1)
+	assert.Equal(t, "1000", schedule.Transaction.PreciseAmount.String())
+	assert.NotContains(t, schedule.Transaction.MetaData, "schedule_occurrence")
+	assert.Equal(t, "payroll", schedule.Transaction.Reference)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"math/big"
+	"time"
+)
+
+const (
+	StatementEntryCredit = "credit"
+	StatementEntryDebit  = "debit"
+)
+
+// Statement lists the postings to a balance over a period, between its opening and closing balance.
+// Amounts are in minor units; Precision is the precision of the postings and is used to display them.
+type Statement struct {
+	BalanceID      string           `json:"balance_id"`
+	Currency       string           `json:"currency"`
+	Precision      float64          `json:"precision"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance *big.Int         `json:"opening_balance"`
+	ClosingBalance *big.Int         `json:"closing_balance"`
+	TotalCredits   *big.Int         `json:"total_credits"`
+	TotalDebits    *big.Int         `json:"total_debits"`
+	Entries        []StatementEntry `json:"entries"`
+}
+
+// StatementEntry is a single posting on a statement.
+type StatementEntry struct {
+	TransactionID string    `json:"transaction_id"`
+	Reference     string    `json:"reference"`
+	Description   string    `json:"description"`
+	Counterparty  string    `json:"counterparty"` // The balance on the other side of the posting
+	Direction     string    `json:"direction"`
+	Amount        *big.Int  `json:"amount"`
+	Precision     float64   `json:"precision"`
+	BalanceAfter  *big.Int  `json:"balance_after"`
+	CreatedAt     time.Time `json:"created_at"`
+	EffectiveDate time.Time `json:"effective_date"`
+}
@@ -17,10 +17,14 @@ package model
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -34,33 +38,84 @@ type Distribution struct {
 	Identifier    string `json:"identifier"`
 	Distribution  string `json:"distribution"` // Can be a percentage (e.g., "10%"), a fixed amount (e.g., "100"), or "left"
 	TransactionID string `json:"transaction_id"`
+	Remainder     bool   `json:"remainder,omitempty"` // Receives whatever the other legs leave unallocated
 }
 
 type Transaction struct {
-	ID                 int64                  `json:"-"`
-	PreciseAmount      int64                  `json:"precise_amount,omitempty"`
-	Amount             float64                `json:"amount"`
-	Rate               float64                `json:"rate"`
-	Precision          float64                `json:"precision"`
-	TransactionID      string                 `json:"transaction_id"`
-	ParentTransaction  string                 `json:"parent_transaction"`
-	Source             string                 `json:"source,omitempty"`
-	Destination        string                 `json:"destination,omitempty"`
-	Reference          string                 `json:"reference"`
-	Currency           string                 `json:"currency"`
-	Description        string                 `json:"description,omitempty"`
-	Status             string                 `json:"status"`
-	Hash               string                 `json:"hash"`
-	AllowOverdraft     bool                   `json:"allow_overdraft"`
-	Inflight           bool                   `json:"inflight"`
-	SkipBalanceUpdate  bool                   `json:"-"`
-	GroupIds           []string               `json:"-"`
-	Sources            []Distribution         `json:"sources,omitempty"`
-	Destinations       []Distribution         `json:"destinations,omitempty"`
-	CreatedAt          time.Time              `json:"created_at"`
-	ScheduledFor       time.Time              `json:"scheduled_for,omitempty"`
-	InflightExpiryDate time.Time              `json:"inflight_expiry_date,omitempty"`
-	MetaData           map[string]interface{} `json:"meta_data,omitempty"`
+	ID                        int64                  `json:"-"`
+	PreciseAmount             *big.Int               `json:"precise_amount,omitempty"`
+	SourceBalanceVersion      int64                  `json:"source_balance_version,omitempty"`      // Version of the source balance after this transaction
+	DestinationBalanceVersion int64                  `json:"destination_balance_version,omitempty"` // Version of the destination balance after this transaction
+	SourceBalanceBefore       *big.Int               `json:"source_balance_before,omitempty"`
+	SourceBalanceAfter        *big.Int               `json:"source_balance_after,omitempty"`
+	DestinationBalanceBefore  *big.Int               `json:"destination_balance_before,omitempty"`
+	DestinationBalanceAfter   *big.Int               `json:"destination_balance_after,omitempty"`
+	Amount                    float64                `json:"amount"`
+	Rate                      float64                `json:"rate"`
+	QuoteID                   string                 `json:"quote_id,omitempty"` // FX quote whose locked rate converts the transaction
+	Precision                 float64                `json:"precision"`
+	TransactionID             string                 `json:"transaction_id"`
+	ParentTransaction         string                 `json:"parent_transaction"`
+	ScheduleID                string                 `json:"schedule_id,omitempty"` // Schedule that generated this transaction
+	Source                    string                 `json:"source,omitempty"`
+	Destination               string                 `json:"destination,omitempty"`
+	Reference                 string                 `json:"reference"`
+	Currency                  string                 `json:"currency"`
+	Description               string                 `json:"description,omitempty"`
+	Status                    string                 `json:"status"`
+	Hash                      string                 `json:"hash"`
+	AllowOverdraft            bool                   `json:"allow_overdraft"`
+	Inflight                  bool                   `json:"inflight"`
+	Atomic                    bool                   `json:"atomic"`                      // Posts all sources/destinations legs as one journal entry
+	Refund                    bool                   `json:"refund,omitempty"`            // Reverses part or all of ParentTransaction
+	RefundedAmount            *big.Int               `json:"refunded_amount,omitempty"`   // Total of the refunds of this transaction, in minor units
+	RefundableAmount          *big.Int               `json:"refundable_amount,omitempty"` // Amount of this transaction that can still be refunded, in minor units
+	SkipBalanceUpdate         bool                   `json:"-"`
+	GroupIds                  []string               `json:"-"`
+	Sources                   []Distribution         `json:"sources,omitempty"`
+	Destinations              []Distribution         `json:"destinations,omitempty"`
+	Fees                      []*Transaction         `json:"fees,omitempty"` // Fee legs charged on the transaction, posted with it as one journal entry
+	CreatedAt                 time.Time              `json:"created_at"`
+	EffectiveDate             time.Time              `json:"effective_date"` // Value date the transaction is booked on, CreatedAt unless set by the client
+	ScheduledFor              time.Time              `json:"scheduled_for,omitempty"`
+	InflightExpiryDate        time.Time              `json:"inflight_expiry_date,omitempty"`
+	MetaData                  map[string]interface{} `json:"meta_data,omitempty"`
+}
+
+// TransactionFilter selects the transactions returned by a listing. Empty fields do not filter.
+type TransactionFilter struct {
+	Source            string            `json:"source"`
+	Destination       string            `json:"destination"`
+	BalanceID         string            `json:"balance_id"` // Matches either side of the transaction
+	Status            string            `json:"status"`
+	Currency          string            `json:"currency"`
+	ReferencePrefix   string            `json:"reference_prefix"`
+	ParentTransaction string            `json:"parent_transaction"`
+	From              time.Time         `json:"from"`
+	To                time.Time         `json:"to"`
+	EffectiveFrom     time.Time         `json:"effective_from"`
+	EffectiveTo       time.Time         `json:"effective_to"`
+	MetaData          map[string]string `json:"meta_data"`
+	Limit             int               `json:"limit"`
+	Cursor            string            `json:"cursor"` // Returned as NextCursor by the previous page
+}
+
+// TransactionPage is a page of transactions, newest first.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// PostingDate returns the date a transaction is booked on, which decides the accounting period it falls in.
+// It is the effective date, or the creation time if none was set. A transaction that has not been queued yet is booked now.
+func (transaction *Transaction) PostingDate() time.Time {
+	if !transaction.EffectiveDate.IsZero() {
+		return transaction.EffectiveDate
+	}
+	if transaction.CreatedAt.IsZero() {
+		return time.Now()
+	}
+	return transaction.CreatedAt
 }
 
 func (transaction *Transaction) ToJSON() ([]byte, error) {
@@ -76,6 +131,9 @@ func (transaction *Transaction) ToJSON() ([]byte, error) {
 	return data, nil
 }
 
+// SplitTransaction breaks a transaction with sources or destinations into one child transaction per distribution.
+// Legs are produced in distribution order and a distribution that already carries a TransactionID keeps it,
+// so splitting the same parent twice (e.g. when an atomic entry is retried by a worker) yields the same legs.
 func (transaction *Transaction) SplitTransaction(ctx context.Context) ([]*Transaction, error) {
 	ctx, span := tracer.Start(ctx, "SplitTransaction")
 	defer span.End()
@@ -85,6 +143,8 @@ func (transaction *Transaction) SplitTransaction(ctx context.Context) ([]*Transa
 		ds = transaction.Sources
 	} else if len(transaction.Destinations) > 0 {
 		ds = transaction.Destinations
+	} else {
+		return nil, nil // A single transfer has nothing to split
 	}
 
 	span.AddEvent("Starting distribution calculation", trace.WithAttributes(
@@ -93,32 +153,36 @@ func (transaction *Transaction) SplitTransaction(ctx context.Context) ([]*Transa
 		attribute.Int("distribution.count", len(ds)),
 	))
 
-	distributions, err := CalculateDistributions(ctx, transaction.Amount, ds)
+	distributions, err := CalculatePreciseDistributions(ctx, preciseAmountOf(transaction), transaction.Precision, ds)
 	if err != nil {
 		span.RecordError(err)
 		return nil, err
 	}
 
 	var transactions []*Transaction
-	counter := 1
-	for direction, amount := range distributions {
-		newTransaction := *transaction                               // Create a copy of the original transaction
-		newTransaction.TransactionID = GenerateUUIDWithSuffix("txn") // Set the transaction ID
-		newTransaction.Hash = newTransaction.HashTxn()               // Set the transaction hash
-		newTransaction.Amount = amount                               // Set the amount based on the distribution
-		newTransaction.Sources = nil                                 // Clear the Sources slice since we're dealing with individual sources now
-		newTransaction.Destinations = nil                            // Clear the Destinations slice since we're dealing with individual sources now
-		newTransaction.ParentTransaction = transaction.TransactionID // Set the parent transaction ID
+	for i, dist := range ds {
+		newTransaction := *transaction // Create a copy of the original transaction
+		newTransaction.TransactionID = dist.TransactionID
+		if newTransaction.TransactionID == "" {
+			newTransaction.TransactionID = GenerateUUIDWithSuffix("txn") // Set the transaction ID
+		}
+		newTransaction.PreciseAmount = distributions[dist.Identifier]                                     // Set the amount based on the distribution
+		newTransaction.Amount = FromPreciseAmount(newTransaction.PreciseAmount, newTransaction.Precision) // Keep the amount in sync with the precise amount
+		newTransaction.Sources = nil                                                                      // Clear the Sources slice since we're dealing with individual sources now
+		newTransaction.Destinations = nil                                                                 // Clear the Destinations slice since we're dealing with individual sources now
+		newTransaction.Atomic = false                                                                     // Legs are plain postings, only the parent is a journal entry
+		newTransaction.Fees = nil                                                                         // Fees are charged once, on the parent
+		newTransaction.ParentTransaction = transaction.TransactionID                                      // Set the parent transaction ID
 		if len(transaction.Sources) > 0 {
-			newTransaction.Source = direction // Set the source
-			transaction.Sources[counter-1].TransactionID = newTransaction.TransactionID
+			newTransaction.Source = dist.Identifier // Set the source
+			transaction.Sources[i].TransactionID = newTransaction.TransactionID
 		} else if len(transaction.Destinations) > 0 {
-			newTransaction.Destination = direction // Set the destination
-			transaction.Destinations[counter-1].TransactionID = newTransaction.TransactionID
+			newTransaction.Destination = dist.Identifier // Set the destination
+			transaction.Destinations[i].TransactionID = newTransaction.TransactionID
 		}
 
-		newTransaction.Reference = fmt.Sprintf("%s-%d", transaction.Reference, counter)
-		counter++
+		newTransaction.Reference = fmt.Sprintf("%s-%d", transaction.Reference, i+1)
+		newTransaction.Hash = newTransaction.HashTxn() // Set the transaction hash
 		transactions = append(transactions, &newTransaction)
 
 		span.AddEvent("Created new transaction", trace.WithAttributes(
@@ -136,91 +200,221 @@ func (transaction *Transaction) SplitTransaction(ctx context.Context) ([]*Transa
 }
 
 // CalculateDistributions calculates and returns the amount for each identifier (source or destination) based on its distribution.
+// The maths is done on exact decimals, see CalculatePreciseDistributions for the split used when posting.
 func CalculateDistributions(ctx context.Context, totalAmount float64, distributions []Distribution) (map[string]float64, error) {
 	_, span := tracer.Start(ctx, "CalculateDistributions")
 	defer span.End()
 
-	resultDistributions := make(map[string]float64)
-	var amountLeft = totalAmount
-	var totalPercentage float64 = 0
-	var fixedTotal float64 = 0
-
 	span.AddEvent("Starting distribution calculation", trace.WithAttributes(
 		attribute.Float64("total_amount", totalAmount),
 		attribute.Int("distribution.count", len(distributions)),
 	))
 
+	amounts, err := distribute(decimalFromFloat(totalAmount), distributions, nil)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	resultDistributions := make(map[string]float64, len(amounts))
+	for identifier, amount := range amounts {
+		resultDistributions[identifier], _ = amount.Float64()
+	}
+
+	span.AddEvent("Distribution calculation completed", trace.WithAttributes(
+		attribute.Int("result.count", len(resultDistributions)),
+	))
+	return resultDistributions, nil
+}
+
+// CalculatePreciseDistributions splits a precise amount (in minor units) across distributions without losing a minor unit.
+// Fixed amounts are converted with the precision and must be whole minor units, percentages are rounded down,
+// and the "left" distribution receives everything not allocated. Without a "left" distribution the fixed amounts and
+// percentages must cover the whole amount, and the minor units lost to rounding go to the distribution marked as the
+// remainder, or else one each to the percentages with the largest fractional parts (ties go to the earlier distribution).
+// Any other shortfall is an error, so the amounts returned always add up to the total.
+func CalculatePreciseDistributions(ctx context.Context, totalPrecise *big.Int, precision float64, distributions []Distribution) (map[string]*big.Int, error) {
+	_, span := tracer.Start(ctx, "CalculatePreciseDistributions")
+	defer span.End()
+
+	if precision == 0 {
+		precision = 1
+	}
+
+	amounts, err := distribute(new(big.Rat).SetInt(totalPrecise), distributions, decimalFromFloat(precision))
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	resultDistributions := make(map[string]*big.Int, len(amounts))
+	for identifier, amount := range amounts {
+		resultDistributions[identifier] = new(big.Int).Set(amount.Num())
+	}
+
+	span.AddEvent("Precise distribution calculation completed", trace.WithAttributes(
+		attribute.String("total_precise_amount", totalPrecise.String()),
+		attribute.Int("result.count", len(resultDistributions)),
+	))
+	return resultDistributions, nil
+}
+
+// distribute allocates total across distributions using exact decimal arithmetic.
+// When precision is set, total is in minor units, fixed amounts are scaled by precision and every share is a whole number.
+// Without a precision nothing is rounded, so the distributions must cover the total exactly unless one of them is "left".
+func distribute(total *big.Rat, distributions []Distribution, precision *big.Rat) (map[string]*big.Rat, error) {
+	resultDistributions := make(map[string]*big.Rat)
+	amountLeft := new(big.Rat).Set(total)
+	totalPercentage := new(big.Rat)
+	fixedTotal := new(big.Rat)
+	hundred := big.NewRat(100, 1)
+	fractions := make(map[string]*big.Rat) // What rounding down took off each percentage
+	var percentages []string
+	remainderLeg := ""
+	seen := make(map[string]struct{}, len(distributions))
+
 	// First pass: calculate fixed and percentage amounts, track total percentage
 	for _, dist := range distributions {
+		if _, exists := seen[dist.Identifier]; exists {
+			return nil, fmt.Errorf("duplicate identifier %s in distribution", dist.Identifier)
+		}
+		seen[dist.Identifier] = struct{}{}
+		if dist.Remainder {
+			if remainderLeg != "" {
+				return nil, errors.New("multiple identifiers marked to receive the remainder")
+			}
+			remainderLeg = dist.Identifier
+		}
+
 		if dist.Distribution == "left" {
 			continue // Handle "left" distribution later
-		} else if dist.Distribution[len(dist.Distribution)-1] == '%' {
+		} else if strings.HasSuffix(dist.Distribution, "%") {
 			// Percentage distribution
-			percentage, err := strconv.ParseFloat(dist.Distribution[:len(dist.Distribution)-1], 64)
+			percentage, err := ParseDecimal(strings.TrimSuffix(dist.Distribution, "%"))
 			if err != nil {
-				span.RecordError(err)
 				return nil, errors.New("invalid percentage format")
 			}
-			totalPercentage += percentage
-			amount := (percentage / 100) * totalAmount
+			totalPercentage.Add(totalPercentage, percentage)
+			amount := new(big.Rat).Quo(new(big.Rat).Mul(percentage, total), hundred)
+			if precision != nil {
+				floored := floorRat(amount)
+				fractions[dist.Identifier] = new(big.Rat).Sub(amount, floored)
+				amount = floored
+			}
 			resultDistributions[dist.Identifier] = amount
-			amountLeft -= amount
-			span.AddEvent("Calculated percentage distribution", trace.WithAttributes(
-				attribute.String("identifier", dist.Identifier),
-				attribute.Float64("percentage", percentage),
-				attribute.Float64("amount", amount),
-			))
+			amountLeft.Sub(amountLeft, amount)
+			percentages = append(percentages, dist.Identifier)
 		} else {
 			// Fixed amount distribution
-			fixedAmount, err := strconv.ParseFloat(dist.Distribution, 64)
+			fixedAmount, err := ParseDecimal(dist.Distribution)
 			if err != nil {
-				span.RecordError(err)
 				return nil, errors.New("invalid fixed amount format")
 			}
-			if fixedAmount > amountLeft {
-				err := errors.New("fixed amount exceeds remaining transaction amount")
-				span.RecordError(err)
-				return nil, err
+			if precision != nil {
+				fixedAmount.Mul(fixedAmount, precision)
+				if !fixedAmount.IsInt() {
+					return nil, fmt.Errorf("fixed amount %s has more decimal places than the precision allows", dist.Distribution)
+				}
+			}
+			if fixedAmount.Cmp(amountLeft) > 0 {
+				return nil, errors.New("fixed amount exceeds remaining transaction amount")
 			}
 			resultDistributions[dist.Identifier] = fixedAmount
-			fixedTotal += fixedAmount
-			amountLeft -= fixedAmount
-			span.AddEvent("Calculated fixed amount distribution", trace.WithAttributes(
-				attribute.String("identifier", dist.Identifier),
-				attribute.Float64("fixed_amount", fixedAmount),
-				attribute.Float64("amount_left", amountLeft),
-			))
+			fixedTotal.Add(fixedTotal, fixedAmount)
+			amountLeft.Sub(amountLeft, fixedAmount)
 		}
 	}
 
 	// Validate total percentage and fixed amounts do not exceed 100% or total amount
-	if totalPercentage > 100 || fixedTotal > totalAmount {
-		err := errors.New("total distributions exceed 100% or total amount")
-		span.RecordError(err)
-		return nil, err
+	if totalPercentage.Cmp(hundred) > 0 || fixedTotal.Cmp(total) > 0 || amountLeft.Sign() < 0 {
+		return nil, errors.New("total distributions exceed 100% or total amount")
 	}
 
 	// Second pass: calculate "left" distribution
+	hasLeft := false
 	for _, dist := range distributions {
 		if dist.Distribution == "left" {
-			if _, exists := resultDistributions[dist.Identifier]; exists {
-				err := errors.New("multiple identifiers with 'left' distribution")
-				span.RecordError(err)
-				return nil, err
+			if hasLeft {
+				return nil, errors.New("multiple identifiers with 'left' distribution")
 			}
-			resultDistributions[dist.Identifier] = amountLeft
-			span.AddEvent("Calculated 'left' distribution", trace.WithAttributes(
-				attribute.String("identifier", dist.Identifier),
-				attribute.Float64("amount_left", amountLeft),
-			))
-			break // Only one identifier should have "left" distribution
+			resultDistributions[dist.Identifier] = new(big.Rat).Set(amountLeft)
+			amountLeft.SetInt64(0)
+			hasLeft = true
 		}
 	}
+	if hasLeft && remainderLeg != "" {
+		return nil, errors.New("a remainder identifier cannot be combined with a 'left' distribution")
+	}
 
-	span.AddEvent("Distribution calculation completed", trace.WithAttributes(
-		attribute.Float64("amount_left", amountLeft),
-		attribute.Int("result.count", len(resultDistributions)),
-	))
+	// A named remainder leg takes whatever is left. Without one, the minor units lost to rounding are handed
+	// out, but only when the fixed amounts and percentage shares cover the total exactly. Anything else is
+	// amount the distributions do not cover.
+	covered := new(big.Rat).Add(fixedTotal, new(big.Rat).Quo(new(big.Rat).Mul(totalPercentage, total), hundred))
+	if amountLeft.Sign() > 0 && remainderLeg != "" {
+		resultDistributions[remainderLeg].Add(resultDistributions[remainderLeg], amountLeft)
+		amountLeft.SetInt64(0)
+	} else if amountLeft.Sign() > 0 && precision != nil && covered.Cmp(total) == 0 {
+		// Largest remainder first, the stable sort keeps distribution order for ties
+		sort.SliceStable(percentages, func(i, j int) bool {
+			return fractions[percentages[i]].Cmp(fractions[percentages[j]]) > 0
+		})
+		one := big.NewRat(1, 1)
+		for _, identifier := range percentages {
+			if amountLeft.Sign() == 0 {
+				break
+			}
+			resultDistributions[identifier].Add(resultDistributions[identifier], one)
+			amountLeft.Sub(amountLeft, one)
+		}
+		amountLeft.SetInt64(0)
+	}
+	if amountLeft.Sign() > 0 {
+		if precision != nil {
+			return nil, fmt.Errorf("distributions leave %s minor units unallocated, add a 'left' distribution to receive them", amountLeft.RatString())
+		}
+		unallocated, _ := amountLeft.Float64()
+		return nil, fmt.Errorf("distributions leave %s unallocated, add a 'left' distribution to receive it", strconv.FormatFloat(unallocated, 'f', -1, 64))
+	}
+
+	// The legs must add up to the total before anything is queued
+	allocated := new(big.Rat)
+	for _, amount := range resultDistributions {
+		allocated.Add(allocated, amount)
+	}
+	if allocated.Cmp(total) != 0 {
+		return nil, fmt.Errorf("distributions add up to %s, not %s", allocated.RatString(), total.RatString())
+	}
 
 	return resultDistributions, nil
 }
+
+// floorRat rounds a non-negative rational number down to a whole number.
+func floorRat(value *big.Rat) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Quo(value.Num(), value.Denom()))
+}
+
+// EncodeTransactionCursor encodes the position of a transaction in a listing, which is ordered by creation time and then ID.
+func EncodeTransactionCursor(createdAt time.Time, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", createdAt.UnixNano(), id)))
+}
+
+// DecodeTransactionCursor decodes a cursor produced by EncodeTransactionCursor.
+func DecodeTransactionCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid cursor")
+	}
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return time.Time{}, 0, errors.New("invalid cursor")
+	}
+	createdAt, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid cursor")
+	}
+	position, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid cursor")
+	}
+	return time.Unix(0, createdAt).UTC(), position, nil
+}
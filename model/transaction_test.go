@@ -17,8 +17,10 @@ package model
 
 import (
 	"context"
+	"math/big"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestCalculateDistributions(t *testing.T) {
@@ -53,6 +55,16 @@ func TestCalculateDistributions(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name:        "Uncovered Amount",
+			totalAmount: 1000,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "200"}, // Fixed amount
+				{Identifier: "B", Distribution: "50%"}, // Leaves 300 with no "left" distribution
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name:        "Invalid Format",
 			totalAmount: 1000,
@@ -77,3 +89,264 @@ func TestCalculateDistributions(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitTransactionReusesLegIDs(t *testing.T) {
+	txn := &Transaction{
+		TransactionID: "txn_parent",
+		Reference:     "ref",
+		Amount:        100,
+		Destination:   "bln_dest",
+		Atomic:        true,
+		Sources: []Distribution{
+			{Identifier: "bln_a", Distribution: "40"},
+			{Identifier: "bln_b", Distribution: "left"},
+		},
+	}
+
+	first, err := txn.SplitTransaction(context.Background())
+	if err != nil {
+		t.Fatalf("SplitTransaction() error = %v", err)
+	}
+	second, err := txn.SplitTransaction(context.Background())
+	if err != nil {
+		t.Fatalf("SplitTransaction() error = %v", err)
+	}
+
+	if len(first) != 2 || len(second) != 2 {
+		t.Fatalf("SplitTransaction() got %d and %d legs, want 2", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].TransactionID != second[i].TransactionID {
+			t.Errorf("leg %d ID changed between splits: %s != %s", i, first[i].TransactionID, second[i].TransactionID)
+		}
+		if first[i].Atomic || first[i].ParentTransaction != "txn_parent" {
+			t.Errorf("leg %d should be a plain child of the parent", i)
+		}
+	}
+	if first[0].Amount != 40 || first[1].Amount != 60 {
+		t.Errorf("SplitTransaction() amounts = %v, %v, want 40, 60", first[0].Amount, first[1].Amount)
+	}
+
+	txn.Sources = append(txn.Sources, Distribution{Identifier: "bln_a", Distribution: "0"})
+	if _, err := txn.SplitTransaction(context.Background()); err == nil {
+		t.Error("SplitTransaction() expected error for duplicate identifier")
+	}
+}
+
+func TestSplitTransactionWithoutDistributions(t *testing.T) {
+	txn := &Transaction{TransactionID: "txn_single", Reference: "ref", Amount: 10, Precision: 100, Source: "bln_a", Destination: "bln_b"}
+
+	legs, err := txn.SplitTransaction(context.Background())
+	if err != nil {
+		t.Fatalf("SplitTransaction() error = %v", err)
+	}
+	if len(legs) != 0 {
+		t.Errorf("SplitTransaction() got %d legs, want none", len(legs))
+	}
+}
+
+func TestCalculatePreciseDistributions(t *testing.T) {
+	got, err := CalculatePreciseDistributions(context.Background(), big.NewInt(1000), 100, []Distribution{
+		{Identifier: "A", Distribution: "33.33%"},
+		{Identifier: "B", Distribution: "33.33%"},
+		{Identifier: "C", Distribution: "left"},
+	})
+	if err != nil {
+		t.Fatalf("CalculatePreciseDistributions() error = %v", err)
+	}
+	want := map[string]*big.Int{"A": big.NewInt(333), "B": big.NewInt(333), "C": big.NewInt(334)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CalculatePreciseDistributions() got = %v, want %v", got, want)
+	}
+
+	got, err = CalculatePreciseDistributions(context.Background(), big.NewInt(1000), 100, []Distribution{
+		{Identifier: "A", Distribution: "50%"},
+		{Identifier: "B", Distribution: "50%"},
+	})
+	if err != nil {
+		t.Fatalf("CalculatePreciseDistributions() error = %v", err)
+	}
+	if new(big.Int).Add(got["A"], got["B"]).Int64() != 1000 {
+		t.Errorf("CalculatePreciseDistributions() lost minor units: %v", got)
+	}
+
+	if _, err := CalculatePreciseDistributions(context.Background(), big.NewInt(1000), 100, []Distribution{{Identifier: "A", Distribution: "1.001"}}); err == nil {
+		t.Error("CalculatePreciseDistributions() expected error for fixed amount finer than precision")
+	}
+
+	for _, distribution := range []string{"0x10%", "1_0%", "0b11", "1_000"} {
+		if _, err := CalculatePreciseDistributions(context.Background(), big.NewInt(1000), 100, []Distribution{{Identifier: "A", Distribution: distribution}}); err == nil {
+			t.Errorf("CalculatePreciseDistributions() expected error for distribution %s", distribution)
+		}
+	}
+}
+
+func TestCalculatePreciseDistributions_Remainder(t *testing.T) {
+	thirds := func(remainder string) []Distribution {
+		ds := []Distribution{
+			{Identifier: "A", Distribution: "33.33%"},
+			{Identifier: "B", Distribution: "33.33%"},
+			{Identifier: "C", Distribution: "33.34%"},
+		}
+		for i := range ds {
+			ds[i].Remainder = ds[i].Identifier == remainder
+		}
+		return ds
+	}
+
+	tests := []struct {
+		name          string
+		total         int64
+		distributions []Distribution
+		want          map[string]int64
+		wantErr       bool
+	}{
+		{
+			name:  "Rounding remainder goes to the first leg on a tie",
+			total: 1001,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "50%"}, // 500.5
+				{Identifier: "B", Distribution: "50%"}, // 500.5
+			},
+			want: map[string]int64{"A": 501, "B": 500},
+		},
+		{
+			name:          "Largest remainder wins",
+			total:         1000,
+			distributions: thirds(""), // 333.3, 333.3, 333.4
+			want:          map[string]int64{"A": 333, "B": 333, "C": 334},
+		},
+		{
+			name:          "Remainder goes to the named leg",
+			total:         1000,
+			distributions: thirds("A"),
+			want:          map[string]int64{"A": 334, "B": 333, "C": 333},
+		},
+		{
+			name:  "Percentages under 100% are an error",
+			total: 10000,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "33.33%"},
+				{Identifier: "B", Distribution: "33.33%"},
+				{Identifier: "C", Distribution: "33.33%"},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Named remainder leg takes the uncovered amount",
+			total: 10000,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "33.33%", Remainder: true},
+				{Identifier: "B", Distribution: "33.33%"},
+				{Identifier: "C", Distribution: "33.33%"},
+			},
+			want: map[string]int64{"A": 3334, "B": 3333, "C": 3333},
+		},
+		{
+			name:  "Small under-covered total is not topped up",
+			total: 2,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "10%"},
+				{Identifier: "B", Distribution: "10%"},
+				{Identifier: "C", Distribution: "10%"},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Under-covered shares are not rounded up",
+			total: 10,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "33%"},
+				{Identifier: "B", Distribution: "33%"},
+				{Identifier: "C", Distribution: "33%"},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Uncovered amount is an error",
+			total: 10000,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "50%"},
+				{Identifier: "B", Distribution: "10"},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Remainder cannot be combined with left",
+			total: 10000,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "33.33%", Remainder: true},
+				{Identifier: "B", Distribution: "left"},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Only one leg can take the remainder",
+			total: 10000,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "50%", Remainder: true},
+				{Identifier: "B", Distribution: "50%", Remainder: true},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Duplicate identifier",
+			total: 10000,
+			distributions: []Distribution{
+				{Identifier: "A", Distribution: "50%"},
+				{Identifier: "A", Distribution: "50%"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculatePreciseDistributions(context.Background(), big.NewInt(tt.total), 100, tt.distributions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculatePreciseDistributions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for identifier, want := range tt.want {
+				if got[identifier].Int64() != want {
+					t.Errorf("CalculatePreciseDistributions() %s = %s, want %d", identifier, got[identifier], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitTransactionKeepsMinorUnits(t *testing.T) {
+	txn := &Transaction{TransactionID: "txn_parent", Reference: "ref", Amount: 19.99, Precision: 100, Destination: "bln_dest",
+		Sources: []Distribution{{Identifier: "bln_a", Distribution: "10.01"}, {Identifier: "bln_b", Distribution: "left"}}}
+
+	legs, err := txn.SplitTransaction(context.Background())
+	if err != nil {
+		t.Fatalf("SplitTransaction() error = %v", err)
+	}
+	if legs[0].PreciseAmount.Int64() != 1001 || legs[1].PreciseAmount.Int64() != 998 {
+		t.Errorf("SplitTransaction() precise amounts = %s, %s, want 1001, 998", legs[0].PreciseAmount, legs[1].PreciseAmount)
+	}
+	if legs[1].Amount != 9.98 {
+		t.Errorf("SplitTransaction() amount = %v, want 9.98", legs[1].Amount)
+	}
+}
+
+func TestTransactionCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 2, 10, 0, 0, 123456000, time.UTC)
+	cursor := EncodeTransactionCursor(createdAt, 42)
+
+	decodedAt, id, err := DecodeTransactionCursor(cursor)
+	if err != nil {
+		t.Fatalf("DecodeTransactionCursor() error = %v", err)
+	}
+	if !createdAt.Equal(decodedAt) || id != 42 {
+		t.Errorf("DecodeTransactionCursor() = %v, %d, want %v, 42", decodedAt, id, createdAt)
+	}
+
+	if _, _, err := DecodeTransactionCursor("bm90LWEtY3Vyc29y"); err == nil {
+		t.Error("DecodeTransactionCursor() expected an error for an invalid cursor")
+	}
+}
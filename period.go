@@ -0,0 +1,244 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/internal/notification"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// periodTracer is an OpenTelemetry tracer for tracking accounting periods.
+var (
+	periodTracer = otel.Tracer("blnk.periods")
+)
+
+// sendPeriodWebhook notifies about a closed or reopened accounting period.
+func sendPeriodWebhook(event string, period *model.AccountingPeriod) {
+	go func() {
+		if err := SendWebhook(NewWebhook{Event: event, Payload: period}); err != nil {
+			notification.NotifyError(err)
+		}
+	}()
+}
+
+// ClosePeriod closes an accounting period of a ledger, e.g. September 2026. Once closed, no transaction with a posting
+// date in the period can be recorded, refunded or voided on the ledger's balances, and the balance of each of them at
+// the end of the period is kept. Corrections go into an open period as adjusting entries.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - ledgerID string: The ID of the ledger.
+// - name string: The name of the period.
+// - start time.Time: The start of the period, exclusive.
+// - end time.Time: The end of the period, inclusive. It cannot be in the future.
+//
+// Returns:
+// - *model.AccountingPeriod: A pointer to the closed period.
+// - error: An error if the period is invalid, overlaps a closed period of the ledger, or could not be closed.
+func (l *Blnk) ClosePeriod(ctx context.Context, ledgerID, name string, start, end time.Time) (*model.AccountingPeriod, error) {
+	ctx, span := periodTracer.Start(ctx, "ClosePeriod")
+	defer span.End()
+
+	if !start.Before(end) {
+		err := errors.New("start_date must be before end_date")
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+	if end.After(time.Now()) {
+		err := errors.New("end_date cannot be in the future")
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+
+	period, err := l.datasource.ClosePeriod(ctx, model.AccountingPeriod{LedgerID: ledgerID, Name: name, StartDate: start, EndDate: end})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	sendPeriodWebhook(PeriodClosedEvent, period)
+
+	span.AddEvent("Accounting period closed", trace.WithAttributes(
+		attribute.String("ledger.id", ledgerID),
+		attribute.String("period.id", period.PeriodID),
+	))
+	return period, nil
+}
+
+// ReopenPeriod reopens a closed accounting period so transactions can be posted in it again.
+// The reason and time are recorded on the period. Closing the span again creates a new period with a new snapshot.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - periodID string: The ID of the period.
+// - reason string: Why the period is reopened.
+//
+// Returns:
+// - *model.AccountingPeriod: A pointer to the reopened period.
+// - error: An error if no reason is given, the period is not closed, or it could not be reopened.
+func (l *Blnk) ReopenPeriod(ctx context.Context, periodID, reason string) (*model.AccountingPeriod, error) {
+	ctx, span := periodTracer.Start(ctx, "ReopenPeriod")
+	defer span.End()
+
+	if reason == "" {
+		err := errors.New("a reason is required to reopen an accounting period")
+		span.RecordError(err)
+		return nil, apierror.NewAPIError(apierror.ErrBadRequest, err.Error(), err)
+	}
+
+	period, err := l.datasource.ReopenPeriod(ctx, periodID, reason)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	sendPeriodWebhook(PeriodReopenedEvent, period)
+
+	span.AddEvent("Accounting period reopened", trace.WithAttributes(
+		attribute.String("ledger.id", period.LedgerID),
+		attribute.String("period.id", period.PeriodID),
+	))
+	return period, nil
+}
+
+// GetAccountingPeriod retrieves an accounting period by its ID.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - periodID string: The ID of the period.
+//
+// Returns:
+// - *model.AccountingPeriod: A pointer to the period.
+// - error: An error if the period could not be retrieved.
+func (l *Blnk) GetAccountingPeriod(ctx context.Context, periodID string) (*model.AccountingPeriod, error) {
+	return l.datasource.GetAccountingPeriod(ctx, periodID)
+}
+
+// GetAccountingPeriods retrieves the accounting periods of a ledger, latest first, including reopened ones.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - ledgerID string: The ID of the ledger.
+//
+// Returns:
+// - []model.AccountingPeriod: The periods of the ledger.
+// - error: An error if the ledger or its periods could not be retrieved.
+func (l *Blnk) GetAccountingPeriods(ctx context.Context, ledgerID string) ([]model.AccountingPeriod, error) {
+	if _, err := l.datasource.GetLedgerByID(ledgerID); err != nil {
+		return nil, err
+	}
+	return l.datasource.GetAccountingPeriods(ctx, ledgerID)
+}
+
+// GetPeriodBalances retrieves the balances of a ledger as they stood at the end of one of its accounting periods.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - periodID string: The ID of the period.
+// - limit int: The maximum number of balances to return.
+// - offset int: The offset to start fetching balances from.
+//
+// Returns:
+// - []model.PeriodBalance: The snapshotted balances, ordered by balance ID.
+// - error: An error if the period or its balances could not be retrieved.
+func (l *Blnk) GetPeriodBalances(ctx context.Context, periodID string, limit, offset int) ([]model.PeriodBalance, error) {
+	if _, err := l.datasource.GetAccountingPeriod(ctx, periodID); err != nil {
+		return nil, err
+	}
+	return l.datasource.GetPeriodBalances(ctx, periodID, limit, offset)
+}
+
+// checkPeriodOpen returns an error if a posting date falls in a closed accounting period of the ledger of any of the balances.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - at time.Time: The posting date.
+// - balances ...*model.Balance: The balances being posted to.
+//
+// Returns:
+// - error: An error wrapping model.ErrPeriodClosed if a ledger closed the period, or if the periods could not be retrieved.
+func (l *Blnk) checkPeriodOpen(ctx context.Context, at time.Time, balances ...*model.Balance) error {
+	checked := make(map[string]bool, len(balances))
+	for _, balance := range balances {
+		if checked[balance.LedgerID] {
+			continue
+		}
+		checked[balance.LedgerID] = true
+
+		period, err := l.datasource.GetClosedPeriod(ctx, balance.LedgerID, at)
+		if err != nil {
+			return err
+		}
+		if period != nil {
+			return fmt.Errorf("%w: ledger %s closed the period %s (%s)", model.ErrPeriodClosed, balance.LedgerID, period.PeriodID, period.Name)
+		}
+	}
+	return nil
+}
+
+// checkTransactionPeriodOpen returns an error if a transaction falls in a closed accounting period of the ledger of
+// its source or destination, so it cannot be recorded, refunded or voided. A balance that does not exist yet,
+// e.g. one still to be created from an indicator, holds nothing in a closed period and is skipped.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction being queued, refunded or voided.
+//
+// Returns:
+// - error: An error wrapping model.ErrPeriodClosed if a ledger closed the period, or if a balance could not be retrieved.
+func (l *Blnk) checkTransactionPeriodOpen(ctx context.Context, transaction *model.Transaction) error {
+	var balances []*model.Balance
+	for _, id := range []string{transaction.Source, transaction.Destination} {
+		balance, err := l.datasource.GetBalanceByIDLite(id)
+		if err != nil {
+			var apiErr apierror.APIError
+			if errors.As(err, &apiErr) && apiErr.Code == apierror.ErrNotFound {
+				continue
+			}
+			return err
+		}
+		balances = append(balances, balance)
+	}
+	return l.checkPeriodOpen(ctx, transaction.PostingDate(), balances...)
+}
+
+// checkEffectiveDate returns an error if a transaction was given an effective date in the future, or in a closed
+// accounting period of the ledger of its source or destination. A transaction without one is booked now, after
+// every closed period, so it is not checked.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transaction *model.Transaction: The transaction being queued.
+//
+// Returns:
+// - error: An error if the effective date is in the future or in a closed period.
+func (l *Blnk) checkEffectiveDate(ctx context.Context, transaction *model.Transaction) error {
+	if transaction.EffectiveDate.IsZero() {
+		return nil
+	}
+	if transaction.EffectiveDate.After(time.Now()) {
+		return errors.New("effective_date cannot be in the future")
+	}
+	return l.checkTransactionPeriodOpen(ctx, transaction)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jerry-enebeli/blnk/database/mocks"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClosePeriod(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	start := time.Date(2026, 8, 31, 23, 59, 59, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+
+	_, err := blnk.ClosePeriod(ctx, "ldg_1", "September 2026", end, start)
+	assert.ErrorContains(t, err, "start_date must be before end_date")
+
+	_, err = blnk.ClosePeriod(ctx, "ldg_1", "Next year", start, time.Now().AddDate(1, 0, 0))
+	assert.ErrorContains(t, err, "end_date cannot be in the future")
+
+	closed := &model.AccountingPeriod{PeriodID: "per_1", LedgerID: "ldg_1", Name: "September 2026", StartDate: start, EndDate: end, Status: model.PeriodStatusClosed}
+	mockDS.On("ClosePeriod", mock.Anything, model.AccountingPeriod{LedgerID: "ldg_1", Name: "September 2026", StartDate: start, EndDate: end}).Return(closed, nil)
+
+	period, err := blnk.ClosePeriod(ctx, "ldg_1", "September 2026", start, end)
+	assert.NoError(t, err)
+	assert.Equal(t, closed, period)
+	mockDS.AssertExpectations(t)
+}
+
+func TestReopenPeriod_RequiresReason(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+
+	_, err := blnk.ReopenPeriod(context.Background(), "per_1", "")
+	assert.Error(t, err)
+	assert.Equal(t, apierror.ErrBadRequest, err.(apierror.APIError).Code)
+	mockDS.AssertNotCalled(t, "ReopenPeriod", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckPeriodOpen(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	september := &model.AccountingPeriod{PeriodID: "per_1", LedgerID: "ldg_books", Name: "September 2026"}
+	backdated := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+	current := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	mockDS.On("GetClosedPeriod", ctx, "ldg_books", backdated).Return(september, nil)
+	mockDS.On("GetClosedPeriod", ctx, "ldg_books", current).Return(nil, nil)
+	mockDS.On("GetClosedPeriod", ctx, "ldg_wallets", mock.Anything).Return(nil, nil)
+
+	cash := &model.Balance{BalanceID: "bln_cash", LedgerID: "ldg_books"}
+	fees := &model.Balance{BalanceID: "bln_fees", LedgerID: "ldg_books"}
+	wallet := &model.Balance{BalanceID: "bln_wallet", LedgerID: "ldg_wallets"}
+
+	assert.NoError(t, blnk.checkPeriodOpen(ctx, current, cash, wallet))
+	assert.NoError(t, blnk.checkPeriodOpen(ctx, backdated, wallet))
+
+	err := blnk.checkPeriodOpen(ctx, backdated, wallet, cash)
+	assert.ErrorIs(t, err, model.ErrPeriodClosed)
+	assert.Contains(t, err.Error(), "per_1")
+
+	// Balances of the same ledger are checked once
+	assert.NoError(t, blnk.checkPeriodOpen(ctx, current, cash, fees))
+	mockDS.AssertNumberOfCalls(t, "GetClosedPeriod", 6)
+}
+
+func TestCheckEffectiveDate(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	backdated := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+
+	// Transactions booked now are not checked
+	assert.NoError(t, blnk.checkEffectiveDate(ctx, &model.Transaction{Source: "bln_cash", Destination: "@world"}))
+
+	err := blnk.checkEffectiveDate(ctx, &model.Transaction{Source: "bln_cash", Destination: "@world", EffectiveDate: time.Now().Add(time.Hour)})
+	assert.ErrorContains(t, err, "effective_date cannot be in the future")
+
+	mockDS.On("GetBalanceByIDLite", "bln_cash").Return(&model.Balance{BalanceID: "bln_cash", LedgerID: "ldg_books"}, nil)
+	mockDS.On("GetBalanceByIDLite", "@world").Return((*model.Balance)(nil), apierror.NewAPIError(apierror.ErrNotFound, "Balance with ID '@world' not found", nil))
+	mockDS.On("GetClosedPeriod", ctx, "ldg_books", backdated).Return(&model.AccountingPeriod{PeriodID: "per_1", Name: "September 2026"}, nil)
+
+	err = blnk.checkEffectiveDate(ctx, &model.Transaction{Source: "bln_cash", Destination: "@world", EffectiveDate: backdated})
+	assert.ErrorIs(t, err, model.ErrPeriodClosed)
+}
+
+func TestRecordTransaction_PeriodClosedBeforeCommit(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when starting miniredis", err)
+	}
+	defer mr.Close()
+
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS, redis: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+
+	mockDS.On("TransactionExistsByRef", mock.Anything, "ref_1").Return(false, nil)
+	mockDS.On("GetBalanceByIDLite", "bln_source").Return(&model.Balance{BalanceID: "bln_source", LedgerID: "ldg_1", Currency: "USD", Balance: big.NewInt(10000), CreditBalance: big.NewInt(10000), DebitBalance: big.NewInt(0), Version: 3}, nil)
+	mockDS.On("GetBalanceByIDLite", "bln_dest").Return(&model.Balance{BalanceID: "bln_dest", LedgerID: "ldg_1", Currency: "USD", Balance: big.NewInt(0), CreditBalance: big.NewInt(0), DebitBalance: big.NewInt(0)}, nil)
+	mockDS.On("GetClosedPeriod", mock.Anything, "ldg_1", mock.Anything).Return(nil, nil)
+	// The period is closed between the check and the write, which checks it again
+	mockDS.On("RecordJournalEntry", mock.Anything, mock.MatchedBy(func(txn *model.Transaction) bool {
+		return txn.TransactionID == "txn_1" && txn.SourceBalanceVersion == 4 && txn.DestinationBalanceVersion == 1
+	}), mock.Anything, mock.MatchedBy(func(balances []*model.Balance) bool {
+		return len(balances) == 2 && balances[0].BalanceID == "bln_source" && balances[1].BalanceID == "bln_dest"
+	})).Return(fmt.Errorf("%w: ledger ldg_1 closed the period per_1 (Q1)", model.ErrPeriodClosed))
+
+	txn := &model.Transaction{TransactionID: "txn_1", Reference: "ref_1", Source: "bln_source", Destination: "bln_dest", Currency: "USD",
+		Precision: 100, Amount: 50, PreciseAmount: big.NewInt(5000), Status: StatusQueued}
+	_, err = blnk.RecordTransaction(context.Background(), txn)
+	assert.ErrorIs(t, err, model.ErrPeriodClosed)
+
+	// The balances are only written with the transaction
+	mockDS.AssertNotCalled(t, "UpdateBalances", mock.Anything, mock.Anything, mock.Anything)
+	mockDS.AssertExpectations(t)
+}
@@ -35,6 +35,11 @@ const (
 	WEBHOOK_QUEUE         = "new:webhoook"
 	INDEX_QUEUE           = "new:index"
 	EXPIREDINFLIGHT_QUEUE = "new:inflight-expiry"
+	SNAPSHOT_QUEUE        = "new:balance-snapshot"
+	SCHEDULE_QUEUE        = "new:schedule"
+	MONITOR_ALERT_QUEUE   = "new:monitor-alert"
+	MONITOR_CHECK_QUEUE   = "new:monitor-check"
+	IDEMPOTENCY_QUEUE     = "new:idempotency-purge"
 	NumberOfQueues        = 20
 )
 
@@ -125,6 +130,58 @@ func (q *Queue) queueIndexData(id string, collection string, data interface{}) e
 	return nil
 }
 
+// queueMonitorAlert enqueues a balance monitor alert for delivery to the monitor's callback URL.
+// The event ID is the task ID, so an alert is only queued once.
+//
+// Parameters:
+// - event *model.MonitorEvent: The event of the fired monitor.
+// - maxRetries int: How many times a failed delivery is retried.
+// - backoffSeconds int64: The delay before the first retry, doubled on every further retry.
+//
+// Returns:
+// - error: An error if the task could not be enqueued.
+func (q *Queue) queueMonitorAlert(event *model.MonitorEvent, maxRetries int, backoffSeconds int64) error {
+	payload, err := json.Marshal(MonitorAlertTask{Event: *event, BackoffSeconds: backoffSeconds})
+	if err != nil {
+		return err
+	}
+
+	taskOptions := []asynq.Option{asynq.TaskID(event.EventID), asynq.Queue(MONITOR_ALERT_QUEUE), asynq.MaxRetry(maxRetries)}
+	task := asynq.NewTask(MONITOR_ALERT_QUEUE, payload, taskOptions...)
+	info, err := q.Client.Enqueue(task)
+	if err != nil {
+		log.Println(err, info)
+		return err
+	}
+	log.Printf(" [*] Successfully enqueued monitor alert: %+v", event.EventID)
+	return nil
+}
+
+// queueMonitorCheck enqueues a check of the monitors of a balance updated by a transaction. Monitors are checked by
+// a worker, so their evaluation and actions run after the transaction has released its balance lock.
+//
+// Parameters:
+// - balance *model.Balance: The updated balance.
+// - transaction *model.Transaction: The transaction that updated the balance.
+//
+// Returns:
+// - error: An error if the task could not be enqueued.
+func (q *Queue) queueMonitorCheck(balance *model.Balance, transaction *model.Transaction) error {
+	payload, err := json.Marshal(MonitorCheckTask{Balance: *balance, Transaction: *transaction})
+	if err != nil {
+		return err
+	}
+
+	taskOptions := []asynq.Option{asynq.Queue(MONITOR_CHECK_QUEUE)}
+	task := asynq.NewTask(MONITOR_CHECK_QUEUE, payload, taskOptions...)
+	info, err := q.Client.Enqueue(task)
+	if err != nil {
+		log.Println(err, info)
+		return err
+	}
+	return nil
+}
+
 // Enqueue enqueues a transaction to the Redis queue.
 //
 // Parameters:
@@ -0,0 +1,393 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// reportTracer is an OpenTelemetry tracer for tracking ledger reports.
+var (
+	reportTracer = otel.Tracer("blnk.reports")
+)
+
+// reportPeriodEnd returns the end of a report period, which is now if no end is given or the end is in the future.
+// It returns an error if the period does not start before it ends.
+func reportPeriodEnd(from, to time.Time) (time.Time, error) {
+	if now := time.Now(); to.IsZero() || to.After(now) {
+		to = now
+	}
+	if !from.Before(to) {
+		return to, errors.New("from must be before to")
+	}
+	return to, nil
+}
+
+// getLedgerActivity checks the ledger exists and sums the postings to each of its balances over a period.
+func (l *Blnk) getLedgerActivity(ctx context.Context, ledgerID string, from, to time.Time) ([]model.BalanceActivity, error) {
+	if _, err := l.datasource.GetLedgerByID(ledgerID); err != nil {
+		return nil, err
+	}
+	return l.datasource.GetLedgerActivity(ctx, ledgerID, from, to)
+}
+
+// GetTrialBalance builds the trial balance of a ledger for a period: every balance with its opening balance,
+// the debits and credits posted to it in the period and its closing balance. Each currency is totalled on its own
+// and flagged when its debits and credits do not match.
+// The period ends now if no end is given or the end is in the future.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - ledgerID string: The ID of the ledger.
+// - from time.Time: The start of the period. A zero time starts the period at the ledger's first posting.
+// - to time.Time: The end of the period.
+//
+// Returns:
+// - *model.TrialBalance: A pointer to the trial balance.
+// - error: An error if the period is invalid or the trial balance could not be built.
+func (l *Blnk) GetTrialBalance(ctx context.Context, ledgerID string, from, to time.Time) (*model.TrialBalance, error) {
+	ctx, span := reportTracer.Start(ctx, "GetTrialBalance")
+	defer span.End()
+
+	to, err := reportPeriodEnd(from, to)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	activity, err := l.getLedgerActivity(ctx, ledgerID, from, to)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	trialBalance := &model.TrialBalance{LedgerID: ledgerID, From: from, To: to, Balanced: true, Totals: []model.TrialBalanceTotal{}, Lines: []model.TrialBalanceLine{}}
+	var totals []*model.TrialBalanceTotal
+	byCurrency := make(map[string]*model.TrialBalanceTotal)
+	for i := range activity {
+		a := &activity[i]
+		line := model.TrialBalanceLine{
+			BalanceID: a.BalanceID, Indicator: a.Indicator, Currency: a.Currency, Precision: a.Precision, AccountType: a.AccountType, NormalSide: a.NormalSide,
+			OpeningBalance: a.OpeningBalance(), Debits: a.Debits, Credits: a.Credits, ClosingBalance: a.ClosingBalance(),
+		}
+		trialBalance.Lines = append(trialBalance.Lines, line)
+
+		key := fmt.Sprintf("%s:%v", a.Currency, a.Precision)
+		total, ok := byCurrency[key]
+		if !ok {
+			total = &model.TrialBalanceTotal{Currency: a.Currency, Precision: a.Precision, Debits: new(big.Int), Credits: new(big.Int),
+				DebitBalances: new(big.Int), CreditBalances: new(big.Int)}
+			byCurrency[key] = total
+			totals = append(totals, total)
+		}
+		total.Debits.Add(total.Debits, a.Debits)
+		total.Credits.Add(total.Credits, a.Credits)
+		if line.ClosingBalance.Sign() < 0 {
+			total.DebitBalances.Sub(total.DebitBalances, line.ClosingBalance)
+		} else {
+			total.CreditBalances.Add(total.CreditBalances, line.ClosingBalance)
+		}
+	}
+
+	for _, total := range totals {
+		total.Imbalance = new(big.Int).Sub(total.CreditBalances, total.DebitBalances)
+		total.Balanced = total.Imbalance.Sign() == 0 && total.Debits.Cmp(total.Credits) == 0
+		trialBalance.Balanced = trialBalance.Balanced && total.Balanced
+		trialBalance.Totals = append(trialBalance.Totals, *total)
+	}
+
+	span.AddEvent("Trial balance built", trace.WithAttributes(
+		attribute.String("ledger.id", ledgerID),
+		attribute.Int("report.balances", len(trialBalance.Lines)),
+		attribute.Bool("report.balanced", trialBalance.Balanced),
+	))
+	return trialBalance, nil
+}
+
+// GetBalanceSheet builds the balance sheet of a ledger at a point in time from the balances that have an account type.
+// Income less expenses posted up to then is reported as net income, and the sheet is flagged when assets do not
+// equal liabilities, equity and net income together.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - ledgerID string: The ID of the ledger.
+// - currency string: The currency to report on. Optional when every typed balance of the ledger uses one currency.
+// - asOf time.Time: The point in time to report on. A zero or future time reports on the ledger as it is now.
+//
+// Returns:
+// - *model.BalanceSheet: A pointer to the balance sheet.
+// - error: An error if the balance sheet could not be built.
+func (l *Blnk) GetBalanceSheet(ctx context.Context, ledgerID, currency string, asOf time.Time) (*model.BalanceSheet, error) {
+	ctx, span := reportTracer.Start(ctx, "GetBalanceSheet")
+	defer span.End()
+
+	asOf, err := reportPeriodEnd(time.Time{}, asOf)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	activity, err := l.getLedgerActivity(ctx, ledgerID, time.Time{}, asOf)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	accounts, currency, precision, err := chartOfAccounts(ledgerID, activity, currency)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	sheet := &model.BalanceSheet{
+		LedgerID: ledgerID, Currency: currency, Precision: precision, AsOf: asOf,
+		Assets:      model.NewReportSection(model.AccountTypeAsset),
+		Liabilities: model.NewReportSection(model.AccountTypeLiability),
+		Equity:      model.NewReportSection(model.AccountTypeEquity),
+	}
+	income, expenses := model.NewReportSection(model.AccountTypeIncome), model.NewReportSection(model.AccountTypeExpense)
+	sections := map[string]*model.ReportSection{
+		model.AccountTypeAsset:     &sheet.Assets,
+		model.AccountTypeLiability: &sheet.Liabilities,
+		model.AccountTypeEquity:    &sheet.Equity,
+		model.AccountTypeIncome:    &income,
+		model.AccountTypeExpense:   &expenses,
+	}
+	for _, a := range accounts {
+		normalSide := accountNormalSide(a)
+		sections[a.AccountType].Add(a.BalanceID, a.Indicator, normalSide, model.SignByNormalSide(a.ClosingBalance(), normalSide))
+	}
+
+	sheet.NetIncome = new(big.Int).Sub(income.Total, expenses.Total)
+	claims := new(big.Int).Add(sheet.Liabilities.Total, sheet.Equity.Total)
+	claims.Add(claims, sheet.NetIncome)
+	sheet.Imbalance = new(big.Int).Sub(sheet.Assets.Total, claims)
+	sheet.Balanced = sheet.Imbalance.Sign() == 0
+
+	span.AddEvent("Balance sheet built", trace.WithAttributes(
+		attribute.String("ledger.id", ledgerID),
+		attribute.Bool("report.balanced", sheet.Balanced),
+	))
+	return sheet, nil
+}
+
+// GetIncomeStatement builds the income statement of a ledger for a period from the income and expense balances:
+// what was earned and spent in the period and the net income.
+// The period ends now if no end is given or the end is in the future.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - ledgerID string: The ID of the ledger.
+// - currency string: The currency to report on. Optional when every typed balance of the ledger uses one currency.
+// - from time.Time: The start of the period.
+// - to time.Time: The end of the period.
+//
+// Returns:
+// - *model.IncomeStatement: A pointer to the income statement.
+// - error: An error if the period is invalid or the income statement could not be built.
+func (l *Blnk) GetIncomeStatement(ctx context.Context, ledgerID, currency string, from, to time.Time) (*model.IncomeStatement, error) {
+	ctx, span := reportTracer.Start(ctx, "GetIncomeStatement")
+	defer span.End()
+
+	to, err := reportPeriodEnd(from, to)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	activity, err := l.getLedgerActivity(ctx, ledgerID, from, to)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	accounts, currency, precision, err := chartOfAccounts(ledgerID, activity, currency)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	statement := &model.IncomeStatement{
+		LedgerID: ledgerID, Currency: currency, Precision: precision, From: from, To: to,
+		Income:   model.NewReportSection(model.AccountTypeIncome),
+		Expenses: model.NewReportSection(model.AccountTypeExpense),
+	}
+	for _, a := range accounts {
+		section := &statement.Income
+		if a.AccountType == model.AccountTypeExpense {
+			section = &statement.Expenses
+		} else if a.AccountType != model.AccountTypeIncome {
+			continue
+		}
+		normalSide := accountNormalSide(a)
+		movement := new(big.Int).Sub(a.Credits, a.Debits)
+		section.Add(a.BalanceID, a.Indicator, normalSide, model.SignByNormalSide(movement, normalSide))
+	}
+	statement.NetIncome = new(big.Int).Sub(statement.Income.Total, statement.Expenses.Total)
+
+	span.AddEvent("Income statement built", trace.WithAttributes(
+		attribute.String("ledger.id", ledgerID),
+		attribute.String("report.net_income", statement.NetIncome.String()),
+	))
+	return statement, nil
+}
+
+// chartOfAccounts picks the balances of a ledger that have an account type and are in the given currency.
+// Without a currency, every typed balance must share one. The balances must also share a precision,
+// since their minor units are added up.
+//
+// Returns:
+// - []model.BalanceActivity: The typed balances.
+// - string: The currency of the balances.
+// - float64: The precision of the balances, 1 if there are none.
+// - error: An error if the balances do not share a currency and precision.
+func chartOfAccounts(ledgerID string, activity []model.BalanceActivity, currency string) ([]model.BalanceActivity, string, float64, error) {
+	var accounts []model.BalanceActivity
+	precision := float64(0)
+	requested := currency
+	for _, a := range activity {
+		if a.AccountType == "" || (requested != "" && a.Currency != requested) {
+			continue
+		}
+		if currency == "" {
+			currency = a.Currency
+		}
+		if a.Currency != currency {
+			return nil, "", 0, fmt.Errorf("ledger %s holds balances in several currencies, pass a currency", ledgerID)
+		}
+		if precision != 0 && a.Precision != precision {
+			return nil, "", 0, fmt.Errorf("the %s balances of ledger %s use different precisions", currency, ledgerID)
+		}
+		precision = a.Precision
+		accounts = append(accounts, a)
+	}
+	if precision == 0 {
+		precision = 1
+	}
+	return accounts, currency, precision, nil
+}
+
+// accountNormalSide returns the normal side of a typed balance, falling back to its account type's side.
+func accountNormalSide(a model.BalanceActivity) string {
+	if a.NormalSide != "" {
+		return a.NormalSide
+	}
+	return model.DefaultNormalSide(a.AccountType)
+}
+
+// WriteTrialBalanceCSV writes a trial balance as CSV, with a total row per currency after the balances.
+// Closing balances are repeated in a debit or credit column, as accountants lay out a trial balance;
+// on a total row the closing balance is the imbalance.
+//
+// Parameters:
+// - w io.Writer: The writer to write the CSV to.
+// - trialBalance *model.TrialBalance: The trial balance to write.
+//
+// Returns:
+// - error: An error if the CSV could not be written.
+func WriteTrialBalanceCSV(w io.Writer, trialBalance *model.TrialBalance) error {
+	rows := [][]string{
+		{"balance_id", "indicator", "account_type", "normal_side", "currency", "opening_balance", "debits", "credits", "closing_balance", "debit_balance", "credit_balance"},
+	}
+	for _, line := range trialBalance.Lines {
+		debitBalance, creditBalance := "", ""
+		if line.ClosingBalance.Sign() < 0 {
+			debitBalance = model.FormatPreciseAmount(new(big.Int).Neg(line.ClosingBalance), line.Precision)
+		} else {
+			creditBalance = model.FormatPreciseAmount(line.ClosingBalance, line.Precision)
+		}
+		rows = append(rows, []string{
+			line.BalanceID, line.Indicator, line.AccountType, line.NormalSide, line.Currency,
+			model.FormatPreciseAmount(line.OpeningBalance, line.Precision), model.FormatPreciseAmount(line.Debits, line.Precision),
+			model.FormatPreciseAmount(line.Credits, line.Precision), model.FormatPreciseAmount(line.ClosingBalance, line.Precision),
+			debitBalance, creditBalance,
+		})
+	}
+	for _, total := range trialBalance.Totals {
+		rows = append(rows, []string{
+			"Total", "", "", "", total.Currency, "",
+			model.FormatPreciseAmount(total.Debits, total.Precision), model.FormatPreciseAmount(total.Credits, total.Precision),
+			model.FormatPreciseAmount(total.Imbalance, total.Precision),
+			model.FormatPreciseAmount(total.DebitBalances, total.Precision), model.FormatPreciseAmount(total.CreditBalances, total.Precision),
+		})
+	}
+	return writeReportCSV(w, rows)
+}
+
+// WriteBalanceSheetCSV writes a balance sheet as CSV: the lines and total of each section, then the net income and the imbalance.
+//
+// Parameters:
+// - w io.Writer: The writer to write the CSV to.
+// - sheet *model.BalanceSheet: The balance sheet to write.
+//
+// Returns:
+// - error: An error if the CSV could not be written.
+func WriteBalanceSheetCSV(w io.Writer, sheet *model.BalanceSheet) error {
+	rows := reportSectionRows(sheet.Precision, sheet.Assets, sheet.Liabilities, sheet.Equity)
+	rows = append(rows,
+		[]string{"net_income", "", "", "", "", model.FormatPreciseAmount(sheet.NetIncome, sheet.Precision)},
+		[]string{"imbalance", "", "", "", "", model.FormatPreciseAmount(sheet.Imbalance, sheet.Precision)},
+	)
+	return writeReportCSV(w, rows)
+}
+
+// WriteIncomeStatementCSV writes an income statement as CSV: the lines and total of each section, then the net income.
+//
+// Parameters:
+// - w io.Writer: The writer to write the CSV to.
+// - statement *model.IncomeStatement: The income statement to write.
+//
+// Returns:
+// - error: An error if the CSV could not be written.
+func WriteIncomeStatementCSV(w io.Writer, statement *model.IncomeStatement) error {
+	rows := reportSectionRows(statement.Precision, statement.Income, statement.Expenses)
+	rows = append(rows, []string{"net_income", "", "", "", "", model.FormatPreciseAmount(statement.NetIncome, statement.Precision)})
+	return writeReportCSV(w, rows)
+}
+
+// reportSectionRows lays out the sections of a financial statement as CSV rows, starting with the header.
+func reportSectionRows(precision float64, sections ...model.ReportSection) [][]string {
+	rows := [][]string{{"section", "balance_id", "indicator", "normal_side", "contra", "amount"}}
+	for _, section := range sections {
+		for _, line := range section.Lines {
+			rows = append(rows, []string{section.AccountType, line.BalanceID, line.Indicator, line.NormalSide,
+				fmt.Sprintf("%t", line.Contra), model.FormatPreciseAmount(line.Amount, precision)})
+		}
+		rows = append(rows, []string{section.AccountType, "Total", "", "", "", model.FormatPreciseAmount(section.Total, precision)})
+	}
+	return rows
+}
+
+// writeReportCSV writes the rows of a report as CSV.
+func writeReportCSV(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
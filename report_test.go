@@ -0,0 +1,156 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/database/mocks"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// reportActivity is a small books of a ledger: the owner invests 1,000.00, sales bring in 5.00 and rent costs 3.00,
+// while equipment depreciates by 1.00. An untyped EUR balance received 0.50 from outside the ledger.
+func reportActivity() []model.BalanceActivity {
+	activity := func(id, currency, accountType, normalSide string, credits, debits int64) model.BalanceActivity {
+		return model.BalanceActivity{BalanceID: id, Currency: currency, Precision: 100, AccountType: accountType, NormalSide: normalSide,
+			OpeningCredits: new(big.Int), OpeningDebits: new(big.Int), Credits: big.NewInt(credits), Debits: big.NewInt(debits)}
+	}
+	return []model.BalanceActivity{
+		activity("bln_cash", "USD", model.AccountTypeAsset, model.NormalSideDebit, 300, 100500),
+		activity("bln_depreciation", "USD", model.AccountTypeAsset, model.NormalSideCredit, 100, 0),
+		activity("bln_capital", "USD", model.AccountTypeEquity, model.NormalSideCredit, 100000, 0),
+		activity("bln_sales", "USD", model.AccountTypeIncome, model.NormalSideCredit, 500, 0),
+		activity("bln_rent", "USD", model.AccountTypeExpense, model.NormalSideDebit, 0, 300),
+		activity("bln_wear", "USD", model.AccountTypeExpense, model.NormalSideDebit, 0, 100),
+		activity("bln_eur", "EUR", "", "", 50, 0),
+	}
+}
+
+func TestGetTrialBalance(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	mockDS.On("GetLedgerByID", "ldg_1").Return(&model.Ledger{LedgerID: "ldg_1"}, nil)
+	mockDS.On("GetLedgerActivity", mock.Anything, "ldg_1", time.Time{}, to).Return(reportActivity(), nil)
+
+	trialBalance, err := blnk.GetTrialBalance(ctx, "ldg_1", time.Time{}, to)
+	assert.NoError(t, err)
+	assert.Len(t, trialBalance.Lines, 7)
+	assert.Equal(t, "-100200", trialBalance.Lines[0].ClosingBalance.String())
+	assert.False(t, trialBalance.Balanced)
+
+	usd, eur := trialBalance.Totals[0], trialBalance.Totals[1]
+	assert.Equal(t, "USD", usd.Currency)
+	assert.Equal(t, "100900", usd.Debits.String())
+	assert.Equal(t, "100900", usd.Credits.String())
+	assert.Equal(t, "100600", usd.DebitBalances.String())
+	assert.True(t, usd.Balanced)
+	assert.Equal(t, "EUR", eur.Currency)
+	assert.Equal(t, "50", eur.Imbalance.String())
+	assert.False(t, eur.Balanced)
+
+	var csvOut bytes.Buffer
+	assert.NoError(t, WriteTrialBalanceCSV(&csvOut, trialBalance))
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	assert.Len(t, lines, 10)
+	assert.Equal(t, "bln_cash,,asset,debit,USD,0.00,1005.00,3.00,-1002.00,1002.00,", lines[1])
+	assert.Equal(t, "Total,,,,USD,,1009.00,1009.00,0.00,1006.00,1006.00", lines[8])
+	assert.Equal(t, "Total,,,,EUR,,0.00,0.50,0.50,0.00,0.50", lines[9])
+}
+
+func TestGetBalanceSheet(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	asOf := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	mockDS.On("GetLedgerByID", "ldg_1").Return(&model.Ledger{LedgerID: "ldg_1"}, nil)
+	mockDS.On("GetLedgerActivity", mock.Anything, "ldg_1", time.Time{}, asOf).Return(reportActivity(), nil)
+
+	sheet, err := blnk.GetBalanceSheet(ctx, "ldg_1", "", asOf)
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", sheet.Currency)
+	assert.Equal(t, "100100", sheet.Assets.Total.String())
+	assert.True(t, sheet.Assets.Lines[1].Contra)
+	assert.Equal(t, "100000", sheet.Equity.Total.String())
+	assert.Equal(t, "100", sheet.NetIncome.String())
+	assert.True(t, sheet.Balanced)
+
+	var csvOut bytes.Buffer
+	assert.NoError(t, WriteBalanceSheetCSV(&csvOut, sheet))
+	assert.Contains(t, csvOut.String(), "asset,bln_depreciation,,credit,true,1.00\n")
+	assert.Contains(t, csvOut.String(), "imbalance,,,,,0.00\n")
+
+	_, err = blnk.GetBalanceSheet(ctx, "ldg_1", "GBP", asOf)
+	assert.NoError(t, err)
+}
+
+func TestGetBalanceSheet_SeveralCurrencies(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	asOf := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	activity := reportActivity()
+	activity[6].AccountType, activity[6].NormalSide = model.AccountTypeAsset, model.NormalSideDebit
+	mockDS.On("GetLedgerByID", "ldg_1").Return(&model.Ledger{LedgerID: "ldg_1"}, nil)
+	mockDS.On("GetLedgerActivity", mock.Anything, "ldg_1", time.Time{}, asOf).Return(activity, nil)
+
+	_, err := blnk.GetBalanceSheet(ctx, "ldg_1", "", asOf)
+	assert.EqualError(t, err, "ledger ldg_1 holds balances in several currencies, pass a currency")
+
+	sheet, err := blnk.GetBalanceSheet(ctx, "ldg_1", "EUR", asOf)
+	assert.NoError(t, err)
+	assert.Equal(t, "-50", sheet.Assets.Total.String())
+	assert.False(t, sheet.Balanced)
+}
+
+func TestGetIncomeStatement(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	activity := reportActivity()
+	// Sales made before the period do not count towards it
+	activity[3].OpeningCredits = big.NewInt(20000)
+	mockDS.On("GetLedgerByID", "ldg_1").Return(&model.Ledger{LedgerID: "ldg_1"}, nil)
+	mockDS.On("GetLedgerActivity", mock.Anything, "ldg_1", from, to).Return(activity, nil)
+
+	statement, err := blnk.GetIncomeStatement(ctx, "ldg_1", "USD", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, "500", statement.Income.Total.String())
+	assert.Equal(t, "400", statement.Expenses.Total.String())
+	assert.Equal(t, "100", statement.NetIncome.String())
+
+	var csvOut bytes.Buffer
+	assert.NoError(t, WriteIncomeStatementCSV(&csvOut, statement))
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	assert.Equal(t, "section,balance_id,indicator,normal_side,contra,amount", lines[0])
+	assert.Equal(t, "net_income,,,,,1.00", lines[len(lines)-1])
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scheduleTracer is an OpenTelemetry tracer for tracking recurring transaction schedules.
+var (
+	scheduleTracer = otel.Tracer("blnk.schedules")
+)
+
+const (
+	// dueSchedulesBatchSize is the number of due schedules read per page when running schedules.
+	dueSchedulesBatchSize = 100
+	// maxScheduleCatchUp bounds how many missed occurrences of a single schedule are queued in one run.
+	maxScheduleCatchUp = 100
+)
+
+// CreateSchedule creates a schedule that queues its transaction template on every occurrence of a cron expression
+// or an RRULE, starting from its start time (now by default).
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - schedule *model.Schedule: The schedule to create.
+//
+// Returns:
+// - *model.Schedule: A pointer to the created Schedule model.
+// - error: An error if the schedule is invalid or could not be created.
+func (l *Blnk) CreateSchedule(ctx context.Context, schedule *model.Schedule) (*model.Schedule, error) {
+	ctx, span := scheduleTracer.Start(ctx, "CreateSchedule")
+	defer span.End()
+
+	if schedule.Transaction.Reference == "" {
+		err := errors.New("transaction reference is required")
+		span.RecordError(err)
+		return nil, err
+	}
+	if schedule.StartAt.IsZero() {
+		schedule.StartAt = time.Now()
+	}
+	if err := schedule.ValidateRecurrence(); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Occurrences before now are skipped, but StartAt itself is the first candidate
+	after := schedule.StartAt.Add(-time.Nanosecond)
+	if now := time.Now(); after.Before(now) {
+		after = now
+	}
+	next, ok, err := schedule.NextOccurrence(after)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !ok {
+		err := errors.New("schedule has no occurrences after its start")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	schedule.Status = model.ScheduleStatusActive
+	schedule.NextRunAt = next
+	schedule.Occurrences = 0
+	if err := l.datasource.CreateSchedule(ctx, schedule); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.AddEvent("Schedule created", trace.WithAttributes(
+		attribute.String("schedule.id", schedule.ScheduleID),
+		attribute.String("schedule.next_run_at", next.Format(time.RFC3339)),
+	))
+	return schedule, nil
+}
+
+// GetSchedule retrieves a schedule by its ID.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - id string: The ID of the schedule.
+//
+// Returns:
+// - *model.Schedule: A pointer to the Schedule model.
+// - error: An error if the schedule could not be retrieved.
+func (l *Blnk) GetSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	ctx, span := scheduleTracer.Start(ctx, "GetSchedule")
+	defer span.End()
+
+	schedule, err := l.datasource.GetScheduleByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// GetAllSchedules retrieves a page of schedules.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - limit int: The maximum number of schedules to return.
+// - offset int: The offset to start from.
+//
+// Returns:
+// - []model.Schedule: A slice of Schedule models.
+// - error: An error if the schedules could not be retrieved.
+func (l *Blnk) GetAllSchedules(ctx context.Context, limit, offset int) ([]model.Schedule, error) {
+	ctx, span := scheduleTracer.Start(ctx, "GetAllSchedules")
+	defer span.End()
+
+	schedules, err := l.datasource.GetAllSchedules(ctx, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// PauseSchedule stops an active schedule from queueing occurrences until it is resumed.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - id string: The ID of the schedule.
+//
+// Returns:
+// - *model.Schedule: A pointer to the paused Schedule model.
+// - error: An error if the schedule is not active or could not be updated.
+func (l *Blnk) PauseSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	ctx, span := scheduleTracer.Start(ctx, "PauseSchedule")
+	defer span.End()
+
+	schedule, err := l.datasource.GetScheduleByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if schedule.Status != model.ScheduleStatusActive {
+		err := fmt.Errorf("schedule %s is %s and cannot be paused", id, schedule.Status)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	schedule.Status = model.ScheduleStatusPaused
+	if err := l.datasource.UpdateScheduleStatus(ctx, id, schedule.Status, schedule.NextRunAt); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.AddEvent("Schedule paused", trace.WithAttributes(attribute.String("schedule.id", id)))
+	return schedule, nil
+}
+
+// ResumeSchedule reactivates a paused schedule. Occurrences missed while it was paused are skipped.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - id string: The ID of the schedule.
+//
+// Returns:
+// - *model.Schedule: A pointer to the resumed Schedule model.
+// - error: An error if the schedule is not paused or could not be updated.
+func (l *Blnk) ResumeSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	ctx, span := scheduleTracer.Start(ctx, "ResumeSchedule")
+	defer span.End()
+
+	schedule, err := l.datasource.GetScheduleByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if schedule.Status != model.ScheduleStatusPaused {
+		err := fmt.Errorf("schedule %s is %s and cannot be resumed", id, schedule.Status)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	next, ok, err := schedule.NextOccurrence(time.Now())
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	schedule.Status, schedule.NextRunAt = model.ScheduleStatusActive, next
+	if !ok {
+		schedule.Status, schedule.NextRunAt = model.ScheduleStatusCompleted, time.Time{}
+	}
+
+	if err := l.datasource.UpdateScheduleStatus(ctx, id, schedule.Status, schedule.NextRunAt); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.AddEvent("Schedule resumed", trace.WithAttributes(attribute.String("schedule.id", id)))
+	return schedule, nil
+}
+
+// CancelSchedule permanently stops a schedule. Occurrences already queued are not affected.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - id string: The ID of the schedule.
+//
+// Returns:
+// - *model.Schedule: A pointer to the cancelled Schedule model.
+// - error: An error if the schedule has already ended or could not be updated.
+func (l *Blnk) CancelSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	ctx, span := scheduleTracer.Start(ctx, "CancelSchedule")
+	defer span.End()
+
+	schedule, err := l.datasource.GetScheduleByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if schedule.Status == model.ScheduleStatusCancelled || schedule.Status == model.ScheduleStatusCompleted {
+		err := fmt.Errorf("schedule %s is already %s", id, schedule.Status)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	schedule.Status, schedule.NextRunAt = model.ScheduleStatusCancelled, time.Time{}
+	if err := l.datasource.UpdateScheduleStatus(ctx, id, schedule.Status, schedule.NextRunAt); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.AddEvent("Schedule cancelled", trace.WithAttributes(attribute.String("schedule.id", id)))
+	return schedule, nil
+}
+
+// RunDueSchedules queues the occurrences of every active schedule that is due. Each occurrence is queued before
+// the schedule is advanced, so a crash in between queues it again with the same derived reference and the duplicate
+// is rejected when it is processed.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+//
+// Returns:
+// - int: The number of occurrences queued.
+// - error: An error if the due schedules could not be retrieved.
+func (l *Blnk) RunDueSchedules(ctx context.Context) (int, error) {
+	ctx, span := scheduleTracer.Start(ctx, "RunDueSchedules")
+	defer span.End()
+
+	now := time.Now()
+	schedules, err := l.datasource.GetDueSchedules(ctx, now, dueSchedulesBatchSize)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	queued := 0
+	for i := range schedules {
+		count, err := l.runSchedule(ctx, &schedules[i], now)
+		queued += count
+		if err != nil {
+			// A failing schedule must not hold back the others, it is retried on the next run
+			span.RecordError(fmt.Errorf("schedule %s: %w", schedules[i].ScheduleID, err))
+		}
+	}
+
+	span.AddEvent("Due schedules run", trace.WithAttributes(
+		attribute.Int("schedule.count", len(schedules)),
+		attribute.Int("schedule.occurrences_queued", queued),
+	))
+	return queued, nil
+}
+
+// runSchedule queues the due occurrences of a schedule, catching up on occurrences missed while no worker was running.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - schedule *model.Schedule: The due schedule.
+// - now time.Time: The time the schedules are run at.
+//
+// Returns:
+// - int: The number of occurrences queued.
+// - error: An error if an occurrence could not be queued or the schedule could not be advanced.
+func (l *Blnk) runSchedule(ctx context.Context, schedule *model.Schedule, now time.Time) (int, error) {
+	queued := 0
+	for queued < maxScheduleCatchUp && schedule.Status == model.ScheduleStatusActive && !schedule.NextRunAt.After(now) {
+		runAt := schedule.NextRunAt
+		occurrence := schedule.Occurrences + 1
+
+		if _, err := l.QueueTransaction(ctx, schedule.OccurrenceTransaction(occurrence)); err != nil {
+			return queued, err
+		}
+		queued++
+
+		schedule.Occurrences = occurrence
+		schedule.LastRunAt = runAt
+		next, ok, err := schedule.NextOccurrence(runAt)
+		if err != nil {
+			return queued, err
+		}
+		schedule.NextRunAt = next
+		if !ok {
+			schedule.Status, schedule.NextRunAt = model.ScheduleStatusCompleted, time.Time{}
+		}
+
+		advanced, err := l.datasource.AdvanceSchedule(ctx, schedule, runAt)
+		if err != nil {
+			return queued, err
+		}
+		if !advanced {
+			// The schedule was paused, cancelled or advanced by another runner in the meantime
+			return queued, nil
+		}
+	}
+	return queued, nil
+}
@@ -20,6 +20,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
 	"time"
 
@@ -109,8 +111,15 @@ func (t *TypesenseClient) HandleNotification(table string, data map[string]inter
 		}
 	}
 
+	// Amounts in minor units can exceed int64, so they are indexed as strings.
+	for _, field := range latestSchema.Fields {
+		if field.Type == "string" {
+			data[field.Name] = stringifyNumber(data[field.Name])
+		}
+	}
+
 	// Handle time fields and convert them to Unix timestamps if necessary.
-	timeFields := []string{"created_at", "scheduled_for", "inflight_expiry_date", "inflight_expires_at", "completed_at", "started_at"}
+	timeFields := []string{"created_at", "effective_date", "scheduled_for", "inflight_expiry_date", "inflight_expires_at", "completed_at", "started_at"}
 	for _, field := range timeFields {
 		if fieldValue, ok := data[field]; ok {
 			switch v := fieldValue.(type) {
@@ -194,6 +203,20 @@ func (t *TypesenseClient) MigrateTypeSenseSchema(ctx context.Context, collection
 	// Compare the current schema with the latest schema and get any new fields.
 	newFields := compareSchemas(currentSchema, latestSchema)
 
+	// Fields whose type changed are dropped and added back with the new type in a single update.
+	for _, field := range changedFields(currentSchema, latestSchema) {
+		drop := true
+		updateSchema := &api.CollectionUpdateSchema{
+			Fields: []api.Field{{Name: field.Name, Drop: &drop}, field},
+		}
+
+		_, err := collection.Update(ctx, updateSchema)
+		if err != nil {
+			return fmt.Errorf("failed to change type of field %s: %w", field.Name, err)
+		}
+		logrus.Infof("Changed type of field %s in collection %s to %s", field.Name, collectionName, field.Type)
+	}
+
 	// Add each new field to the collection.
 	for _, field := range newFields {
 		updateSchema := &api.CollectionUpdateSchema{
@@ -230,6 +253,45 @@ func compareSchemas(oldSchema, newSchema *api.CollectionSchema) []api.Field {
 	return newFields
 }
 
+// changedFields returns the fields present in both schemas whose type differs in the new schema.
+func changedFields(oldSchema, newSchema *api.CollectionSchema) []api.Field {
+	var fields []api.Field
+	oldFieldTypes := make(map[string]string)
+
+	for _, field := range oldSchema.Fields {
+		oldFieldTypes[field.Name] = field.Type
+	}
+
+	for _, field := range newSchema.Fields {
+		if oldType, ok := oldFieldTypes[field.Name]; ok && oldType != field.Type {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// stringifyNumber converts a numeric value to its decimal string so it can be stored in a string field without losing digits.
+func stringifyNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	case *big.Int:
+		if v == nil {
+			return "0"
+		}
+		return v.String()
+	default:
+		return value
+	}
+}
+
 // getDefaultValue returns the default value for a given field type in Typesense.
 func getDefaultValue(fieldType string) interface{} {
 	switch fieldType {
@@ -289,13 +351,16 @@ func getBalanceSchema() *api.CollectionSchema {
 	return &api.CollectionSchema{
 		Name: "balances",
 		Fields: []api.Field{
-			{Name: "balance", Type: "int64", Facet: &facet},
+			{Name: "balance", Type: "string", Facet: &facet},
 			{Name: "version", Type: "int64", Facet: &facet},
-			{Name: "inflight_balance", Type: "int64", Facet: &facet},
-			{Name: "credit_balance", Type: "int64", Facet: &facet},
-			{Name: "inflight_credit_balance", Type: "int64", Facet: &facet},
-			{Name: "debit_balance", Type: "int64", Facet: &facet},
-			{Name: "inflight_debit_balance", Type: "int64", Facet: &facet},
+			{Name: "inflight_balance", Type: "string", Facet: &facet},
+			{Name: "credit_balance", Type: "string", Facet: &facet},
+			{Name: "inflight_credit_balance", Type: "string", Facet: &facet},
+			{Name: "debit_balance", Type: "string", Facet: &facet},
+			{Name: "inflight_debit_balance", Type: "string", Facet: &facet},
+			{Name: "available_balance", Type: "string", Facet: &facet},
+			{Name: "overdraft_limit", Type: "string", Facet: &facet},
+			{Name: "status", Type: "string", Facet: &facet},
 			{Name: "precision", Type: "float", Facet: &facet},
 			{Name: "ledger_id", Type: "string", Facet: &facet},
 			{Name: "identity_id", Type: "string", Facet: &facet},
@@ -317,7 +382,7 @@ func getTransactionSchema() *api.CollectionSchema {
 	return &api.CollectionSchema{
 		Name: "transactions",
 		Fields: []api.Field{
-			{Name: "precise_amount", Type: "int64", Facet: &facet},
+			{Name: "precise_amount", Type: "string", Facet: &facet},
 			{Name: "amount", Type: "float", Facet: &facet},
 			{Name: "rate", Type: "float", Facet: &facet},
 			{Name: "precision", Type: "float", Facet: &facet},
@@ -335,6 +400,7 @@ func getTransactionSchema() *api.CollectionSchema {
 			{Name: "sources", Type: "string[]", Facet: &facet},
 			{Name: "destinations", Type: "string[]", Facet: &facet},
 			{Name: "created_at", Type: "int64", Facet: &facet},
+			{Name: "effective_date", Type: "int64", Facet: &facet},
 			{Name: "scheduled_for", Type: "int64", Facet: &facet},
 			{Name: "inflight_expiry_date", Type: "int64", Facet: &facet},
 			{Name: "meta_data", Type: "string", Facet: &facet},
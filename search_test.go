@@ -0,0 +1,47 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typesense/typesense-go/typesense/api"
+)
+
+func TestChangedFields(t *testing.T) {
+	oldSchema := &api.CollectionSchema{Fields: []api.Field{
+		{Name: "balance", Type: "int64"},
+		{Name: "currency", Type: "string"},
+	}}
+
+	changed := changedFields(oldSchema, getBalanceSchema())
+	assert.Len(t, changed, 1)
+	assert.Equal(t, "balance", changed[0].Name)
+	assert.Equal(t, "string", changed[0].Type)
+}
+
+func TestStringifyNumber(t *testing.T) {
+	amount, _ := new(big.Int).SetString("1500000000000000000000000", 10)
+
+	assert.Equal(t, "1500000000000000000000000", stringifyNumber(json.Number("1500000000000000000000000")))
+	assert.Equal(t, "1500000000000000000000000", stringifyNumber(amount))
+	assert.Equal(t, "1999", stringifyNumber(float64(1999)))
+	assert.Equal(t, "bln_1", stringifyNumber("bln_1"))
+}
@@ -0,0 +1,39 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS blnk.balance_snapshots
+(
+    id                      SERIAL PRIMARY KEY,
+    balance_id              TEXT      NOT NULL REFERENCES blnk.balances (balance_id),
+    balance                 NUMERIC   NOT NULL DEFAULT 0,
+    credit_balance          NUMERIC   NOT NULL DEFAULT 0,
+    debit_balance           NUMERIC   NOT NULL DEFAULT 0,
+    inflight_balance        NUMERIC   NOT NULL DEFAULT 0,
+    inflight_credit_balance NUMERIC   NOT NULL DEFAULT 0,
+    inflight_debit_balance  NUMERIC   NOT NULL DEFAULT 0,
+    snapshot_time           TIMESTAMP NOT NULL,
+    last_transaction_seq    BIGINT    NOT NULL DEFAULT 0,
+    created_at              TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_snapshots_balance_time ON blnk.balance_snapshots (balance_id, snapshot_time DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_source_created_at ON blnk.transactions (source, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_destination_created_at ON blnk.transactions (destination, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS blnk.idx_transactions_destination_created_at;
+DROP INDEX IF EXISTS blnk.idx_transactions_source_created_at;
+DROP TABLE IF EXISTS blnk.balance_snapshots;
@@ -0,0 +1,66 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- +migrate Up
+ALTER TABLE blnk.balances
+    ALTER COLUMN inflight_balance TYPE NUMERIC USING inflight_balance::NUMERIC,
+    ALTER COLUMN inflight_credit_balance TYPE NUMERIC USING inflight_credit_balance::NUMERIC,
+    ALTER COLUMN inflight_debit_balance TYPE NUMERIC USING inflight_debit_balance::NUMERIC;
+
+ALTER TABLE blnk.transactions
+    ALTER COLUMN amount TYPE NUMERIC USING amount::NUMERIC,
+    ALTER COLUMN precise_amount TYPE NUMERIC USING precise_amount::NUMERIC,
+    ALTER COLUMN rate TYPE NUMERIC USING rate::NUMERIC;
+
+ALTER TABLE blnk.balance_monitors
+    ALTER COLUMN value TYPE NUMERIC USING value::NUMERIC,
+    ALTER COLUMN precise_value TYPE NUMERIC USING precise_value::NUMERIC;
+
+DROP FUNCTION IF EXISTS blnk.get_balances_by_id(TEXT, TEXT);
+create or replace function blnk.get_balances_by_id(source_id TEXT, destination_id TEXT)
+    returns table(balance_id TEXT, currency TEXT, currency_multiplier NUMERIC,ledger_id TEXT, balance NUMERIC, credit_balance NUMERIC, debit_balance NUMERIC, inflight_balance NUMERIC, inflight_credit_balance NUMERIC, inflight_debit_balance NUMERIC, created_at TIMESTAMP, version INTEGER)
+    language sql
+    immutable
+    strict
+as
+$$
+select b.balance_id,b.currency,b.currency_multiplier,b.ledger_id,b.balance,b.credit_balance,b.debit_balance,b.inflight_balance,b.inflight_credit_balance,b.inflight_debit_balance,b.created_at,b.version FROM blnk.balances b WHERE b.balance_id IN (source_id, destination_id)
+$$;
+
+-- +migrate Down
+DROP FUNCTION IF EXISTS blnk.get_balances_by_id(TEXT, TEXT);
+create or replace function blnk.get_balances_by_id(source_id TEXT, destination_id TEXT)
+    returns table(balance_id TEXT, currency TEXT, currency_multiplier BIGINT,ledger_id TEXT, balance BIGINT, credit_balance BIGINT, debit_balance BIGINT, inflight_balance BIGINT, inflight_credit_balance BIGINT, inflight_debit_balance BIGINT, created_at TIMESTAMP, version INTEGER)
+    language sql
+    immutable
+    strict
+as
+$$
+select b.balance_id,b.currency,b.currency_multiplier,b.ledger_id,b.balance,b.credit_balance,b.debit_balance,b.inflight_balance,b.inflight_credit_balance,b.inflight_debit_balance,b.created_at,b.version FROM blnk.balances b WHERE b.balance_id IN (source_id, destination_id)
+$$;
+
+ALTER TABLE blnk.balance_monitors
+    ALTER COLUMN value TYPE BIGINT USING value::BIGINT,
+    ALTER COLUMN precise_value TYPE BIGINT USING precise_value::BIGINT;
+
+ALTER TABLE blnk.transactions
+    ALTER COLUMN amount TYPE FLOAT USING amount::FLOAT,
+    ALTER COLUMN precise_amount TYPE BIGINT USING precise_amount::BIGINT,
+    ALTER COLUMN rate TYPE BIGINT USING rate::BIGINT;
+
+ALTER TABLE blnk.balances
+    ALTER COLUMN inflight_balance TYPE BIGINT USING inflight_balance::BIGINT,
+    ALTER COLUMN inflight_credit_balance TYPE BIGINT USING inflight_credit_balance::BIGINT,
+    ALTER COLUMN inflight_debit_balance TYPE BIGINT USING inflight_debit_balance::BIGINT;
@@ -0,0 +1,44 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS blnk.schedules
+(
+    id              SERIAL PRIMARY KEY,
+    schedule_id     TEXT      NOT NULL UNIQUE,
+    transaction     JSONB     NOT NULL,
+    cron            TEXT,
+    rrule           TEXT,
+    status          TEXT      NOT NULL CHECK (status IN ('ACTIVE', 'PAUSED', 'CANCELLED', 'COMPLETED')),
+    start_at        TIMESTAMP NOT NULL,
+    end_at          TIMESTAMP,
+    max_occurrences INTEGER   NOT NULL DEFAULT 0,
+    occurrences     INTEGER   NOT NULL DEFAULT 0,
+    next_run_at     TIMESTAMP,
+    last_run_at     TIMESTAMP,
+    meta_data       JSONB,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((cron IS NULL) <> (rrule IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON blnk.schedules (next_run_at) WHERE status = 'ACTIVE';
+
+ALTER TABLE blnk.transactions ADD COLUMN IF NOT EXISTS schedule_id TEXT REFERENCES blnk.schedules (schedule_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_schedule_id ON blnk.transactions (schedule_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS blnk.idx_transactions_schedule_id;
+ALTER TABLE blnk.transactions DROP COLUMN IF EXISTS schedule_id;
+DROP INDEX IF EXISTS blnk.idx_schedules_due;
+DROP TABLE IF EXISTS blnk.schedules;
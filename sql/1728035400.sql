@@ -0,0 +1,21 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at_id ON blnk.transactions (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_reference_prefix ON blnk.transactions (reference text_pattern_ops);
+
+-- +migrate Down
+DROP INDEX IF EXISTS blnk.idx_transactions_reference_prefix;
+DROP INDEX IF EXISTS blnk.idx_transactions_created_at_id;
@@ -0,0 +1,49 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+ALTER TABLE blnk.balance_monitors
+    ALTER COLUMN field DROP NOT NULL,
+    ALTER COLUMN operator DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS conditions JSONB,
+    ADD COLUMN IF NOT EXISTS trigger_mode TEXT NOT NULL DEFAULT 'level' CHECK (trigger_mode IN ('level', 'edge')),
+    ADD COLUMN IF NOT EXISTS cooldown_seconds BIGINT NOT NULL DEFAULT 0 CHECK (cooldown_seconds >= 0),
+    ADD COLUMN IF NOT EXISTS last_state BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS last_triggered_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS blnk.monitor_events
+(
+    id           SERIAL PRIMARY KEY,
+    event_id     TEXT      NOT NULL UNIQUE,
+    monitor_id   TEXT      NOT NULL REFERENCES blnk.balance_monitors (monitor_id) ON DELETE CASCADE,
+    balance_id   TEXT      NOT NULL,
+    balance      JSONB     NOT NULL,
+    triggered_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_monitor_events_monitor_id_triggered_at ON blnk.monitor_events (monitor_id, triggered_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS blnk.monitor_events;
+
+DELETE FROM blnk.balance_monitors WHERE field IS NULL OR operator IS NULL;
+
+ALTER TABLE blnk.balance_monitors
+    DROP COLUMN IF EXISTS last_triggered_at,
+    DROP COLUMN IF EXISTS last_state,
+    DROP COLUMN IF EXISTS cooldown_seconds,
+    DROP COLUMN IF EXISTS trigger_mode,
+    DROP COLUMN IF EXISTS conditions,
+    ALTER COLUMN operator SET NOT NULL,
+    ALTER COLUMN field SET NOT NULL;
@@ -0,0 +1,29 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+ALTER TABLE blnk.balance_monitors
+    ADD COLUMN IF NOT EXISTS call_back_secret TEXT,
+    ADD COLUMN IF NOT EXISTS max_retries INTEGER NOT NULL DEFAULT 5 CHECK (max_retries >= 0),
+    ADD COLUMN IF NOT EXISTS retry_backoff_seconds BIGINT NOT NULL DEFAULT 30 CHECK (retry_backoff_seconds > 0);
+
+ALTER TABLE blnk.monitor_events ADD COLUMN IF NOT EXISTS transaction_id TEXT;
+
+-- +migrate Down
+ALTER TABLE blnk.monitor_events DROP COLUMN IF EXISTS transaction_id;
+
+ALTER TABLE blnk.balance_monitors
+    DROP COLUMN IF EXISTS retry_backoff_seconds,
+    DROP COLUMN IF EXISTS max_retries,
+    DROP COLUMN IF EXISTS call_back_secret;
@@ -0,0 +1,28 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- +migrate Up
+ALTER TABLE blnk.balances ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'FROZEN'));
+
+ALTER TABLE blnk.balance_monitors ADD COLUMN IF NOT EXISTS actions JSONB;
+
+ALTER TABLE blnk.monitor_events ADD COLUMN IF NOT EXISTS actions JSONB;
+
+-- +migrate Down
+ALTER TABLE blnk.monitor_events DROP COLUMN IF EXISTS actions;
+
+ALTER TABLE blnk.balance_monitors DROP COLUMN IF EXISTS actions;
+
+ALTER TABLE blnk.balances DROP COLUMN IF EXISTS status;
@@ -0,0 +1,39 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- +migrate Up
+ALTER TABLE blnk.balances DROP CONSTRAINT IF EXISTS balances_status_check;
+ALTER TABLE blnk.balances ADD CONSTRAINT balances_status_check CHECK (status IN ('ACTIVE', 'FROZEN', 'DEBIT_BLOCKED', 'CLOSED'));
+
+CREATE TABLE IF NOT EXISTS blnk.balance_status_changes
+(
+    id          SERIAL PRIMARY KEY,
+    change_id   TEXT      NOT NULL UNIQUE,
+    balance_id  TEXT      NOT NULL REFERENCES blnk.balances (balance_id),
+    from_status TEXT      NOT NULL,
+    to_status   TEXT      NOT NULL,
+    reason      TEXT      NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_status_changes_balance_id_created_at ON blnk.balance_status_changes (balance_id, created_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS blnk.balance_status_changes;
+
+UPDATE blnk.balances SET status = 'FROZEN' WHERE status IN ('DEBIT_BLOCKED', 'CLOSED');
+
+ALTER TABLE blnk.balances DROP CONSTRAINT IF EXISTS balances_status_check;
+ALTER TABLE blnk.balances ADD CONSTRAINT balances_status_check CHECK (status IN ('ACTIVE', 'FROZEN'));
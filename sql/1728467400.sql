@@ -0,0 +1,29 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+ALTER TABLE blnk.balances ADD COLUMN IF NOT EXISTS account_type TEXT CHECK (account_type IN ('asset', 'liability', 'equity', 'income', 'expense'));
+ALTER TABLE blnk.balances ADD COLUMN IF NOT EXISTS normal_side TEXT CHECK (normal_side IN ('debit', 'credit'));
+
+CREATE INDEX IF NOT EXISTS idx_balances_ledger_id_account_type ON blnk.balances (ledger_id, account_type);
+
+ALTER TABLE blnk.ledgers ADD COLUMN IF NOT EXISTS transfer_rules JSONB;
+
+-- +migrate Down
+ALTER TABLE blnk.ledgers DROP COLUMN IF EXISTS transfer_rules;
+
+DROP INDEX IF EXISTS blnk.idx_balances_ledger_id_account_type;
+
+ALTER TABLE blnk.balances DROP COLUMN IF EXISTS normal_side;
+ALTER TABLE blnk.balances DROP COLUMN IF EXISTS account_type;
@@ -0,0 +1,50 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS blnk.accounting_periods
+(
+    id            SERIAL PRIMARY KEY,
+    period_id     TEXT      NOT NULL UNIQUE,
+    ledger_id     TEXT      NOT NULL REFERENCES blnk.ledgers (ledger_id),
+    name          TEXT      NOT NULL,
+    start_date    TIMESTAMP NOT NULL,
+    end_date      TIMESTAMP NOT NULL,
+    status        TEXT      NOT NULL DEFAULT 'CLOSED' CHECK (status IN ('CLOSED', 'REOPENED')),
+    closed_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    reopened_at   TIMESTAMP,
+    reopen_reason TEXT,
+    CHECK (start_date < end_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounting_periods_ledger_id_end_date ON blnk.accounting_periods (ledger_id, end_date);
+
+CREATE TABLE IF NOT EXISTS blnk.period_balances
+(
+    id                  SERIAL PRIMARY KEY,
+    period_id           TEXT    NOT NULL REFERENCES blnk.accounting_periods (period_id),
+    balance_id          TEXT    NOT NULL REFERENCES blnk.balances (balance_id),
+    currency            TEXT    NOT NULL,
+    currency_multiplier NUMERIC NOT NULL,
+    account_type        TEXT,
+    normal_side         TEXT,
+    balance             NUMERIC NOT NULL,
+    credit_balance      NUMERIC NOT NULL,
+    debit_balance       NUMERIC NOT NULL,
+    UNIQUE (period_id, balance_id)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS blnk.period_balances;
+DROP TABLE IF EXISTS blnk.accounting_periods;
@@ -0,0 +1,27 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+ALTER TABLE blnk.transactions ADD COLUMN IF NOT EXISTS effective_date TIMESTAMP;
+UPDATE blnk.transactions SET effective_date = created_at WHERE effective_date IS NULL;
+ALTER TABLE blnk.transactions ALTER COLUMN effective_date SET DEFAULT NOW();
+ALTER TABLE blnk.transactions ALTER COLUMN effective_date SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_source_effective_date ON blnk.transactions (source, effective_date);
+CREATE INDEX IF NOT EXISTS idx_transactions_destination_effective_date ON blnk.transactions (destination, effective_date);
+
+-- +migrate Down
+DROP INDEX IF EXISTS blnk.idx_transactions_destination_effective_date;
+DROP INDEX IF EXISTS blnk.idx_transactions_source_effective_date;
+ALTER TABLE blnk.transactions DROP COLUMN IF EXISTS effective_date;
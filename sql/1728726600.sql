@@ -0,0 +1,32 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS blnk.fx_rates
+(
+    id             SERIAL PRIMARY KEY,
+    rate_id        TEXT      NOT NULL UNIQUE,
+    base_currency  TEXT      NOT NULL,
+    quote_currency TEXT      NOT NULL,
+    rate           NUMERIC   NOT NULL CHECK (rate > 0),
+    effective_from TIMESTAMP NOT NULL,
+    source         TEXT,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (base_currency <> quote_currency)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fx_rates_pair_effective_from ON blnk.fx_rates (base_currency, quote_currency, effective_from DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS blnk.fx_rates;
@@ -0,0 +1,33 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS blnk.fx_quotes
+(
+    id             SERIAL PRIMARY KEY,
+    quote_id       TEXT      NOT NULL UNIQUE,
+    base_currency  TEXT      NOT NULL,
+    quote_currency TEXT      NOT NULL,
+    rate           NUMERIC   NOT NULL CHECK (rate > 0),
+    market_rate    NUMERIC   NOT NULL,
+    rate_id        TEXT      NOT NULL,
+    status         TEXT      NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'USED')),
+    expires_at     TIMESTAMP NOT NULL,
+    transaction_id TEXT,
+    used_at        TIMESTAMP,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS blnk.fx_quotes;
@@ -0,0 +1,39 @@
+-- Copyright 2024 Blnk Finance Authors.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS blnk.fee_rules
+(
+    id                SERIAL PRIMARY KEY,
+    rule_id           TEXT      NOT NULL UNIQUE,
+    name              TEXT      NOT NULL,
+    type              TEXT      NOT NULL CHECK (type IN ('percentage', 'fixed', 'tiered')),
+    percentage        NUMERIC   NOT NULL DEFAULT 0,
+    amount            NUMERIC   NOT NULL DEFAULT 0,
+    tiers             JSONB,
+    min_amount        NUMERIC   NOT NULL DEFAULT 0,
+    max_amount        NUMERIC   NOT NULL DEFAULT 0,
+    fee_balance       TEXT      NOT NULL,
+    ledger_id         TEXT,
+    currency          TEXT,
+    meta_data         JSONB,
+    identity_category TEXT,
+    active            BOOLEAN   NOT NULL DEFAULT TRUE,
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_rules_active_currency ON blnk.fee_rules (active, currency);
+
+-- +migrate Down
+DROP TABLE IF EXISTS blnk.fee_rules;
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/internal/pdf"
+	"github.com/jerry-enebeli/blnk/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetBalanceStatement builds the statement of a balance for a period: its balance at the start of the period,
+// every posting made in the period with the running balance after it, and its balance at the end of the period.
+// The period ends now if no end is given or the end is in the future.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - balanceID string: The ID of the balance.
+// - from time.Time: The start of the period.
+// - to time.Time: The end of the period.
+//
+// Returns:
+// - *model.Statement: A pointer to the statement.
+// - error: An error if the period is invalid or the statement could not be built.
+func (l *Blnk) GetBalanceStatement(ctx context.Context, balanceID string, from, to time.Time) (*model.Statement, error) {
+	ctx, span := balanceTracer.Start(ctx, "GetBalanceStatement")
+	defer span.End()
+
+	if now := time.Now(); to.IsZero() || to.After(now) {
+		to = now
+	}
+	if !from.Before(to) {
+		err := errors.New("from must be before to")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	opening, err := l.datasource.GetBalanceAtTime(ctx, balanceID, from)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	entries, err := l.datasource.GetStatementEntries(ctx, balanceID, from, to)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	statement := &model.Statement{
+		BalanceID:      balanceID,
+		Currency:       opening.Currency,
+		Precision:      1,
+		From:           from,
+		To:             to,
+		OpeningBalance: new(big.Int).Set(opening.Balance),
+		TotalCredits:   new(big.Int),
+		TotalDebits:    new(big.Int),
+		Entries:        entries,
+	}
+
+	running := new(big.Int).Set(opening.Balance)
+	for i := range statement.Entries {
+		entry := &statement.Entries[i]
+		if entry.Direction == model.StatementEntryCredit {
+			running.Add(running, entry.Amount)
+			statement.TotalCredits.Add(statement.TotalCredits, entry.Amount)
+		} else {
+			running.Sub(running, entry.Amount)
+			statement.TotalDebits.Add(statement.TotalDebits, entry.Amount)
+		}
+		entry.BalanceAfter = new(big.Int).Set(running)
+		if entry.Precision != 0 {
+			statement.Precision = entry.Precision
+		}
+	}
+	statement.ClosingBalance = running
+
+	span.AddEvent("Statement built", trace.WithAttributes(
+		attribute.String("balance.id", balanceID),
+		attribute.Int("statement.entries", len(entries)),
+	))
+	return statement, nil
+}
+
+// WriteStatementCSV writes a statement as CSV, with the opening balance as the first row and the closing balance as the last.
+//
+// Parameters:
+// - w io.Writer: The writer to write the CSV to.
+// - statement *model.Statement: The statement to write.
+//
+// Returns:
+// - error: An error if the CSV could not be written.
+func WriteStatementCSV(w io.Writer, statement *model.Statement) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		{"date", "transaction_id", "reference", "description", "counterparty", "direction", "amount", "balance"},
+		{statement.From.Format(time.RFC3339), "", "", "Opening balance", "", "", "", model.FormatPreciseAmount(statement.OpeningBalance, statement.Precision)},
+	}
+	for _, entry := range statement.Entries {
+		rows = append(rows, []string{
+			entry.EffectiveDate.Format(time.RFC3339), entry.TransactionID, entry.Reference, entry.Description, entry.Counterparty, entry.Direction,
+			model.FormatPreciseAmount(entry.Amount, entry.Precision), model.FormatPreciseAmount(entry.BalanceAfter, statement.Precision),
+		})
+	}
+	rows = append(rows, []string{statement.To.Format(time.RFC3339), "", "", "Closing balance", "", "", "", model.FormatPreciseAmount(statement.ClosingBalance, statement.Precision)})
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteStatementPDF renders a statement as a PDF document.
+//
+// Parameters:
+// - w io.Writer: The writer to write the PDF to.
+// - statement *model.Statement: The statement to render.
+//
+// Returns:
+// - error: An error if the PDF could not be written.
+func WriteStatementPDF(w io.Writer, statement *model.Statement) error {
+	// Sized so that full transaction and balance IDs fit on an A4 landscape page
+	const size = 7
+	row := "%-19s %-40s %-20s %-22s %-40s %-6s %14s %14s"
+	truncate := func(value string, width int) string {
+		if runes := []rune(value); len(runes) > width {
+			return string(runes[:width-1]) + "~"
+		}
+		return value
+	}
+
+	doc := pdf.New()
+	doc.Heading(fmt.Sprintf("Statement for balance %s", statement.BalanceID), 12)
+	doc.Text(fmt.Sprintf("Period: %s to %s", statement.From.Format(time.RFC3339), statement.To.Format(time.RFC3339)), size)
+	doc.Text(fmt.Sprintf("Currency: %s", statement.Currency), size)
+	doc.Text(fmt.Sprintf("Opening balance: %s", model.FormatPreciseAmount(statement.OpeningBalance, statement.Precision)), size)
+	doc.Text(fmt.Sprintf("Total credits: %s", model.FormatPreciseAmount(statement.TotalCredits, statement.Precision)), size)
+	doc.Text(fmt.Sprintf("Total debits: %s", model.FormatPreciseAmount(statement.TotalDebits, statement.Precision)), size)
+	doc.Text(fmt.Sprintf("Closing balance: %s", model.FormatPreciseAmount(statement.ClosingBalance, statement.Precision)), size)
+	doc.Space(size)
+
+	doc.Text(fmt.Sprintf(row, "Date", "Transaction", "Reference", "Description", "Counterparty", "Type", "Amount", "Balance"), size)
+	for _, entry := range statement.Entries {
+		doc.Text(fmt.Sprintf(row,
+			entry.EffectiveDate.Format("2006-01-02 15:04:05"), truncate(entry.TransactionID, 40), truncate(entry.Reference, 20), truncate(entry.Description, 22),
+			truncate(entry.Counterparty, 40), entry.Direction, model.FormatPreciseAmount(entry.Amount, entry.Precision), model.FormatPreciseAmount(entry.BalanceAfter, statement.Precision),
+		), size)
+	}
+	if len(statement.Entries) == 0 {
+		doc.Text("No postings in this period.", size)
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 Blnk Finance Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blnk
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jerry-enebeli/blnk/database/mocks"
+	"github.com/jerry-enebeli/blnk/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetBalanceStatement(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	mockDS.On("GetBalanceAtTime", mock.Anything, "bln_1", from).Return(&model.Balance{BalanceID: "bln_1", Currency: "USD", Balance: big.NewInt(1000)}, nil)
+	mockDS.On("GetStatementEntries", mock.Anything, "bln_1", from, to).Return([]model.StatementEntry{
+		{TransactionID: "txn_1", Reference: "inv_1", Description: "Invoice, May", Counterparty: "bln_2", Direction: model.StatementEntryCredit, Amount: big.NewInt(500), Precision: 100, CreatedAt: from.Add(48 * time.Hour), EffectiveDate: from.Add(time.Hour)},
+		{TransactionID: "txn_2", Reference: "fee_1", Counterparty: "bln_3", Direction: model.StatementEntryDebit, Amount: big.NewInt(200), Precision: 100, CreatedAt: from.Add(2 * time.Hour), EffectiveDate: from.Add(2 * time.Hour)},
+	}, nil)
+
+	statement, err := blnk.GetBalanceStatement(ctx, "bln_1", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, "1000", statement.OpeningBalance.String())
+	assert.Equal(t, "1500", statement.Entries[0].BalanceAfter.String())
+	assert.Equal(t, "1300", statement.Entries[1].BalanceAfter.String())
+	assert.Equal(t, "1300", statement.ClosingBalance.String())
+	assert.Equal(t, "500", statement.TotalCredits.String())
+	assert.Equal(t, "200", statement.TotalDebits.String())
+
+	var csvOut bytes.Buffer
+	assert.NoError(t, WriteStatementCSV(&csvOut, statement))
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "2024-05-01T00:00:00Z,,,Opening balance,,,,10.00", lines[1])
+	// Entries are dated by their effective date, the back-dated invoice was recorded two days later
+	assert.Equal(t, `2024-05-01T01:00:00Z,txn_1,inv_1,"Invoice, May",bln_2,credit,5.00,15.00`, lines[2])
+	assert.Equal(t, "2024-06-01T00:00:00Z,,,Closing balance,,,,13.00", lines[4])
+
+	var pdfOut bytes.Buffer
+	assert.NoError(t, WriteStatementPDF(&pdfOut, statement))
+	assert.True(t, strings.HasPrefix(pdfOut.String(), "%PDF-"))
+	assert.Contains(t, pdfOut.String(), "Closing balance: 13.00")
+}
+
+func TestGetBalanceStatement_InvalidPeriod(t *testing.T) {
+	blnk := &Blnk{datasource: new(mocks.MockDataSource)}
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := blnk.GetBalanceStatement(context.Background(), "bln_1", from, from.Add(-time.Hour))
+	assert.Error(t, err)
+}
@@ -147,18 +147,28 @@ func (l *Blnk) getSourceAndDestination(ctx context.Context, transaction *model.T
 
 // acquireLock acquires a distributed lock for a transaction to ensure exclusive access to the source balance.
 // It starts a tracing span, attempts to acquire the lock, and records relevant events and errors.
+// The lock is keyed on the balance ID, as journal entries lock it, so a source indicator is resolved to its balance first.
 //
 // Parameters:
 // - ctx context.Context: The context for the operation.
-// - transaction *model.Transaction: The transaction for which to acquire the lock.
+// - transaction *model.Transaction: The transaction for which to acquire the lock. An indicator source is replaced by its balance ID.
 //
 // Returns:
 // - *redlock.Locker: A pointer to the acquired Locker if successful.
-// - error: An error if the lock could not be acquired.
+// - error: An error if the source balance could not be resolved or the lock could not be acquired.
 func (l *Blnk) acquireLock(ctx context.Context, transaction *model.Transaction) (*redlock.Locker, error) {
 	ctx, span := tracer.Start(ctx, "Acquiring Lock")
 	defer span.End()
 
+	if strings.HasPrefix(transaction.Source, "@") {
+		sourceBalance, err := l.getOrCreateBalanceByIndicator(ctx, transaction.Source, transaction.Currency)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		transaction.Source = sourceBalance.BalanceID
+	}
+
 	locker := redlock.NewLocker(l.redis, transaction.Source, model.GenerateUUIDWithSuffix("loc"))
 	err := locker.Lock(ctx, time.Minute*30)
 	if err != nil {
@@ -205,22 +215,23 @@ func (l *Blnk) updateTransactionDetails(ctx context.Context, transaction *model.
 	return &newTransaction
 }
 
-// persistTransaction persists a transaction to the database.
-// It starts a tracing span, records the transaction, and handles any errors that occur during the process.
+// persistTransaction persists a transaction to the database together with the balances it was applied to.
+// It starts a tracing span and records the balances and the transaction in a single database transaction,
+// so a transfer is either posted whole or not at all.
 //
 // Parameters:
 // - ctx context.Context: The context for the operation.
 // - transaction *model.Transaction: The transaction to be persisted.
+// - balances ...*model.Balance: The balances the transaction was applied to.
 //
 // Returns:
 // - *model.Transaction: A pointer to the persisted Transaction model.
 // - error: An error if the transaction could not be persisted.
-func (l *Blnk) persistTransaction(ctx context.Context, transaction *model.Transaction) (*model.Transaction, error) {
+func (l *Blnk) persistTransaction(ctx context.Context, transaction *model.Transaction, balances ...*model.Balance) (*model.Transaction, error) {
 	ctx, span := tracer.Start(ctx, "Persisting Transaction")
 	defer span.End()
 
-	transaction, err := l.datasource.RecordTransaction(ctx, transaction)
-	if err != nil {
+	if err := l.datasource.RecordJournalEntry(ctx, transaction, nil, balances); err != nil {
 		span.RecordError(err)
 		logrus.Errorf("ERROR saving transaction to db. %s", err)
 		return nil, err
@@ -258,61 +269,6 @@ func (l *Blnk) postTransactionActions(ctx context.Context, transaction *model.Tr
 	}()
 }
 
-// updateBalances updates the source and destination balances in the database.
-// It starts a tracing span, updates the balances, and performs post-update actions such as checking balance monitors
-// and queuing the balances for indexing.
-//
-// Parameters:
-// - ctx context.Context: The context for the operation.
-// - sourceBalance *model.Balance: The source balance to be updated.
-// - destinationBalance *model.Balance: The destination balance to be updated.
-//
-// Returns:
-// - error: An error if the balances could not be updated.
-func (l *Blnk) updateBalances(ctx context.Context, sourceBalance, destinationBalance *model.Balance) error {
-	ctx, span := tracer.Start(ctx, "Updating Balances")
-	defer span.End()
-
-	var wg sync.WaitGroup
-
-	// Update the balances in the datasource
-	if err := l.datasource.UpdateBalances(ctx, sourceBalance, destinationBalance); err != nil {
-		span.RecordError(err)
-		return err
-	}
-
-	// Add two tasks to the wait group
-	wg.Add(2)
-
-	// Goroutine to check monitors and queue index data for the source balance
-	go func() {
-		defer wg.Done()
-		l.checkBalanceMonitors(ctx, sourceBalance)
-		err := l.queue.queueIndexData(sourceBalance.BalanceID, "balances", sourceBalance)
-		if err != nil {
-			span.RecordError(err)
-			notification.NotifyError(err)
-		}
-	}()
-
-	// Goroutine to check monitors and queue index data for the destination balance
-	go func() {
-		defer wg.Done()
-		l.checkBalanceMonitors(ctx, destinationBalance)
-		err := l.queue.queueIndexData(destinationBalance.BalanceID, "balances", destinationBalance)
-		if err != nil {
-			span.RecordError(err)
-			notification.NotifyError(err)
-		}
-	}()
-
-	// Wait for both goroutines to complete
-	wg.Wait()
-
-	span.AddEvent("Balances updated")
-	return nil
-}
-
 // validateTxn validates a transaction by checking if its reference has already been used.
 // It starts a tracing span, checks the existence of the transaction reference, and records relevant events and errors.
 //
@@ -367,7 +323,10 @@ func (l *Blnk) applyTransactionToBalances(ctx context.Context, balances []*model
 		return nil
 	}
 
-	transactionAmount := new(big.Int).SetInt64(transaction.PreciseAmount)
+	transactionAmount := transaction.PreciseAmount
+	if transactionAmount == nil {
+		transactionAmount = model.ApplyPrecision(transaction)
+	}
 
 	// Handle voided transactions
 	if transaction.Status == StatusVoid {
@@ -638,7 +597,7 @@ func (l *Blnk) RefundWorker(ctx context.Context, jobs <-chan *model.Transaction,
 
 	defer wg.Done()
 	for originalTxn := range jobs {
-		queuedRefundTxn, err := l.RefundTransaction(ctx, originalTxn.TransactionID)
+		queuedRefundTxn, err := l.RefundTransaction(ctx, originalTxn.TransactionID, amount)
 		if err != nil {
 			results <- BatchJobResult{Error: err}
 			span.RecordError(err)
@@ -727,6 +686,11 @@ func (l *Blnk) RecordTransaction(ctx context.Context, transaction *model.Transac
 	ctx, span := tracer.Start(ctx, "RecordTransaction")
 	defer span.End()
 
+	// Atomic multi-leg transactions are posted as a single journal entry with their own locking
+	if isJournalEntry(transaction) {
+		return l.RecordJournalEntry(ctx, transaction)
+	}
+
 	return l.executeWithLock(ctx, transaction, func(ctx context.Context) (*model.Transaction, error) {
 		// Validate and prepare the transaction, including retrieving source and destination balances
 		transaction, sourceBalance, destinationBalance, err := l.validateAndPrepareTransaction(ctx, transaction)
@@ -751,6 +715,9 @@ func (l *Blnk) RecordTransaction(ctx context.Context, transaction *model.Transac
 		// Perform post-transaction actions such as indexing and sending webhooks
 		l.postTransactionActions(ctx, transaction)
 
+		// A conversion away from the rate in effect books its spread
+		l.bookFXSpread(ctx, transaction, sourceBalance, destinationBalance)
+
 		span.AddEvent("Transaction processed", trace.WithAttributes(attribute.String("transaction.id", transaction.TransactionID)))
 		return transaction, nil
 	})
@@ -812,11 +779,45 @@ func (l *Blnk) validateAndPrepareTransaction(ctx context.Context, transaction *m
 		return nil, nil, nil, l.logAndRecordError(span, "failed to get source and destination balances", err)
 	}
 
+	// The status of each balance decides whether it can be debited or credited
+	if err := sourceBalance.CanDebit(); err != nil {
+		span.RecordError(err)
+		return nil, nil, nil, l.logAndRecordError(span, "source balance cannot be debited", err)
+	}
+	if err := destinationBalance.CanCredit(); err != nil {
+		span.RecordError(err)
+		return nil, nil, nil, l.logAndRecordError(span, "destination balance cannot be credited", err)
+	}
+
+	// The ledgers' transfer rules decide which account types may transact
+	if err := l.checkTransferRules(sourceBalance, destinationBalance); err != nil {
+		span.RecordError(err)
+		return nil, nil, nil, l.logAndRecordError(span, "transfer not allowed between balances", err)
+	}
+
+	// Nothing can be posted in a period the ledgers have closed
+	if err := l.checkPeriodOpen(ctx, transaction.PostingDate(), sourceBalance, destinationBalance); err != nil {
+		span.RecordError(err)
+		return nil, nil, nil, l.logAndRecordError(span, "transaction falls in a closed accounting period", err)
+	}
+
+	// A sweep or top-up moves what the balances call for now, not when it was queued
+	if err := l.sizeMonitorAction(ctx, transaction, sourceBalance, destinationBalance); err != nil {
+		span.RecordError(err)
+		return nil, nil, nil, l.logAndRecordError(span, "monitor action has nothing to move", err)
+	}
+
 	// Create a copy of the transaction and update it (immutable)
 	newTransaction := *transaction // Copy the original transaction
 	newTransaction.Source = sourceBalance.BalanceID
 	newTransaction.Destination = destinationBalance.BalanceID
 
+	// The currencies must line up, or a rate must convert between them
+	if err := l.applyFXRate(ctx, &newTransaction, sourceBalance, destinationBalance); err != nil {
+		span.RecordError(err)
+		return nil, nil, nil, l.logAndRecordError(span, "currency mismatch between transaction and balances", err)
+	}
+
 	span.AddEvent("Transaction validated and prepared", trace.WithAttributes(
 		attribute.String("source.balance_id", sourceBalance.BalanceID),
 		attribute.String("destination.balance_id", destinationBalance.BalanceID)))
@@ -825,8 +826,9 @@ func (l *Blnk) validateAndPrepareTransaction(ctx context.Context, transaction *m
 	return &newTransaction, sourceBalance, destinationBalance, nil
 }
 
-// processBalances processes the source and destination balances by applying the transaction and updating the balances.
-// It starts a tracing span, applies the transaction to the balances, updates the balances, and records relevant events and errors.
+// processBalances processes the source and destination balances by applying the transaction to them.
+// It starts a tracing span, applies the transaction to the balances, records the running balances on the transaction,
+// and records relevant events and errors. The balances are written with the transaction by finalizeTransaction.
 //
 // Parameters:
 // - ctx context.Context: The context for the operation.
@@ -835,29 +837,64 @@ func (l *Blnk) validateAndPrepareTransaction(ctx context.Context, transaction *m
 // - destinationBalance *model.Balance: The destination balance to be updated.
 //
 // Returns:
-// - error: An error if the transaction could not be applied to the balances or if the balances could not be updated.
+// - error: An error if the transaction could not be applied to the balances.
 func (l *Blnk) processBalances(ctx context.Context, transaction *model.Transaction, sourceBalance, destinationBalance *model.Balance) error {
 	ctx, span := tracer.Start(ctx, "ProcessBalances")
 	defer span.End()
 
+	// Keep the balances as they were before the transaction for the running balance
+	sourceBefore, destinationBefore := balanceAmount(sourceBalance), balanceAmount(destinationBalance)
+
 	// Apply the transaction to the source and destination balances
 	if err := l.applyTransactionToBalances(ctx, []*model.Balance{sourceBalance, destinationBalance}, transaction); err != nil {
 		span.RecordError(err)
 		return l.logAndRecordError(span, "failed to apply transaction to balances", err)
 	}
 
-	// Update the source and destination balances in the datasource
-	if err := l.updateBalances(ctx, sourceBalance, destinationBalance); err != nil {
-		span.RecordError(err)
-		return l.logAndRecordError(span, "failed to update balances", err)
-	}
+	// Record the running balances and the balance versions produced by this transaction
+	setRunningBalances(transaction, sourceBalance, destinationBalance, sourceBefore, destinationBefore)
 
 	span.AddEvent("Balances processed")
 	return nil
 }
 
+// balanceAmount returns a copy of a balance's current amount, treating an unset amount as zero.
+//
+// Parameters:
+// - balance *model.Balance: The balance to read.
+//
+// Returns:
+// - *big.Int: A copy of the balance amount.
+func balanceAmount(balance *model.Balance) *big.Int {
+	if balance.Balance == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(balance.Balance)
+}
+
+// setRunningBalances records the source and destination balances before and after a transaction,
+// together with the balance versions it produces, so every posting can be audited on its own.
+// Each balance is written once with the transaction, or once for a whole journal entry, at its next version.
+//
+// Parameters:
+// - transaction *model.Transaction: The transaction to update.
+// - sourceBalance *model.Balance: The source balance after the transaction.
+// - destinationBalance *model.Balance: The destination balance after the transaction.
+// - sourceBefore *big.Int: The source balance amount before the transaction.
+// - destinationBefore *big.Int: The destination balance amount before the transaction.
+func setRunningBalances(transaction *model.Transaction, sourceBalance, destinationBalance *model.Balance, sourceBefore, destinationBefore *big.Int) {
+	transaction.SourceBalanceBefore = sourceBefore
+	transaction.SourceBalanceAfter = balanceAmount(sourceBalance)
+	transaction.SourceBalanceVersion = sourceBalance.Version + 1
+	transaction.DestinationBalanceBefore = destinationBefore
+	transaction.DestinationBalanceAfter = balanceAmount(destinationBalance)
+	transaction.DestinationBalanceVersion = destinationBalance.Version + 1
+}
+
 // finalizeTransaction finalizes the transaction by updating its details and persisting it to the database.
-// It starts a tracing span, updates the transaction details, persists the transaction, and records relevant events and errors.
+// It starts a tracing span, updates the transaction details, persists the transaction with its balances, and queues
+// checks of the balance monitors and the balances for indexing. Monitors are checked by a worker, so their actions
+// run after the transaction releases its lock.
 //
 // Parameters:
 // - ctx context.Context: The context for the operation.
@@ -875,13 +912,16 @@ func (l *Blnk) finalizeTransaction(ctx context.Context, transaction *model.Trans
 	// Update the transaction details with the source and destination balances
 	transaction = l.updateTransactionDetails(ctx, transaction, sourceBalance, destinationBalance)
 
-	// Persist the transaction to the database
-	transaction, err := l.persistTransaction(ctx, transaction)
+	// Persist the transaction and the balances to the database
+	transaction, err := l.persistTransaction(ctx, transaction, sourceBalance, destinationBalance)
 	if err != nil {
 		span.RecordError(err)
 		return nil, l.logAndRecordError(span, "failed to persist transaction", err)
 	}
 
+	// Check monitors and index the balances once the transaction has released its lock
+	l.queueBalanceMonitorChecks(ctx, transaction, sourceBalance, destinationBalance)
+
 	span.AddEvent("Transaction processed", trace.WithAttributes(attribute.String("transaction.id", transaction.TransactionID)))
 
 	return transaction, nil
@@ -953,6 +993,14 @@ func (l *Blnk) RejectTransaction(ctx context.Context, transaction *model.Transac
 		return nil, err
 	}
 
+	// A rejected refund never moved any money, so it no longer counts towards the refunded total of its original
+	if transaction.Refund && transaction.ParentTransaction != "" && transaction.PreciseAmount != nil {
+		if err := l.datasource.ReleaseRefund(ctx, transaction.ParentTransaction, transaction.PreciseAmount); err != nil {
+			span.RecordError(err)
+			logrus.Errorf("failed to release refund of transaction %s: %v", transaction.ParentTransaction, err)
+		}
+	}
+
 	span.AddEvent("Transaction rejected", trace.WithAttributes(attribute.String("transaction.id", transaction.TransactionID)))
 
 	return transaction, nil
@@ -1014,30 +1062,39 @@ func (l *Blnk) validateAndUpdateAmount(ctx context.Context, transaction *model.T
 	}
 
 	originalAmount := transaction.PreciseAmount
-	amountLeft := originalAmount - committedAmount
+	if originalAmount == nil {
+		originalAmount = model.ApplyPrecision(transaction)
+	}
+	amountLeft := new(big.Int).Sub(originalAmount, committedAmount)
 
 	// Update the transaction amount based on the provided amount
 	if amount != 0 {
+		preciseAmount, err := model.ToPreciseAmount(amount, transaction.Precision)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
 		transaction.Amount = amount
-		transaction.PreciseAmount = 0
+		transaction.PreciseAmount = preciseAmount
 	} else {
-		transaction.Amount = float64(amountLeft) / transaction.Precision
+		transaction.Amount = model.FromPreciseAmount(amountLeft, transaction.Precision)
+		transaction.PreciseAmount = amountLeft
 	}
 
 	// Validate the remaining amount
-	if amountLeft < model.ApplyPrecision(transaction) {
+	if amountLeft.Cmp(transaction.PreciseAmount) < 0 {
 		err := fmt.Errorf("cannot commit %s %.2f. You can only commit an amount between 1.00 - %s%.2f",
-			transaction.Currency, amount, transaction.Currency, float64(amountLeft)/transaction.Precision)
+			transaction.Currency, amount, transaction.Currency, model.FromPreciseAmount(amountLeft, transaction.Precision))
 		span.RecordError(err)
 		return err
-	} else if amountLeft == 0 {
+	} else if amountLeft.Sign() == 0 {
 		err := fmt.Errorf("cannot commit %s %.2f. Transaction already committed with amount of - %s%.2f",
-			transaction.Currency, amount, transaction.Currency, float64(committedAmount)/transaction.Precision)
+			transaction.Currency, amount, transaction.Currency, model.FromPreciseAmount(committedAmount, transaction.Precision))
 		span.RecordError(err)
 		return err
 	}
 
-	span.AddEvent("Amount validated and updated", trace.WithAttributes(attribute.Float64("amount.left", float64(amountLeft)/transaction.Precision)))
+	span.AddEvent("Amount validated and updated", trace.WithAttributes(attribute.String("amount.left", amountLeft.String())))
 	return nil
 }
 
@@ -1060,6 +1117,7 @@ func (l *Blnk) finalizeCommitment(ctx context.Context, transaction *model.Transa
 	transaction.ParentTransaction = transaction.TransactionID
 	transaction.TransactionID = model.GenerateUUIDWithSuffix("txn")
 	transaction.Reference = model.GenerateUUIDWithSuffix("ref")
+	transaction.EffectiveDate = time.Time{} // Booked when it is committed, not on the date of the inflight transaction
 	transaction.Hash = transaction.HashTxn()
 
 	// Queue the transaction for further processing
@@ -1094,6 +1152,12 @@ func (l *Blnk) VoidInflightTransaction(ctx context.Context, transactionID string
 		return nil, err
 	}
 
+	// An inflight transaction in a closed period stays as it was closed
+	if err := l.checkTransactionPeriodOpen(ctx, transaction); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
 	// Calculate the remaining amount for the transaction
 	amountLeft, err := l.calculateRemainingAmount(ctx, transaction)
 	if err != nil {
@@ -1178,9 +1242,9 @@ func (l *Blnk) fetchAndValidateInflightTransaction(ctx context.Context, transact
 // - transaction *model.Transaction: The transaction for which to calculate the remaining amount.
 //
 // Returns:
-// - int64: The remaining amount for the transaction.
+// - *big.Int: The remaining amount for the transaction.
 // - error: An error if the committed amount could not be fetched.
-func (l *Blnk) calculateRemainingAmount(ctx context.Context, transaction *model.Transaction) (int64, error) {
+func (l *Blnk) calculateRemainingAmount(ctx context.Context, transaction *model.Transaction) (*big.Int, error) {
 	ctx, span := tracer.Start(ctx, "CalculateRemainingAmount")
 	defer span.End()
 
@@ -1188,13 +1252,17 @@ func (l *Blnk) calculateRemainingAmount(ctx context.Context, transaction *model.
 	committedAmount, err := l.datasource.GetTotalCommittedTransactions(ctx, transaction.TransactionID)
 	if err != nil {
 		span.RecordError(err)
-		return 0, l.logAndRecordError(span, "error fetching committed amount", err)
+		return nil, l.logAndRecordError(span, "error fetching committed amount", err)
 	}
 
 	// Calculate the remaining amount
-	remainingAmount := transaction.PreciseAmount - committedAmount
+	originalAmount := transaction.PreciseAmount
+	if originalAmount == nil {
+		originalAmount = model.ApplyPrecision(transaction)
+	}
+	remainingAmount := new(big.Int).Sub(originalAmount, committedAmount)
 
-	span.AddEvent("Remaining amount calculated", trace.WithAttributes(attribute.Int64("amount.remaining", remainingAmount)))
+	span.AddEvent("Remaining amount calculated", trace.WithAttributes(attribute.String("amount.remaining", remainingAmount.String())))
 	return remainingAmount, nil
 }
 
@@ -1204,22 +1272,23 @@ func (l *Blnk) calculateRemainingAmount(ctx context.Context, transaction *model.
 // Parameters:
 // - ctx context.Context: The context for the operation.
 // - transaction *model.Transaction: The transaction to be voided.
-// - amountLeft int64: The remaining amount to be set in the transaction.
+// - amountLeft *big.Int: The remaining amount to be set in the transaction.
 //
 // Returns:
 // - *model.Transaction: A pointer to the voided Transaction model.
 // - error: An error if the transaction could not be queued.
-func (l *Blnk) finalizeVoidTransaction(ctx context.Context, transaction *model.Transaction, amountLeft int64) (*model.Transaction, error) {
+func (l *Blnk) finalizeVoidTransaction(ctx context.Context, transaction *model.Transaction, amountLeft *big.Int) (*model.Transaction, error) {
 	ctx, span := tracer.Start(ctx, "FinalizeVoidTransaction")
 	defer span.End()
 
 	// Update the transaction status to void and set the remaining amount
 	transaction.Status = StatusVoid
-	transaction.Amount = float64(amountLeft) / transaction.Precision
+	transaction.Amount = model.FromPreciseAmount(amountLeft, transaction.Precision)
 	transaction.PreciseAmount = amountLeft
 	transaction.ParentTransaction = transaction.TransactionID
 	transaction.TransactionID = model.GenerateUUIDWithSuffix("txn")
 	transaction.Reference = model.GenerateUUIDWithSuffix("ref")
+	transaction.EffectiveDate = time.Time{} // Booked when it is voided, not on the date of the inflight transaction
 	transaction.Hash = transaction.HashTxn()
 
 	// Queue the transaction for further processing
@@ -1247,10 +1316,36 @@ func (l *Blnk) QueueTransaction(ctx context.Context, transaction *model.Transact
 	ctx, span := tracer.Start(ctx, "QueueTransaction")
 	defer span.End()
 
+	// A back-dated transaction is checked against closed periods before it is queued
+	if err := l.checkEffectiveDate(ctx, transaction); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
 	// Set transaction status and metadata
 	span.AddEvent("Setting transaction status and metadata")
 	setTransactionStatus(transaction)
-	setTransactionMetadata(transaction)
+	if err := l.applyCurrency(ctx, transaction); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := setTransactionMetadata(transaction); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	transaction.Atomic = isJournalEntry(transaction)
+
+	// Fee rules add their fee legs, which are posted with the transaction as one journal entry
+	if err := l.applyFees(ctx, transaction); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// A conversion at an FX quote uses the quote up before the transaction is queued
+	if err := l.useFXQuote(ctx, transaction); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
 
 	// Attempt to split the transaction if needed
 	transactions, err := transaction.SplitTransaction(ctx)
@@ -1262,8 +1357,9 @@ func (l *Blnk) QueueTransaction(ctx context.Context, transaction *model.Transact
 		return nil, err
 	}
 
-	// Enqueue the transaction(s)
-	if len(transactions) == 0 {
+	// Enqueue the transaction(s). An atomic entry is queued whole, its legs were only split
+	// to validate the distributions and assign their transaction IDs.
+	if len(transactions) == 0 || transaction.Atomic {
 		transactions = []*model.Transaction{transaction}
 	}
 
@@ -1274,6 +1370,11 @@ func (l *Blnk) QueueTransaction(ctx context.Context, transaction *model.Transact
 
 	if err := enqueueTransactions(ctx, l.queue, transaction, transactions); err != nil {
 		span.RecordError(err)
+		if transaction.QuoteID != "" {
+			if releaseErr := l.datasource.ReleaseFXQuote(ctx, transaction.QuoteID, transaction.TransactionID); releaseErr != nil {
+				logrus.Errorf("failed to release FX quote %s: %v", transaction.QuoteID, releaseErr)
+			}
+		}
 		span.AddEvent("Failed to enqueue transactions", trace.WithAttributes(
 			attribute.String("transaction.id", transaction.TransactionID),
 		))
@@ -1308,14 +1409,33 @@ func setTransactionStatus(transaction *model.Transaction) {
 // setTransactionMetadata sets the metadata for a transaction, including skipping balance updates, setting creation time,
 // generating a new transaction ID, hashing the transaction, and calculating the precise amount.
 //
+// The precise amount is computed exactly, so an amount with more decimal places than the precision allows is rejected.
+// A precise amount that is already set (e.g. an 18-decimal token amount) is kept as is and the amount is derived from it.
+//
 // Parameters:
 // - transaction *model.Transaction: The transaction for which to set the metadata.
-func setTransactionMetadata(transaction *model.Transaction) {
+//
+// Returns:
+// - error: An error if the amount cannot be converted into whole minor units.
+func setTransactionMetadata(transaction *model.Transaction) error {
+	if transaction.PreciseAmount == nil {
+		preciseAmount, err := model.ToPreciseAmount(transaction.Amount, transaction.Precision)
+		if err != nil {
+			return err
+		}
+		transaction.PreciseAmount = preciseAmount
+	} else {
+		transaction.Amount = model.FromPreciseAmount(transaction.PreciseAmount, transaction.Precision)
+	}
+
 	transaction.SkipBalanceUpdate = true
 	transaction.CreatedAt = time.Now()
+	if transaction.EffectiveDate.IsZero() {
+		transaction.EffectiveDate = transaction.CreatedAt
+	}
 	transaction.TransactionID = model.GenerateUUIDWithSuffix("txn")
 	transaction.Hash = transaction.HashTxn()
-	transaction.PreciseAmount = int64(transaction.Amount * transaction.Precision)
+	return nil
 }
 
 // enqueueTransactions enqueues the original transaction or its split transactions into the provided queue.
@@ -1393,6 +1513,30 @@ func (l *Blnk) GetAllTransactions(limit, offset int) ([]model.Transaction, error
 	return transactions, nil
 }
 
+// ListTransactions retrieves a page of transactions matching a filter, newest first, straight from the database.
+// It starts a tracing span, fetches the page, and records relevant events and errors.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - filter model.TransactionFilter: The filters, page size and cursor of the page to retrieve.
+//
+// Returns:
+// - *model.TransactionPage: The page of transactions along with the cursor of the next page.
+// - error: An error if the transactions could not be retrieved.
+func (l *Blnk) ListTransactions(ctx context.Context, filter model.TransactionFilter) (*model.TransactionPage, error) {
+	ctx, span := tracer.Start(ctx, "ListTransactions")
+	defer span.End()
+
+	page, err := l.datasource.ListTransactions(ctx, filter)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.AddEvent("Transactions listed", trace.WithAttributes(attribute.Int("transaction.count", len(page.Transactions))))
+	return page, nil
+}
+
 // GetTransactionByRef retrieves a transaction by its reference from the datasource.
 // It starts a tracing span, fetches the transaction by reference, and records relevant events and errors.
 //
@@ -1443,17 +1587,20 @@ func (l *Blnk) UpdateTransactionStatus(ctx context.Context, id string, status st
 	return nil
 }
 
-// RefundTransaction processes a refund for a given transaction by its ID.
-// It starts a tracing span, retrieves the original transaction, validates its status, creates a new refund transaction, and queues it.
+// RefundTransaction processes a full or partial refund for a given transaction by its ID.
+// It starts a tracing span, retrieves the original transaction, validates its status and refundable amount, reserves the refund
+// against the original, creates a new refund transaction, and queues it. A transaction can be refunded several times, as long as
+// the refunds together do not exceed its amount.
 //
 // Parameters:
 // - ctx context.Context: The context for the operation.
 // - transactionID string: The ID of the transaction to be refunded.
+// - amount float64: The amount to refund, or 0 to refund everything that has not been refunded yet.
 //
 // Returns:
 // - *model.Transaction: A pointer to the refunded Transaction model.
 // - error: An error if the transaction could not be refunded.
-func (l *Blnk) RefundTransaction(ctx context.Context, transactionID string) (*model.Transaction, error) {
+func (l *Blnk) RefundTransaction(ctx context.Context, transactionID string, amount float64) (*model.Transaction, error) {
 	ctx, span := tracer.Start(ctx, "RefundTransaction")
 	defer span.End()
 
@@ -1462,9 +1609,8 @@ func (l *Blnk) RefundTransaction(ctx context.Context, transactionID string) (*mo
 	if err != nil {
 		// Check if the error is due to no row found
 		if strings.Contains(err.Error(), fmt.Sprintf("Transaction with ID '%s' not found", transactionID)) {
-			// Check the queue for the transaction
+			// A transaction still in the queue has no row to reserve the refund against yet
 			queuedTxn, err := l.queue.GetTransactionFromQueue(transactionID)
-			log.Println("found transaction in queue using it for refund", transactionID, queuedTxn.TransactionID)
 			if err != nil {
 				span.RecordError(err)
 				return &model.Transaction{}, err
@@ -1474,7 +1620,9 @@ func (l *Blnk) RefundTransaction(ctx context.Context, transactionID string) (*mo
 				span.RecordError(err)
 				return nil, err
 			}
-			originalTxn = queuedTxn
+			err = fmt.Errorf("transaction %s has not been recorded yet and is not in a state that can be refunded", transactionID)
+			span.RecordError(err)
+			return nil, err
 		} else {
 			span.RecordError(err)
 			return &model.Transaction{}, err
@@ -1487,6 +1635,21 @@ func (l *Blnk) RefundTransaction(ctx context.Context, transactionID string) (*mo
 		span.RecordError(err)
 		return nil, err
 	}
+	if originalTxn.Refund {
+		err := fmt.Errorf("transaction is a refund and cannot be refunded")
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := l.checkTransactionPeriodOpen(ctx, originalTxn); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	refundAmount, err := l.reserveRefund(ctx, originalTxn, amount)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
 
 	// Update the transaction status for refund processing
 	if originalTxn.Status == StatusVoid {
@@ -1502,14 +1665,107 @@ func (l *Blnk) RefundTransaction(ctx context.Context, transactionID string) (*mo
 	newTransaction.Source = originalTxn.Destination
 	newTransaction.Destination = originalTxn.Source
 	newTransaction.AllowOverdraft = true
+	newTransaction.Refund = true
+	newTransaction.PreciseAmount = refundAmount
+	newTransaction.RefundedAmount = nil
+	newTransaction.RefundableAmount = nil
+	newTransaction.EffectiveDate = time.Time{} // Refunds are booked now, in an open period
 
 	// Queue the refund transaction
 	refundTxn, err := l.QueueTransaction(ctx, &newTransaction)
 	if err != nil {
 		span.RecordError(err)
+		if releaseErr := l.datasource.ReleaseRefund(ctx, originalTxn.TransactionID, refundAmount); releaseErr != nil {
+			logrus.Errorf("failed to release refund of transaction %s: %v", originalTxn.TransactionID, releaseErr)
+		}
 		return &model.Transaction{}, err
 	}
 
-	span.AddEvent("Transaction refunded", trace.WithAttributes(attribute.String("transaction.id", refundTxn.TransactionID)))
+	span.AddEvent("Transaction refunded", trace.WithAttributes(
+		attribute.String("transaction.id", refundTxn.TransactionID),
+		attribute.String("refund.amount", refundAmount.String()),
+	))
 	return refundTxn, nil
 }
+
+// reserveRefund works out the precise amount of a refund and reserves it against the original transaction, so the refunds
+// of a transaction never add up to more than its amount.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - originalTxn *model.Transaction: The recorded transaction being refunded.
+// - amount float64: The requested refund amount, or 0 for everything that has not been refunded yet.
+//
+// Returns:
+// - *big.Int: The precise amount of the refund.
+// - error: An error if the amount is invalid or exceeds the refundable amount.
+func (l *Blnk) reserveRefund(ctx context.Context, originalTxn *model.Transaction, amount float64) (*big.Int, error) {
+	originalAmount := originalTxn.PreciseAmount
+	if originalAmount == nil {
+		originalAmount = model.ApplyPrecision(originalTxn)
+	}
+
+	refundable := originalTxn.RefundableAmount
+	if refundable == nil {
+		refundable = originalAmount
+	}
+	if refundable.Sign() <= 0 {
+		return nil, fmt.Errorf("transaction %s has already been fully refunded", originalTxn.TransactionID)
+	}
+
+	refundAmount := new(big.Int).Set(refundable)
+	if amount != 0 {
+		preciseAmount, err := model.ToPreciseAmount(amount, originalTxn.Precision)
+		if err != nil {
+			return nil, err
+		}
+		if preciseAmount.Sign() <= 0 || preciseAmount.Cmp(refundable) > 0 {
+			return nil, fmt.Errorf("cannot refund %s %.2f. You can only refund an amount up to %s%.2f",
+				originalTxn.Currency, amount, originalTxn.Currency, model.FromPreciseAmount(refundable, originalTxn.Precision))
+		}
+		refundAmount = preciseAmount
+	}
+
+	reserved, err := l.datasource.ReserveRefund(ctx, originalTxn.TransactionID, refundAmount)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		// Another refund of the same transaction was reserved since it was read
+		return nil, fmt.Errorf("cannot refund %s %.2f. The refunds of transaction %s would exceed its amount",
+			originalTxn.Currency, model.FromPreciseAmount(refundAmount, originalTxn.Precision), originalTxn.TransactionID)
+	}
+	return refundAmount, nil
+}
+
+// RefundTransactions refunds a transaction by its ID. When the ID is the parent of a split transaction, every refundable leg
+// is refunded. A partial amount applies to a single transaction, so it is rejected when the ID resolves to several legs
+// rather than refunding it from each of them.
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - transactionID string: The ID of the transaction, or of the parent of the legs, to be refunded.
+// - amount float64: The amount to refund, or 0 to refund everything that has not been refunded yet.
+//
+// Returns:
+// - []*model.Transaction: The queued refund transactions.
+// - error: An error if the transaction could not be refunded.
+func (l *Blnk) RefundTransactions(ctx context.Context, transactionID string, amount float64) ([]*model.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "RefundTransactions")
+	defer span.End()
+
+	if amount != 0 {
+		legs, err := l.GetRefundableTransactionsByParentID(ctx, transactionID, 2, 0)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if len(legs) > 1 {
+			err := fmt.Errorf("cannot refund %.2f of transaction %s as it has several legs. Refund each leg by its own ID instead", amount, transactionID)
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+
+	return l.ProcessTransactionInBatches(ctx, transactionID, amount, 1, false, l.GetRefundableTransactionsByParentID, l.RefundWorker)
+}
@@ -18,19 +18,25 @@ package blnk
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"math/big"
 	"regexp"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jerry-enebeli/blnk/database/mocks"
+	redlock "github.com/jerry-enebeli/blnk/internal/lock"
 	"github.com/jerry-enebeli/blnk/model"
+	"github.com/redis/go-redis/v9"
 
 	"github.com/brianvoe/gofakeit/v6"
 
 	"github.com/DATA-DOG/go-sqlmock"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestRecordTransaction(t *testing.T) {
@@ -57,18 +63,20 @@ func TestRecordTransaction(t *testing.T) {
         SELECT EXISTS(SELECT 1 FROM blnk.transactions WHERE reference = $1)
     `)).WithArgs(txn.Reference).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
-	sourceBalanceRows := sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version"}).
-		AddRow(source, "NGN", "", 1, "ledger-id-source", int64(10000), int64(10000), 0, 0, 0, 0, time.Now(), 0)
+	sourceBalanceRows := sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version", "overdraft_limit", "status", "account_type", "normal_side"}).
+		AddRow(source, "", "NGN", 1, "ledger-id-source", int64(10000), int64(10000), 0, 0, 0, 0, time.Now(), 0, 0, "ACTIVE", nil, nil)
 
-	destinationBalanceRows := sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version"}).
-		AddRow(destination, "", "NGN", 1, "ledger-id-destination", 0, 0, 0, 0, 0, 0, time.Now(), 0)
+	destinationBalanceRows := sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version", "overdraft_limit", "status", "account_type", "normal_side"}).
+		AddRow(destination, "", "NGN", 1, "ledger-id-destination", 0, 0, 0, 0, 0, 0, time.Now(), 0, 0, "ACTIVE", nil, nil)
 
 	// Updated regex to be more flexible
-	balanceQuery := `SELECT balance_id, indicator, currency, currency_multiplier, ledger_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, created_at, version FROM blnk.balances WHERE balance_id = \$1`
+	balanceQuery := `SELECT balance_id, indicator, currency, currency_multiplier, ledger_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, created_at, version, overdraft_limit, status FROM blnk.balances WHERE balance_id = \$1`
 	balanceQueryPattern := regexp.MustCompile(`\s+`).ReplaceAllString(balanceQuery, `\s*`)
 
 	mock.ExpectQuery(balanceQueryPattern).WithArgs(source).WillReturnRows(sourceBalanceRows)
 	mock.ExpectQuery(balanceQueryPattern).WithArgs(destination).WillReturnRows(destinationBalanceRows)
+	mock.ExpectQuery(`FROM blnk.accounting_periods`).WithArgs("ledger-id-source", model.PeriodStatusClosed, sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`FROM blnk.accounting_periods`).WithArgs("ledger-id-destination", model.PeriodStatusClosed, sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
 	mock.ExpectBegin()
 
 	mock.ExpectExec(regexp.QuoteMeta(`
@@ -162,25 +170,27 @@ func TestRecordTransactionWithRate(t *testing.T) {
 		Rate:           1300,
 		AllowOverdraft: true,
 		Precision:      100,
-		Currency:       "NGN",
+		Currency:       "USD",
 	}
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
         SELECT EXISTS(SELECT 1 FROM blnk.transactions WHERE reference = $1)
     `)).WithArgs(txn.Reference).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
-	sourceBalanceRows := sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version"}).
-		AddRow(source, "", "USD", 1, "ledger-id-source", 0, 0, 0, 0, 0, 0, time.Now(), 0)
+	sourceBalanceRows := sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version", "overdraft_limit", "status", "account_type", "normal_side"}).
+		AddRow(source, "", "USD", 1, "ledger-id-source", 0, 0, 0, 0, 0, 0, time.Now(), 0, 0, "ACTIVE", nil, nil)
 
-	destinationBalanceRows := sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version"}).
-		AddRow(destination, "", "NGN", 1, "ledger-id-destination", 0, 0, 0, 0, 0, 0, time.Now(), 0)
+	destinationBalanceRows := sqlmock.NewRows([]string{"balance_id", "indicator", "currency", "currency_multiplier", "ledger_id", "balance", "credit_balance", "debit_balance", "inflight_balance", "inflight_credit_balance", "inflight_debit_balance", "created_at", "version", "overdraft_limit", "status", "account_type", "normal_side"}).
+		AddRow(destination, "", "NGN", 1, "ledger-id-destination", 0, 0, 0, 0, 0, 0, time.Now(), 0, 0, "ACTIVE", nil, nil)
 
 	// Updated regex to be more flexible
-	balanceQuery := `SELECT balance_id, indicator, currency, currency_multiplier, ledger_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, created_at, version FROM blnk.balances WHERE balance_id = \$1`
+	balanceQuery := `SELECT balance_id, indicator, currency, currency_multiplier, ledger_id, balance, credit_balance, debit_balance, inflight_balance, inflight_credit_balance, inflight_debit_balance, created_at, version, overdraft_limit, status FROM blnk.balances WHERE balance_id = \$1`
 	balanceQueryPattern := regexp.MustCompile(`\s+`).ReplaceAllString(balanceQuery, `\s*`)
 
 	mock.ExpectQuery(balanceQueryPattern).WithArgs(source).WillReturnRows(sourceBalanceRows)
 	mock.ExpectQuery(balanceQueryPattern).WithArgs(destination).WillReturnRows(destinationBalanceRows)
+	mock.ExpectQuery(`FROM blnk.accounting_periods`).WithArgs("ledger-id-source", model.PeriodStatusClosed, sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`FROM blnk.accounting_periods`).WithArgs("ledger-id-destination", model.PeriodStatusClosed, sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
 	mock.ExpectBegin()
 
 	mock.ExpectExec(regexp.QuoteMeta(`
@@ -249,6 +259,10 @@ func TestRecordTransactionWithRate(t *testing.T) {
 		sqlmock.AnyArg(),
 	).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// No rate of the pair is on record, so no spread is booked against the rate given
+	mock.ExpectQuery(`FROM blnk.fx_rates`).WithArgs("USD", "NGN", sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`FROM blnk.fx_rates`).WithArgs("NGN", "USD", sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
+
 	_, err = d.RecordTransaction(context.Background(), txn)
 	assert.NoError(t, err)
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -302,3 +316,117 @@ func TestVoidInflightTransaction_Negative(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestReserveRefund_Partial(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+	ctx := context.Background()
+
+	// 10.00 of which 6.00 has already been refunded
+	original := &model.Transaction{TransactionID: "txn_1", Currency: "USD", Precision: 100, PreciseAmount: big.NewInt(1000), RefundableAmount: big.NewInt(400)}
+
+	_, err := blnk.reserveRefund(ctx, original, 5)
+	assert.Error(t, err)
+
+	mockDS.On("ReserveRefund", ctx, "txn_1", big.NewInt(300)).Return(true, nil).Once()
+	amount, err := blnk.reserveRefund(ctx, original, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "300", amount.String())
+
+	// Without an amount, everything still refundable is refunded
+	mockDS.On("ReserveRefund", ctx, "txn_1", big.NewInt(400)).Return(true, nil).Once()
+	amount, err = blnk.reserveRefund(ctx, original, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "400", amount.String())
+
+	// A concurrent refund took the rest in the meantime
+	mockDS.On("ReserveRefund", ctx, "txn_1", big.NewInt(100)).Return(false, nil).Once()
+	_, err = blnk.reserveRefund(ctx, original, 1)
+	assert.Error(t, err)
+
+	original.RefundableAmount = big.NewInt(0)
+	_, err = blnk.reserveRefund(ctx, original, 0)
+	assert.ErrorContains(t, err, "already been fully refunded")
+
+	mockDS.AssertExpectations(t)
+}
+
+func TestAcquireLock_ResolvesIndicator(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when starting miniredis", err)
+	}
+	defer mr.Close()
+
+	mockDS := new(mocks.MockDataSource)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	blnk := &Blnk{datasource: mockDS, redis: client}
+	ctx := context.Background()
+
+	mockDS.On("GetBalanceByIndicator", "@World", "USD").Return(&model.Balance{BalanceID: "bln_world", Indicator: "@World", Currency: "USD"}, nil)
+
+	txn := &model.Transaction{Source: "@World", Currency: "USD"}
+	locker, err := blnk.acquireLock(ctx, txn)
+	assert.NoError(t, err)
+	assert.Equal(t, "bln_world", txn.Source)
+
+	// A journal entry locks the same balance by its ID and must wait for the transfer
+	assert.Error(t, redlock.NewLocker(client, "bln_world", "loc_journal").Lock(ctx, time.Second))
+
+	blnk.releaseLock(ctx, locker)
+	assert.NoError(t, redlock.NewLocker(client, "bln_world", "loc_journal").Lock(ctx, time.Second))
+}
+
+func TestRefundTransactions_PartialAmountOfSplitParent(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	blnk := &Blnk{datasource: mockDS}
+
+	legs := []*model.Transaction{
+		{TransactionID: "txn_leg_1", ParentTransaction: "txn_parent", Precision: 100, PreciseAmount: big.NewInt(4000)},
+		{TransactionID: "txn_leg_2", ParentTransaction: "txn_parent", Precision: 100, PreciseAmount: big.NewInt(6000)},
+	}
+	mockDS.On("GetRefundableTransactionsByParentID", mock.Anything, "txn_parent", 2, int64(0)).Return(legs, nil).Once()
+
+	// 10.00 must not be refunded from each of the legs
+	_, err := blnk.RefundTransactions(context.Background(), "txn_parent", 10)
+	assert.ErrorContains(t, err, "several legs")
+
+	mockDS.AssertExpectations(t)
+}
+
+func TestValidateAndPrepareTransaction_BalanceStatus(t *testing.T) {
+	tests := []struct {
+		name              string
+		sourceStatus      string
+		destinationStatus string
+		expectedError     string
+	}{
+		{"active balances", model.BalanceStatusActive, model.BalanceStatusActive, ""},
+		{"frozen source", model.BalanceStatusFrozen, model.BalanceStatusActive, "balance bln_src is frozen"},
+		{"debit-blocked source", model.BalanceStatusDebitBlocked, model.BalanceStatusActive, "balance bln_src is blocked from debits"},
+		{"debit-blocked destination", model.BalanceStatusActive, model.BalanceStatusDebitBlocked, ""},
+		{"frozen destination", model.BalanceStatusActive, model.BalanceStatusFrozen, "balance bln_dst is frozen"},
+		{"closed destination", model.BalanceStatusActive, model.BalanceStatusClosed, "balance bln_dst is closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDS := new(mocks.MockDataSource)
+			blnk := &Blnk{datasource: mockDS}
+			txn := &model.Transaction{Reference: "ref_" + tt.name, Source: "bln_src", Destination: "bln_dst", Currency: "USD"}
+
+			mockDS.On("TransactionExistsByRef", mock.Anything, txn.Reference).Return(false, nil)
+			mockDS.On("GetBalanceByIDLite", "bln_src").Return(&model.Balance{BalanceID: "bln_src", Currency: "USD", Status: tt.sourceStatus}, nil)
+			mockDS.On("GetBalanceByIDLite", "bln_dst").Return(&model.Balance{BalanceID: "bln_dst", Currency: "USD", Status: tt.destinationStatus}, nil)
+			mockDS.On("GetClosedPeriod", mock.Anything, "", mock.Anything).Return(nil, nil)
+
+			_, _, _, err := blnk.validateAndPrepareTransaction(context.Background(), txn)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, model.ErrBalanceUnavailable)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
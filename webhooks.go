@@ -16,19 +16,53 @@ package blnk
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/jerry-enebeli/blnk/config"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/internal/notification"
+	"github.com/jerry-enebeli/blnk/model"
 
 	"github.com/hibiken/asynq"
 )
 
+const (
+	// MonitorAlertEvent is the event of balance monitor alerts.
+	MonitorAlertEvent = "balance.monitor"
+	// BalanceStatusChangedEvent is the event of balance status changes.
+	BalanceStatusChangedEvent = "balance.status_changed"
+	// PeriodClosedEvent is the event of closed accounting periods.
+	PeriodClosedEvent = "period.closed"
+	// PeriodReopenedEvent is the event of reopened accounting periods.
+	PeriodReopenedEvent = "period.reopened"
+	// maxMonitorRetryDelay caps the delay between retries of a balance monitor alert delivery.
+	maxMonitorRetryDelay = time.Hour
+)
+
+// monitorCallbackClient delivers balance monitor alerts to callback URLs.
+var monitorCallbackClient = &http.Client{Timeout: 10 * time.Second}
+
+// BalanceStatusChanged is the payload of a balance status change webhook.
+type BalanceStatusChanged struct {
+	model.BalanceStatusChange
+	Balance *model.Balance `json:"balance,omitempty"`
+}
+
 // NewWebhook represents the structure of a webhook notification.
 // It includes an event type and associated payload data.
 type NewWebhook struct {
@@ -36,6 +70,19 @@ type NewWebhook struct {
 	Payload interface{} `json:"data"`  // The data associated with the event.
 }
 
+// MonitorAlert is the payload of a balance monitor alert. It holds the monitor event, with the snapshot of the
+// balance and the ID of the transaction that triggered it, and the monitor definition.
+type MonitorAlert struct {
+	model.MonitorEvent
+	Monitor model.BalanceMonitor `json:"monitor"`
+}
+
+// MonitorAlertTask is a balance monitor alert queued for delivery to the monitor's callback URL.
+type MonitorAlertTask struct {
+	Event          model.MonitorEvent `json:"event"`
+	BackoffSeconds int64              `json:"backoff_seconds"`
+}
+
 // getEventFromStatus maps a transaction status to a corresponding event string.
 //
 // Parameters:
@@ -192,3 +239,149 @@ func ProcessWebhook(_ context.Context, task *asynq.Task) error {
 	}
 	return nil
 }
+
+// sendMonitorAlert sends the alert of a fired balance monitor. Monitors with a callback URL have it queued for
+// delivery there with their own secret and retry policy, others send it as a webhook notification.
+//
+// Parameters:
+// - monitor model.BalanceMonitor: The monitor that fired.
+// - event *model.MonitorEvent: The recorded event.
+//
+// Returns:
+// - error: An error if the alert could not be queued.
+func (l *Blnk) sendMonitorAlert(monitor model.BalanceMonitor, event *model.MonitorEvent) error {
+	if monitor.CallBackURL != "" {
+		return l.queue.queueMonitorAlert(event, monitor.MaxRetries, monitor.RetryBackoffSeconds)
+	}
+
+	go func() {
+		err := SendWebhook(NewWebhook{
+			Event:   MonitorAlertEvent,
+			Payload: MonitorAlert{MonitorEvent: *event, Monitor: monitor},
+		})
+		if err != nil {
+			notification.NotifyError(err)
+		}
+	}()
+	return nil
+}
+
+// DeliverMonitorAlert posts a balance monitor alert to the monitor's callback URL. The monitor is read at delivery
+// time, so retries use its latest callback URL and secret. Alerts of deleted monitors are dropped.
+// When the monitor has a secret, the request is signed in the X-Blnk-Signature header (see SignWebhookPayload).
+//
+// Parameters:
+// - ctx context.Context: The context for the operation.
+// - event model.MonitorEvent: The event of the fired monitor.
+//
+// Returns:
+// - error: An error if the alert could not be delivered or the callback responded with a non-2XX status, so it is retried.
+func (l *Blnk) DeliverMonitorAlert(ctx context.Context, event model.MonitorEvent) error {
+	ctx, span := balanceTracer.Start(ctx, "DeliverMonitorAlert")
+	defer span.End()
+
+	monitor, err := l.datasource.GetMonitorByID(event.MonitorID)
+	if err != nil {
+		var apiErr apierror.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == apierror.ErrNotFound {
+			span.AddEvent("Monitor deleted, alert dropped")
+			return nil
+		}
+		span.RecordError(err)
+		return err
+	}
+	if monitor.CallBackURL == "" {
+		span.AddEvent("Monitor has no callback URL, alert dropped")
+		return nil
+	}
+
+	body, err := json.Marshal(NewWebhook{
+		Event:   MonitorAlertEvent,
+		Payload: MonitorAlert{MonitorEvent: event, Monitor: *monitor},
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, monitor.CallBackURL, bytes.NewReader(body))
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Blnk-Event-Id", event.EventID)
+	if monitor.CallBackSecret != "" {
+		req.Header.Set("X-Blnk-Signature", SignWebhookPayload(monitor.CallBackSecret, time.Now(), body))
+	}
+
+	resp, err := monitorCallbackClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			logrus.Error(err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("monitor callback responded with status code %d", resp.StatusCode)
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("Monitor alert delivered", trace.WithAttributes(
+		attribute.String("monitor.id", event.MonitorID),
+		attribute.String("monitor.event_id", event.EventID),
+	))
+	return nil
+}
+
+// SignWebhookPayload signs a webhook body with a secret. The signature has the form "t=<unix timestamp>,v1=<hex HMAC-SHA256>",
+// where the HMAC covers "<unix timestamp>.<body>", so receivers can verify the sender and reject replayed deliveries.
+//
+// Parameters:
+// - secret string: The secret shared with the receiver.
+// - timestamp time.Time: The time of the delivery.
+// - body []byte: The request body.
+//
+// Returns:
+// - string: The signature header value.
+func SignWebhookPayload(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", unix, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// MonitorAlertRetryDelay is an asynq retry delay function. Balance monitor alerts back off exponentially from the
+// retry backoff of their monitor, up to an hour, and other tasks use the asynq default.
+//
+// Parameters:
+// - n int: The number of times the task has been retried.
+// - err error: The error returned by the task handler.
+// - task *asynq.Task: The task being retried.
+//
+// Returns:
+// - time.Duration: The delay before the next retry.
+func MonitorAlertRetryDelay(n int, err error, task *asynq.Task) time.Duration {
+	if task.Type() == MONITOR_ALERT_QUEUE {
+		var alert MonitorAlertTask
+		if json.Unmarshal(task.Payload(), &alert) == nil && alert.BackoffSeconds > 0 {
+			delay := time.Duration(alert.BackoffSeconds) * time.Second
+			for i := 0; i < n && delay < maxMonitorRetryDelay; i++ {
+				delay *= 2
+			}
+			if delay > maxMonitorRetryDelay {
+				delay = maxMonitorRetryDelay
+			}
+			return delay
+		}
+	}
+	return asynq.DefaultRetryDelayFunc(n, err, task)
+}
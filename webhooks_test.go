@@ -17,10 +17,25 @@ limitations under the License.
 package blnk
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
 	"github.com/jerry-enebeli/blnk/config"
+	"github.com/jerry-enebeli/blnk/database/mocks"
+	"github.com/jerry-enebeli/blnk/internal/apierror"
+	"github.com/jerry-enebeli/blnk/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -72,3 +87,89 @@ func TestSendWebhook(t *testing.T) {
 	assert.NotEmpty(t, tasks)
 
 }
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"event":"balance.monitor"}`)
+	timestamp := time.Unix(1700000000, 0)
+
+	mac := hmac.New(sha256.New, []byte("whsec"))
+	mac.Write([]byte("1700000000." + string(body)))
+	expected := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, expected, SignWebhookPayload("whsec", timestamp, body))
+	assert.NotEqual(t, expected, SignWebhookPayload("other", timestamp, body))
+}
+
+func TestMonitorAlertRetryDelay(t *testing.T) {
+	payload, err := json.Marshal(MonitorAlertTask{BackoffSeconds: 10})
+	assert.NoError(t, err)
+	task := asynq.NewTask(MONITOR_ALERT_QUEUE, payload)
+
+	assert.Equal(t, 10*time.Second, MonitorAlertRetryDelay(0, nil, task))
+	assert.Equal(t, 40*time.Second, MonitorAlertRetryDelay(2, nil, task))
+	assert.Equal(t, time.Hour, MonitorAlertRetryDelay(20, nil, task))
+}
+
+func TestDeliverMonitorAlert(t *testing.T) {
+	var signature, eventID string
+	var alert struct {
+		Event string       `json:"event"`
+		Data  MonitorAlert `json:"data"`
+	}
+	var rawBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Blnk-Signature")
+		eventID = r.Header.Get("X-Blnk-Event-Id")
+		rawBody, _ = io.ReadAll(r.Body)
+		_ = json.Unmarshal(rawBody, &alert)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockDS := new(mocks.MockDataSource)
+	l := &Blnk{datasource: mockDS}
+	monitor := &model.BalanceMonitor{MonitorID: "mon_1", BalanceID: "bln_1", CallBackURL: server.URL, CallBackSecret: "whsec"}
+	mockDS.On("GetMonitorByID", "mon_1").Return(monitor, nil)
+
+	event := model.MonitorEvent{EventID: "mev_1", MonitorID: "mon_1", BalanceID: "bln_1", TransactionID: "txn_1",
+		Balance: &model.Balance{BalanceID: "bln_1", Balance: big.NewInt(250)}, TriggeredAt: time.Now()}
+	err := l.DeliverMonitorAlert(context.Background(), event)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "mev_1", eventID)
+	assert.Equal(t, MonitorAlertEvent, alert.Event)
+	assert.Equal(t, "txn_1", alert.Data.TransactionID)
+	assert.Equal(t, big.NewInt(250), alert.Data.Balance.Balance)
+	assert.Equal(t, "mon_1", alert.Data.Monitor.MonitorID)
+	assert.NotContains(t, string(rawBody), "whsec")
+
+	// The signature verifies against the delivered body
+	timestamp := strings.TrimPrefix(strings.Split(signature, ",")[0], "t=")
+	mac := hmac.New(sha256.New, []byte("whsec"))
+	mac.Write([]byte(timestamp + "." + string(rawBody)))
+	assert.Equal(t, "v1="+hex.EncodeToString(mac.Sum(nil)), strings.Split(signature, ",")[1])
+	mockDS.AssertExpectations(t)
+}
+
+func TestDeliverMonitorAlert_FailedDeliveryIsRetried(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	mockDS := new(mocks.MockDataSource)
+	l := &Blnk{datasource: mockDS}
+	mockDS.On("GetMonitorByID", "mon_1").Return(&model.BalanceMonitor{MonitorID: "mon_1", CallBackURL: server.URL}, nil)
+
+	err := l.DeliverMonitorAlert(context.Background(), model.MonitorEvent{EventID: "mev_1", MonitorID: "mon_1"})
+	assert.Error(t, err)
+}
+
+func TestDeliverMonitorAlert_DeletedMonitorIsDropped(t *testing.T) {
+	mockDS := new(mocks.MockDataSource)
+	l := &Blnk{datasource: mockDS}
+	mockDS.On("GetMonitorByID", "mon_1").Return((*model.BalanceMonitor)(nil), apierror.NewAPIError(apierror.ErrNotFound, "Monitor with ID 'mon_1' not found", nil))
+
+	err := l.DeliverMonitorAlert(context.Background(), model.MonitorEvent{EventID: "mev_1", MonitorID: "mon_1"})
+	assert.NoError(t, err)
+}